@@ -3,9 +3,14 @@ package internal
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"html/template"
 	"log"
+	"net"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 
@@ -60,6 +65,12 @@ func setup(app *orz.App) error {
 		appConfig.JWT.ExpiresHours = 168 // 7天
 	}
 
+	// 探针客户端证书校验
+	if err := setupAgentTLS(app, appConfig.AgentTLS); err != nil {
+		app.Logger().Error("配置探针客户端证书失败", zap.Error(err))
+		return err
+	}
+
 	// 初始化应用组件
 	components, err := InitializeApp(app.Logger(), app.GetDatabase(), &appConfig)
 	if err != nil {
@@ -80,6 +91,18 @@ func setup(app *orz.App) error {
 
 	// 启动WebSocket管理器
 	go components.WSManager.Run(ctx)
+	// 批量写入 VictoriaMetrics
+	go components.VMWriter.Run(ctx)
+	// 多节点共享探针会话
+	go components.Cluster.Run(ctx)
+	// 多节点部署时共享探针最新指标
+	go components.MetricService.RunLatestSync(ctx)
+	// 批量写入探针最后活跃时间
+	go components.AgentService.RunPresence(ctx)
+	// 标记超时未确认的探针配置
+	go components.AgentConfigService.Run(ctx)
+	// 向浏览器推送实时事件
+	go components.LiveService.Run(ctx)
 
 	// 启动指标监控任务（用于告警检测）
 	go startMetricsMonitoring(ctx, components, app.Logger())
@@ -96,6 +119,9 @@ func setup(app *orz.App) error {
 	// 启动 DDNS 定时任务
 	go components.DDNSService.Run(ctx)
 
+	// 启动日志事件清理任务
+	go components.LogService.Run(ctx)
+
 	// 设置API
 	setupApi(app, components)
 
@@ -188,6 +214,9 @@ func setupApi(app *orz.App, components *AppComponents) {
 		publicApiWithOptionalAuth.GET("/agents/:id/network-interfaces", components.AgentHandler.GetAvailableNetworkInterfaces)
 		publicApiWithOptionalAuth.GET("/agents/:id/traffic", components.AgentHandler.GetTrafficStats)
 
+		// 实时事件推送（SSE）- 探针最新指标、上线/离线和告警
+		publicApiWithOptionalAuth.GET("/live", components.LiveHandler.Stream)
+
 		// 监控统计数据（公开访问，支持可选认证）- 用于公共展示页面
 		publicApiWithOptionalAuth.GET("/monitors", components.MonitorHandler.GetMonitors)
 		publicApiWithOptionalAuth.GET("/monitors/:id/stats", components.MonitorHandler.GetStatsByID)
@@ -196,6 +225,10 @@ func setupApi(app *orz.App, components *AppComponents) {
 
 		// Logo（公开访问）- 用于公共页面只获取 Logo
 		publicApiWithOptionalAuth.GET("/logo", components.PropertyHandler.GetLogo)
+
+		// 公开状态页
+		publicApiWithOptionalAuth.GET("/status-page", components.StatusPageHandler.GetPublicByHost)
+		publicApiWithOptionalAuth.GET("/status-pages/:slug", components.StatusPageHandler.GetPublicBySlug)
 	}
 
 	// WebSocket 路由（探针连接）
@@ -230,10 +263,20 @@ func setupApi(app *orz.App, components *AppComponents) {
 		adminApi.GET("/agents/statistics", components.AgentHandler.GetStatistics)
 		adminApi.GET("/agents/tags", components.AgentHandler.GetTags)
 		adminApi.GET("/agents/:id", components.AgentHandler.GetForAdmin)
+		adminApi.GET("/agents/:id/processes", components.AgentHandler.GetProcesses)
+		adminApi.GET("/agents/:id/containers", components.AgentHandler.GetContainers)
+		adminApi.GET("/agents/:id/systemd", components.AgentHandler.GetSystemdUnits)
+		adminApi.GET("/agents/:id/disk-health", components.AgentHandler.GetDiskHealth)
+		adminApi.GET("/agents/:id/prometheus-targets", components.AgentHandler.GetPrometheusTargets)
+		adminApi.GET("/agents/:id/custom-metrics", components.AgentHandler.GetCustomMetricNames)
+		adminApi.GET("/agents/:id/custom-metrics/:name", components.AgentHandler.GetCustomMetrics)
 		adminApi.PUT("/agents/:id", components.AgentHandler.UpdateInfo)
 		adminApi.POST("/agents/batch/tags", components.AgentHandler.BatchUpdateTags)
 		adminApi.DELETE("/agents/:id", components.AgentHandler.Delete)
 		adminApi.POST("/agents/:id/command", components.AgentHandler.SendCommand)
+		adminApi.POST("/agents/:id/credential/rotate", components.AgentHandler.RotateCredential)
+		adminApi.POST("/agents/:id/credential/revoke", components.AgentHandler.RevokeCredential)
+		adminApi.POST("/agents/:id/credential/reset", components.AgentHandler.ResetCredential)
 
 		// 流量管理（管理员访问）
 		adminApi.PUT("/agents/:id/traffic-config", components.AgentHandler.UpdateTrafficConfig)
@@ -242,6 +285,7 @@ func setupApi(app *orz.App, components *AppComponents) {
 		// VPS审计结果（管理员访问）
 		adminApi.GET("/agents/:id/audit/result", components.AgentHandler.GetAuditResult)
 		adminApi.GET("/agents/:id/audit/results", components.AgentHandler.ListAuditResults)
+		adminApi.GET("/agents/:id/config-status", components.AgentHandler.ListConfigStates)
 
 		// 防篡改管理（管理员功能）
 		adminApi.GET("/agents/:id/tamper/config", components.TamperHandler.GetTamperConfig)
@@ -249,6 +293,13 @@ func setupApi(app *orz.App, components *AppComponents) {
 		adminApi.GET("/agents/:id/tamper/events", components.TamperHandler.GetTamperEvents)
 		adminApi.GET("/agents/:id/tamper/alerts", components.TamperHandler.GetTamperAlerts)
 
+		// 日志监控（管理员功能）
+		adminApi.GET("/agents/:id/log-rules", components.LogHandler.ListRules)
+		adminApi.POST("/agents/:id/log-rules", components.LogHandler.CreateRule)
+		adminApi.PUT("/log-rules/:id", components.LogHandler.UpdateRule)
+		adminApi.DELETE("/log-rules/:id", components.LogHandler.DeleteRule)
+		adminApi.GET("/agents/:id/log-events", components.LogHandler.PagingEvents)
+
 		// 通用属性管理
 		adminApi.GET("/properties/:id", components.PropertyHandler.GetProperty)
 		adminApi.PUT("/properties/:id", components.PropertyHandler.SetProperty)
@@ -263,10 +314,35 @@ func setupApi(app *orz.App, components *AppComponents) {
 		// 服务监控配置
 		adminApi.GET("/monitors", components.MonitorHandler.List)
 		adminApi.POST("/monitors", components.MonitorHandler.Create)
+		adminApi.GET("/monitors/groups", components.MonitorHandler.GetGroups)
+		adminApi.GET("/monitors/export", components.MonitorHandler.Export)
+		adminApi.POST("/monitors/import", components.MonitorHandler.Import)
 		adminApi.GET("/monitors/:id", components.MonitorHandler.Get)
 		adminApi.PUT("/monitors/:id", components.MonitorHandler.Update)
 		adminApi.DELETE("/monitors/:id", components.MonitorHandler.Delete)
 
+		// SLA 报告
+		adminApi.GET("/monitors/:id/sla", components.SLAHandler.GetMonitorSLA)
+		adminApi.GET("/monitors/:id/sla/incidents", components.SLAHandler.GetMonitorIncidents)
+		adminApi.GET("/agents/:id/sla", components.SLAHandler.GetAgentSLA)
+		adminApi.GET("/sla/report", components.SLAHandler.GetMonthlyReport)
+
+		// 状态页
+		adminApi.GET("/status-pages", components.StatusPageHandler.Paging)
+		adminApi.POST("/status-pages", components.StatusPageHandler.Create)
+		adminApi.GET("/status-pages/:id", components.StatusPageHandler.Get)
+		adminApi.PUT("/status-pages/:id", components.StatusPageHandler.Update)
+		adminApi.DELETE("/status-pages/:id", components.StatusPageHandler.Delete)
+		adminApi.GET("/status-pages/:id/incidents", components.StatusPageHandler.ListIncidents)
+		adminApi.POST("/status-pages/:id/incidents", components.StatusPageHandler.CreateIncident)
+		adminApi.PUT("/status-incidents/:id", components.StatusPageHandler.UpdateIncident)
+		adminApi.DELETE("/status-incidents/:id", components.StatusPageHandler.DeleteIncident)
+		adminApi.POST("/status-incidents/:id/updates", components.StatusPageHandler.AddIncidentUpdate)
+		adminApi.GET("/status-pages/:id/maintenances", components.StatusPageHandler.ListMaintenances)
+		adminApi.POST("/status-pages/:id/maintenances", components.StatusPageHandler.CreateMaintenance)
+		adminApi.PUT("/status-maintenances/:id", components.StatusPageHandler.UpdateMaintenance)
+		adminApi.DELETE("/status-maintenances/:id", components.StatusPageHandler.DeleteMaintenance)
+
 		// DNS Provider 管理
 		adminApi.GET("/dns-providers", components.DNSProviderHandler.GetAll)
 		adminApi.POST("/dns-providers", components.DNSProviderHandler.Upsert)
@@ -290,22 +366,78 @@ func setupApi(app *orz.App, components *AppComponents) {
 	publicApi.POST("/auth/github/callback", components.AccountHandler.GitHubLogin)
 }
 
+// setupAgentTLS 服务端直接提供 HTTPS 时按配置的 CA 校验探针的客户端证书
+// 未提供证书的连接（如浏览器）不受影响，证书与探针的绑定在注册时校验
+func setupAgentTLS(app *orz.App, agentTLS *config.AgentTLSConfig) error {
+	if agentTLS == nil || agentTLS.ClientCAFile == "" {
+		return nil
+	}
+	serverTLS := app.GetConfig().Server.TLS
+	if !serverTLS.Enabled || serverTLS.Auto || serverTLS.Cert == "" || serverTLS.Key == "" {
+		app.Logger().Warn("服务端未使用证书文件启用 HTTPS，忽略探针客户端证书 CA 配置，请在反向代理中校验证书并配置 SubjectHeader")
+		return nil
+	}
+
+	caPEM, err := os.ReadFile(agentTLS.ClientCAFile)
+	if err != nil {
+		return fmt.Errorf("读取客户端证书 CA 失败: %w", err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return fmt.Errorf("客户端证书 CA 文件中没有有效的证书: %s", agentTLS.ClientCAFile)
+	}
+	cert, err := tls.LoadX509KeyPair(serverTLS.Cert, serverTLS.Key)
+	if err != nil {
+		return fmt.Errorf("加载服务端证书失败: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+	}
+	e := app.GetEcho()
+	if !e.DisableHTTP2 {
+		tlsConfig.NextProtos = []string{"h2"}
+	}
+
+	addr := app.GetConfig().Server.Addr
+	if addr == "" {
+		addr = ":8080"
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	// echo 启动 HTTPS 时使用已创建的监听器
+	e.TLSListener = tls.NewListener(listener, tlsConfig)
+	app.Logger().Info("agent client certificate verification enabled", zap.String("ca", agentTLS.ClientCAFile))
+	return nil
+}
+
 func autoMigrate(database *gorm.DB) error {
 	// 自动迁移数据库表
 	return database.AutoMigrate(
-		&models.Agent{},               // 探针
-		&models.ApiKey{},              // ApiKey
-		&models.HostMetric{},          // 保留主机静态信息表
-		&models.AuditResult{},         // 审计历史
-		&models.Property{},            // 系统属性
-		&models.AlertRecord{},         // 告警记录
-		&models.AlertState{},          // 告警状态
-		&models.MonitorTask{},         // 服务监控
-		&models.TamperProtectConfig{}, // 防篡改配置
-		&models.TamperEvent{},         // 防篡改事件
-		&models.TamperAlert{},         // 防篡改告警
-		&models.DDNSConfig{},          // DDNS 配置
-		&models.DDNSRecord{},          // DDNS 记录
+		&models.Agent{},                // 探针
+		&models.ApiKey{},               // ApiKey
+		&models.HostMetric{},           // 保留主机静态信息表
+		&models.AuditResult{},          // 审计历史
+		&models.Property{},             // 系统属性
+		&models.AlertRecord{},          // 告警记录
+		&models.AlertState{},           // 告警状态
+		&models.MonitorTask{},          // 服务监控
+		&models.TamperProtectConfig{},  // 防篡改配置
+		&models.TamperEvent{},          // 防篡改事件
+		&models.TamperAlert{},          // 防篡改告警
+		&models.DDNSConfig{},           // DDNS 配置
+		&models.DDNSRecord{},           // DDNS 记录
+		&models.LogRule{},              // 日志监控规则
+		&models.LogEvent{},             // 日志事件
+		&models.StatusPage{},           // 状态页
+		&models.StatusIncident{},       // 状态页故障事件
+		&models.StatusIncidentUpdate{}, // 故障事件更新
+		&models.StatusMaintenance{},    // 计划维护
+		&models.AgentConfigState{},     // 探针配置下发状态
 	)
 }
 
@@ -361,14 +493,19 @@ func startMetricsMonitoring(ctx context.Context, components *AppComponents, logg
 			logger.Info("指标监控任务已停止")
 			return
 		case <-ticker.C:
-			// 检查所有在线探针的最新指标
-			agents, err := components.AgentService.ListOnlineAgents(ctx)
+			// 检查连接在本节点的在线探针的最新指标，其他节点的探针由其所在节点检查
+			agents, err := components.AgentService.ListConnectedAgents(ctx)
 			if err != nil {
 				logger.Error("获取在线探针失败", zap.Error(err))
 				continue
 			}
 
 			for _, agent := range agents {
+				// 恢复超过恢复时间没有新匹配的日志告警（不依赖最新指标）
+				if err := components.AlertService.CheckLogAlerts(ctx, agent.ID); err != nil {
+					logger.Error("检查日志告警失败", zap.String("agentId", agent.ID), zap.Error(err))
+				}
+
 				// 获取最新指标
 				latest, ok := components.MetricService.GetLatestMetrics(agent.ID)
 				if !ok {
@@ -381,7 +518,7 @@ func startMetricsMonitoring(ctx context.Context, components *AppComponents, logg
 				}
 
 				// 提取 CPU、内存、磁盘使用率、网速
-				var cpuUsage, memoryUsage, diskUsage, networkSpeed float64
+				var cpuUsage, memoryUsage, diskUsage, inodeUsage, networkSpeed float64
 
 				if latest.CPU != nil {
 					cpuUsage = latest.CPU.UsagePercent
@@ -393,6 +530,7 @@ func startMetricsMonitoring(ctx context.Context, components *AppComponents, logg
 
 				if latest.Disk != nil {
 					diskUsage = latest.Disk.UsagePercent
+					inodeUsage = latest.Disk.InodesUsagePercent
 				}
 
 				if latest.Network != nil {
@@ -401,12 +539,36 @@ func startMetricsMonitoring(ctx context.Context, components *AppComponents, logg
 				}
 
 				// 检查告警规则
-				if err := components.AlertService.CheckMetrics(ctx, agent.ID, cpuUsage, memoryUsage, diskUsage, networkSpeed); err != nil {
+				if err := components.AlertService.CheckMetrics(ctx, agent.ID, cpuUsage, memoryUsage, diskUsage, inodeUsage, networkSpeed); err != nil {
 					logger.Error("检查告警规则失败", zap.String("agentId", agent.ID), zap.Error(err))
 				}
+
+				// 检查关注进程告警
+				if latest.Process != nil {
+					if err := components.AlertService.CheckProcessAlerts(ctx, agent.ID, latest.Process.Watched); err != nil {
+						logger.Error("检查进程告警失败", zap.String("agentId", agent.ID), zap.Error(err))
+					}
+				}
+
+				// 检查 systemd 单元告警
+				if latest.Systemd != nil {
+					if err := components.AlertService.CheckSystemdAlerts(ctx, agent.ID, latest.Systemd); err != nil {
+						logger.Error("检查systemd单元告警失败", zap.String("agentId", agent.ID), zap.Error(err))
+					}
+				}
+
+				// 检查磁盘健康告警
+				if latest.DiskHealth != nil {
+					if err := components.AlertService.CheckDiskHealthAlerts(ctx, agent.ID, latest.DiskHealth); err != nil {
+						logger.Error("检查磁盘健康告警失败", zap.String("agentId", agent.ID), zap.Error(err))
+					}
+				}
 			}
 
-			// 检查监控相关告警（证书和服务下线）
+			// 检查监控相关告警（证书、服务下线和探针离线），涉及所有探针，多节点部署时只由主节点检查
+			if !components.Cluster.Leader() {
+				continue
+			}
 			if err := components.AlertService.CheckMonitorAlerts(ctx); err != nil {
 				logger.Error("检查监控告警失败", zap.Error(err))
 			}
@@ -427,6 +589,10 @@ func startTrafficResetCheck(ctx context.Context, components *AppComponents, logg
 			logger.Info("流量重置检查任务已停止")
 			return
 		case <-ticker.C:
+			// 多节点部署时只由主节点检查
+			if !components.Cluster.Leader() {
+				continue
+			}
 			if err := components.AgentService.CheckAndResetTraffic(ctx); err != nil {
 				logger.Error("流量重置检查失败", zap.Error(err))
 			}
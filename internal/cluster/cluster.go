@@ -0,0 +1,66 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+)
+
+// 节点之间转发的消息类型
+const (
+	KindSend       = "send"       // 将数据发送给探针
+	KindDisconnect = "disconnect" // 断开探针连接
+)
+
+// ErrNoSession 探针没有连接到任何节点
+var ErrNoSession = errors.New("agent session not found")
+
+// Message 转发给持有探针连接的节点的消息
+type Message struct {
+	AgentID string `json:"agentId"`
+	Kind    string `json:"kind"`
+	Data    []byte `json:"data,omitempty"`
+}
+
+// MessageHandler 处理其他节点转发来的消息
+type MessageHandler func(ctx context.Context, msg Message)
+
+// SessionSource 返回本节点实际持有连接的探针ID
+type SessionSource func() []string
+
+// Backend 多个服务端节点之间共享探针会话、转发消息和最新指标的后端
+// 单节点部署使用 Local，多节点部署使用 Postgres
+type Backend interface {
+	// NodeID 当前节点标识
+	NodeID() string
+	// Distributed 是否在多个节点之间共享，单节点后端不需要同步任何数据
+	Distributed() bool
+	// Leader 当前节点是否为主节点，只需要执行一次的全局任务（离线告警、流量重置等）只在主节点执行
+	// 同一时间最多只有一个节点返回 true，单节点后端始终返回 true
+	Leader() bool
+
+	// Claim 记录探针连接在当前节点
+	Claim(ctx context.Context, agentID string) error
+	// Release 探针从当前节点断开，连接已转移到其他节点时不影响其他节点的记录
+	Release(ctx context.Context, agentID string) error
+	// Lookup 查找持有探针连接的节点，不存在时返回 ErrNoSession
+	Lookup(ctx context.Context, agentID string) (string, error)
+	// Sessions 返回所有节点记录的探针会话，单节点后端不记录会话，返回空
+	Sessions(ctx context.Context) ([]string, error)
+	// SetSessionSource 设置本节点实际持有的探针连接，定时续期时以此为准，清理未能及时释放的会话
+	SetSessionSource(source SessionSource)
+
+	// Send 将消息转发给指定节点
+	Send(ctx context.Context, nodeID string, msg Message) error
+	// SetMessageHandler 设置其他节点转发来的消息的处理器
+	SetMessageHandler(handler MessageHandler)
+
+	// PutLatest 保存探针的最新指标快照，供其他节点读取
+	PutLatest(ctx context.Context, snapshots map[string][]byte) error
+	// GetLatest 读取其他节点保存的最新指标快照
+	GetLatest(ctx context.Context, agentID string) ([]byte, bool, error)
+	// ListLatest 读取键以 prefix 开头的所有最新指标快照，用于按节点保存的快照
+	ListLatest(ctx context.Context, prefix string) (map[string][]byte, error)
+
+	// Run 运行后台任务（接收消息、续期会话等），阻塞直到 ctx 取消
+	Run(ctx context.Context)
+}
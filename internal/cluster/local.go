@@ -0,0 +1,65 @@
+package cluster
+
+import (
+	"context"
+)
+
+// Local 单节点后端，所有探针都连接在当前进程，不需要共享任何数据
+type Local struct{}
+
+// NewLocal 创建单节点后端
+func NewLocal() *Local {
+	return &Local{}
+}
+
+func (l *Local) NodeID() string {
+	return "local"
+}
+
+func (l *Local) Distributed() bool {
+	return false
+}
+
+func (l *Local) Leader() bool {
+	return true
+}
+
+func (l *Local) Claim(context.Context, string) error {
+	return nil
+}
+
+func (l *Local) Release(context.Context, string) error {
+	return nil
+}
+
+func (l *Local) Lookup(context.Context, string) (string, error) {
+	return "", ErrNoSession
+}
+
+func (l *Local) Sessions(context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func (l *Local) SetSessionSource(SessionSource) {}
+
+func (l *Local) Send(context.Context, string, Message) error {
+	return ErrNoSession
+}
+
+func (l *Local) SetMessageHandler(MessageHandler) {}
+
+func (l *Local) PutLatest(context.Context, map[string][]byte) error {
+	return nil
+}
+
+func (l *Local) GetLatest(context.Context, string) ([]byte, bool, error) {
+	return nil, false, nil
+}
+
+func (l *Local) ListLatest(context.Context, string) (map[string][]byte, error) {
+	return nil, nil
+}
+
+func (l *Local) Run(ctx context.Context) {
+	<-ctx.Done()
+}
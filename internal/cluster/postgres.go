@@ -0,0 +1,429 @@
+package cluster
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/stdlib"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// 会话超过该时间没有续期视为所在节点已退出
+	defaultSessionTTL = 90 * time.Second
+	// 通知频道，通知内容为接收消息的节点标识
+	notifyChannel = "pika_cluster"
+	// 主节点记录的名称
+	leaderName = "default"
+)
+
+// clusterSession 探针连接所在的节点
+type clusterSession struct {
+	AgentID   string `gorm:"primaryKey"`
+	NodeID    string `gorm:"index"`
+	RenewedAt int64  `gorm:"index"` // 最后续期时间（时间戳毫秒）
+}
+
+func (clusterSession) TableName() string {
+	return "cluster_sessions"
+}
+
+// clusterMessage 等待目标节点处理的转发消息
+type clusterMessage struct {
+	ID      int64  `gorm:"primaryKey;autoIncrement"`
+	NodeID  string `gorm:"index"`
+	AgentID string
+	Kind    string
+	Data    []byte
+	SentAt  int64 `gorm:"index"` // 发送时间（时间戳毫秒）
+}
+
+func (clusterMessage) TableName() string {
+	return "cluster_messages"
+}
+
+// clusterLatest 探针最新指标快照
+type clusterLatest struct {
+	AgentID string `gorm:"primaryKey"`
+	Data    []byte
+	SavedAt int64 `gorm:"index"` // 保存时间（时间戳毫秒）
+}
+
+func (clusterLatest) TableName() string {
+	return "cluster_latest_metrics"
+}
+
+// clusterLeader 执行全局任务的主节点
+type clusterLeader struct {
+	Name      string `gorm:"primaryKey"`
+	NodeID    string
+	RenewedAt int64 // 最后续期时间（时间戳毫秒）
+}
+
+func (clusterLeader) TableName() string {
+	return "cluster_leaders"
+}
+
+// Postgres 基于 PostgreSQL 的多节点后端
+// 会话和最新指标保存在数据库表中，转发消息写入消息表后通过 LISTEN/NOTIFY 通知目标节点
+type Postgres struct {
+	db        *gorm.DB
+	logger    *zap.Logger
+	nodeID    string
+	ttl       time.Duration
+	onMessage MessageHandler
+	sessions  SessionSource
+
+	leaderUntil atomic.Int64 // 本节点作为主节点的有效期（时间戳毫秒）
+}
+
+// NewPostgres 创建 PostgreSQL 多节点后端，nodeID 为空时根据主机名生成
+func NewPostgres(db *gorm.DB, logger *zap.Logger, nodeID string, ttl time.Duration) (*Postgres, error) {
+	if db.Dialector.Name() != "postgres" {
+		return nil, errors.New("postgres 集群后端需要使用 PostgreSQL 数据库")
+	}
+	if nodeID == "" {
+		hostname, _ := os.Hostname()
+		nodeID = fmt.Sprintf("%s-%s", hostname, uuid.NewString()[:8])
+	}
+	if ttl <= 0 {
+		ttl = defaultSessionTTL
+	}
+	if err := db.AutoMigrate(&clusterSession{}, &clusterMessage{}, &clusterLatest{}, &clusterLeader{}); err != nil {
+		return nil, fmt.Errorf("创建集群数据表失败: %w", err)
+	}
+	return &Postgres{
+		db:     db,
+		logger: logger,
+		nodeID: nodeID,
+		ttl:    ttl,
+	}, nil
+}
+
+func (p *Postgres) NodeID() string {
+	return p.nodeID
+}
+
+func (p *Postgres) Distributed() bool {
+	return true
+}
+
+func (p *Postgres) Leader() bool {
+	return time.Now().UnixMilli() < p.leaderUntil.Load()
+}
+
+func (p *Postgres) cutoff() int64 {
+	return time.Now().Add(-p.ttl).UnixMilli()
+}
+
+func (p *Postgres) Claim(ctx context.Context, agentID string) error {
+	return p.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "agent_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"node_id", "renewed_at"}),
+		}).
+		Create(&clusterSession{AgentID: agentID, NodeID: p.nodeID, RenewedAt: time.Now().UnixMilli()}).Error
+}
+
+func (p *Postgres) Release(ctx context.Context, agentID string) error {
+	return p.db.WithContext(ctx).
+		Where("agent_id = ? AND node_id = ?", agentID, p.nodeID).
+		Delete(&clusterSession{}).Error
+}
+
+func (p *Postgres) Lookup(ctx context.Context, agentID string) (string, error) {
+	var session clusterSession
+	err := p.db.WithContext(ctx).
+		Where("agent_id = ? AND renewed_at >= ?", agentID, p.cutoff()).
+		First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", ErrNoSession
+	}
+	if err != nil {
+		return "", err
+	}
+	return session.NodeID, nil
+}
+
+func (p *Postgres) Sessions(ctx context.Context) ([]string, error) {
+	var agentIDs []string
+	err := p.db.WithContext(ctx).
+		Model(&clusterSession{}).
+		Where("renewed_at >= ?", p.cutoff()).
+		Pluck("agent_id", &agentIDs).Error
+	return agentIDs, err
+}
+
+func (p *Postgres) SetSessionSource(source SessionSource) {
+	p.sessions = source
+}
+
+func (p *Postgres) Send(ctx context.Context, nodeID string, msg Message) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&clusterMessage{
+			NodeID:  nodeID,
+			AgentID: msg.AgentID,
+			Kind:    msg.Kind,
+			Data:    msg.Data,
+			SentAt:  time.Now().UnixMilli(),
+		}).Error; err != nil {
+			return err
+		}
+		// 通知在事务提交后送达，目标节点一定能读到消息
+		return tx.Exec("SELECT pg_notify(?, ?)", notifyChannel, nodeID).Error
+	})
+}
+
+func (p *Postgres) SetMessageHandler(handler MessageHandler) {
+	p.onMessage = handler
+}
+
+func (p *Postgres) PutLatest(ctx context.Context, snapshots map[string][]byte) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+	now := time.Now().UnixMilli()
+	rows := make([]clusterLatest, 0, len(snapshots))
+	for agentID, data := range snapshots {
+		rows = append(rows, clusterLatest{AgentID: agentID, Data: data, SavedAt: now})
+	}
+	return p.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "agent_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"data", "saved_at"}),
+		}).
+		Create(&rows).Error
+}
+
+func (p *Postgres) GetLatest(ctx context.Context, agentID string) ([]byte, bool, error) {
+	var latest clusterLatest
+	err := p.db.WithContext(ctx).
+		Where("agent_id = ? AND saved_at >= ?", agentID, p.cutoff()).
+		First(&latest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return latest.Data, true, nil
+}
+
+func (p *Postgres) ListLatest(ctx context.Context, prefix string) (map[string][]byte, error) {
+	var rows []clusterLatest
+	err := p.db.WithContext(ctx).
+		Where(`agent_id LIKE ? ESCAPE '\' AND saved_at >= ?`, escapeLike(prefix)+"%", p.cutoff()).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make(map[string][]byte, len(rows))
+	for _, row := range rows {
+		snapshots[row.AgentID] = row.Data
+	}
+	return snapshots, nil
+}
+
+// escapeLike 转义 LIKE 中的通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// Run 监听转发消息，定时续期本节点的会话并清理过期数据
+func (p *Postgres) Run(ctx context.Context) {
+	go p.listen(ctx)
+	// 启动时立即竞选主节点，无需等待第一次续期
+	p.elect(ctx)
+
+	ticker := time.NewTicker(p.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// 退出前释放本节点的会话，其他节点无需等待会话过期
+			cleanupCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := p.db.WithContext(cleanupCtx).Where("node_id = ?", p.nodeID).Delete(&clusterSession{}).Error; err != nil {
+				p.logger.Warn("failed to release cluster sessions", zap.Error(err))
+			}
+			// 让出主节点，其他节点下次续期时即可接替
+			p.leaderUntil.Store(0)
+			if err := p.db.WithContext(cleanupCtx).Where("name = ? AND node_id = ?", leaderName, p.nodeID).Delete(&clusterLeader{}).Error; err != nil {
+				p.logger.Warn("failed to release cluster leader", zap.Error(err))
+			}
+			cancel()
+			return
+		case <-ticker.C:
+			p.maintain(ctx)
+		}
+	}
+}
+
+func (p *Postgres) maintain(ctx context.Context) {
+	db := p.db.WithContext(ctx)
+	p.elect(ctx)
+	if p.sessions != nil {
+		p.renew(ctx, p.sessions())
+	}
+
+	cutoff := p.cutoff()
+	if err := db.Where("renewed_at < ?", cutoff).Delete(&clusterSession{}).Error; err != nil {
+		p.logger.Warn("failed to delete expired cluster sessions", zap.Error(err))
+	}
+	// 目标节点已退出的消息不再处理
+	if err := db.Where("sent_at < ?", cutoff).Delete(&clusterMessage{}).Error; err != nil {
+		p.logger.Warn("failed to delete expired cluster messages", zap.Error(err))
+	}
+	if err := db.Where("saved_at < ?", cutoff).Delete(&clusterLatest{}).Error; err != nil {
+		p.logger.Warn("failed to delete expired latest metrics", zap.Error(err))
+	}
+}
+
+// renew 续期本节点持有的会话，补回被误清理的会话，删除本节点已断开的会话
+func (p *Postgres) renew(ctx context.Context, agentIDs []string) {
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		release := tx.Where("node_id = ?", p.nodeID)
+		if len(agentIDs) > 0 {
+			release = release.Where("agent_id NOT IN ?", agentIDs)
+		}
+		if err := release.Delete(&clusterSession{}).Error; err != nil {
+			return err
+		}
+		if len(agentIDs) == 0 {
+			return nil
+		}
+
+		now := time.Now().UnixMilli()
+		if err := tx.Model(&clusterSession{}).
+			Where("node_id = ? AND agent_id IN ?", p.nodeID, agentIDs).
+			Update("renewed_at", now).Error; err != nil {
+			return err
+		}
+		// 探针已重新连接到其他节点时保留其他节点的记录
+		rows := make([]clusterSession, 0, len(agentIDs))
+		for _, agentID := range agentIDs {
+			rows = append(rows, clusterSession{AgentID: agentID, NodeID: p.nodeID, RenewedAt: now})
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+	})
+	if err != nil {
+		p.logger.Warn("failed to renew cluster sessions", zap.Error(err))
+	}
+}
+
+// elect 续期主节点，没有主节点或主节点超过 ttl 没有续期时由本节点接替
+func (p *Postgres) elect(ctx context.Context) {
+	now := time.Now()
+	result := p.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"node_id", "renewed_at"}),
+			Where: clause.Where{Exprs: []clause.Expression{
+				gorm.Expr("cluster_leaders.node_id = ? OR cluster_leaders.renewed_at < ?", p.nodeID, p.cutoff()),
+			}},
+		}).
+		Create(&clusterLeader{Name: leaderName, NodeID: p.nodeID, RenewedAt: now.UnixMilli()})
+	if result.Error != nil {
+		// 续期失败时保留原有效期，到期后自动放弃
+		p.logger.Warn("failed to elect cluster leader", zap.Error(result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		p.leaderUntil.Store(0)
+		return
+	}
+	// 有效期短于其他节点接替所需的 ttl，续期失败时本节点先放弃，避免两个节点同时执行
+	p.leaderUntil.Store(now.Add(p.ttl * 2 / 3).UnixMilli())
+}
+
+// listen 持续监听通知，连接断开后重试
+func (p *Postgres) listen(ctx context.Context) {
+	for {
+		err := p.listenOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		p.logger.Warn("cluster listener disconnected, retrying", zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func (p *Postgres) listenOnce(ctx context.Context) error {
+	sqlDB, err := p.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unsupported postgres driver connection: %T", driverConn)
+		}
+		pgConn := stdConn.Conn()
+		if _, err := pgConn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+			return err
+		}
+		defer func() {
+			// 连接归还连接池前取消监听
+			unlistenCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			_, _ = pgConn.Exec(unlistenCtx, "UNLISTEN "+notifyChannel)
+			cancel()
+		}()
+
+		// 监听建立前可能已有发给本节点的消息
+		p.receive(ctx)
+
+		for {
+			notification, err := pgConn.WaitForNotification(ctx)
+			if err != nil {
+				return err
+			}
+			if notification.Payload == p.nodeID {
+				p.receive(ctx)
+			}
+		}
+	})
+}
+
+// receive 取出并处理发给本节点的所有消息
+func (p *Postgres) receive(ctx context.Context) {
+	var messages []clusterMessage
+	if err := p.db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("node_id = ?", p.nodeID).
+		Delete(&messages).Error; err != nil {
+		p.logger.Warn("failed to receive cluster messages", zap.Error(err))
+		return
+	}
+	if p.onMessage == nil {
+		return
+	}
+
+	// 按发送顺序处理
+	slices.SortFunc(messages, func(a, b clusterMessage) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	for _, msg := range messages {
+		p.onMessage(ctx, Message{AgentID: msg.AgentID, Kind: msg.Kind, Data: msg.Data})
+	}
+}
@@ -0,0 +1,261 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestNodes 创建共用同一个数据库的多个节点，除转发消息（依赖 LISTEN/NOTIFY）外的逻辑与 PostgreSQL 一致
+func newTestNodes(t *testing.T, nodeIDs ...string) []*Postgres {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "cluster.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&clusterSession{}, &clusterMessage{}, &clusterLatest{}, &clusterLeader{}); err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := make([]*Postgres, 0, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		nodes = append(nodes, &Postgres{db: db, logger: zap.NewNop(), nodeID: nodeID, ttl: defaultSessionTTL})
+	}
+	return nodes
+}
+
+// expire 将表中所有记录的时间改为已过期
+func expire(t *testing.T, p *Postgres, model interface{}, column string) {
+	t.Helper()
+	old := time.Now().Add(-2 * p.ttl).UnixMilli()
+	if err := p.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Model(model).Update(column, old).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	ctx := context.Background()
+	nodes := newTestNodes(t, "n1", "n2")
+	n1, n2 := nodes[0], nodes[1]
+
+	if _, err := n1.Lookup(ctx, "a"); !errors.Is(err, ErrNoSession) {
+		t.Fatalf("没有会话时应返回 ErrNoSession，实际为 %v", err)
+	}
+
+	if err := n1.Claim(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	// 探针重新连接到 n2
+	if err := n2.Claim(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	// n1 稍后才处理断开，不应影响 n2 的记录
+	if err := n1.Release(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if nodeID, err := n1.Lookup(ctx, "a"); err != nil || nodeID != "n2" {
+		t.Errorf("探针应连接在 n2，实际为 %q %v", nodeID, err)
+	}
+
+	if err := n1.Claim(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := n2.Sessions(ctx)
+	slices.Sort(sessions)
+	if err != nil || !reflect.DeepEqual(sessions, []string{"a", "b"}) {
+		t.Errorf("会话为 %v %v，应为 [a b]", sessions, err)
+	}
+
+	// 过期的会话视为不存在
+	expire(t, n1, &clusterSession{}, "renewed_at")
+	if _, err := n1.Lookup(ctx, "a"); !errors.Is(err, ErrNoSession) {
+		t.Errorf("会话过期后应返回 ErrNoSession，实际为 %v", err)
+	}
+	if sessions, _ := n1.Sessions(ctx); len(sessions) != 0 {
+		t.Errorf("会话过期后不应返回: %v", sessions)
+	}
+}
+
+func TestRenew(t *testing.T) {
+	ctx := context.Background()
+	nodes := newTestNodes(t, "n1", "n2")
+	n1, n2 := nodes[0], nodes[1]
+
+	for _, agentID := range []string{"a", "b", "c"} {
+		if err := n1.Claim(ctx, agentID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// b 已重新连接到 n2，n1 仍未处理断开
+	if err := n2.Claim(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+	expire(t, n1, &clusterSession{}, "renewed_at")
+	// a 的记录被误清理
+	if err := n1.db.Where("agent_id = ?", "a").Delete(&clusterSession{}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// n1 实际持有 a 和 b，c 已断开
+	n1.renew(ctx, []string{"a", "b"})
+
+	var sessions []clusterSession
+	if err := n1.db.Order("agent_id").Find(&sessions).Error; err != nil {
+		t.Fatal(err)
+	}
+	cutoff := n1.cutoff()
+	got := make(map[string]string, len(sessions))
+	for _, session := range sessions {
+		if session.NodeID == "n1" && session.RenewedAt < cutoff {
+			t.Errorf("%s 的会话没有续期", session.AgentID)
+		}
+		got[session.AgentID] = session.NodeID
+	}
+	want := map[string]string{"a": "n1", "b": "n2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("续期后的会话为 %v，应为 %v", got, want)
+	}
+
+	// 没有持有任何连接时释放本节点的所有会话
+	n1.renew(ctx, nil)
+	if nodeID, err := n2.Lookup(ctx, "a"); !errors.Is(err, ErrNoSession) {
+		t.Errorf("a 的会话应已释放，实际在 %q", nodeID)
+	}
+}
+
+func TestLatest(t *testing.T) {
+	ctx := context.Background()
+	nodes := newTestNodes(t, "n1", "n2")
+	n1, n2 := nodes[0], nodes[1]
+
+	if err := n1.PutLatest(ctx, map[string][]byte{"a": []byte("1"), "monitor@n_1": []byte("m1")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := n2.PutLatest(ctx, map[string][]byte{"a": []byte("2"), "monitor@nx1": []byte("m2")}); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, ok, err := n1.GetLatest(ctx, "a"); err != nil || !ok || string(data) != "2" {
+		t.Errorf("a 的快照为 %q %v %v，应为 2", data, ok, err)
+	}
+	if _, ok, err := n1.GetLatest(ctx, "b"); err != nil || ok {
+		t.Errorf("不存在的快照应返回 false: %v %v", ok, err)
+	}
+
+	snapshots, err := n1.ListLatest(ctx, "monitor@")
+	want := map[string][]byte{"monitor@n_1": []byte("m1"), "monitor@nx1": []byte("m2")}
+	if err != nil || !reflect.DeepEqual(snapshots, want) {
+		t.Errorf("快照为 %q %v，应为 %q", snapshots, err, want)
+	}
+	// 前缀中的 _ 不是通配符
+	snapshots, err = n1.ListLatest(ctx, "monitor@n_")
+	if err != nil || len(snapshots) != 1 || snapshots["monitor@n_1"] == nil {
+		t.Errorf("快照为 %q %v，应只有 monitor@n_1", snapshots, err)
+	}
+
+	expire(t, n1, &clusterLatest{}, "saved_at")
+	if _, ok, _ := n1.GetLatest(ctx, "a"); ok {
+		t.Error("过期的快照不应返回")
+	}
+	if snapshots, _ := n1.ListLatest(ctx, "monitor@"); len(snapshots) != 0 {
+		t.Errorf("过期的快照不应返回: %q", snapshots)
+	}
+}
+
+func TestElect(t *testing.T) {
+	ctx := context.Background()
+	nodes := newTestNodes(t, "n1", "n2")
+	n1, n2 := nodes[0], nodes[1]
+
+	if n1.Leader() {
+		t.Fatal("竞选前不应是主节点")
+	}
+	n1.elect(ctx)
+	n2.elect(ctx)
+	if !n1.Leader() || n2.Leader() {
+		t.Fatalf("n1 应为主节点: n1=%v n2=%v", n1.Leader(), n2.Leader())
+	}
+
+	// 主节点续期
+	n1.elect(ctx)
+	n2.elect(ctx)
+	if !n1.Leader() || n2.Leader() {
+		t.Fatalf("续期后 n1 应仍为主节点: n1=%v n2=%v", n1.Leader(), n2.Leader())
+	}
+
+	// n1 超过 ttl 没有续期，本地有效期先于其他节点接替到期
+	expire(t, n1, &clusterLeader{}, "renewed_at")
+	n1.leaderUntil.Store(time.Now().Add(-time.Second).UnixMilli())
+	if n1.Leader() {
+		t.Error("有效期过后不应再是主节点")
+	}
+	n2.elect(ctx)
+	n1.elect(ctx)
+	if n1.Leader() || !n2.Leader() {
+		t.Errorf("n2 应接替为主节点: n1=%v n2=%v", n1.Leader(), n2.Leader())
+	}
+}
+
+func TestMaintain(t *testing.T) {
+	ctx := context.Background()
+	nodes := newTestNodes(t, "n1", "n2")
+	n1, n2 := nodes[0], nodes[1]
+	n1.SetSessionSource(func() []string { return []string{"a"} })
+
+	if err := n2.Claim(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := n2.PutLatest(ctx, map[string][]byte{"b": []byte("1")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := n2.db.Create(&clusterMessage{NodeID: "n3", AgentID: "c", Kind: KindSend, SentAt: time.Now().UnixMilli()}).Error; err != nil {
+		t.Fatal(err)
+	}
+	// n2 已退出
+	expire(t, n2, &clusterSession{}, "renewed_at")
+	expire(t, n2, &clusterLatest{}, "saved_at")
+	expire(t, n2, &clusterMessage{}, "sent_at")
+
+	n1.maintain(ctx)
+
+	if !n1.Leader() {
+		t.Error("续期时应竞选主节点")
+	}
+	var sessions []string
+	if err := n1.db.Model(&clusterSession{}).Pluck("agent_id", &sessions).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sessions, []string{"a"}) {
+		t.Errorf("会话为 %v，应只保留 n1 持有的 a", sessions)
+	}
+	for _, model := range []interface{}{&clusterLatest{}, &clusterMessage{}} {
+		var count int64
+		if err := n1.db.Model(model).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Errorf("%T 过期的记录应被清理，剩余 %d 条", model, count)
+		}
+	}
+}
+
+func TestLocal(t *testing.T) {
+	var backend Backend = NewLocal()
+	if backend.Distributed() || !backend.Leader() {
+		t.Error("单节点后端不共享数据，始终是主节点")
+	}
+	if _, err := backend.Lookup(context.Background(), "a"); !errors.Is(err, ErrNoSession) {
+		t.Errorf("单节点后端不记录会话，实际为 %v", err)
+	}
+}
@@ -8,6 +8,9 @@ type AppConfig struct {
 	GitHub          *GitHubOAuthConfig `json:"GitHub"`          // GitHub OAuth配置（可选）
 	GeoIP           *GeoIPConfig       `json:"GeoIP"`           // GeoIP配置（可选）
 	VictoriaMetrics *VMConfig          `json:"VictoriaMetrics"` // VictoriaMetrics配置（可选）
+	AgentTLS        *AgentTLSConfig    `json:"AgentTLS"`        // 探针客户端证书配置（可选）
+	TrustedProxies  []string           `json:"TrustedProxies"`  // 受信任的反向代理地址（IP 或 CIDR），只采用这些代理传递的证书主题请求头和转发 IP（可选）
+	Cluster         *ClusterConfig     `json:"Cluster"`         // 多节点部署配置（可选）
 }
 
 // JWTConfig JWT配置
@@ -45,7 +48,24 @@ type GeoIPConfig struct {
 type VMConfig struct {
 	Enabled       bool   `json:"Enabled"`       // 是否启用VictoriaMetrics
 	URL           string `json:"URL"`           // VictoriaMetrics地址
-	RetentionDays int    `json:"RetentionDays"` // 数据保留天数（用于文档说明）
+	RetentionDays int    `json:"RetentionDays"` // 数据保留天数，应与 VictoriaMetrics 的 -retentionPeriod 一致，更早的补发指标直接丢弃
 	WriteTimeout  int    `json:"WriteTimeout"`  // 写入超时（秒）
 	QueryTimeout  int    `json:"QueryTimeout"`  // 查询超时（秒）
+	BatchSize     int    `json:"BatchSize"`     // 每次批量写入的最大指标数
+	FlushInterval int    `json:"FlushInterval"` // 批量写入间隔（毫秒）
+	MaxBuffered   int    `json:"MaxBuffered"`   // 写入缓冲区最多保留的指标数，超出时丢弃最旧的数据
+}
+
+// AgentTLSConfig 探针客户端证书（mTLS）配置
+type AgentTLSConfig struct {
+	ClientCAFile      string `json:"ClientCAFile"`      // 签发探针客户端证书的 CA（PEM），服务端启用 HTTPS 时用于校验探针证书
+	RequireClientCert bool   `json:"RequireClientCert"` // 探针注册时必须提供客户端证书
+	SubjectHeader     string `json:"SubjectHeader"`     // 由反向代理终止 TLS 时，从该请求头读取代理已校验的证书主题（如：X-SSL-Client-S-DN），只采用 TrustedProxies 中的代理传递的请求头
+}
+
+// ClusterConfig 多节点部署配置
+type ClusterConfig struct {
+	Backend    string `json:"Backend"`    // 共享探针会话的后端：local（默认，单节点）、postgres（需使用 PostgreSQL 数据库）
+	NodeID     string `json:"NodeID"`     // 节点标识，为空时根据主机名生成
+	SessionTTL int    `json:"SessionTTL"` // 节点失联多久后视为退出（秒），默认 90
 }
@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"slices"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	"github.com/dushixiang/pika"
+	"github.com/dushixiang/pika/internal/config"
 	"github.com/dushixiang/pika/internal/models"
 	"github.com/dushixiang/pika/internal/protocol"
 	"github.com/dushixiang/pika/internal/service"
@@ -23,6 +25,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// 未配置 VictoriaMetrics.RetentionDays 时补发指标的最大天数，与 VictoriaMetrics 默认的保留时长（1 个月）一致
+const defaultBackfillRetentionDays = 30
+
 type AgentHandler struct {
 	logger        *zap.Logger
 	agentService  *service.AgentService
@@ -30,12 +35,23 @@ type AgentHandler struct {
 	monitorSvc    *service.MonitorService
 	tamperService *service.TamperService
 	ddnsService   *service.DDNSService
+	logService    *service.LogService
 	wsManager     *ws.Manager
 	upgrader      websocket.Upgrader
+
+	agentConfigService *service.AgentConfigService
+
+	maxBackfillAge time.Duration // 补发指标的最大时长，超出数据保留时长的指标直接丢弃
 }
 
 func NewAgentHandler(logger *zap.Logger, agentService *service.AgentService, metricService *service.MetricService,
-	monitorService *service.MonitorService, tamperService *service.TamperService, ddnsService *service.DDNSService, wsManager *ws.Manager) *AgentHandler {
+	monitorService *service.MonitorService, tamperService *service.TamperService, ddnsService *service.DDNSService,
+	logService *service.LogService, wsManager *ws.Manager, agentConfigService *service.AgentConfigService, cfg *config.AppConfig) *AgentHandler {
+
+	retentionDays := defaultBackfillRetentionDays
+	if cfg.VictoriaMetrics != nil && cfg.VictoriaMetrics.RetentionDays > 0 {
+		retentionDays = cfg.VictoriaMetrics.RetentionDays
+	}
 
 	h := &AgentHandler{
 		logger:        logger,
@@ -44,13 +60,19 @@ func NewAgentHandler(logger *zap.Logger, agentService *service.AgentService, met
 		monitorSvc:    monitorService,
 		tamperService: tamperService,
 		ddnsService:   ddnsService,
+		logService:    logService,
 		wsManager:     wsManager,
+
+		agentConfigService: agentConfigService,
+
+		maxBackfillAge: time.Duration(retentionDays) * 24 * time.Hour,
 	}
 
 	// 初始化upgrader，需要在创建handler之后因为需要引用h.checkOrigin
 	h.upgrader = websocket.Upgrader{
-		ReadBufferSize:  1024 * 32,
-		WriteBufferSize: 1024 * 32,
+		ReadBufferSize:    1024 * 32,
+		WriteBufferSize:   1024 * 32,
+		EnableCompression: true,
 	}
 
 	// 设置WebSocket消息处理器
@@ -102,31 +124,27 @@ func (h *AgentHandler) HandleWebSocket(c echo.Context) error {
 	}
 
 	// 注册探针 - 使用独立的context,不依赖HTTP请求的context
-	agent, err := h.agentService.RegisterAgent(context.Background(), c.RealIP(), &registerReq.AgentInfo, registerReq.ApiKey)
+	ip := c.RealIP()
+	agent, token, err := h.agentService.RegisterAgent(context.Background(), ip, h.agentService.TrustedClientIP(c.Request(), ip), &registerReq, h.agentService.ClientCertSubject(c.Request()))
 	if err != nil {
 		// 发送注册失败响应
-		h.sendRegisterError(conn, err.Error())
+		h.sendRegisterError(conn, err)
 		conn.Close()
 		return err
 	}
 
-	defer func() {
-		// 设置探针状态为离线
-		_ = h.agentService.UpdateAgentStatus(context.Background(), agent.ID, 0)
-	}()
-
 	// 发送注册成功响应
-	if err := h.sendRegisterSuccess(conn, agent.ID); err != nil {
+	if err := h.sendRegisterSuccess(conn, agent.ID, token); err != nil {
 		h.logger.Error("failed to send register ack", zap.Error(err))
 		conn.Close()
 		return err
 	}
 
-	// 下发防篡改配置
-	if err := h.sendTamperConfig(conn, agent.ID); err != nil {
-		h.logger.Error("failed to send tamper config", zap.Error(err))
-		// 配置下发失败不中断连接，只记录日志
-	}
+	// 下发防篡改、日志监控等配置，并重新下发探针离线期间未应用的配置
+	// 配置下发失败不中断连接，只记录日志
+	h.agentConfigService.Resync(context.Background(), agent, func(data []byte) error {
+		return conn.WriteMessage(websocket.TextMessage, data)
+	})
 
 	// 创建客户端并注册到管理器
 	client := &ws.Client{
@@ -149,8 +167,8 @@ func (h *AgentHandler) HandleWebSocket(c echo.Context) error {
 func (h *AgentHandler) handleWebSocketMessage(ctx context.Context, agentID string, messageType string, data json.RawMessage) error {
 	switch protocol.MessageType(messageType) {
 	case protocol.MessageTypeHeartbeat:
-		// 心跳消息，更新探针状态
-		return h.agentService.UpdateAgentStatus(ctx, agentID, 1)
+		// 心跳只用于保持连接活跃，最后活跃时间由 WebSocket 管理器记录并批量写入
+		return nil
 
 	case protocol.MessageTypeMetrics:
 		// 指标数据
@@ -162,7 +180,81 @@ func (h *AgentHandler) handleWebSocketMessage(ctx context.Context, agentID strin
 		if err != nil {
 			return err
 		}
-		return h.metricService.HandleMetricData(ctx, agentID, string(metricsWrapper.Type), json.RawMessage(metricsData))
+		return h.metricService.HandleMetricData(ctx, agentID, string(metricsWrapper.Type), 0, json.RawMessage(metricsData))
+
+	case protocol.MessageTypeMetricsBatch:
+		// 一次采集的所有指标，逐条处理，单条失败不影响其他指标
+		var batch []struct {
+			Type protocol.MetricType `json:"type"`
+			Data json.RawMessage     `json:"data"`
+		}
+		if err := json.Unmarshal(data, &batch); err != nil {
+			return err
+		}
+		for _, item := range batch {
+			if err := h.metricService.HandleMetricData(ctx, agentID, string(item.Type), 0, item.Data); err != nil {
+				h.logger.Warn("failed to handle metric data",
+					zap.String("agentID", agentID),
+					zap.String("type", string(item.Type)),
+					zap.Error(err))
+			}
+		}
+		return nil
+
+	case protocol.MessageTypeMetricsBackfill:
+		// 探针断线期间缓存的历史指标，按采集时间写入，不更新最新指标
+		var backfill struct {
+			SentAt  int64 `json:"sentAt"`
+			Samples []struct {
+				Type      protocol.MetricType `json:"type"`
+				Data      json.RawMessage     `json:"data"`
+				Timestamp int64               `json:"timestamp"`
+			} `json:"samples"`
+		}
+		if err := json.Unmarshal(data, &backfill); err != nil {
+			return err
+		}
+		// 以探针发送时间和服务端接收时间的差值校正探针时钟偏差
+		now := time.Now().UnixMilli()
+		var offset int64
+		if backfill.SentAt > 0 {
+			offset = now - backfill.SentAt
+		}
+		oldest := now - h.maxBackfillAge.Milliseconds()
+		var expired int
+		for _, item := range backfill.Samples {
+			timestamp := item.Timestamp + offset
+			if item.Timestamp <= 0 || timestamp < oldest {
+				expired++
+				continue
+			}
+			timestamp = min(timestamp, now)
+			if err := h.metricService.HandleMetricData(ctx, agentID, string(item.Type), timestamp, item.Data); err != nil {
+				h.logger.Warn("failed to handle backfill metric data",
+					zap.String("agentID", agentID),
+					zap.String("type", string(item.Type)),
+					zap.Error(err))
+			}
+		}
+		if expired > 0 {
+			h.logger.Warn("dropped expired backfill metric data",
+				zap.String("agentID", agentID),
+				zap.Int("count", expired))
+		}
+		return nil
+
+	case protocol.MessageTypeCredentialAck:
+		// 探针已保存轮换后的凭证
+		return h.agentService.ConfirmCredential(ctx, agentID)
+
+	case protocol.MessageTypeAck:
+		// 配置应用结果确认
+		var ack protocol.AckData
+		if err := json.Unmarshal(data, &ack); err != nil {
+			h.logger.Error("failed to unmarshal ack", zap.Error(err))
+			return err
+		}
+		return h.agentConfigService.HandleAck(ctx, agentID, &ack)
 
 	case protocol.MessageTypeCommandResp:
 		// 指令响应
@@ -199,6 +291,15 @@ func (h *AgentHandler) handleWebSocketMessage(ctx context.Context, agentID strin
 		}
 		return h.ddnsService.HandleIPReport(ctx, agentID, &ipReport)
 
+	case protocol.MessageTypeLogEvent:
+		// 日志规则匹配到的日志行
+		var events []protocol.LogEventData
+		if err := json.Unmarshal(data, &events); err != nil {
+			h.logger.Error("failed to unmarshal log events", zap.Error(err))
+			return err
+		}
+		return h.logService.HandleEvents(ctx, agentID, events)
+
 	case protocol.MessageTypeTamperProtect:
 		// 防篡改配置响应
 		var protectResp protocol.TamperProtectResponse
@@ -228,11 +329,15 @@ func (h *AgentHandler) handleWebSocketMessage(ctx context.Context, agentID strin
 	}
 }
 
-// sendRegisterSuccess 发送注册成功响应
-func (h *AgentHandler) sendRegisterSuccess(conn *websocket.Conn, agentID string) error {
+// sendRegisterSuccess 发送注册成功响应，token 不为空时携带新签发的探针凭证
+func (h *AgentHandler) sendRegisterSuccess(conn *websocket.Conn, agentID, token string) error {
 	resp := protocol.RegisterResponse{
-		AgentID: agentID,
-		Status:  "success",
+		AgentID:  agentID,
+		Status:   "success",
+		Token:    token,
+		Features: []string{protocol.FeatureMetricsBatch, protocol.FeatureMetricsBackfill},
+
+		ProtocolVersion: protocol.ProtocolVersion,
 	}
 	return conn.WriteJSON(protocol.OutboundMessage{
 		Type: protocol.MessageTypeRegisterAck,
@@ -241,10 +346,11 @@ func (h *AgentHandler) sendRegisterSuccess(conn *websocket.Conn, agentID string)
 }
 
 // sendRegisterError 发送注册失败响应
-func (h *AgentHandler) sendRegisterError(conn *websocket.Conn, errMsg string) error {
+func (h *AgentHandler) sendRegisterError(conn *websocket.Conn, err error) error {
 	resp := protocol.RegisterResponse{
-		Status:  "error",
-		Message: errMsg,
+		Status:         "error",
+		Message:        err.Error(),
+		ApiKeyRequired: errors.Is(err, service.ErrApiKeyRequired),
 	}
 
 	return conn.WriteJSON(protocol.OutboundMessage{
@@ -253,39 +359,6 @@ func (h *AgentHandler) sendRegisterError(conn *websocket.Conn, errMsg string) er
 	})
 }
 
-// sendTamperConfig 发送防篡改配置（探针初始化时发送完整配置作为新增）
-func (h *AgentHandler) sendTamperConfig(conn *websocket.Conn, agentID string) error {
-	// 获取探针的防篡改配置
-	config, err := h.tamperService.GetConfigByAgentID(agentID)
-	if err != nil {
-		return err
-	}
-
-	// 构建配置数据 - 将完整配置作为新增发送（探针刚连接，所有路径都是新增）
-	var paths []string
-	if config != nil && len(config.Paths) > 0 {
-		paths = config.Paths
-	} else {
-		paths = []string{} // 空列表
-	}
-
-	// 使用增量配置格式，将所有路径作为新增
-	configData := protocol.TamperProtectConfig{
-		Added:   paths,
-		Removed: []string{}, // 初始化时没有需要移除的
-	}
-
-	msgData, err := json.Marshal(protocol.OutboundMessage{
-		Type: protocol.MessageTypeTamperProtect,
-		Data: configData,
-	})
-	if err != nil {
-		return err
-	}
-
-	return conn.WriteMessage(websocket.TextMessage, msgData)
-}
-
 // Paging 探针分页查询
 func (h *AgentHandler) Paging(c echo.Context) error {
 	hostname := c.QueryParam("hostname")
@@ -437,7 +510,10 @@ func (h *AgentHandler) GetMetrics(c echo.Context) error {
 	// 验证指标类型
 	validTypes := map[string]bool{
 		"cpu": true, "memory": true, "disk": true, "network": true, "network_connection": true,
-		"disk_io": true, "gpu": true, "temperature": true, "monitor": true,
+		"disk_io": true, "gpu": true, "temperature": true, "monitor": true, "process": true,
+		"container": true, "systemd": true, "disk_health": true, "log": true,
+		"cpu_mode": true, "cpu_core": true, "inode": true,
+		"load": true, "pressure": true, "context_switch": true, "file_descriptor": true, "entropy": true,
 	}
 	if metricType == "" {
 		return orz.NewError(400, "指标类型不能为空")
@@ -445,6 +521,13 @@ func (h *AgentHandler) GetMetrics(c echo.Context) error {
 	if !validTypes[metricType] {
 		return orz.NewError(400, "无效的指标类型")
 	}
+	// 进程、容器、systemd 单元名称、磁盘信息和日志规则属于敏感信息，仅登录后可查询
+	sensitiveTypes := map[string]bool{
+		"process": true, "container": true, "systemd": true, "disk_health": true, "log": true,
+	}
+	if sensitiveTypes[metricType] && !utils.IsAuthenticated(c) {
+		return orz.NewError(401, "未登录")
+	}
 
 	// 解析时间范围
 	start, end, err := parseTimeRangeOrStartEnd(rangeParam, startParam, endParam)
@@ -480,6 +563,63 @@ func (h *AgentHandler) GetLatestMetrics(c echo.Context) error {
 	return orz.Ok(c, metrics)
 }
 
+// GetProcesses 获取探针最新的进程数据（Top 进程和关注进程）
+func (h *AgentHandler) GetProcesses(c echo.Context) error {
+	id := c.Param("id")
+
+	metrics, ok := h.metricService.GetLatestMetrics(id)
+	if !ok || metrics.Process == nil {
+		return orz.NewError(404, "探针进程数据不存在")
+	}
+
+	return orz.Ok(c, metrics.Process)
+}
+
+// GetContainers 获取探针最新的容器数据
+func (h *AgentHandler) GetContainers(c echo.Context) error {
+	id := c.Param("id")
+
+	metrics, ok := h.metricService.GetLatestMetrics(id)
+	if !ok {
+		return orz.NewError(404, "探针不存在或离线")
+	}
+	if metrics.Containers == nil {
+		return orz.Ok(c, []protocol.ContainerData{})
+	}
+
+	return orz.Ok(c, metrics.Containers)
+}
+
+// GetDiskHealth 获取探针最新的磁盘健康数据
+func (h *AgentHandler) GetDiskHealth(c echo.Context) error {
+	id := c.Param("id")
+
+	metrics, ok := h.metricService.GetLatestMetrics(id)
+	if !ok {
+		return orz.NewError(404, "探针不存在或离线")
+	}
+	if metrics.DiskHealth == nil {
+		return orz.Ok(c, []protocol.DiskHealthData{})
+	}
+
+	return orz.Ok(c, metrics.DiskHealth)
+}
+
+// GetSystemdUnits 获取探针最新的 systemd 单元状态
+func (h *AgentHandler) GetSystemdUnits(c echo.Context) error {
+	id := c.Param("id")
+
+	metrics, ok := h.metricService.GetLatestMetrics(id)
+	if !ok {
+		return orz.NewError(404, "探针不存在或离线")
+	}
+	if metrics.Systemd == nil {
+		return orz.Ok(c, []protocol.SystemdUnitData{})
+	}
+
+	return orz.Ok(c, metrics.Systemd)
+}
+
 // GetAvailableNetworkInterfaces 获取探针的可用网卡列表（公开接口，已登录返回全部，未登录返回公开可见）
 func (h *AgentHandler) GetAvailableNetworkInterfaces(c echo.Context) error {
 	id := c.Param("id")
@@ -500,6 +640,55 @@ func (h *AgentHandler) GetAvailableNetworkInterfaces(c echo.Context) error {
 	})
 }
 
+// GetPrometheusTargets 获取探针 Prometheus 抓取目标的最新状态
+func (h *AgentHandler) GetPrometheusTargets(c echo.Context) error {
+	id := c.Param("id")
+
+	metrics, ok := h.metricService.GetLatestMetrics(id)
+	if !ok {
+		return orz.NewError(404, "探针不存在或离线")
+	}
+	if metrics.PrometheusTargets == nil {
+		return orz.Ok(c, []protocol.PrometheusScrapeData{})
+	}
+
+	return orz.Ok(c, metrics.PrometheusTargets)
+}
+
+// GetCustomMetricNames 获取探针上报过的自定义指标名称
+func (h *AgentHandler) GetCustomMetricNames(c echo.Context) error {
+	id := c.Param("id")
+	ctx := c.Request().Context()
+
+	names, err := h.metricService.GetCustomMetricNames(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, orz.Map{
+		"names": names,
+	})
+}
+
+// GetCustomMetrics 获取自定义指标数据
+func (h *AgentHandler) GetCustomMetrics(c echo.Context) error {
+	id := c.Param("id")
+	name := c.Param("name")
+	ctx := c.Request().Context()
+
+	start, end, err := parseTimeRangeOrStartEnd(c.QueryParam("range"), c.QueryParam("start"), c.QueryParam("end"))
+	if err != nil {
+		return orz.NewError(400, err.Error())
+	}
+
+	metrics, err := h.metricService.GetCustomMetrics(ctx, id, name, start, end)
+	if err != nil {
+		return orz.NewError(400, "无效的自定义指标名称")
+	}
+
+	return orz.Ok(c, metrics)
+}
+
 // GetAgents 获取探针列表（公开接口，已登录返回全部，未登录返回公开可见）
 func (h *AgentHandler) GetAgents(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -582,6 +771,11 @@ func (h *AgentHandler) DownloadAgent(c echo.Context) error {
 	return c.Stream(http.StatusOK, "application/octet-stream", agentFile)
 }
 
+// 指令需要的探针能力
+var commandCapabilities = map[string]string{
+	"vps_audit": protocol.CapabilityAudit,
+}
+
 // SendCommand 向探针发送指令
 func (h *AgentHandler) SendCommand(c echo.Context) error {
 	agentID := c.Param("id")
@@ -591,12 +785,18 @@ func (h *AgentHandler) SendCommand(c echo.Context) error {
 		return orz.NewError(400, "指令类型不能为空")
 	}
 
-	// 检查agent是否在线
-	_, exists := h.wsManager.GetClient(agentID)
-	if !exists {
+	// 检查agent是否在线（可能连接在其他节点）
+	if !h.wsManager.IsOnline(c.Request().Context(), agentID) {
 		return orz.NewError(400, "探针未连接")
 	}
 
+	// 检查探针是否支持该指令
+	if capability, ok := commandCapabilities[cmdType]; ok {
+		if err := h.agentService.RequireCapability(c.Request().Context(), agentID, capability); err != nil {
+			return err
+		}
+	}
+
 	// 生成指令ID
 	cmdID := fmt.Sprintf("%s_%d", cmdType, time.Now().UnixMilli())
 
@@ -627,6 +827,74 @@ func (h *AgentHandler) SendCommand(c echo.Context) error {
 	})
 }
 
+// RotateCredential 轮换探针凭证，探针在线时立即下发新凭证，离线时在下次注册时重新签发
+func (h *AgentHandler) RotateCredential(c echo.Context) error {
+	agentID := c.Param("id")
+	ctx := c.Request().Context()
+
+	token, err := h.agentService.RotateCredential(ctx, agentID)
+	if err != nil {
+		return err
+	}
+
+	if !h.wsManager.IsOnline(ctx, agentID) {
+		return orz.Ok(c, orz.Map{
+			"message": "凭证已轮换，探针下次连接时生效",
+		})
+	}
+
+	msgData, err := json.Marshal(protocol.OutboundMessage{
+		Type: protocol.MessageTypeCredential,
+		Data: protocol.CredentialData{Token: token},
+	})
+	if err != nil {
+		return err
+	}
+	if err := h.wsManager.SendToClient(agentID, msgData); err != nil {
+		h.logger.Warn("failed to send credential", zap.String("agentID", agentID), zap.Error(err))
+		return orz.Ok(c, orz.Map{
+			"message": "凭证已轮换，探针下次连接时生效",
+		})
+	}
+
+	return orz.Ok(c, orz.Map{
+		"message": "凭证已轮换并下发到探针",
+	})
+}
+
+// RevokeCredential 吊销探针凭证并断开连接
+func (h *AgentHandler) RevokeCredential(c echo.Context) error {
+	agentID := c.Param("id")
+	ctx := c.Request().Context()
+
+	if err := h.agentService.RevokeCredential(ctx, agentID); err != nil {
+		return err
+	}
+
+	// 如果探针在线，断开连接
+	if err := h.wsManager.Disconnect(ctx, agentID); err != nil {
+		h.logger.Warn("failed to disconnect agent", zap.String("agentID", agentID), zap.Error(err))
+	}
+
+	return orz.Ok(c, orz.Map{
+		"message": "凭证已吊销",
+	})
+}
+
+// ResetCredential 允许探针使用 API 密钥重新注册
+func (h *AgentHandler) ResetCredential(c echo.Context) error {
+	agentID := c.Param("id")
+	ctx := c.Request().Context()
+
+	if err := h.agentService.ResetCredential(ctx, agentID); err != nil {
+		return err
+	}
+
+	return orz.Ok(c, orz.Map{
+		"message": "已允许探针重新注册",
+	})
+}
+
 // GetAuditResult 获取审计结果(原始数据)
 func (h *AgentHandler) GetAuditResult(c echo.Context) error {
 	agentID := c.Param("id")
@@ -656,6 +924,22 @@ func (h *AgentHandler) ListAuditResults(c echo.Context) error {
 	})
 }
 
+// ListConfigStates 获取探针的配置下发状态
+func (h *AgentHandler) ListConfigStates(c echo.Context) error {
+	agentID := c.Param("id")
+	ctx := c.Request().Context()
+
+	states, err := h.agentConfigService.ListStates(ctx, agentID)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, orz.Map{
+		"items": states,
+		"total": len(states),
+	})
+}
+
 // UpdateInfo 更新探针信息（名称、标签、到期时间、可见性）
 func (h *AgentHandler) UpdateInfo(c echo.Context) error {
 	agentID := c.Param("id")
@@ -665,6 +949,8 @@ func (h *AgentHandler) UpdateInfo(c echo.Context) error {
 		Tags       []string `json:"tags"`
 		ExpireTime int64    `json:"expireTime"`
 		Visibility string   `json:"visibility"`
+		// 未传时保持原有的证书绑定
+		CertSubject *string `json:"certSubject"`
 	}
 	if err := c.Bind(&req); err != nil {
 		return orz.NewError(400, "请求参数错误")
@@ -680,6 +966,9 @@ func (h *AgentHandler) UpdateInfo(c echo.Context) error {
 	agent.Tags = req.Tags
 	agent.ExpireTime = req.ExpireTime
 	agent.Visibility = req.Visibility
+	if req.CertSubject != nil {
+		agent.CertSubject = strings.TrimSpace(*req.CertSubject)
+	}
 	agent.UpdatedAt = time.Now().UnixMilli()
 
 	if err := h.agentService.AgentRepo.Save(ctx, &agent); err != nil {
@@ -714,8 +1003,8 @@ func (h *AgentHandler) Delete(c echo.Context) error {
 	}
 
 	// 如果探针在线，先断开连接
-	if client, exists := h.wsManager.GetClient(agentID); exists {
-		client.Conn.Close()
+	if err := h.wsManager.Disconnect(ctx, agentID); err != nil {
+		h.logger.Warn("failed to disconnect agent", zap.String("agentID", agentID), zap.Error(err))
 	}
 
 	// 删除探针及其所有相关数据
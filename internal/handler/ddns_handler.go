@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/protocol"
 	"github.com/dushixiang/pika/internal/service"
 	"github.com/go-orz/orz"
 	"github.com/google/uuid"
@@ -12,14 +13,16 @@ import (
 )
 
 type DDNSHandler struct {
-	logger      *zap.Logger
-	ddnsService *service.DDNSService
+	logger       *zap.Logger
+	ddnsService  *service.DDNSService
+	agentService *service.AgentService
 }
 
-func NewDDNSHandler(logger *zap.Logger, ddnsService *service.DDNSService) *DDNSHandler {
+func NewDDNSHandler(logger *zap.Logger, ddnsService *service.DDNSService, agentService *service.AgentService) *DDNSHandler {
 	return &DDNSHandler{
-		logger:      logger,
-		ddnsService: ddnsService,
+		logger:       logger,
+		ddnsService:  ddnsService,
+		agentService: agentService,
 	}
 }
 
@@ -116,6 +119,11 @@ func (h *DDNSHandler) Create(c echo.Context) error {
 		return orz.NewError(400, "IPv6 获取方式只能是 api 或 interface")
 	}
 
+	ctx := c.Request().Context()
+	if err := h.agentService.RequireCapability(ctx, req.AgentID, protocol.CapabilityDDNS); err != nil {
+		return err
+	}
+
 	config := &models.DDNSConfig{
 		ID:            uuid.New().String(),
 		AgentID:       req.AgentID,
@@ -134,7 +142,6 @@ func (h *DDNSHandler) Create(c echo.Context) error {
 		UpdatedAt:     time.Now().UnixMilli(),
 	}
 
-	ctx := c.Request().Context()
 	if err := h.ddnsService.CreateConfig(ctx, config); err != nil {
 		h.logger.Error("failed to create ddns config", zap.Error(err))
 		return err
@@ -178,6 +185,12 @@ func (h *DDNSHandler) Update(c echo.Context) error {
 		return err
 	}
 
+	if existing.Enabled {
+		if err := h.agentService.RequireCapability(ctx, existing.AgentID, protocol.CapabilityDDNS); err != nil {
+			return err
+		}
+	}
+
 	// 更新字段
 	existing.Name = req.Name
 	existing.Provider = req.Provider
@@ -221,6 +234,14 @@ func (h *DDNSHandler) Enable(c echo.Context) error {
 	id := c.Param("id")
 	ctx := c.Request().Context()
 
+	config, err := h.ddnsService.GetConfig(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := h.agentService.RequireCapability(ctx, config.AgentID, protocol.CapabilityDDNS); err != nil {
+		return err
+	}
+
 	if err := h.ddnsService.UpdateEnabled(ctx, id, true); err != nil {
 		h.logger.Error("failed to enable ddns config", zap.Error(err))
 		return err
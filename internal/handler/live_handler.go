@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/pika/internal/service"
+	"github.com/dushixiang/pika/internal/utils"
+	"github.com/go-orz/orz"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// SSE 连接的保活间隔，避免反向代理关闭空闲连接
+const liveKeepAliveInterval = 30 * time.Second
+
+type LiveHandler struct {
+	logger      *zap.Logger
+	liveService *service.LiveService
+}
+
+func NewLiveHandler(logger *zap.Logger, liveService *service.LiveService) *LiveHandler {
+	return &LiveHandler{
+		logger:      logger,
+		liveService: liveService,
+	}
+}
+
+// Stream 通过 SSE 推送实时事件（公开接口，已登录推送全部，未登录只推送公开探针）
+// 查询参数：agentIds 逗号分隔的探针ID，types 逗号分隔的事件类型，为空时不过滤
+func (h *LiveHandler) Stream(c echo.Context) error {
+	filter := service.LiveFilter{
+		AgentIDs:      splitQueryList(c.QueryParam("agentIds")),
+		Types:         splitQueryList(c.QueryParam("types")),
+		Authenticated: utils.IsAuthenticated(c),
+	}
+	for _, eventType := range filter.Types {
+		if !slices.Contains(service.LiveEventTypes, eventType) {
+			return orz.NewError(400, "无效的事件类型")
+		}
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	// 关闭 Nginx 的响应缓冲
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	ctx := c.Request().Context()
+	sub := h.liveService.Subscribe(ctx, filter)
+	defer h.liveService.Unsubscribe(sub)
+
+	ticker := time.NewTicker(liveKeepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case event, ok := <-sub.Events():
+			if !ok {
+				return nil
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				h.logger.Warn("failed to marshal live event", zap.Error(err))
+				continue
+			}
+			if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
+
+// splitQueryList 解析逗号分隔的查询参数，忽略空值
+func splitQueryList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
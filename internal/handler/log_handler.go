@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/internal/service"
+	"github.com/go-orz/orz"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type LogHandler struct {
+	logger       *zap.Logger
+	logService   *service.LogService
+	agentService *service.AgentService
+}
+
+func NewLogHandler(logger *zap.Logger, logService *service.LogService, agentService *service.AgentService) *LogHandler {
+	return &LogHandler{
+		logger:       logger,
+		logService:   logService,
+		agentService: agentService,
+	}
+}
+
+// LogRuleRequest 创建/更新日志规则请求
+type LogRuleRequest struct {
+	Name               string   `json:"name" validate:"required"`
+	Enabled            bool     `json:"enabled"`
+	Paths              []string `json:"paths"`
+	Journald           bool     `json:"journald"`
+	JournalUnits       []string `json:"journalUnits"`
+	Pattern            string   `json:"pattern" validate:"required"`
+	ShipLines          bool     `json:"shipLines"`
+	AlertEnabled       bool     `json:"alertEnabled"`
+	AlertWindow        int      `json:"alertWindow"`
+	MaxEventsPerMinute int      `json:"maxEventsPerMinute"`
+}
+
+// ListRules 获取探针的日志规则
+func (h *LogHandler) ListRules(c echo.Context) error {
+	agentID := c.Param("id")
+	ctx := c.Request().Context()
+
+	rules, err := h.logService.ListRules(ctx, agentID)
+	if err != nil {
+		h.logger.Error("failed to list log rules", zap.Error(err))
+		return err
+	}
+
+	return orz.Ok(c, rules)
+}
+
+// CreateRule 创建日志规则
+func (h *LogHandler) CreateRule(c echo.Context) error {
+	agentID := c.Param("id")
+
+	var req LogRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	if err := h.agentService.RequireCapability(ctx, agentID, protocol.CapabilityLogTail); err != nil {
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	rule := &models.LogRule{
+		ID:        uuid.New().String(),
+		AgentID:   agentID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	req.apply(rule)
+
+	if err := h.logService.CreateRule(ctx, rule); err != nil {
+		h.logger.Error("failed to create log rule", zap.Error(err))
+		return err
+	}
+
+	return orz.Ok(c, rule)
+}
+
+// UpdateRule 更新日志规则
+func (h *LogHandler) UpdateRule(c echo.Context) error {
+	id := c.Param("id")
+
+	var req LogRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	existing, err := h.logService.GetRule(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if req.Enabled {
+		if err := h.agentService.RequireCapability(ctx, existing.AgentID, protocol.CapabilityLogTail); err != nil {
+			return err
+		}
+	}
+
+	req.apply(existing)
+	existing.UpdatedAt = time.Now().UnixMilli()
+
+	if err := h.logService.UpdateRule(ctx, existing); err != nil {
+		h.logger.Error("failed to update log rule", zap.Error(err))
+		return err
+	}
+
+	return orz.Ok(c, orz.Map{
+		"message": "日志规则更新成功",
+	})
+}
+
+// DeleteRule 删除日志规则
+func (h *LogHandler) DeleteRule(c echo.Context) error {
+	id := c.Param("id")
+	ctx := c.Request().Context()
+
+	if err := h.logService.DeleteRule(ctx, id); err != nil {
+		h.logger.Error("failed to delete log rule", zap.Error(err))
+		return err
+	}
+
+	return orz.Ok(c, orz.Map{
+		"message": "日志规则删除成功",
+	})
+}
+
+// PagingEvents 日志事件分页查询
+func (h *LogHandler) PagingEvents(c echo.Context) error {
+	agentID := c.Param("id")
+	ruleID := c.QueryParam("ruleId")
+	keyword := c.QueryParam("keyword")
+
+	pr := orz.GetPageRequest(c, "timestamp")
+
+	builder := orz.NewPageBuilder(h.logService.LogEventRepo).
+		PageRequest(pr).
+		Equal("agent_id", agentID).
+		Contains("line", keyword)
+
+	if ruleID != "" {
+		builder = builder.Equal("rule_id", ruleID)
+	}
+
+	ctx := c.Request().Context()
+	page, err := builder.Execute(ctx)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, orz.Map{
+		"items": page.Items,
+		"total": page.Total,
+	})
+}
+
+func (r *LogRuleRequest) apply(rule *models.LogRule) {
+	rule.Name = r.Name
+	rule.Enabled = r.Enabled
+	rule.Paths = r.Paths
+	rule.Journald = r.Journald
+	rule.JournalUnits = r.JournalUnits
+	rule.Pattern = r.Pattern
+	rule.ShipLines = r.ShipLines
+	rule.AlertEnabled = r.AlertEnabled
+	rule.AlertWindow = r.AlertWindow
+	rule.MaxEventsPerMinute = r.MaxEventsPerMinute
+}
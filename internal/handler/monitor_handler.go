@@ -1,6 +1,11 @@
 package handler
 
 import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
 	"github.com/dushixiang/pika/internal/service"
 	"github.com/dushixiang/pika/internal/utils"
 	"github.com/go-orz/orz"
@@ -27,6 +32,7 @@ func NewMonitorHandler(logger *zap.Logger, monitorService *service.MonitorServic
 func (h *MonitorHandler) List(c echo.Context) error {
 	keyword := c.QueryParam("keyword")
 	enabled := c.QueryParam("enabled")
+	groupName := c.QueryParam("groupName")
 
 	pr := orz.GetPageRequest(c, "name")
 
@@ -41,6 +47,11 @@ func (h *MonitorHandler) List(c echo.Context) error {
 		builder.Equal("enabled", "0")
 	}
 
+	// 处理分组筛选
+	if groupName != "" {
+		builder.Equal("group_name", groupName)
+	}
+
 	ctx := c.Request().Context()
 	page, err := builder.Execute(ctx)
 	if err != nil {
@@ -76,6 +87,59 @@ func (h *MonitorHandler) List(c echo.Context) error {
 	return orz.Ok(c, page)
 }
 
+// GetGroups 获取所有监控分组名称
+func (h *MonitorHandler) GetGroups(c echo.Context) error {
+	groups, err := h.monitorService.GetAllGroupNames(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return orz.Ok(c, orz.Map{
+		"groups": groups,
+	})
+}
+
+// Export 导出所有监控任务（format: yaml/json）
+func (h *MonitorHandler) Export(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format != "json" {
+		format = "yaml"
+	}
+
+	data, err := h.monitorService.ExportMonitors(c.Request().Context(), format)
+	if err != nil {
+		return err
+	}
+
+	contentType := "application/x-yaml; charset=utf-8"
+	if format == "json" {
+		contentType = "application/json; charset=utf-8"
+	}
+	filename := fmt.Sprintf("pika-monitors-%s.%s", time.Now().Format("20060102150405"), format)
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	return c.Blob(http.StatusOK, contentType, data)
+}
+
+// Import 按名称幂等导入监控任务，请求体为 YAML 或 JSON
+// dryRun=true 时只返回差异不落库，prune=true 时删除文件中不存在的监控任务
+func (h *MonitorHandler) Import(c echo.Context) error {
+	data, err := io.ReadAll(io.LimitReader(c.Request().Body, 10<<20))
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return orz.NewError(400, "导入内容不能为空")
+	}
+
+	dryRun := c.QueryParam("dryRun") == "true"
+	prune := c.QueryParam("prune") == "true"
+
+	result, err := h.monitorService.ImportMonitors(c.Request().Context(), data, dryRun, prune)
+	if err != nil {
+		return err
+	}
+	return orz.Ok(c, result)
+}
+
 func (h *MonitorHandler) Create(c echo.Context) error {
 	var req service.MonitorTaskRequest
 	if err := c.Bind(&req); err != nil {
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dushixiang/pika/internal/service"
+	"github.com/go-orz/orz"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type SLAHandler struct {
+	logger     *zap.Logger
+	slaService *service.SLAService
+}
+
+func NewSLAHandler(logger *zap.Logger, slaService *service.SLAService) *SLAHandler {
+	return &SLAHandler{
+		logger:     logger,
+		slaService: slaService,
+	}
+}
+
+// GetMonitorSLA 获取监控任务的可用率和 SLO 统计
+// GET /api/admin/monitors/:id/sla?range=30d 或 ?start=&end=
+func (h *SLAHandler) GetMonitorSLA(c echo.Context) error {
+	id := c.Param("id")
+	ctx := c.Request().Context()
+
+	start, end, err := parseSLATimeRange(c.QueryParam("range"), c.QueryParam("start"), c.QueryParam("end"))
+	if err != nil {
+		return orz.NewError(400, err.Error())
+	}
+
+	sla, err := h.slaService.GetMonitorSLA(ctx, id, start, end)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, sla)
+}
+
+// GetMonitorIncidents 获取监控任务的停机事件列表
+// GET /api/admin/monitors/:id/sla/incidents?range=30d 或 ?start=&end=
+func (h *SLAHandler) GetMonitorIncidents(c echo.Context) error {
+	id := c.Param("id")
+	ctx := c.Request().Context()
+
+	start, end, err := parseSLATimeRange(c.QueryParam("range"), c.QueryParam("start"), c.QueryParam("end"))
+	if err != nil {
+		return orz.NewError(400, err.Error())
+	}
+
+	incidents, err := h.slaService.GetMonitorIncidents(ctx, id, start, end)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, incidents)
+}
+
+// GetAgentSLA 获取探针上各监控任务的可用率
+// GET /api/admin/agents/:id/sla?range=30d 或 ?start=&end=
+func (h *SLAHandler) GetAgentSLA(c echo.Context) error {
+	id := c.Param("id")
+	ctx := c.Request().Context()
+
+	start, end, err := parseSLATimeRange(c.QueryParam("range"), c.QueryParam("start"), c.QueryParam("end"))
+	if err != nil {
+		return orz.NewError(400, err.Error())
+	}
+
+	sla, err := h.slaService.GetAgentSLA(ctx, id, start, end)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, sla)
+}
+
+// GetMonthlyReport 导出月度 SLA 报告
+// GET /api/admin/sla/report?month=2025-01&format=json|csv
+func (h *SLAHandler) GetMonthlyReport(c echo.Context) error {
+	ctx := c.Request().Context()
+	month := c.QueryParam("month")
+	format := c.QueryParam("format")
+
+	report, err := h.slaService.GetMonthlyReport(ctx, month)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "", "json":
+		return orz.Ok(c, report)
+	case "csv":
+		var buf bytes.Buffer
+		if err := h.slaService.WriteReportCSV(&buf, report); err != nil {
+			h.logger.Error("导出 SLA 报告失败", zap.Error(err))
+			return err
+		}
+		filename := fmt.Sprintf("sla-report-%s.csv", report.Month)
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+		return c.Blob(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+	default:
+		return orz.NewError(400, "无效的导出格式，支持: json, csv")
+	}
+}
+
+// parseSLATimeRange 解析 SLA 时间范围，默认最近 30 天
+func parseSLATimeRange(rangeParam, startParam, endParam string) (start, end int64, err error) {
+	if startParam != "" || endParam != "" {
+		return parseTimeRangeOrStartEnd("", startParam, endParam)
+	}
+
+	if rangeParam == "" {
+		rangeParam = "30d"
+	}
+
+	for _, w := range service.SLAWindows {
+		if w.Name == rangeParam {
+			end = time.Now().UnixMilli()
+			return end - w.Duration.Milliseconds(), end, nil
+		}
+	}
+
+	return 0, 0, fmt.Errorf("无效的时间范围，支持: 24h, 7d, 30d, 90d")
+}
@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/dushixiang/pika/internal/service"
+	"github.com/go-orz/orz"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type StatusPageHandler struct {
+	logger            *zap.Logger
+	statusPageService *service.StatusPageService
+}
+
+func NewStatusPageHandler(logger *zap.Logger, statusPageService *service.StatusPageService) *StatusPageHandler {
+	return &StatusPageHandler{
+		logger:            logger,
+		statusPageService: statusPageService,
+	}
+}
+
+// Paging 状态页分页查询
+func (h *StatusPageHandler) Paging(c echo.Context) error {
+	keyword := c.QueryParam("keyword")
+
+	pr := orz.GetPageRequest(c, "created_at", "title", "slug")
+
+	builder := orz.NewPageBuilder(h.statusPageService.StatusPageRepo).
+		PageRequest(pr).
+		Keyword([]string{"title", "slug", "domain"}, keyword)
+
+	ctx := c.Request().Context()
+	page, err := builder.Execute(ctx)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, orz.Map{
+		"items": page.Items,
+		"total": page.Total,
+	})
+}
+
+// Create 创建状态页
+func (h *StatusPageHandler) Create(c echo.Context) error {
+	var req service.StatusPageRequest
+	if err := c.Bind(&req); err != nil {
+		return orz.NewError(400, "请求参数错误")
+	}
+
+	ctx := c.Request().Context()
+	page, err := h.statusPageService.CreateStatusPage(ctx, &req)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, page)
+}
+
+// Get 获取状态页配置
+func (h *StatusPageHandler) Get(c echo.Context) error {
+	id := c.Param("id")
+
+	ctx := c.Request().Context()
+	page, err := h.statusPageService.StatusPageRepo.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, page)
+}
+
+// Update 更新状态页
+func (h *StatusPageHandler) Update(c echo.Context) error {
+	id := c.Param("id")
+
+	var req service.StatusPageRequest
+	if err := c.Bind(&req); err != nil {
+		return orz.NewError(400, "请求参数错误")
+	}
+
+	ctx := c.Request().Context()
+	page, err := h.statusPageService.UpdateStatusPage(ctx, id, &req)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, page)
+}
+
+// Delete 删除状态页
+func (h *StatusPageHandler) Delete(c echo.Context) error {
+	id := c.Param("id")
+
+	ctx := c.Request().Context()
+	if err := h.statusPageService.DeleteStatusPage(ctx, id); err != nil {
+		h.logger.Error("failed to delete status page", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// ListIncidents 列出状态页的故障事件
+func (h *StatusPageHandler) ListIncidents(c echo.Context) error {
+	id := c.Param("id")
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	ctx := c.Request().Context()
+	incidents, err := h.statusPageService.ListIncidents(ctx, id, limit)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, incidents)
+}
+
+// CreateIncident 发布故障事件
+func (h *StatusPageHandler) CreateIncident(c echo.Context) error {
+	id := c.Param("id")
+
+	var req service.StatusIncidentRequest
+	if err := c.Bind(&req); err != nil {
+		return orz.NewError(400, "请求参数错误")
+	}
+
+	ctx := c.Request().Context()
+	incident, err := h.statusPageService.CreateIncident(ctx, id, &req)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, incident)
+}
+
+// UpdateIncident 修改故障事件
+func (h *StatusPageHandler) UpdateIncident(c echo.Context) error {
+	id := c.Param("id")
+
+	var req service.StatusIncidentRequest
+	if err := c.Bind(&req); err != nil {
+		return orz.NewError(400, "请求参数错误")
+	}
+
+	ctx := c.Request().Context()
+	incident, err := h.statusPageService.UpdateIncident(ctx, id, &req)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, incident)
+}
+
+// AddIncidentUpdate 追加故障事件进展
+func (h *StatusPageHandler) AddIncidentUpdate(c echo.Context) error {
+	id := c.Param("id")
+
+	var req service.StatusIncidentUpdateRequest
+	if err := c.Bind(&req); err != nil {
+		return orz.NewError(400, "请求参数错误")
+	}
+
+	ctx := c.Request().Context()
+	update, err := h.statusPageService.AddIncidentUpdate(ctx, id, &req)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, update)
+}
+
+// DeleteIncident 删除故障事件
+func (h *StatusPageHandler) DeleteIncident(c echo.Context) error {
+	id := c.Param("id")
+
+	ctx := c.Request().Context()
+	return h.statusPageService.DeleteIncident(ctx, id)
+}
+
+// ListMaintenances 列出状态页的计划维护
+func (h *StatusPageHandler) ListMaintenances(c echo.Context) error {
+	id := c.Param("id")
+
+	ctx := c.Request().Context()
+	items, err := h.statusPageService.ListMaintenances(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, items)
+}
+
+// CreateMaintenance 发布计划维护
+func (h *StatusPageHandler) CreateMaintenance(c echo.Context) error {
+	id := c.Param("id")
+
+	var req service.StatusMaintenanceRequest
+	if err := c.Bind(&req); err != nil {
+		return orz.NewError(400, "请求参数错误")
+	}
+
+	ctx := c.Request().Context()
+	item, err := h.statusPageService.CreateMaintenance(ctx, id, &req)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, item)
+}
+
+// UpdateMaintenance 修改计划维护
+func (h *StatusPageHandler) UpdateMaintenance(c echo.Context) error {
+	id := c.Param("id")
+
+	var req service.StatusMaintenanceRequest
+	if err := c.Bind(&req); err != nil {
+		return orz.NewError(400, "请求参数错误")
+	}
+
+	ctx := c.Request().Context()
+	item, err := h.statusPageService.UpdateMaintenance(ctx, id, &req)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, item)
+}
+
+// DeleteMaintenance 删除计划维护
+func (h *StatusPageHandler) DeleteMaintenance(c echo.Context) error {
+	id := c.Param("id")
+
+	ctx := c.Request().Context()
+	return h.statusPageService.DeleteMaintenance(ctx, id)
+}
+
+// GetPublicBySlug 获取公开状态页（无需登录）
+// GET /api/status-pages/:slug
+func (h *StatusPageHandler) GetPublicBySlug(c echo.Context) error {
+	ctx := c.Request().Context()
+	view, err := h.statusPageService.GetPublicViewBySlug(ctx, c.Param("slug"))
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, view)
+}
+
+// GetPublicByHost 根据访问域名获取公开状态页（无需登录）
+// GET /api/status-page
+func (h *StatusPageHandler) GetPublicByHost(c echo.Context) error {
+	ctx := c.Request().Context()
+	view, err := h.statusPageService.GetPublicViewByHost(ctx, c.Request().Host)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, view)
+}
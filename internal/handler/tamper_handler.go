@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/dushixiang/pika/internal/protocol"
 	"github.com/dushixiang/pika/internal/service"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
@@ -12,12 +13,14 @@ import (
 type TamperHandler struct {
 	logger        *zap.Logger
 	tamperService *service.TamperService
+	agentService  *service.AgentService
 }
 
-func NewTamperHandler(logger *zap.Logger, tamperService *service.TamperService) *TamperHandler {
+func NewTamperHandler(logger *zap.Logger, tamperService *service.TamperService, agentService *service.AgentService) *TamperHandler {
 	return &TamperHandler{
 		logger:        logger,
 		tamperService: tamperService,
+		agentService:  agentService,
 	}
 }
 
@@ -43,6 +46,16 @@ func (h *TamperHandler) UpdateTamperConfig(c echo.Context) error {
 		})
 	}
 
+	// 清空配置不需要探针支持防篡改
+	if len(req.Paths) > 0 {
+		if err := h.agentService.RequireCapability(c.Request().Context(), agentID, protocol.CapabilityTamper); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			})
+		}
+	}
+
 	config, err := h.tamperService.UpdateConfig(agentID, req.Paths)
 	if err != nil {
 		h.logger.Error("更新防篡改配置失败", zap.Error(err), zap.String("agentId", agentID))
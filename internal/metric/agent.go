@@ -12,6 +12,9 @@ type DiskSummary struct {
 	Total        uint64  `json:"total"`        // 总容量(字节)
 	Used         uint64  `json:"used"`         // 已使用(字节)
 	Free         uint64  `json:"free"`         // 空闲(字节)
+
+	InodesUsagePercent float64 `json:"inodesUsagePercent"` // 各分区中最高的 inode 使用率
+	ReadOnlyDisks      int     `json:"readOnlyDisks"`      // 只读挂载的分区数量
 }
 
 // NetworkSummary 网络汇总数据
@@ -34,4 +37,10 @@ type LatestMetrics struct {
 	GPU               []protocol.GPUData              `json:"gpu,omitempty"`
 	Temp              []protocol.TemperatureData      `json:"temperature,omitempty"`
 	Monitors          []protocol.MonitorData          `json:"monitors,omitempty"`
+	Kernel            *protocol.KernelData            `json:"kernel,omitempty"`
+	Process           *protocol.ProcessData           `json:"-"` // 包含命令行等敏感信息，仅通过管理接口返回
+	Containers        []protocol.ContainerData        `json:"-"` // 容器名称和镜像仅通过管理接口返回
+	Systemd           []protocol.SystemdUnitData      `json:"-"` // systemd 单元状态仅通过管理接口返回
+	DiskHealth        []protocol.DiskHealthData       `json:"-"` // 包含磁盘序列号，仅通过管理接口返回
+	PrometheusTargets []protocol.PrometheusScrapeData `json:"-"` // Prometheus 抓取目标状态（不含样本），仅通过管理接口返回
 }
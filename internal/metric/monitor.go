@@ -37,6 +37,7 @@ type PublicMonitorOverview struct {
 	Target           string `json:"target"`
 	ShowTargetPublic bool   `json:"showTargetPublic"` // 在公开页面是否显示目标地址
 	Description      string `json:"description"`
+	GroupName        string `json:"groupName"` // 分组名称
 	Enabled          bool   `json:"enabled"`
 	Interval         int    `json:"interval"`
 	AgentCount       int    `json:"agentCount"`
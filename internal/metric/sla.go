@@ -0,0 +1,118 @@
+package metric
+
+// UptimeWindow 固定时间窗口的可用率
+type UptimeWindow struct {
+	Window  string  `json:"window"`  // 窗口名称: 24h/7d/30d/90d
+	Start   int64   `json:"start"`   // 开始时间(毫秒时间戳)
+	End     int64   `json:"end"`     // 结束时间(毫秒时间戳)
+	Uptime  float64 `json:"uptime"`  // 可用率(%)
+	HasData bool    `json:"hasData"` // 窗口内是否有检测数据
+}
+
+// SLAIncident 停机事件
+type SLAIncident struct {
+	MonitorID   string `json:"monitorId"`
+	MonitorName string `json:"monitorName,omitempty"`
+	Start       int64  `json:"start"`    // 开始时间(毫秒时间戳)
+	End         int64  `json:"end"`      // 结束时间(毫秒时间戳)，进行中的事件为最后一次检测时间
+	Duration    int64  `json:"duration"` // 持续时长(毫秒)
+	Ongoing     bool   `json:"ongoing"`  // 是否仍在持续
+}
+
+// SLOBudget SLO 目标及错误预算消耗情况
+type SLOBudget struct {
+	Target            float64 `json:"target"`            // 目标可用率(%)
+	Met               bool    `json:"met"`               // 是否达标
+	AllowedDowntime   int64   `json:"allowedDowntime"`   // 允许的停机时长(毫秒)
+	ConsumedDowntime  int64   `json:"consumedDowntime"`  // 已消耗的停机时长(毫秒)
+	RemainingDowntime int64   `json:"remainingDowntime"` // 剩余错误预算(毫秒)，超支时为负数
+	BudgetConsumed    float64 `json:"budgetConsumed"`    // 错误预算消耗比例(%)
+	BurnRate          float64 `json:"burnRate"`          // 燃烧速率，1 表示恰好在周期结束时耗尽预算
+}
+
+// AgentUptime 单个探针视角的可用率
+type AgentUptime struct {
+	AgentID   string  `json:"agentId"`
+	AgentName string  `json:"agentName"`
+	Uptime    float64 `json:"uptime"`   // 可用率(%)
+	Downtime  int64   `json:"downtime"` // 停机时长(毫秒)
+}
+
+// MonitorSLA 监控任务的 SLA 统计
+type MonitorSLA struct {
+	MonitorID     string         `json:"monitorId"`
+	MonitorName   string         `json:"monitorName"`
+	Type          string         `json:"type"`
+	Start         int64          `json:"start"`
+	End           int64          `json:"end"`
+	HasData       bool           `json:"hasData"`       // 时间范围内是否有检测数据
+	Uptime        float64        `json:"uptime"`        // 整体可用率(%)，任一探针检测正常即视为可用
+	Observed      int64          `json:"observed"`      // 有检测数据覆盖的时长(毫秒)
+	Downtime      int64          `json:"downtime"`      // 停机时长(毫秒)
+	IncidentCount int            `json:"incidentCount"` // 停机事件次数
+	SLO           *SLOBudget     `json:"slo,omitempty"` // 未设置 SLO 目标时为空
+	Agents        []AgentUptime  `json:"agents"`        // 各探针的可用率
+	Windows       []UptimeWindow `json:"windows"`       // 24h/7d/30d/90d 可用率
+}
+
+// MonitorUptime 探针上单个监控任务的可用率
+type MonitorUptime struct {
+	MonitorID     string  `json:"monitorId"`
+	MonitorName   string  `json:"monitorName"`
+	Uptime        float64 `json:"uptime"`        // 可用率(%)
+	Downtime      int64   `json:"downtime"`      // 停机时长(毫秒)
+	IncidentCount int     `json:"incidentCount"` // 停机事件次数
+}
+
+// AgentSLA 探针维度的 SLA 统计
+type AgentSLA struct {
+	AgentID   string          `json:"agentId"`
+	AgentName string          `json:"agentName"`
+	Start     int64           `json:"start"`
+	End       int64           `json:"end"`
+	Uptime    float64         `json:"uptime"` // 该探针上所有监控任务的平均可用率(%)
+	Monitors  []MonitorUptime `json:"monitors"`
+}
+
+// SLAReportItem 月度报告中单个监控任务的数据
+type SLAReportItem struct {
+	MonitorID       string        `json:"monitorId"`
+	MonitorName     string        `json:"monitorName"`
+	Type            string        `json:"type"`
+	HasData         bool          `json:"hasData"`
+	Uptime          float64       `json:"uptime"`          // 可用率(%)
+	UptimeText      string        `json:"uptimeText"`      // 格式化后的可用率，如 99.95%
+	Downtime        int64         `json:"downtime"`        // 停机时长(毫秒)
+	DowntimeText    string        `json:"downtimeText"`    // 格式化后的停机时长
+	IncidentCount   int           `json:"incidentCount"`   // 停机事件次数
+	LongestIncident int64         `json:"longestIncident"` // 最长停机时长(毫秒)
+	SLO             *SLOBudget    `json:"slo,omitempty"`
+	Incidents       []SLAIncident `json:"incidents"`
+}
+
+// SLAReport 月度 SLA 报告
+type SLAReport struct {
+	Month       string           `json:"month"` // 报告月份，如 2025-01
+	Start       int64            `json:"start"`
+	End         int64            `json:"end"`
+	GeneratedAt int64            `json:"generatedAt"`
+	Summary     SLAReportSummary `json:"summary"`
+	Items       []SLAReportItem  `json:"items"`
+}
+
+// SLAReportSummary 月度报告汇总
+type SLAReportSummary struct {
+	MonitorCount   int     `json:"monitorCount"`   // 监控任务数量
+	AvgUptime      float64 `json:"avgUptime"`      // 平均可用率(%)
+	TotalIncidents int     `json:"totalIncidents"` // 停机事件总数
+	SLOTotal       int     `json:"sloTotal"`       // 设置了 SLO 的监控任务数量
+	SLOMet         int     `json:"sloMet"`         // 达标的监控任务数量
+}
+
+// DailyUptime 单日可用率（用于状态页的可用率条）
+type DailyUptime struct {
+	Date     string  `json:"date"`     // 日期，如 2025-01-02
+	HasData  bool    `json:"hasData"`  // 当天是否有检测数据
+	Uptime   float64 `json:"uptime"`   // 可用率(%)
+	Downtime int64   `json:"downtime"` // 停机时长(毫秒)
+}
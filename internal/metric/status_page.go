@@ -0,0 +1,36 @@
+package metric
+
+import "github.com/dushixiang/pika/internal/models"
+
+// StatusPageView 公开状态页数据
+type StatusPageView struct {
+	ID           string                     `json:"id"`
+	Slug         string                     `json:"slug"`
+	Title        string                     `json:"title"`
+	Description  string                     `json:"description"`
+	SystemNameZh string                     `json:"systemNameZh"`
+	SystemNameEn string                     `json:"systemNameEn"`
+	Logo         string                     `json:"logo,omitempty"` // Logo 地址，未设置时为空
+	Status       string                     `json:"status"`         // 整体状态: operational, degraded, major_outage, maintenance
+	Groups       []StatusPageGroupView      `json:"groups"`
+	Incidents    []models.StatusIncident    `json:"incidents"`    // 未恢复及近期恢复的故障事件
+	Maintenances []models.StatusMaintenance `json:"maintenances"` // 进行中及即将开始的计划维护
+	UpdatedAt    int64                      `json:"updatedAt"`    // 数据生成时间
+}
+
+// StatusPageGroupView 状态页分组
+type StatusPageGroupView struct {
+	Name     string                  `json:"name"`
+	Monitors []StatusPageMonitorView `json:"monitors"`
+}
+
+// StatusPageMonitorView 状态页中的监控项，不包含目标地址
+type StatusPageMonitorView struct {
+	ID           string        `json:"id"`
+	Name         string        `json:"name"`
+	Type         string        `json:"type"`
+	Status       string        `json:"status"`       // up, down, unknown, maintenance
+	ResponseTime int64         `json:"responseTime"` // 当前平均响应时间(ms)
+	Uptime       float64       `json:"uptime"`       // 展示周期内的可用率(%)
+	Days         []DailyUptime `json:"days"`         // 每日可用率，按日期正序
+}
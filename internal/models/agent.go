@@ -1,6 +1,11 @@
 package models
 
-import "gorm.io/datatypes"
+import (
+	"slices"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"gorm.io/datatypes"
+)
 
 // Agent 探针信息
 type Agent struct {
@@ -28,6 +33,33 @@ type Agent struct {
 	TrafficAlertSent80  bool   `json:"trafficAlertSent80"`  // 是否已发送80%告警
 	TrafficAlertSent90  bool   `json:"trafficAlertSent90"`  // 是否已发送90%告警
 	TrafficAlertSent100 bool   `json:"trafficAlertSent100"` // 是否已发送100%告警
+
+	// 探针凭证相关字段，服务端只保存凭证的哈希
+	CredentialHash         string `json:"-"`                        // 当前凭证哈希(SHA-256)
+	PreviousCredentialHash string `json:"-"`                        // 轮换前的凭证哈希，探针确认新凭证后失效
+	CredentialStatus       string `json:"credentialStatus"`         // 凭证状态: 空-未签发, pending-等待探针确认, active-已签发, revoked-已吊销
+	CredentialIssuedAt     int64  `json:"credentialIssuedAt"`       // 凭证签发时间(时间戳毫秒)
+	CertSubject            string `gorm:"index" json:"certSubject"` // 绑定的客户端证书主题，为空时首次使用证书注册自动绑定
+
+	// 协议版本和能力，每次注册时更新
+	ProtocolVersion int                         `json:"protocolVersion"` // 探针协议版本，0 表示不支持能力协商的旧版本
+	Capabilities    datatypes.JSONSlice[string] `json:"capabilities"`    // 探针支持的功能
+}
+
+// 探针凭证状态
+// pending 状态下凭证在探针确认保存后才生效，期间仍可使用 API 密钥注册，管理员允许重新注册后也处于该状态
+const (
+	CredentialStatusPending = "pending"
+	CredentialStatusActive  = "active"
+	CredentialStatusRevoked = "revoked"
+)
+
+// HasCapability 判断探针是否支持指定功能，旧版本探针按 protocol.LegacyCapabilities 判断
+func (a *Agent) HasCapability(capability string) bool {
+	if a.ProtocolVersion == 0 {
+		return slices.Contains(protocol.LegacyCapabilities(a.OS), capability)
+	}
+	return slices.Contains(a.Capabilities, capability)
 }
 
 func (Agent) TableName() string {
@@ -0,0 +1,36 @@
+package models
+
+// 配置下发状态
+const (
+	ConfigStatusQueued  = "queued"  // 探针未连接，等待连接后下发
+	ConfigStatusPending = "pending" // 已下发，等待探针确认
+	ConfigStatusApplied = "applied" // 探针已应用
+	ConfigStatusFailed  = "failed"  // 探针未能应用
+	ConfigStatusTimeout = "timeout" // 超时未收到探针确认
+	ConfigStatusSent    = "sent"    // 已下发，探针不支持确认
+)
+
+// AgentConfigState 探针配置的期望状态与已应用状态
+type AgentConfigState struct {
+	ID          string `gorm:"primaryKey" json:"id"`   // 探针ID:配置类型:配置对象ID
+	AgentID     string `gorm:"index" json:"agentId"`   // 探针ID
+	Kind        string `json:"kind"`                   // 配置类型: tamper, ddns, log
+	Ref         string `json:"ref"`                    // 配置对象ID，如 DDNS 配置ID，探针级配置为空
+	MessageID   string `gorm:"index" json:"messageId"` // 最后一次下发的消息ID
+	DesiredHash string `json:"desiredHash"`            // 期望配置的摘要
+	AppliedHash string `json:"appliedHash"`            // 探针已应用配置的摘要
+	Status      string `gorm:"index" json:"status"`    // 下发状态
+	Error       string `json:"error,omitempty"`        // 探针未能应用的原因
+	SentAt      int64  `json:"sentAt"`                 // 最后下发时间（时间戳毫秒）
+	AppliedAt   int64  `json:"appliedAt"`              // 最后应用时间（时间戳毫秒）
+	UpdatedAt   int64  `json:"updatedAt" gorm:"autoUpdateTime:milli"`
+}
+
+func (AgentConfigState) TableName() string {
+	return "agent_config_states"
+}
+
+// InSync 探针已应用当前期望的配置
+func (s AgentConfigState) InSync() bool {
+	return s.AppliedHash != "" && s.AppliedHash == s.DesiredHash
+}
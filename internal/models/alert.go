@@ -11,12 +11,20 @@ type AlertRecord struct {
 	ActualValue float64 `json:"actualValue"`                           // 实际值
 	Level       string  `json:"level"`                                 // 告警级别: info, warning, critical
 	Status      string  `json:"status"`                                // 状态: firing（告警中）, resolved（已恢复）
+	Suppressed  string  `json:"suppressed,omitempty"`                  // 抑制原因: suppressed-by-dependency, suppressed-by-agent-offline，被抑制的告警不发送通知
 	FiredAt     int64   `gorm:"index" json:"firedAt"`                  // 触发时间（时间戳毫秒）
 	ResolvedAt  int64   `json:"resolvedAt,omitempty"`                  // 恢复时间（时间戳毫秒）
 	CreatedAt   int64   `json:"createdAt"`                             // 创建时间（时间戳毫秒）
 	UpdatedAt   int64   `json:"updatedAt" gorm:"autoUpdateTime:milli"` // 更新时间（时间戳毫秒）
 }
 
+const (
+	// AlertSuppressedByDependency 上游依赖的监控任务异常
+	AlertSuppressedByDependency = "suppressed-by-dependency"
+	// AlertSuppressedByAgentOffline 执行监控的探针已离线
+	AlertSuppressedByAgentOffline = "suppressed-by-agent-offline"
+)
+
 func (AlertRecord) TableName() string {
 	return "alert_records"
 }
@@ -33,6 +41,7 @@ type AlertState struct {
 	LastCheckTime int64   `json:"lastCheckTime"`                         // 上次检查时间
 	IsFiring      bool    `json:"isFiring"`                              // 是否正在告警
 	LastRecordID  int64   `json:"lastRecordId"`                          // 最后一条告警记录ID
+	Suppressed    bool    `json:"suppressed"`                            // 最后一条告警记录被抑制，尚未发送通知
 	CreatedAt     int64   `json:"createdAt"`                             // 创建时间（时间戳毫秒）
 	UpdatedAt     int64   `json:"updatedAt" gorm:"autoUpdateTime:milli"` // 更新时间（时间戳毫秒）
 }
@@ -0,0 +1,43 @@
+package models
+
+import "gorm.io/datatypes"
+
+// LogRule 日志监控规则
+type LogRule struct {
+	ID           string                      `gorm:"primaryKey" json:"id"`        // 规则ID (UUID)
+	AgentID      string                      `gorm:"index" json:"agentId"`        // 探针ID
+	Name         string                      `json:"name"`                        // 规则名称
+	Enabled      bool                        `gorm:"default:true" json:"enabled"` // 是否启用
+	Paths        datatypes.JSONSlice[string] `json:"paths"`                       // 日志文件路径，支持通配符
+	Journald     bool                        `json:"journald"`                    // 是否读取 journald
+	JournalUnits datatypes.JSONSlice[string] `json:"journalUnits"`                // journald 单元过滤
+	Pattern      string                      `json:"pattern"`                     // 匹配的正则表达式
+
+	ShipLines          bool `json:"shipLines"`          // 是否上报匹配的日志行
+	AlertEnabled       bool `json:"alertEnabled"`       // 匹配时是否触发告警
+	AlertWindow        int  `json:"alertWindow"`        // 告警恢复时间（秒），超过该时间没有新的匹配则恢复
+	MaxEventsPerMinute int  `json:"maxEventsPerMinute"` // 每分钟最多上报的日志行数
+
+	CreatedAt int64 `json:"createdAt"`                             // 创建时间（时间戳毫秒）
+	UpdatedAt int64 `json:"updatedAt" gorm:"autoUpdateTime:milli"` // 更新时间（时间戳毫秒）
+}
+
+func (LogRule) TableName() string {
+	return "log_rules"
+}
+
+// LogEvent 匹配到的日志行
+type LogEvent struct {
+	ID        string `gorm:"primaryKey" json:"id"`          // 事件ID (UUID)
+	AgentID   string `gorm:"index;not null" json:"agentId"` // 探针ID
+	RuleID    string `gorm:"index" json:"ruleId"`           // 规则ID
+	RuleName  string `json:"ruleName"`                      // 规则名称
+	Source    string `json:"source"`                        // 日志来源：文件路径或 journald
+	Line      string `json:"line"`                          // 日志内容
+	Timestamp int64  `gorm:"index" json:"timestamp"`        // 读取时间（时间戳毫秒）
+	CreatedAt int64  `json:"createdAt"`                     // 记录创建时间（时间戳毫秒）
+}
+
+func (LogEvent) TableName() string {
+	return "log_events"
+}
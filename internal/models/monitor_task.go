@@ -16,12 +16,19 @@ type MonitorTask struct {
 	ShowTargetPublic bool                                           `json:"showTargetPublic"`                      // 在公开页面是否显示目标地址
 	Visibility       string                                         `gorm:"default:public" json:"visibility"`      // 可见性: public-匿名可见, private-登录可见
 	Interval         int                                            `json:"interval"`                              // 检测频率（秒），默认 60
+	CronExpr         string                                         `json:"cronExpr"`                              // Cron 表达式（可选，支持秒级），设置后忽略检测频率
+	Timezone         string                                         `json:"timezone"`                              // 调度时区，如 Asia/Shanghai，为空使用服务端时区
+	ActiveWindows    datatypes.JSONSlice[MonitorTimeWindow]         `json:"activeWindows"`                         // 生效时间窗口，为空表示全天执行
+	Jitter           int                                            `json:"jitter"`                                // Cron 调度的错峰范围（秒），按任务 ID 固定偏移
 	AgentIds         datatypes.JSONSlice[string]                    `json:"agentIds"`                              // 指定的探针 ID 列表（JSON 数组）
 	AgentNames       []string                                       `gorm:"-" json:"agentNames"`                   // 指定的探针名称列表
 	Tags             datatypes.JSONSlice[string]                    `json:"tags"`                                  // 指定的标签列表（JSON 数组），拥有这些标签的探针都会执行此监控
 	HTTPConfig       datatypes.JSONType[protocol.HTTPMonitorConfig] `json:"httpConfig"`                            // HTTP 监控配置
 	TCPConfig        datatypes.JSONType[protocol.TCPMonitorConfig]  `json:"tcpConfig"`                             // TCP 监控配置
 	ICMPConfig       datatypes.JSONType[protocol.ICMPMonitorConfig] `json:"icmpConfig"`                            // ICMP 监控配置
+	SLOTarget        float64                                        `json:"sloTarget"`                             // SLO 目标可用率（百分比，如 99.9），0 表示不设置
+	GroupName        string                                         `gorm:"index" json:"groupName"`                // 分组名称
+	DependsOn        datatypes.JSONSlice[string]                    `json:"dependsOn"`                             // 依赖的上游监控任务 ID，上游异常时抑制本任务的告警通知
 	CreatedAt        int64                                          `gorm:"autoCreateTime:milli" json:"createdAt"` // 创建时间
 	UpdatedAt        int64                                          `gorm:"autoUpdateTime:milli" json:"updatedAt"` // 更新时间
 }
@@ -29,3 +36,10 @@ type MonitorTask struct {
 func (MonitorTask) TableName() string {
 	return "monitor_tasks"
 }
+
+// MonitorTimeWindow 监控任务的生效时间窗口
+type MonitorTimeWindow struct {
+	Days  []int  `json:"days,omitempty"` // 生效的星期（0 为周日），为空表示每天
+	Start string `json:"start"`          // 开始时间，如 09:00
+	End   string `json:"end"`            // 结束时间，如 18:00，早于开始时间表示跨天
+}
@@ -86,6 +86,11 @@ type AlertRules struct {
 	DiskThreshold float64 `json:"diskThreshold"` // 磁盘使用率阈值(0-100)
 	DiskDuration  int     `json:"diskDuration"`  // 持续时间（秒）
 
+	// inode 告警配置（任一分区的 inode 使用率超过阈值）
+	InodeEnabled   bool    `json:"inodeEnabled"`   // 是否启用 inode 告警
+	InodeThreshold float64 `json:"inodeThreshold"` // inode 使用率阈值(0-100)
+	InodeDuration  int     `json:"inodeDuration"`  // 持续时间（秒）
+
 	// 网络告警配置
 	NetworkEnabled   bool    `json:"networkEnabled"`   // 是否启用网络告警
 	NetworkThreshold float64 `json:"networkThreshold"` // 网速阈值(MB/s)
@@ -102,4 +107,18 @@ type AlertRules struct {
 	// 探针离线告警配置
 	AgentOfflineEnabled  bool `json:"agentOfflineEnabled"`  // 是否启用探针离线告警
 	AgentOfflineDuration int  `json:"agentOfflineDuration"` // 持续时间（秒）
+
+	// 进程消失告警配置（探针配置了关注进程时生效）
+	ProcessEnabled  bool `json:"processEnabled"`  // 是否启用进程消失告警
+	ProcessDuration int  `json:"processDuration"` // 持续时间（秒）
+
+	// systemd 单元告警配置（单元进入 failed 状态或自动重启时告警）
+	SystemdEnabled       bool `json:"systemdEnabled"`       // 是否启用 systemd 单元告警
+	SystemdRestartWindow int  `json:"systemdRestartWindow"` // 重启告警的恢复时间（秒），在此时间内没有再次重启则恢复
+
+	// 磁盘健康告警配置（SMART 评估未通过、NVMe 严重警告、存在待映射扇区等）
+	DiskHealthEnabled bool `json:"diskHealthEnabled"` // 是否启用磁盘健康告警
+
+	// 日志关键字告警总开关（具体的匹配规则和恢复时间在探针的日志规则中配置）
+	LogEnabled bool `json:"logEnabled"` // 是否启用日志关键字告警
 }
@@ -0,0 +1,90 @@
+package models
+
+import "gorm.io/datatypes"
+
+// StatusPageGroup 状态页中的监控分组
+type StatusPageGroup struct {
+	Name       string   `json:"name"`       // 分组名称
+	MonitorIds []string `json:"monitorIds"` // 分组内的监控任务 ID（按展示顺序）
+}
+
+// StatusPage 公开状态页
+type StatusPage struct {
+	ID          string                                `gorm:"primaryKey" json:"id"`                  // 状态页 ID
+	Slug        string                                `gorm:"uniqueIndex" json:"slug"`               // 访问路径标识，如 /status/{slug}
+	Title       string                                `json:"title"`                                 // 标题
+	Description string                                `json:"description"`                           // 描述信息
+	Domain      string                                `gorm:"index" json:"domain"`                   // 自定义域名，按请求 Host 匹配
+	Enabled     bool                                  `json:"enabled"`                               // 是否启用
+	Groups      datatypes.JSONType[[]StatusPageGroup] `json:"groups"`                                // 监控分组
+	CreatedAt   int64                                 `gorm:"autoCreateTime:milli" json:"createdAt"` // 创建时间
+	UpdatedAt   int64                                 `gorm:"autoUpdateTime:milli" json:"updatedAt"` // 更新时间
+}
+
+func (StatusPage) TableName() string {
+	return "status_pages"
+}
+
+// MonitorIds 返回状态页包含的全部监控任务 ID（去重，保持顺序）
+func (p StatusPage) MonitorIds() []string {
+	seen := make(map[string]struct{})
+	var ids []string
+	for _, group := range p.Groups.Data() {
+		for _, id := range group.MonitorIds {
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// StatusIncident 状态页上手动发布的故障事件
+type StatusIncident struct {
+	ID           string                      `gorm:"primaryKey" json:"id"`                  // 事件 ID
+	StatusPageID string                      `gorm:"index" json:"statusPageId"`             // 所属状态页
+	Title        string                      `json:"title"`                                 // 标题
+	Status       string                      `gorm:"index" json:"status"`                   // 状态: investigating, identified, monitoring, resolved
+	Impact       string                      `json:"impact"`                                // 影响程度: none, minor, major, critical
+	MonitorIds   datatypes.JSONSlice[string] `json:"monitorIds"`                            // 受影响的监控任务
+	ResolvedAt   int64                       `json:"resolvedAt"`                            // 恢复时间
+	Updates      []StatusIncidentUpdate      `gorm:"-" json:"updates"`                      // 更新时间线
+	CreatedAt    int64                       `gorm:"autoCreateTime:milli" json:"createdAt"` // 创建时间
+	UpdatedAt    int64                       `gorm:"autoUpdateTime:milli" json:"updatedAt"` // 更新时间
+}
+
+func (StatusIncident) TableName() string {
+	return "status_incidents"
+}
+
+// StatusIncidentUpdate 故障事件的进展更新
+type StatusIncidentUpdate struct {
+	ID         string `gorm:"primaryKey" json:"id"`                  // 更新 ID
+	IncidentID string `gorm:"index" json:"incidentId"`               // 所属事件
+	Status     string `json:"status"`                                // 更新时的事件状态
+	Message    string `json:"message"`                               // 更新内容
+	CreatedAt  int64  `gorm:"autoCreateTime:milli" json:"createdAt"` // 发布时间
+}
+
+func (StatusIncidentUpdate) TableName() string {
+	return "status_incident_updates"
+}
+
+// StatusMaintenance 计划维护公告
+type StatusMaintenance struct {
+	ID           string                      `gorm:"primaryKey" json:"id"`                  // 维护 ID
+	StatusPageID string                      `gorm:"index" json:"statusPageId"`             // 所属状态页
+	Title        string                      `json:"title"`                                 // 标题
+	Description  string                      `json:"description"`                           // 维护说明
+	MonitorIds   datatypes.JSONSlice[string] `json:"monitorIds"`                            // 受影响的监控任务
+	StartAt      int64                       `gorm:"index" json:"startAt"`                  // 开始时间（毫秒时间戳）
+	EndAt        int64                       `gorm:"index" json:"endAt"`                    // 结束时间（毫秒时间戳）
+	CreatedAt    int64                       `gorm:"autoCreateTime:milli" json:"createdAt"` // 创建时间
+	UpdatedAt    int64                       `gorm:"autoUpdateTime:milli" json:"updatedAt"` // 更新时间
+}
+
+func (StatusMaintenance) TableName() string {
+	return "status_maintenances"
+}
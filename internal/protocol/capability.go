@@ -0,0 +1,47 @@
+package protocol
+
+// ProtocolVersion 当前协议版本，探针与服务端不兼容的协议变更时递增
+// 旧版本探针注册时不发送协议版本，视为 0
+const ProtocolVersion = 1
+
+// 探针能力，探针注册时上报自身支持的功能，服务端只向支持的探针下发对应配置
+const (
+	CapabilityGPU         = "gpu"          // 检测到 GPU（nvidia-smi 可用）
+	CapabilityTamper      = "tamper"       // 防篡改保护，仅 Linux
+	CapabilityDDNS        = "ddns"         // DDNS IP 上报
+	CapabilityAudit       = "audit"        // VPS 安全审计，仅 Linux
+	CapabilityLogTail     = "log_tail"     // 日志监控
+	CapabilityMonitorHTTP = "monitor_http" // HTTP/HTTPS 服务监控
+	CapabilityMonitorTCP  = "monitor_tcp"  // TCP 端口监控
+	CapabilityMonitorICMP = "monitor_icmp" // ICMP/Ping 监控
+	CapabilityConfigAck   = "config_ack"   // 应用配置后回复 ack 消息
+)
+
+// LegacyCapabilities 返回不支持能力协商的旧版本探针默认具备的能力
+// 服务监控、DDNS、防篡改和安全审计早于能力协商存在，防篡改和安全审计只有 Linux 探针支持
+// GPU、日志监控等之后新增的功能无法确认旧版本探针是否支持，不向其下发
+func LegacyCapabilities(os string) []string {
+	caps := []string{
+		CapabilityDDNS,
+		CapabilityMonitorHTTP,
+		CapabilityMonitorTCP,
+		CapabilityMonitorICMP,
+	}
+	if os == "linux" {
+		caps = append(caps, CapabilityTamper, CapabilityAudit)
+	}
+	return caps
+}
+
+// MonitorCapability 返回监控类型对应的探针能力，未知类型返回空字符串
+func MonitorCapability(monitorType string) string {
+	switch monitorType {
+	case "http", "https":
+		return CapabilityMonitorHTTP
+	case "tcp":
+		return CapabilityMonitorTCP
+	case "icmp", "ping":
+		return CapabilityMonitorICMP
+	}
+	return ""
+}
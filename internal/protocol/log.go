@@ -0,0 +1,34 @@
+package protocol
+
+// LogConfigData 日志监控配置（服务端下发给客户端，每次下发完整规则列表）
+type LogConfigData struct {
+	Rules []LogRuleData `json:"rules"`
+}
+
+// LogRuleData 日志匹配规则
+type LogRuleData struct {
+	ID                 string   `json:"id"`                     // 规则ID
+	Name               string   `json:"name"`                   // 规则名称
+	Paths              []string `json:"paths,omitempty"`        // 日志文件路径，支持通配符
+	Journald           bool     `json:"journald,omitempty"`     // 是否读取 journald
+	JournalUnits       []string `json:"journalUnits,omitempty"` // journald 单元过滤，为空时读取全部
+	Pattern            string   `json:"pattern"`                // 匹配的正则表达式（RE2 语法）
+	Ship               bool     `json:"ship"`                   // 是否上报匹配的日志行
+	MaxEventsPerMinute int      `json:"maxEventsPerMinute"`     // 每分钟最多上报的日志行数
+}
+
+// LogMatchData 日志规则匹配计数（探针启动以来的累计值）
+type LogMatchData struct {
+	RuleID  string `json:"ruleId"`  // 规则ID
+	Source  string `json:"source"`  // 日志来源：文件路径或 journald
+	Matches uint64 `json:"matches"` // 匹配行数
+	Dropped uint64 `json:"dropped"` // 因限流未上报的行数
+}
+
+// LogEventData 匹配到的日志行（客户端发送）
+type LogEventData struct {
+	RuleID    string `json:"ruleId"`    // 规则ID
+	Source    string `json:"source"`    // 日志来源：文件路径或 journald
+	Line      string `json:"line"`      // 日志内容，过长时被截断
+	Timestamp int64  `json:"timestamp"` // 读取时间(毫秒)
+}
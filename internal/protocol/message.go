@@ -4,20 +4,39 @@ import "encoding/json"
 
 // InputMessage WebSocket消息结构（主要用于接收）
 type InputMessage struct {
+	ID   string          `json:"id,omitempty"`
 	Type MessageType     `json:"type"`
 	Data json.RawMessage `json:"data"`
 }
 
 // OutboundMessage WebSocket 出站消息结构
+// ID 不为空时接收方需要回复 ack 消息，旧版本探针不识别该字段
 type OutboundMessage struct {
+	ID   string      `json:"id,omitempty"`
 	Type MessageType `json:"type"`
 	Data interface{} `json:"data"`
 }
 
+// AckData 探针处理带 ID 的消息后回复的确认，OK 为 false 时表示未能应用（nack）
+type AckData struct {
+	ID    string      `json:"id"`              // 被确认的消息ID
+	Type  MessageType `json:"type"`            // 被确认的消息类型
+	OK    bool        `json:"ok"`              // 是否已应用
+	Error string      `json:"error,omitempty"` // 未能应用的原因
+}
+
 // RegisterRequest 注册请求
 type RegisterRequest struct {
 	AgentInfo AgentInfo `json:"agentInfo"`
 	ApiKey    string    `json:"apiKey"`
+	// Token 探针凭证，签发后替代 ApiKey 注册
+	Token string `json:"token,omitempty"`
+	// SupportsCredential 探针支持保存凭证，旧版本探针不发送该字段，服务端不会为其签发凭证
+	SupportsCredential bool `json:"supportsCredential,omitempty"`
+	// ProtocolVersion 探针使用的协议版本，旧版本探针不发送该字段
+	ProtocolVersion int `json:"protocolVersion,omitempty"`
+	// Capabilities 探针支持的功能，见 Capability 常量
+	Capabilities []string `json:"capabilities,omitempty"`
 }
 
 // RegisterResponse 注册响应
@@ -25,6 +44,26 @@ type RegisterResponse struct {
 	AgentID string `json:"agentId"`
 	Status  string `json:"status"`
 	Message string `json:"message,omitempty"`
+	Token   string `json:"token,omitempty"` // 新签发的探针凭证，探针需要保存
+	// ApiKeyRequired 注册失败时表示需要携带 API 密钥重新注册（凭证失效或管理员允许重新注册）
+	ApiKeyRequired bool `json:"apiKeyRequired,omitempty"`
+	// ProtocolVersion 服务端使用的协议版本
+	ProtocolVersion int `json:"protocolVersion,omitempty"`
+	// Features 服务端支持的可选功能，旧版本服务端不发送该字段
+	Features []string `json:"features,omitempty"`
+}
+
+// 服务端可选功能
+const (
+	// FeatureMetricsBatch 支持将一次采集的所有指标合并为一条 metrics_batch 消息
+	FeatureMetricsBatch = "metrics_batch"
+	// FeatureMetricsBackfill 支持接收探针断线期间缓存的历史指标
+	FeatureMetricsBackfill = "metrics_backfill"
+)
+
+// CredentialData 服务端轮换后下发的新凭证
+type CredentialData struct {
+	Token string `json:"token"`
 }
 
 // AgentInfo 探针信息
@@ -41,6 +80,15 @@ type AgentInfo struct {
 type MetricsPayload struct {
 	Type MetricType  `json:"type"`
 	Data interface{} `json:"data"`
+	// Timestamp 采集时间（时间戳毫秒），只有补发的历史指标携带，实时指标以服务端接收时间为准
+	Timestamp int64 `json:"timestamp,omitempty"`
+}
+
+// MetricsBackfill 探针断线期间缓存的历史指标，重连后补发
+type MetricsBackfill struct {
+	// SentAt 探针发送时间（时间戳毫秒），服务端据此校正探针与服务端的时钟偏差
+	SentAt  int64            `json:"sentAt"`
+	Samples []MetricsPayload `json:"samples"`
 }
 type MessageType string
 
@@ -52,9 +100,15 @@ const (
 	MessageTypeHeartbeat   MessageType = "heartbeat"
 	MessageTypeCommand     MessageType = "command"
 	MessageTypeCommandResp MessageType = "command_response"
+	MessageTypeAck         MessageType = "ack" // 数据为 AckData
+	// 凭证消息
+	MessageTypeCredential    MessageType = "credential"
+	MessageTypeCredentialAck MessageType = "credential_ack"
 	// 指标消息
-	MessageTypeMetrics       MessageType = "metrics"
-	MessageTypeMonitorConfig MessageType = "monitor_config"
+	MessageTypeMetrics         MessageType = "metrics"
+	MessageTypeMetricsBatch    MessageType = "metrics_batch"    // 数据为 []MetricsPayload
+	MessageTypeMetricsBackfill MessageType = "metrics_backfill" // 数据为 MetricsBackfill
+	MessageTypeMonitorConfig   MessageType = "monitor_config"
 	// 防篡改消息
 	MessageTypeTamperProtect MessageType = "tamper_protect"
 	MessageTypeTamperEvent   MessageType = "tamper_event"
@@ -62,6 +116,9 @@ const (
 	// DDNS 消息
 	MessageTypeDDNSConfig   MessageType = "ddns_config"
 	MessageTypeDDNSIPReport MessageType = "ddns_ip_report"
+	// 日志监控消息
+	MessageTypeLogConfig MessageType = "log_config"
+	MessageTypeLogEvent  MessageType = "log_event"
 )
 
 type MetricType string
@@ -78,6 +135,14 @@ const (
 	MetricTypeGPU               MetricType = "gpu"
 	MetricTypeTemperature       MetricType = "temperature"
 	MetricTypeMonitor           MetricType = "monitor"
+	MetricTypeProcess           MetricType = "process"
+	MetricTypeContainer         MetricType = "container"
+	MetricTypeSystemd           MetricType = "systemd"
+	MetricTypeKernel            MetricType = "kernel"
+	MetricTypeDiskHealth        MetricType = "disk_health"
+	MetricTypeCustom            MetricType = "custom"
+	MetricTypePrometheus        MetricType = "prometheus"
+	MetricTypeLog               MetricType = "log"
 )
 
 // CPUData CPU数据
@@ -87,8 +152,24 @@ type CPUData struct {
 	PhysicalCores int    `json:"physicalCores"`
 	ModelName     string `json:"modelName"`
 	// 动态信息
-	UsagePercent float64   `json:"usagePercent"`
-	PerCore      []float64 `json:"perCore,omitempty"`
+	UsagePercent float64    `json:"usagePercent"`
+	PerCore      []float64  `json:"perCore,omitempty"`
+	Modes        *CPUModes  `json:"modes,omitempty"`        // 各模式 CPU 时间占比，首次采集时为空
+	PerCoreModes []CPUModes `json:"perCoreModes,omitempty"` // 每个核心的各模式 CPU 时间占比
+}
+
+// CPUModes 两次采集之间各模式 CPU 时间的占比(%)
+// Linux 上 user/nice 已包含 guest/guest_nice，guest 单独列出便于观察虚拟机负载
+type CPUModes struct {
+	User    float64 `json:"user"`
+	Nice    float64 `json:"nice"`
+	System  float64 `json:"system"`
+	Idle    float64 `json:"idle"`
+	Iowait  float64 `json:"iowait"`
+	Irq     float64 `json:"irq"`
+	Softirq float64 `json:"softirq"`
+	Steal   float64 `json:"steal"`
+	Guest   float64 `json:"guest"`
 }
 
 // MemoryData 内存数据
@@ -114,6 +195,15 @@ type DiskData struct {
 	Used         uint64  `json:"used"`
 	Free         uint64  `json:"free"`
 	UsagePercent float64 `json:"usagePercent"`
+
+	// inode 使用情况，部分文件系统（如 btrfs）不提供 inode 数量，此时均为 0
+	InodesTotal        uint64  `json:"inodesTotal"`
+	InodesUsed         uint64  `json:"inodesUsed"`
+	InodesFree         uint64  `json:"inodesFree"`
+	InodesUsagePercent float64 `json:"inodesUsagePercent"`
+
+	ReadOnly bool     `json:"readOnly"`          // 是否以只读方式挂载
+	Options  []string `json:"options,omitempty"` // 挂载选项
 }
 
 // DiskIOData 磁盘IO数据
@@ -165,6 +255,41 @@ type LoadData struct {
 	Load15 float64 `json:"load15"`
 }
 
+// KernelData 内核与操作系统指标，无法获取的项为空（非 Linux 平台只有负载）
+type KernelData struct {
+	Load                *LoadData       `json:"load,omitempty"`                // 系统负载
+	Pressure            *PressureData   `json:"pressure,omitempty"`            // 压力阻塞信息（PSI，内核 4.20+）
+	FileDescriptors     *FileDescriptor `json:"fileDescriptors,omitempty"`     // 系统文件描述符
+	Conntrack           *ConntrackData  `json:"conntrack,omitempty"`           // 连接跟踪表（需加载 nf_conntrack）
+	EntropyAvailable    *uint64         `json:"entropyAvailable,omitempty"`    // 可用熵（位）
+	ContextSwitchesRate uint64          `json:"contextSwitchesRate,omitempty"` // 上下文切换速率(次/秒)
+	InterruptsRate      uint64          `json:"interruptsRate,omitempty"`      // 中断速率(次/秒)
+	ForksRate           uint64          `json:"forksRate,omitempty"`           // 进程创建速率(次/秒)
+	ProcsRunning        uint64          `json:"procsRunning,omitempty"`        // 可运行的进程数
+	ProcsBlocked        uint64          `json:"procsBlocked,omitempty"`        // 等待 IO 阻塞的进程数
+}
+
+// PressureData 压力阻塞信息，值为最近 10 秒内任务因资源不足而阻塞的时间占比(%)
+type PressureData struct {
+	CPUSome    float64 `json:"cpuSome"`    // 至少一个任务等待 CPU
+	MemorySome float64 `json:"memorySome"` // 至少一个任务等待内存
+	MemoryFull float64 `json:"memoryFull"` // 所有非空闲任务都在等待内存
+	IOSome     float64 `json:"ioSome"`     // 至少一个任务等待 IO
+	IOFull     float64 `json:"ioFull"`     // 所有非空闲任务都在等待 IO
+}
+
+// FileDescriptor 系统文件描述符使用情况
+type FileDescriptor struct {
+	Allocated uint64 `json:"allocated"` // 已分配
+	Max       uint64 `json:"max"`       // 上限（fs.file-max）
+}
+
+// ConntrackData 连接跟踪表使用情况
+type ConntrackData struct {
+	Entries uint64 `json:"entries"` // 当前条目数
+	Max     uint64 `json:"max"`     // 上限（nf_conntrack_max）
+}
+
 // HostInfoData 主机信息
 type HostInfoData struct {
 	Hostname             string `json:"hostname"`
@@ -202,6 +327,105 @@ type TemperatureData struct {
 	Type        string  `json:"type"`
 }
 
+// ProcessData 进程数据
+type ProcessData struct {
+	Top     []ProcessStat        `json:"top"`     // CPU、内存占用最高的进程
+	Watched []WatchedProcessData `json:"watched"` // 重点关注的进程
+}
+
+// ProcessStat 单个进程的资源占用
+type ProcessStat struct {
+	PID           int32   `json:"pid"`
+	Name          string  `json:"name"`
+	Cmdline       string  `json:"cmdline,omitempty"`
+	Username      string  `json:"username,omitempty"`
+	CPUPercent    float64 `json:"cpuPercent"`    // CPU 使用率（单核为 100）
+	MemoryPercent float64 `json:"memoryPercent"` // 内存使用率
+	RSS           uint64  `json:"rss"`           // 常驻内存(字节)
+	NumFDs        int32   `json:"numFds"`        // 打开的文件描述符数量
+	NumThreads    int32   `json:"numThreads"`    // 线程数
+	CreateTime    int64   `json:"createTime"`    // 启动时间(毫秒时间戳)
+}
+
+// WatchedProcessData 重点关注进程的汇总数据（同一规则可能匹配多个进程）
+type WatchedProcessData struct {
+	Name       string  `json:"name"`       // 规则名称
+	Running    bool    `json:"running"`    // 是否有匹配的进程在运行
+	Count      int     `json:"count"`      // 匹配的进程数量
+	PIDs       []int32 `json:"pids"`       // 匹配的进程 PID
+	CPUPercent float64 `json:"cpuPercent"` // CPU 使用率合计
+	RSS        uint64  `json:"rss"`        // 常驻内存合计(字节)
+	NumFDs     int32   `json:"numFds"`     // 文件描述符合计
+	NumThreads int32   `json:"numThreads"` // 线程数合计
+	StartedAt  int64   `json:"startedAt"`  // 主进程启动时间(毫秒时间戳)
+	Restarts   int     `json:"restarts"`   // 探针启动以来检测到的重启次数
+}
+
+// CustomMetricData 自定义指标数据（脚本输出或本地推送）
+type CustomMetricData struct {
+	Name      string            `json:"name"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Value     float64           `json:"value"`
+	Timestamp int64             `json:"timestamp,omitempty"` // 毫秒时间戳，为 0 时使用服务端接收时间
+}
+
+// PrometheusScrapeData 探针抓取本机 Prometheus 目标的结果
+type PrometheusScrapeData struct {
+	Job            string             `json:"job"`
+	Instance       string             `json:"instance"`
+	Up             bool               `json:"up"`
+	ScrapeDuration float64            `json:"scrapeDuration"` // 抓取耗时（秒）
+	Timestamp      int64              `json:"timestamp"`      // 抓取时间（毫秒）
+	Error          string             `json:"error,omitempty"`
+	Samples        []CustomMetricData `json:"samples,omitempty"` // 已附加 job、instance 标签
+}
+
+// DiskHealthData 磁盘健康数据（SMART / NVMe 健康日志），设备不支持的项为 0
+type DiskHealthData struct {
+	Device               string  `json:"device"`               // 设备，例如 /dev/sda
+	Model                string  `json:"model"`                // 型号
+	Serial               string  `json:"serial"`               // 序列号
+	Protocol             string  `json:"protocol"`             // 协议: ATA, NVMe, SCSI
+	Passed               bool    `json:"passed"`               // SMART 总体健康评估是否通过
+	Temperature          float64 `json:"temperature"`          // 温度(摄氏度)
+	PowerOnHours         uint64  `json:"powerOnHours"`         // 通电时间(小时)
+	ReallocatedSectors   uint64  `json:"reallocatedSectors"`   // 重映射扇区数 (ATA 5)
+	PendingSectors       uint64  `json:"pendingSectors"`       // 待映射扇区数 (ATA 197)
+	UncorrectableSectors uint64  `json:"uncorrectableSectors"` // 无法校正的扇区数 (ATA 198)
+	PercentageUsed       float64 `json:"percentageUsed"`       // 寿命已使用百分比，可能超过 100
+	AvailableSpare       float64 `json:"availableSpare"`       // 可用备用空间百分比 (NVMe)
+	AvailableSpareThresh float64 `json:"availableSpareThresh"` // 可用备用空间告警阈值 (NVMe)
+	MediaErrors          uint64  `json:"mediaErrors"`          // 介质错误数 (NVMe)
+	CriticalWarning      uint8   `json:"criticalWarning"`      // 严重警告位 (NVMe)，非 0 表示存在问题
+}
+
+// ContainerData 容器数据
+type ContainerData struct {
+	ID                  string  `json:"id"`                  // 容器 ID（前 12 位）
+	Name                string  `json:"name"`                // 容器名称，无法获取元数据时为容器 ID
+	Image               string  `json:"image,omitempty"`     // 镜像
+	Runtime             string  `json:"runtime"`             // 运行时: docker, containerd, podman, crio
+	CPUPercent          float64 `json:"cpuPercent"`          // CPU 使用率（单核为 100）
+	MemoryUsage         uint64  `json:"memoryUsage"`         // 内存使用量(字节，不含可回收的文件缓存)
+	MemoryLimit         uint64  `json:"memoryLimit"`         // 内存限制(字节)，0 表示不限制
+	MemoryPercent       float64 `json:"memoryPercent"`       // 内存使用率，未限制时相对于主机内存
+	BlkioReadBytesRate  uint64  `json:"blkioReadBytesRate"`  // 磁盘读取速率(字节/秒)
+	BlkioWriteBytesRate uint64  `json:"blkioWriteBytesRate"` // 磁盘写入速率(字节/秒)
+	NetRxBytesRate      uint64  `json:"netRxBytesRate"`      // 网络接收速率(字节/秒)
+	NetTxBytesRate      uint64  `json:"netTxBytesRate"`      // 网络发送速率(字节/秒)
+	Pids                uint64  `json:"pids"`                // 进程数
+}
+
+// SystemdUnitData systemd 单元状态
+type SystemdUnitData struct {
+	Name        string `json:"name"`        // 单元名称，例如 nginx.service
+	Description string `json:"description"` // 描述
+	LoadState   string `json:"loadState"`   // 加载状态: loaded, not-found, masked
+	ActiveState string `json:"activeState"` // 运行状态: active, inactive, failed, activating, deactivating
+	SubState    string `json:"subState"`    // 子状态: running, exited, dead 等
+	Restarts    uint32 `json:"restarts"`    // systemd 自动重启次数（NRestarts），仅 service 单元
+}
+
 // CommandRequest 指令请求
 type CommandRequest struct {
 	ID   string `json:"id"`   // 指令ID
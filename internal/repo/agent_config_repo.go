@@ -0,0 +1,115 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/pika/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AgentConfigRepo struct {
+	db *gorm.DB
+}
+
+func NewAgentConfigRepo(db *gorm.DB) *AgentConfigRepo {
+	return &AgentConfigRepo{db: db}
+}
+
+// SaveSent 记录配置下发，保留已应用配置的摘要和时间
+func (r *AgentConfigRepo) SaveSent(ctx context.Context, state *models.AgentConfigState) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"message_id", "desired_hash", "status", "error", "sent_at", "updated_at"}),
+		}).
+		Create(state).Error
+}
+
+// UpdateStatus 更新配置下发状态
+func (r *AgentConfigRepo) UpdateStatus(ctx context.Context, id string, status string) error {
+	return r.db.WithContext(ctx).
+		Model(&models.AgentConfigState{}).
+		Where("id = ?", id).
+		Update("status", status).Error
+}
+
+// FindById 根据ID查找配置状态
+func (r *AgentConfigRepo) FindById(ctx context.Context, id string) (*models.AgentConfigState, error) {
+	var state models.AgentConfigState
+	err := r.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(&state).Error
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// FindByMessageID 根据下发的消息ID查找配置状态
+func (r *AgentConfigRepo) FindByMessageID(ctx context.Context, agentID, messageID string) (*models.AgentConfigState, error) {
+	var state models.AgentConfigState
+	err := r.db.WithContext(ctx).
+		Where("agent_id = ? AND message_id = ?", agentID, messageID).
+		First(&state).Error
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// SaveAck 记录探针的确认结果，只更新仍在等待该消息确认的状态
+func (r *AgentConfigRepo) SaveAck(ctx context.Context, id, messageID string, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).
+		Model(&models.AgentConfigState{}).
+		Where("id = ? AND message_id = ?", id, messageID).
+		Updates(updates).Error
+}
+
+// ListByAgentID 获取探针的所有配置状态
+func (r *AgentConfigRepo) ListByAgentID(ctx context.Context, agentID string) ([]models.AgentConfigState, error) {
+	var states []models.AgentConfigState
+	err := r.db.WithContext(ctx).
+		Where("agent_id = ?", agentID).
+		Order("kind, ref").
+		Find(&states).Error
+	return states, err
+}
+
+// ListUnapplied 获取探针尚未应用的配置状态
+func (r *AgentConfigRepo) ListUnapplied(ctx context.Context, agentID string) ([]models.AgentConfigState, error) {
+	var states []models.AgentConfigState
+	err := r.db.WithContext(ctx).
+		Where("agent_id = ? AND status IN ?", agentID, []string{
+			models.ConfigStatusQueued,
+			models.ConfigStatusPending,
+			models.ConfigStatusFailed,
+			models.ConfigStatusTimeout,
+		}).
+		Find(&states).Error
+	return states, err
+}
+
+// MarkTimeout 将下发时间早于 sentBefore 且仍未确认的配置标记为超时，返回受影响的行数
+func (r *AgentConfigRepo) MarkTimeout(ctx context.Context, sentBefore int64) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.AgentConfigState{}).
+		Where("status = ? AND sent_at < ?", models.ConfigStatusPending, sentBefore).
+		Update("status", models.ConfigStatusTimeout)
+	return result.RowsAffected, result.Error
+}
+
+// Delete 删除配置状态
+func (r *AgentConfigRepo) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&models.AgentConfigState{}, "id = ?", id).Error
+}
+
+// DeleteByRef 删除所有探针中指定配置的状态
+func (r *AgentConfigRepo) DeleteByRef(ctx context.Context, kind, ref string) error {
+	return r.db.WithContext(ctx).Where("kind = ? AND ref = ?", kind, ref).Delete(&models.AgentConfigState{}).Error
+}
+
+// DeleteByAgentID 删除探针的所有配置状态
+func (r *AgentConfigRepo) DeleteByAgentID(ctx context.Context, agentID string) error {
+	return r.db.WithContext(ctx).Where("agent_id = ?", agentID).Delete(&models.AgentConfigState{}).Error
+}
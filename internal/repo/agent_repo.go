@@ -35,13 +35,33 @@ func (r *AgentRepo) UpdateStatus(ctx context.Context, agentID string, status int
 		Updates(m).Error
 }
 
-// FindOnlineAgents 查找所有在线探针
-func (r *AgentRepo) FindOnlineAgents(ctx context.Context) ([]models.Agent, error) {
-	var agents []models.Agent
-	err := r.db.WithContext(ctx).
-		Where("status = ?", 1).
-		Find(&agents).Error
-	return agents, err
+// SyncPresence 在一个事务中批量写入最后活跃时间，并校正在线状态
+// onlineIDs 中的探针标记为在线，其余探针标记为离线，只更新状态有变化的行
+func (r *AgentRepo) SyncPresence(ctx context.Context, lastSeen map[string]int64, onlineIDs []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for agentID, at := range lastSeen {
+			if err := tx.Model(&models.Agent{}).
+				Where("id = ?", agentID).
+				Update("last_seen_at", at).Error; err != nil {
+				return err
+			}
+		}
+
+		offline := tx.Model(&models.Agent{}).Where("status = ?", 1)
+		if len(onlineIDs) > 0 {
+			offline = offline.Where("id NOT IN ?", onlineIDs)
+		}
+		if err := offline.Update("status", 0).Error; err != nil {
+			return err
+		}
+
+		if len(onlineIDs) == 0 {
+			return nil
+		}
+		return tx.Model(&models.Agent{}).
+			Where("status <> ? AND id IN ?", 1, onlineIDs).
+			Update("status", 1).Error
+	})
 }
 
 // FindByIP 根据IP查找探针
@@ -68,6 +88,19 @@ func (r *AgentRepo) FindByHostname(ctx context.Context, hostname string) (*model
 	return &agent, nil
 }
 
+// FindByCertSubject 根据客户端证书主题查找绑定的探针，未绑定时返回 nil
+func (r *AgentRepo) FindByCertSubject(ctx context.Context, certSubject string) (*models.Agent, error) {
+	var agents []models.Agent
+	err := r.db.WithContext(ctx).
+		Where("cert_subject = ?", certSubject).
+		Limit(1).
+		Find(&agents).Error
+	if err != nil || len(agents) == 0 {
+		return nil, err
+	}
+	return &agents[0], nil
+}
+
 // FindByHostnameAndIP 根据主机名和IP查找探针
 func (r *AgentRepo) FindByHostnameAndIP(ctx context.Context, hostname, ip string) (*models.Agent, error) {
 	var agent models.Agent
@@ -0,0 +1,72 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+)
+
+type LogRuleRepo struct {
+	orz.Repository[models.LogRule, string]
+	db *gorm.DB
+}
+
+func NewLogRuleRepo(db *gorm.DB) *LogRuleRepo {
+	return &LogRuleRepo{
+		Repository: orz.NewRepository[models.LogRule, string](db),
+		db:         db,
+	}
+}
+
+// ListByAgentID 列出探针的所有日志规则
+func (r *LogRuleRepo) ListByAgentID(ctx context.Context, agentID string) ([]models.LogRule, error) {
+	var rules []models.LogRule
+	err := r.db.WithContext(ctx).
+		Where("agent_id = ?", agentID).
+		Order("created_at DESC").
+		Find(&rules).Error
+	return rules, err
+}
+
+// ListEnabledByAgentID 列出探针已启用的日志规则
+func (r *LogRuleRepo) ListEnabledByAgentID(ctx context.Context, agentID string) ([]models.LogRule, error) {
+	var rules []models.LogRule
+	err := r.db.WithContext(ctx).
+		Where("agent_id = ? AND enabled = ?", agentID, true).
+		Order("created_at ASC").
+		Find(&rules).Error
+	return rules, err
+}
+
+type LogEventRepo struct {
+	orz.Repository[models.LogEvent, string]
+	db *gorm.DB
+}
+
+func NewLogEventRepo(db *gorm.DB) *LogEventRepo {
+	return &LogEventRepo{
+		Repository: orz.NewRepository[models.LogEvent, string](db),
+		db:         db,
+	}
+}
+
+// CreateBatch 批量保存日志事件
+func (r *LogEventRepo) CreateBatch(ctx context.Context, events []models.LogEvent) error {
+	return r.db.WithContext(ctx).CreateInBatches(events, 100).Error
+}
+
+// DeleteByRuleID 删除规则相关的所有日志事件
+func (r *LogEventRepo) DeleteByRuleID(ctx context.Context, ruleID string) error {
+	return r.GetDB(ctx).
+		Where("rule_id = ?", ruleID).
+		Delete(&models.LogEvent{}).Error
+}
+
+// DeleteBefore 删除指定时间之前的日志事件
+func (r *LogEventRepo) DeleteBefore(ctx context.Context, timestamp int64) error {
+	return r.db.WithContext(ctx).
+		Where("timestamp < ?", timestamp).
+		Delete(&models.LogEvent{}).Error
+}
@@ -79,3 +79,16 @@ func (r *MonitorRepo) FindByEnabledAndType(ctx context.Context, enabled bool, ty
 	}
 	return monitors, nil
 }
+
+// FindAllGroupNames 查找所有非空的分组名称（去重）
+func (r *MonitorRepo) FindAllGroupNames(ctx context.Context) ([]string, error) {
+	var names []string
+	if err := r.GetDB(ctx).
+		Model(&models.MonitorTask{}).
+		Where("group_name <> ?", "").
+		Distinct().
+		Pluck("group_name", &names).Error; err != nil {
+		return nil, err
+	}
+	return names, nil
+}
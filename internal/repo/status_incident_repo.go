@@ -0,0 +1,116 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+)
+
+type StatusIncidentRepo struct {
+	orz.Repository[models.StatusIncident, string]
+}
+
+func NewStatusIncidentRepo(db *gorm.DB) *StatusIncidentRepo {
+	return &StatusIncidentRepo{
+		Repository: orz.NewRepository[models.StatusIncident, string](db),
+	}
+}
+
+// ListByStatusPageID 列出状态页的故障事件（最新的在前）
+func (r *StatusIncidentRepo) ListByStatusPageID(ctx context.Context, statusPageID string, limit int) ([]models.StatusIncident, error) {
+	var incidents []models.StatusIncident
+	query := r.GetDB(ctx).
+		Where("status_page_id = ?", statusPageID).
+		Order("created_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	err := query.Find(&incidents).Error
+	return incidents, err
+}
+
+// ListVisible 列出未恢复或在 since 之后恢复的故障事件
+func (r *StatusIncidentRepo) ListVisible(ctx context.Context, statusPageID string, since int64) ([]models.StatusIncident, error) {
+	var incidents []models.StatusIncident
+	err := r.GetDB(ctx).
+		Where("status_page_id = ? AND (status <> ? OR resolved_at >= ?)", statusPageID, "resolved", since).
+		Order("created_at DESC").
+		Find(&incidents).Error
+	return incidents, err
+}
+
+// DeleteByStatusPageID 删除状态页的全部故障事件
+func (r *StatusIncidentRepo) DeleteByStatusPageID(ctx context.Context, statusPageID string) error {
+	return r.GetDB(ctx).
+		Where("status_page_id = ?", statusPageID).
+		Delete(&models.StatusIncident{}).Error
+}
+
+type StatusIncidentUpdateRepo struct {
+	orz.Repository[models.StatusIncidentUpdate, string]
+}
+
+func NewStatusIncidentUpdateRepo(db *gorm.DB) *StatusIncidentUpdateRepo {
+	return &StatusIncidentUpdateRepo{
+		Repository: orz.NewRepository[models.StatusIncidentUpdate, string](db),
+	}
+}
+
+// ListByIncidentIDs 列出多个故障事件的更新记录（最新的在前）
+func (r *StatusIncidentUpdateRepo) ListByIncidentIDs(ctx context.Context, incidentIDs []string) ([]models.StatusIncidentUpdate, error) {
+	var updates []models.StatusIncidentUpdate
+	if len(incidentIDs) == 0 {
+		return updates, nil
+	}
+	err := r.GetDB(ctx).
+		Where("incident_id IN ?", incidentIDs).
+		Order("created_at DESC").
+		Find(&updates).Error
+	return updates, err
+}
+
+// DeleteByIncidentID 删除故障事件的全部更新记录
+func (r *StatusIncidentUpdateRepo) DeleteByIncidentID(ctx context.Context, incidentID string) error {
+	return r.GetDB(ctx).
+		Where("incident_id = ?", incidentID).
+		Delete(&models.StatusIncidentUpdate{}).Error
+}
+
+type StatusMaintenanceRepo struct {
+	orz.Repository[models.StatusMaintenance, string]
+}
+
+func NewStatusMaintenanceRepo(db *gorm.DB) *StatusMaintenanceRepo {
+	return &StatusMaintenanceRepo{
+		Repository: orz.NewRepository[models.StatusMaintenance, string](db),
+	}
+}
+
+// ListByStatusPageID 列出状态页的计划维护（按开始时间倒序）
+func (r *StatusMaintenanceRepo) ListByStatusPageID(ctx context.Context, statusPageID string) ([]models.StatusMaintenance, error) {
+	var items []models.StatusMaintenance
+	err := r.GetDB(ctx).
+		Where("status_page_id = ?", statusPageID).
+		Order("start_at DESC").
+		Find(&items).Error
+	return items, err
+}
+
+// ListNotEnded 列出尚未结束的计划维护（按开始时间正序）
+func (r *StatusMaintenanceRepo) ListNotEnded(ctx context.Context, statusPageID string, now int64) ([]models.StatusMaintenance, error) {
+	var items []models.StatusMaintenance
+	err := r.GetDB(ctx).
+		Where("status_page_id = ? AND end_at > ?", statusPageID, now).
+		Order("start_at ASC").
+		Find(&items).Error
+	return items, err
+}
+
+// DeleteByStatusPageID 删除状态页的全部计划维护
+func (r *StatusMaintenanceRepo) DeleteByStatusPageID(ctx context.Context, statusPageID string) error {
+	return r.GetDB(ctx).
+		Where("status_page_id = ?", statusPageID).
+		Delete(&models.StatusMaintenance{}).Error
+}
@@ -0,0 +1,53 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+)
+
+type StatusPageRepo struct {
+	orz.Repository[models.StatusPage, string]
+}
+
+func NewStatusPageRepo(db *gorm.DB) *StatusPageRepo {
+	return &StatusPageRepo{
+		Repository: orz.NewRepository[models.StatusPage, string](db),
+	}
+}
+
+// FindEnabledBySlug 根据 slug 查找已启用的状态页
+func (r *StatusPageRepo) FindEnabledBySlug(ctx context.Context, slug string) (*models.StatusPage, error) {
+	var page models.StatusPage
+	err := r.GetDB(ctx).
+		Where("slug = ? AND enabled = ?", slug, true).
+		First(&page).Error
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// FindEnabledByDomain 根据自定义域名查找已启用的状态页
+func (r *StatusPageRepo) FindEnabledByDomain(ctx context.Context, domain string) (*models.StatusPage, error) {
+	var page models.StatusPage
+	err := r.GetDB(ctx).
+		Where("domain = ? AND enabled = ?", domain, true).
+		First(&page).Error
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// ExistsBySlug 判断 slug 是否已被其他状态页使用
+func (r *StatusPageRepo) ExistsBySlug(ctx context.Context, slug, excludeID string) (bool, error) {
+	var count int64
+	err := r.GetDB(ctx).
+		Model(&models.StatusPage{}).
+		Where("slug = ? AND id <> ?", slug, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,271 @@
+package schedule
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+	_ "time/tzdata" // 保证精简镜像中也能加载时区
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/robfig/cron/v3"
+)
+
+// DefaultInterval 未设置检测频率时的默认值（秒）
+const DefaultInterval = 60
+
+// 最多向后查找的调度次数，避免时间窗口配置异常时死循环
+const maxLookahead = 1000
+
+var cronParser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// New 根据监控任务的调度配置构建 cron 调度
+//   - 未设置 Cron 表达式时按检测频率执行，并按任务 ID 固定错峰，避免大量任务在同一秒触发
+//   - 设置 Cron 表达式时按表达式执行，Jitter 大于 0 时同样按任务 ID 固定偏移
+//   - 设置了生效时间窗口时，窗口外的调度会被跳过
+func New(task *models.MonitorTask) (cron.Schedule, error) {
+	loc := time.Local
+	if task.Timezone != "" {
+		l, err := time.LoadLocation(task.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("无效的时区: %s", task.Timezone)
+		}
+		loc = l
+	}
+
+	windows, err := parseWindows(task.ActiveWindows)
+	if err != nil {
+		return nil, err
+	}
+
+	var base cron.Schedule
+	cronExpr := strings.TrimSpace(task.CronExpr)
+	if cronExpr != "" {
+		if task.Jitter < 0 {
+			return nil, fmt.Errorf("错峰范围不能为负数")
+		}
+		spec := cronExpr
+		if !strings.HasPrefix(spec, "TZ=") && !strings.HasPrefix(spec, "CRON_TZ=") {
+			spec = fmt.Sprintf("CRON_TZ=%s %s", loc.String(), spec)
+		}
+		parsed, err := cronParser.Parse(spec)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 Cron 表达式: %s", cronExpr)
+		}
+		// 如 0 0 30 2 * 这样语法正确但不存在匹配日期的表达式
+		if parsed.Next(time.Now()).IsZero() {
+			return nil, fmt.Errorf("Cron 表达式永远不会触发: %s", cronExpr)
+		}
+		base = parsed
+		if task.Jitter > 0 {
+			base = &offsetSchedule{
+				base:   parsed,
+				offset: time.Duration(hashOffset(task.ID, task.Jitter)) * time.Second,
+			}
+		}
+	} else {
+		interval := task.Interval
+		if interval <= 0 {
+			interval = DefaultInterval
+		}
+		base = &intervalSchedule{
+			period: int64(interval),
+			offset: int64(hashOffset(task.ID, interval)),
+		}
+	}
+
+	if len(windows) == 0 {
+		return base, nil
+	}
+	sched := &windowSchedule{base: base, loc: loc, windows: windows}
+	if sched.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("调度时间不在任何生效时间窗口内，任务永远不会执行")
+	}
+	return sched, nil
+}
+
+// Describe 返回调度配置的描述，用于日志和判断调度是否变化
+func Describe(task *models.MonitorTask) string {
+	var sb strings.Builder
+	if cronExpr := strings.TrimSpace(task.CronExpr); cronExpr != "" {
+		sb.WriteString("cron " + cronExpr)
+		if task.Jitter > 0 {
+			fmt.Fprintf(&sb, " jitter %ds", task.Jitter)
+		}
+	} else {
+		interval := task.Interval
+		if interval <= 0 {
+			interval = DefaultInterval
+		}
+		fmt.Fprintf(&sb, "@every %ds", interval)
+	}
+	if task.Timezone != "" {
+		sb.WriteString(" tz " + task.Timezone)
+	}
+	for _, w := range task.ActiveWindows {
+		fmt.Fprintf(&sb, " window %v %s-%s", w.Days, w.Start, w.End)
+	}
+	return sb.String()
+}
+
+// hashOffset 根据任务 ID 计算 [0, n) 范围内固定的偏移量
+func hashOffset(id string, n int) int {
+	if n <= 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+	return int(h.Sum32() % uint32(n))
+}
+
+// intervalSchedule 固定间隔调度，触发时间为 offset + k*period（Unix 秒）
+type intervalSchedule struct {
+	period int64
+	offset int64
+}
+
+func (s *intervalSchedule) Next(t time.Time) time.Time {
+	sec := t.Unix()
+	next := ((sec-s.offset)/s.period+1)*s.period + s.offset
+	return time.Unix(next, 0).In(t.Location())
+}
+
+// offsetSchedule 在原调度的基础上整体延后固定时长
+type offsetSchedule struct {
+	base   cron.Schedule
+	offset time.Duration
+}
+
+func (s *offsetSchedule) Next(t time.Time) time.Time {
+	next := s.base.Next(t.Add(-s.offset))
+	if next.IsZero() {
+		return next
+	}
+	return next.Add(s.offset)
+}
+
+// timeWindow 解析后的时间窗口，start/end 为当天的秒数
+type timeWindow struct {
+	days  [7]bool
+	start int
+	end   int
+}
+
+// windowSchedule 只在生效时间窗口内触发的调度
+type windowSchedule struct {
+	base    cron.Schedule
+	loc     *time.Location
+	windows []timeWindow
+}
+
+func (s *windowSchedule) Next(t time.Time) time.Time {
+	next := s.base.Next(t)
+	for i := 0; i < maxLookahead && !next.IsZero(); i++ {
+		if s.contains(next) {
+			return next
+		}
+		start, ok := s.nextWindowStart(next)
+		if !ok {
+			return time.Time{}
+		}
+		next = s.base.Next(start.Add(-time.Second))
+	}
+	return time.Time{}
+}
+
+// contains 判断时间点是否落在任一时间窗口内
+func (s *windowSchedule) contains(t time.Time) bool {
+	lt := t.In(s.loc)
+	sod := lt.Hour()*3600 + lt.Minute()*60 + lt.Second()
+	weekday := int(lt.Weekday())
+	yesterday := (weekday + 6) % 7
+	for _, w := range s.windows {
+		if w.start < w.end {
+			if w.days[weekday] && sod >= w.start && sod < w.end {
+				return true
+			}
+			continue
+		}
+		// 跨天窗口：开始当天的后半段，以及次日的前半段
+		if w.days[weekday] && sod >= w.start {
+			return true
+		}
+		if w.days[yesterday] && sod < w.end {
+			return true
+		}
+	}
+	return false
+}
+
+// nextWindowStart 查找 t 之后最近的时间窗口开始时间
+func (s *windowSchedule) nextWindowStart(t time.Time) (time.Time, bool) {
+	lt := t.In(s.loc)
+	var best time.Time
+	for d := 0; d <= 7; d++ {
+		day := time.Date(lt.Year(), lt.Month(), lt.Day()+d, 0, 0, 0, 0, s.loc)
+		for _, w := range s.windows {
+			if !w.days[int(day.Weekday())] {
+				continue
+			}
+			start := time.Date(day.Year(), day.Month(), day.Day(), w.start/3600, w.start%3600/60, w.start%60, 0, s.loc)
+			if !start.After(t) {
+				continue
+			}
+			if best.IsZero() || start.Before(best) {
+				best = start
+			}
+		}
+		if !best.IsZero() {
+			return best, true
+		}
+	}
+	return best, false
+}
+
+func parseWindows(windows []models.MonitorTimeWindow) ([]timeWindow, error) {
+	result := make([]timeWindow, 0, len(windows))
+	for _, w := range windows {
+		start, err := parseClock(w.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(w.End)
+		if err != nil {
+			return nil, err
+		}
+		if start == end {
+			return nil, fmt.Errorf("时间窗口的开始时间和结束时间不能相同")
+		}
+
+		tw := timeWindow{start: start, end: end}
+		if len(w.Days) == 0 {
+			for i := range tw.days {
+				tw.days[i] = true
+			}
+		}
+		for _, day := range w.Days {
+			if day < 0 || day > 6 {
+				return nil, fmt.Errorf("无效的星期: %d，取值范围为 0-6", day)
+			}
+			tw.days[day] = true
+		}
+		result = append(result, tw)
+	}
+	return result, nil
+}
+
+// parseClock 解析 HH:MM 或 HH:MM:SS 格式的时间，返回当天的秒数，支持 24:00
+func parseClock(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "24:00" || value == "24:00:00" {
+		return 24 * 3600, nil
+	}
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Hour()*3600 + t.Minute()*60 + t.Second(), nil
+		}
+	}
+	return 0, fmt.Errorf("无效的时间格式: %s，应为 HH:MM", value)
+}
@@ -0,0 +1,201 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dushixiang/pika/internal/models"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("加载时区 %s 失败: %v", name, err)
+	}
+	return loc
+}
+
+func TestIntervalSchedule(t *testing.T) {
+	task := &models.MonitorTask{ID: "task-1", Interval: 30}
+	sched, err := New(task)
+	if err != nil {
+		t.Fatalf("构建调度失败: %v", err)
+	}
+
+	offset := int64(hashOffset(task.ID, 30))
+	start := time.Unix(1_700_000_000, 0)
+	next := start
+	for i := 0; i < 5; i++ {
+		prev := next
+		next = sched.Next(prev)
+		if !next.After(prev) {
+			t.Fatalf("下次执行时间 %v 应晚于 %v", next, prev)
+		}
+		if (next.Unix()-offset)%30 != 0 {
+			t.Errorf("执行时间 %v 不在固定错峰的间隔上", next)
+		}
+		if i > 0 && next.Sub(prev) != 30*time.Second {
+			t.Errorf("执行间隔为 %v，应为 30s", next.Sub(prev))
+		}
+	}
+}
+
+func TestDefaultInterval(t *testing.T) {
+	sched, err := New(&models.MonitorTask{ID: "task-1"})
+	if err != nil {
+		t.Fatalf("构建调度失败: %v", err)
+	}
+	first := sched.Next(time.Unix(1_700_000_000, 0))
+	if got := sched.Next(first).Sub(first); got != DefaultInterval*time.Second {
+		t.Errorf("未设置检测频率时的间隔为 %v，应为 %ds", got, DefaultInterval)
+	}
+}
+
+func TestCronTimezone(t *testing.T) {
+	sched, err := New(&models.MonitorTask{ID: "task-1", CronExpr: "0 9 * * *", Timezone: "Asia/Shanghai"})
+	if err != nil {
+		t.Fatalf("构建调度失败: %v", err)
+	}
+
+	// 2024-01-01 00:00 UTC 为北京时间 08:00
+	next := sched.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	want := time.Date(2024, 1, 1, 9, 0, 0, 0, mustLoad(t, "Asia/Shanghai"))
+	if !next.Equal(want) {
+		t.Errorf("下次执行时间为 %v，应为 %v", next, want)
+	}
+}
+
+func TestCronSeconds(t *testing.T) {
+	sched, err := New(&models.MonitorTask{ID: "task-1", CronExpr: "*/10 * * * * *", Timezone: "UTC"})
+	if err != nil {
+		t.Fatalf("构建调度失败: %v", err)
+	}
+	next := sched.Next(time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC))
+	if want := time.Date(2024, 1, 1, 0, 0, 10, 0, time.UTC); !next.Equal(want) {
+		t.Errorf("下次执行时间为 %v，应为 %v", next, want)
+	}
+}
+
+func TestCronJitter(t *testing.T) {
+	task := &models.MonitorTask{ID: "task-1", CronExpr: "0 * * * *", Timezone: "UTC", Jitter: 60}
+	sched, err := New(task)
+	if err != nil {
+		t.Fatalf("构建调度失败: %v", err)
+	}
+
+	offset := time.Duration(hashOffset(task.ID, 60)) * time.Second
+	from := time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC)
+	want := time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC).Add(offset)
+	if next := sched.Next(from); !next.Equal(want) {
+		t.Errorf("下次执行时间为 %v，应为 %v", next, want)
+	}
+	// 偏移量按任务 ID 固定
+	if again, _ := New(task); !again.Next(from).Equal(want) {
+		t.Error("同一任务的错峰偏移量应保持不变")
+	}
+}
+
+func TestWindowSchedule(t *testing.T) {
+	sched, err := New(&models.MonitorTask{
+		ID:       "task-1",
+		CronExpr: "0 * * * *",
+		Timezone: "UTC",
+		ActiveWindows: []models.MonitorTimeWindow{
+			{Days: []int{1, 2, 3, 4, 5}, Start: "09:00", End: "18:00"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("构建调度失败: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		from time.Time
+		want time.Time
+	}{
+		{"窗口内", time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC), time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"窗口结束时间不执行", time.Date(2024, 1, 1, 17, 30, 0, 0, time.UTC), time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)},
+		{"窗口开始前", time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC), time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)},
+		{"跳过周末", time.Date(2024, 1, 5, 18, 0, 0, 0, time.UTC), time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if next := sched.Next(tt.from); !next.Equal(tt.want) {
+				t.Errorf("下次执行时间为 %v，应为 %v", next, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowAcrossMidnight(t *testing.T) {
+	sched, err := New(&models.MonitorTask{
+		ID:       "task-1",
+		CronExpr: "0 * * * *",
+		Timezone: "Asia/Shanghai",
+		ActiveWindows: []models.MonitorTimeWindow{
+			{Days: []int{5}, Start: "22:00", End: "02:00"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("构建调度失败: %v", err)
+	}
+
+	loc := mustLoad(t, "Asia/Shanghai")
+	// 2024-01-05 为周五，窗口持续到周六 02:00
+	tests := []struct {
+		from time.Time
+		want time.Time
+	}{
+		{time.Date(2024, 1, 5, 12, 0, 0, 0, loc), time.Date(2024, 1, 5, 22, 0, 0, 0, loc)},
+		{time.Date(2024, 1, 5, 23, 0, 0, 0, loc), time.Date(2024, 1, 6, 0, 0, 0, 0, loc)},
+		{time.Date(2024, 1, 6, 1, 0, 0, 0, loc), time.Date(2024, 1, 12, 22, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		if next := sched.Next(tt.from); !next.Equal(tt.want) {
+			t.Errorf("从 %v 开始的下次执行时间为 %v，应为 %v", tt.from, next, tt.want)
+		}
+	}
+}
+
+func TestInvalidSchedule(t *testing.T) {
+	tests := []struct {
+		name string
+		task models.MonitorTask
+	}{
+		{"无效的 Cron 表达式", models.MonitorTask{CronExpr: "* * *"}},
+		{"永远不会触发的 Cron 表达式", models.MonitorTask{CronExpr: "0 0 30 2 *"}},
+		{"无效的时区", models.MonitorTask{Timezone: "Mars/Olympus"}},
+		{"负数错峰范围", models.MonitorTask{CronExpr: "* * * * *", Jitter: -1}},
+		{"无效的时间格式", models.MonitorTask{ActiveWindows: []models.MonitorTimeWindow{{Start: "9am", End: "18:00"}}}},
+		{"开始结束时间相同", models.MonitorTask{ActiveWindows: []models.MonitorTimeWindow{{Start: "09:00", End: "09:00"}}}},
+		{"无效的星期", models.MonitorTask{ActiveWindows: []models.MonitorTimeWindow{{Days: []int{7}, Start: "09:00", End: "18:00"}}}},
+		{"调度不在时间窗口内", models.MonitorTask{
+			CronExpr:      "0 3 * * *",
+			Timezone:      "UTC",
+			ActiveWindows: []models.MonitorTimeWindow{{Start: "09:00", End: "18:00"}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(&tt.task); err == nil {
+				t.Error("应返回错误")
+			}
+		})
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	tests := map[string]int{
+		"00:00":    0,
+		"09:30":    9*3600 + 30*60,
+		"23:59:59": 86399,
+		"24:00":    86400,
+	}
+	for value, want := range tests {
+		got, err := parseClock(value)
+		if err != nil || got != want {
+			t.Errorf("parseClock(%q) = %d, %v，应为 %d", value, got, err, want)
+		}
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/schedule"
 	"github.com/dushixiang/pika/internal/service"
 	"github.com/robfig/cron/v3"
 	"go.uber.org/zap"
@@ -15,6 +17,7 @@ import (
 type MonitorTask struct {
 	ID      string       // 监控任务 ID
 	EntryID cron.EntryID // cron 任务的 ID
+	Spec    string       // 调度配置描述
 }
 
 // MonitorScheduler 监控任务调度器
@@ -82,7 +85,7 @@ func (s *MonitorScheduler) LoadTasks() {
 
 		if _, exists := s.tasks[monitor.ID]; !exists {
 			// 新任务，添加到调度器
-			if err := s.addTaskLocked(monitor.ID, monitor.Interval); err != nil {
+			if err := s.addTaskLocked(&monitor); err != nil {
 				s.logger.Error("添加监控任务失败",
 					zap.String("taskID", monitor.ID),
 					zap.String("taskName", monitor.Name),
@@ -100,55 +103,56 @@ func (s *MonitorScheduler) LoadTasks() {
 }
 
 // AddTask 添加监控任务
-func (s *MonitorScheduler) AddTask(monitorID string, interval int) error {
+func (s *MonitorScheduler) AddTask(monitor *models.MonitorTask) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	return s.addTaskLocked(monitorID, interval)
+	return s.addTaskLocked(monitor)
 }
 
 // addTaskLocked 添加监控任务（需要持有锁）
-func (s *MonitorScheduler) addTaskLocked(monitorID string, interval int) error {
+func (s *MonitorScheduler) addTaskLocked(monitor *models.MonitorTask) error {
+	monitorID := monitor.ID
+
 	// 如果任务已存在，先删除
 	if task, exists := s.tasks[monitorID]; exists {
 		s.cron.Remove(task.EntryID)
 		delete(s.tasks, monitorID)
 	}
 
-	// 确保间隔合法
-	if interval <= 0 {
-		interval = 60 // 默认 60 秒
+	// 根据检测频率、Cron 表达式、时区和时间窗口构建调度
+	sched, err := schedule.New(monitor)
+	if err != nil {
+		return fmt.Errorf("构建调度失败: %w", err)
 	}
 
-	// 构建 cron 表达式: @every Ns
-	spec := fmt.Sprintf("@every %ds", interval)
-
 	// 添加到 cron 调度器
-	entryID, err := s.cron.AddFunc(spec, func() {
+	entryID := s.cron.Schedule(sched, cron.FuncJob(func() {
 		s.executeTask(monitorID)
-	})
-	if err != nil {
-		return fmt.Errorf("添加 cron 任务失败: %w", err)
-	}
+	}))
 
 	// 保存任务信息
+	spec := schedule.Describe(monitor)
 	s.tasks[monitorID] = &MonitorTask{
 		ID:      monitorID,
 		EntryID: entryID,
+		Spec:    spec,
 	}
 
 	s.logger.Info("添加监控任务",
 		zap.String("taskID", monitorID),
-		zap.Int("interval", interval))
+		zap.String("spec", spec))
 
 	return nil
 }
 
 // UpdateTask 更新监控任务（先删除再添加）
-func (s *MonitorScheduler) UpdateTask(monitorID string, interval int) error {
+func (s *MonitorScheduler) UpdateTask(monitor *models.MonitorTask) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	// 移除旧监控任务
-	s.removeTaskLocked(monitorID)
+	s.removeTaskLocked(monitor.ID)
 	// 添加新任务
-	return s.addTaskLocked(monitorID, interval)
+	return s.addTaskLocked(monitor)
 }
 
 // RemoveTask 删除监控任务
@@ -223,7 +227,8 @@ func (s *MonitorScheduler) GetTaskStatus() map[string]interface{} {
 
 	for _, task := range s.tasks {
 		taskInfo := map[string]interface{}{
-			"id": task.ID,
+			"id":   task.ID,
+			"spec": task.Spec,
 		}
 
 		// 从 cron entry 获取下次执行时间
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/internal/repo"
+	"github.com/dushixiang/pika/internal/websocket"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 配置类型
+const (
+	ConfigKindTamper = "tamper" // 防篡改保护
+	ConfigKindDDNS   = "ddns"   // DDNS，Ref 为 DDNS 配置ID
+	ConfigKindLog    = "log"    // 日志监控规则
+)
+
+// 超过该时间未收到探针确认的配置标记为超时
+const configAckTimeout = 30 * time.Second
+
+// ConfigMessage 下发给探针的配置消息
+type ConfigMessage struct {
+	Type protocol.MessageType
+	Data interface{}
+}
+
+// ConfigBuilder 生成探针当前应有的配置，用于探针重连后重新下发，返回 nil 表示不再需要下发
+type ConfigBuilder func(ctx context.Context, agent *models.Agent, ref string) (*ConfigMessage, error)
+
+type configKind struct {
+	capability string        // 探针需要具备的能力，为空时不检查
+	always     bool          // 探针每次连接都重新下发（探针重启后不保留该配置），否则只重新下发未应用的配置
+	build      ConfigBuilder // 生成配置
+}
+
+// AgentConfigService 跟踪下发给探针的配置，记录探针是否已应用，并在探针重连后重新下发
+type AgentConfigService struct {
+	logger     *zap.Logger
+	configRepo *repo.AgentConfigRepo
+	agentRepo  *repo.AgentRepo
+	wsManager  *websocket.Manager
+
+	mu    sync.RWMutex
+	kinds map[string]configKind
+}
+
+func NewAgentConfigService(logger *zap.Logger, db *gorm.DB, wsManager *websocket.Manager) *AgentConfigService {
+	return &AgentConfigService{
+		logger:     logger,
+		configRepo: repo.NewAgentConfigRepo(db),
+		agentRepo:  repo.NewAgentRepo(db),
+		wsManager:  wsManager,
+		kinds:      make(map[string]configKind),
+	}
+}
+
+// RegisterKind 注册配置类型，探针重连时通过 build 生成配置重新下发
+func (s *AgentConfigService) RegisterKind(kind, capability string, always bool, build ConfigBuilder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kinds[kind] = configKind{capability: capability, always: always, build: build}
+}
+
+// Deliver 向探针下发配置并记录期望状态，探针不在线时等待探针连接后重新下发
+func (s *AgentConfigService) Deliver(ctx context.Context, agentID, kind, ref string, msg ConfigMessage) error {
+	agent, err := s.agentRepo.FindById(ctx, agentID)
+	if err != nil {
+		return err
+	}
+	return s.DeliverToAgent(ctx, &agent, kind, ref, msg)
+}
+
+// DeliverToAgent 与 Deliver 相同，调用方已查询到探针信息时使用
+func (s *AgentConfigService) DeliverToAgent(ctx context.Context, agent *models.Agent, kind, ref string, msg ConfigMessage) error {
+	return s.deliver(ctx, agent, kind, ref, msg, func(data []byte) error {
+		return s.wsManager.SendToClient(agent.ID, data)
+	})
+}
+
+// Refresh 定时重复下发配置时使用，配置没有变化且探针已应用时只发送、不再记录，避免每次下发都写入数据库
+// 配置有变化或尚未应用时与 DeliverToAgent 相同
+func (s *AgentConfigService) Refresh(ctx context.Context, agent *models.Agent, kind, ref string, msg ConfigMessage) error {
+	data, err := json.Marshal(msg.Data)
+	if err != nil {
+		return err
+	}
+	state, err := s.configRepo.FindById(ctx, configStateID(agent.ID, kind, ref))
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	// 不支持确认的探针无法得知是否已应用，已下发过相同的配置即可
+	if err != nil || state.DesiredHash != configHash(data) || !(state.InSync() || state.Status == models.ConfigStatusSent) {
+		return s.DeliverToAgent(ctx, agent, kind, ref, msg)
+	}
+
+	// 不带消息ID，探针不会回复确认
+	payload, err := json.Marshal(protocol.OutboundMessage{
+		Type: msg.Type,
+		Data: json.RawMessage(data),
+	})
+	if err != nil {
+		return err
+	}
+	return s.wsManager.SendToClient(agent.ID, payload)
+}
+
+func (s *AgentConfigService) deliver(ctx context.Context, agent *models.Agent, kind, ref string, msg ConfigMessage, send func([]byte) error) error {
+	data, err := json.Marshal(msg.Data)
+	if err != nil {
+		return err
+	}
+
+	// 不支持确认的探针只记录已下发
+	status := models.ConfigStatusSent
+	if agent.HasCapability(protocol.CapabilityConfigAck) {
+		status = models.ConfigStatusPending
+	}
+	state := &models.AgentConfigState{
+		ID:          configStateID(agent.ID, kind, ref),
+		AgentID:     agent.ID,
+		Kind:        kind,
+		Ref:         ref,
+		MessageID:   uuid.NewString(),
+		DesiredHash: configHash(data),
+		Status:      status,
+		SentAt:      time.Now().UnixMilli(),
+	}
+	// 先记录再下发，避免确认先于记录到达
+	if err := s.configRepo.SaveSent(ctx, state); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(protocol.OutboundMessage{
+		ID:   state.MessageID,
+		Type: msg.Type,
+		Data: json.RawMessage(data),
+	})
+	if err != nil {
+		return err
+	}
+	if err := send(payload); err != nil {
+		if updateErr := s.configRepo.UpdateStatus(ctx, state.ID, models.ConfigStatusQueued); updateErr != nil {
+			s.logger.Error("更新配置下发状态失败", zap.String("id", state.ID), zap.Error(updateErr))
+		}
+		return err
+	}
+	return nil
+}
+
+// HandleAck 处理探针对配置消息的确认
+func (s *AgentConfigService) HandleAck(ctx context.Context, agentID string, ack *protocol.AckData) error {
+	state, err := s.configRepo.FindByMessageID(ctx, agentID, ack.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 配置已再次下发，旧消息的确认不再有效
+		s.logger.Debug("ignore ack for superseded config",
+			zap.String("agentID", agentID),
+			zap.String("messageID", ack.ID),
+			zap.String("type", string(ack.Type)))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var updates map[string]interface{}
+	if ack.OK {
+		updates = map[string]interface{}{
+			"status":       models.ConfigStatusApplied,
+			"applied_hash": state.DesiredHash,
+			"applied_at":   time.Now().UnixMilli(),
+			"error":        "",
+		}
+	} else {
+		s.logger.Warn("agent failed to apply config",
+			zap.String("agentID", agentID),
+			zap.String("kind", state.Kind),
+			zap.String("ref", state.Ref),
+			zap.String("error", ack.Error))
+		updates = map[string]interface{}{
+			"status": models.ConfigStatusFailed,
+			"error":  ack.Error,
+		}
+	}
+	return s.configRepo.SaveAck(ctx, state.ID, ack.ID, updates)
+}
+
+// Resync 探针连接后重新下发配置：探针级配置全部下发，其余配置只下发尚未应用的部分
+func (s *AgentConfigService) Resync(ctx context.Context, agent *models.Agent, send func([]byte) error) {
+	s.mu.RLock()
+	kinds := make(map[string]configKind, len(s.kinds))
+	for name, kind := range s.kinds {
+		kinds[name] = kind
+	}
+	s.mu.RUnlock()
+
+	resend := func(name string, kind configKind, ref string) {
+		if kind.capability != "" && !agent.HasCapability(kind.capability) {
+			return
+		}
+		msg, err := kind.build(ctx, agent, ref)
+		if err != nil {
+			s.logger.Error("生成探针配置失败", zap.String("agentID", agent.ID), zap.String("kind", name), zap.String("ref", ref), zap.Error(err))
+			return
+		}
+		if msg == nil {
+			// 配置已删除或探针已不在下发范围内
+			if err := s.configRepo.Delete(ctx, configStateID(agent.ID, name, ref)); err != nil {
+				s.logger.Error("删除配置下发状态失败", zap.String("agentID", agent.ID), zap.String("kind", name), zap.Error(err))
+			}
+			return
+		}
+		if err := s.deliver(ctx, agent, name, ref, *msg, send); err != nil {
+			s.logger.Error("重新下发探针配置失败", zap.String("agentID", agent.ID), zap.String("kind", name), zap.String("ref", ref), zap.Error(err))
+		}
+	}
+
+	for name, kind := range kinds {
+		if kind.always {
+			resend(name, kind, "")
+		}
+	}
+
+	states, err := s.configRepo.ListUnapplied(ctx, agent.ID)
+	if err != nil {
+		s.logger.Error("获取未应用的探针配置失败", zap.String("agentID", agent.ID), zap.Error(err))
+		return
+	}
+	for _, state := range states {
+		kind, ok := kinds[state.Kind]
+		if !ok || kind.always {
+			continue
+		}
+		resend(state.Kind, kind, state.Ref)
+	}
+}
+
+// ListStates 获取探针的配置下发状态
+func (s *AgentConfigService) ListStates(ctx context.Context, agentID string) ([]models.AgentConfigState, error) {
+	return s.configRepo.ListByAgentID(ctx, agentID)
+}
+
+// Forget 配置删除后不再跟踪其下发状态
+func (s *AgentConfigService) Forget(ctx context.Context, kind, ref string) error {
+	return s.configRepo.DeleteByRef(ctx, kind, ref)
+}
+
+// Run 定时将超时未确认的配置标记为超时
+func (s *AgentConfigService) Run(ctx context.Context) {
+	ticker := time.NewTicker(configAckTimeout / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			count, err := s.configRepo.MarkTimeout(ctx, time.Now().Add(-configAckTimeout).UnixMilli())
+			if err != nil {
+				s.logger.Error("更新超时的配置下发状态失败", zap.Error(err))
+				continue
+			}
+			if count > 0 {
+				s.logger.Warn("agent config ack timeout", zap.Int64("count", count))
+			}
+		}
+	}
+}
+
+func configStateID(agentID, kind, ref string) string {
+	return agentID + ":" + kind + ":" + ref
+}
+
+func configHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
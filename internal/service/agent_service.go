@@ -2,15 +2,25 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"sort"
+	"strings"
 	"time"
 
+	"github.com/dushixiang/pika/internal/config"
 	"github.com/dushixiang/pika/internal/models"
 	"github.com/dushixiang/pika/internal/protocol"
 	"github.com/dushixiang/pika/internal/repo"
+	"github.com/dushixiang/pika/internal/websocket"
 	"github.com/go-orz/orz"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -19,68 +29,262 @@ import (
 type AgentService struct {
 	logger *zap.Logger
 	*orz.Service
-	AgentRepo     *repo.AgentRepo
-	apiKeyService *ApiKeyService
-	metricService *MetricService
-	geoipService  *GeoIPService
+	AgentRepo       *repo.AgentRepo
+	agentConfigRepo *repo.AgentConfigRepo
+	apiKeyService   *ApiKeyService
+	metricService   *MetricService
+	geoipService    *GeoIPService
+	agentTLS        *config.AgentTLSConfig
+	trustedProxies  []*net.IPNet // 允许传递证书主题请求头和转发 IP 的反向代理
+	wsManager       *websocket.Manager
+
+	onPresence websocket.PresenceHandler // 上线/离线状态写入数据库后回调，用于向浏览器推送
 }
 
-func NewAgentService(logger *zap.Logger, db *gorm.DB, apiKeyService *ApiKeyService, metricService *MetricService, geoipService *GeoIPService) *AgentService {
-	return &AgentService{
-		logger:        logger,
-		Service:       orz.NewService(db),
-		AgentRepo:     repo.NewAgentRepo(db),
-		apiKeyService: apiKeyService,
-		metricService: metricService,
-		geoipService:  geoipService,
+// 在线探针最后活跃时间写入数据库的间隔
+const presenceFlushInterval = 30 * time.Second
+
+// ErrApiKeyRequired 探针需要使用 API 密钥注册，已保存凭证的探针默认不发送 API 密钥
+var ErrApiKeyRequired = errors.New("探针需要使用 API 密钥注册")
+
+func NewAgentService(logger *zap.Logger, db *gorm.DB, apiKeyService *ApiKeyService, metricService *MetricService, geoipService *GeoIPService, appConfig *config.AppConfig, wsManager *websocket.Manager) *AgentService {
+	s := &AgentService{
+		logger:          logger,
+		Service:         orz.NewService(db),
+		AgentRepo:       repo.NewAgentRepo(db),
+		agentConfigRepo: repo.NewAgentConfigRepo(db),
+		apiKeyService:   apiKeyService,
+		metricService:   metricService,
+		geoipService:    geoipService,
+		agentTLS:        appConfig.AgentTLS,
+		wsManager:       wsManager,
 	}
+
+	s.trustedProxies = parseTrustedProxies(logger, appConfig.TrustedProxies)
+	if s.agentTLS != nil && s.agentTLS.SubjectHeader != "" && len(s.trustedProxies) == 0 {
+		logger.Warn("未配置 TrustedProxies，忽略客户端证书主题请求头", zap.String("header", s.agentTLS.SubjectHeader))
+	}
+
+	// 在线状态由 WebSocket 连接决定，上线/离线时立即写入数据库
+	wsManager.SetPresenceHandler(s.handlePresence)
+
+	return s
 }
 
-// RegisterAgent 注册探针
-func (s *AgentService) RegisterAgent(ctx context.Context, ip string, info *protocol.AgentInfo, apiKey string) (*models.Agent, error) {
-	// 验证API密钥
-	if _, err := s.apiKeyService.ValidateApiKey(ctx, apiKey); err != nil {
-		s.logger.Warn("agent registration failed: invalid api key",
-			zap.String("agentID", info.ID),
-			zap.String("hostname", info.Hostname),
-		)
-		return nil, err
+// ClientCertSubject 获取探针连接使用的客户端证书主题，未使用证书时返回空字符串
+// 服务端直接终止 TLS 时读取已校验的证书，由反向代理终止 TLS 时读取配置的请求头
+// 请求头只在直接连接的地址为受信任的代理时读取，避免探针伪造证书主题
+func (s *AgentService) ClientCertSubject(r *http.Request) string {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		return r.TLS.VerifiedChains[0][0].Subject.String()
+	}
+	if s.agentTLS != nil && s.agentTLS.SubjectHeader != "" && s.fromTrustedProxy(r) {
+		return strings.TrimSpace(r.Header.Get(s.agentTLS.SubjectHeader))
+	}
+	return ""
+}
+
+// TrustedClientIP 返回可信的客户端地址，请求直接来自受信任的代理时使用 realIP（由转发请求头得到），否则使用直接连接的地址
+// 未配置受信任的代理时无法区分代理和探针，沿用 realIP，与升级前一致
+func (s *AgentService) TrustedClientIP(r *http.Request, realIP string) string {
+	if len(s.trustedProxies) == 0 || s.fromTrustedProxy(r) {
+		return realIP
+	}
+	return remoteHost(r)
+}
+
+// fromTrustedProxy 判断请求是否直接来自受信任的反向代理
+func (s *AgentService) fromTrustedProxy(r *http.Request) bool {
+	ip := net.ParseIP(remoteHost(r))
+	if ip == nil {
+		return false
+	}
+	for _, network := range s.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// parseTrustedProxies 解析受信任的代理地址，单个 IP 按 /32 或 /128 处理，无效的地址会被忽略
+func parseTrustedProxies(logger *zap.Logger, proxies []string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil {
+				bits := 8 * net.IPv6len
+				if ip.To4() != nil {
+					ip, bits = ip.To4(), 8*net.IPv4len
+				}
+				networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+				continue
+			}
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			logger.Warn("忽略无效的受信任代理地址", zap.String("proxy", proxy))
+			continue
+		}
+		networks = append(networks, network)
 	}
+	return networks
+}
+
+// RegisterAgent 注册探针
+// 新探针使用 API 密钥注册并获得专属凭证，签发凭证后只能使用凭证注册，持有 API 密钥也无法冒充该探针
+// remoteIP 为认领尚未签发凭证的探针时校验的客户端地址（见 TrustedClientIP）
+// certSubject 为客户端证书主题，证书与探针一一绑定
+// 返回的 token 不为空时为新签发的凭证，需要下发给探针保存
+func (s *AgentService) RegisterAgent(ctx context.Context, ip, remoteIP string, req *protocol.RegisterRequest, certSubject string) (*models.Agent, string, error) {
+	info := &req.AgentInfo
 
 	// 验证探针 ID
 	if info.ID == "" {
-		return nil, fmt.Errorf("agent ID 不能为空")
+		return nil, "", fmt.Errorf("agent ID 不能为空")
 	}
 
 	// 使用探针的持久化 ID 来识别同一个探针
 	// 这样即使主机名或 IP 变化，也能正确识别
 	existingAgent, err := s.AgentRepo.FindById(ctx, info.ID)
-	if err == nil {
+	found := err == nil
+
+	updates := make(map[string]interface{})
+	if err := s.verifyCertSubject(ctx, info.ID, certSubject, found, &existingAgent); err != nil {
+		s.logger.Warn("agent registration failed: client certificate rejected",
+			zap.String("agentID", info.ID),
+			zap.String("certSubject", certSubject),
+			zap.String("ip", ip),
+			zap.Error(err),
+		)
+		return nil, "", err
+	}
+	if certSubject != "" && (!found || existingAgent.CertSubject == "") {
+		updates["cert_subject"] = certSubject
+	}
+	var token string
+	switch {
+	case found && existingAgent.CredentialStatus == models.CredentialStatusRevoked:
+		s.logger.Warn("agent registration failed: credential revoked",
+			zap.String("agentID", info.ID),
+			zap.String("hostname", info.Hostname),
+			zap.String("ip", ip),
+		)
+		return nil, "", errors.New("探针凭证已吊销，请在管理后台允许重新注册")
+
+	case found && existingAgent.CredentialStatus == models.CredentialStatusActive:
+		// 已签发凭证的探针不再接受 API 密钥
+		current, previous := matchCredential(&existingAgent, req.Token)
+		switch {
+		case current:
+			if existingAgent.PreviousCredentialHash != "" {
+				updates["previous_credential_hash"] = ""
+			}
+		case previous:
+			// 探针没有收到轮换后的凭证，重新签发，确认前旧凭证仍然有效
+			token, err = s.issueCredential(updates, models.CredentialStatusActive)
+			if err != nil {
+				return nil, "", err
+			}
+		default:
+			s.logger.Warn("agent registration failed: invalid credential",
+				zap.String("agentID", info.ID),
+				zap.String("hostname", info.Hostname),
+				zap.String("ip", ip),
+			)
+			return nil, "", errors.New("探针凭证无效")
+		}
+
+	case found && existingAgent.CredentialStatus == models.CredentialStatusPending && req.Token != "" &&
+		matchCredentialHash(existingAgent.CredentialHash, req.Token):
+		// 探针确认消息丢失，但已保存凭证并使用凭证注册
+		updates["credential_status"] = models.CredentialStatusActive
+
+	default:
+		// 新探针、尚未签发凭证或等待确认凭证的探针使用 API 密钥注册
+		if req.ApiKey == "" {
+			return nil, "", ErrApiKeyRequired
+		}
+		if _, err := s.apiKeyService.ValidateApiKey(ctx, req.ApiKey); err != nil {
+			s.logger.Warn("agent registration failed: invalid api key",
+				zap.String("agentID", info.ID),
+				zap.String("hostname", info.Hostname),
+			)
+			return nil, "", err
+		}
+		// 升级前注册、从未签发凭证的探针只允许从原来的 IP 认领，避免持有 API 密钥即可冒充
+		if found && existingAgent.CredentialStatus == "" && existingAgent.IP != remoteIP {
+			s.logger.Warn("agent registration failed: unclaimed agent registered from another ip",
+				zap.String("agentID", info.ID),
+				zap.String("hostname", info.Hostname),
+				zap.String("ip", remoteIP),
+				zap.String("previousIP", existingAgent.IP),
+			)
+			return nil, "", errors.New("探针尚未签发凭证且 IP 已变化，请在管理后台允许重新注册")
+		}
+		if found && existingAgent.CredentialStatus == "" && len(s.trustedProxies) == 0 {
+			s.logger.Warn("未配置 TrustedProxies，按转发请求头中的 IP 认领尚未签发凭证的探针，请求头可被伪造",
+				zap.String("agentID", info.ID),
+				zap.String("ip", remoteIP),
+			)
+		}
+		if req.SupportsCredential {
+			// 探针确认保存凭证后才生效，确认前仍可使用 API 密钥重新注册
+			token, err = s.issueCredential(updates, models.CredentialStatusPending)
+			if err != nil {
+				return nil, "", err
+			}
+			updates["previous_credential_hash"] = ""
+		} else {
+			s.logger.Warn("agent does not support credential, please upgrade",
+				zap.String("agentID", info.ID),
+				zap.String("version", info.Version))
+		}
+	}
+
+	now := time.Now().UnixMilli()
+	if found {
 		// 更新现有探针信息（允许主机名、IP、名称等变化）
-		now := time.Now().UnixMilli()
 		existingAgent.Hostname = info.Hostname
 		existingAgent.IP = ip
 		existingAgent.OS = info.OS
 		existingAgent.Arch = info.Arch
 		existingAgent.Version = info.Version
-		existingAgent.Status = 1
+		existingAgent.ProtocolVersion = req.ProtocolVersion
+		existingAgent.Capabilities = req.Capabilities
 		existingAgent.LastSeenAt = now
 		existingAgent.UpdatedAt = now
 
 		if err := s.AgentRepo.UpdateById(ctx, &existingAgent); err != nil {
-			return nil, err
+			return nil, "", err
+		}
+		// 凭证和能力字段可能需要清空，单独按字段更新
+		updates["protocol_version"] = req.ProtocolVersion
+		updates["capabilities"] = existingAgent.Capabilities
+		if err := s.AgentRepo.UpdateInfo(ctx, existingAgent.ID, updates); err != nil {
+			return nil, "", err
 		}
 		s.logger.Info("agent re-registered",
 			zap.String("agentID", existingAgent.ID),
 			zap.String("name", info.Name),
 			zap.String("hostname", info.Hostname),
 			zap.String("ip", ip),
-			zap.String("version", info.Version))
-		return &existingAgent, nil
+			zap.String("version", info.Version),
+			zap.Int("protocolVersion", req.ProtocolVersion),
+			zap.Bool("credentialIssued", token != ""))
+		return &existingAgent, token, nil
 	}
 
 	// 创建新探针（使用客户端提供的持久化 ID）
-	now := time.Now().UnixMilli()
 	agent := &models.Agent{
 		ID:         info.ID, // 使用客户端持久化的 ID
 		Name:       info.Name,
@@ -89,14 +293,22 @@ func (s *AgentService) RegisterAgent(ctx context.Context, ip string, info *proto
 		OS:         info.OS,
 		Arch:       info.Arch,
 		Version:    info.Version,
-		Status:     1,
 		LastSeenAt: now,
 		CreatedAt:  now,
 		UpdatedAt:  now,
+
+		ProtocolVersion: req.ProtocolVersion,
+		Capabilities:    req.Capabilities,
+	}
+	agent.CertSubject = certSubject
+	if token != "" {
+		agent.CredentialHash = hashCredential(token)
+		agent.CredentialStatus = models.CredentialStatusPending
+		agent.CredentialIssuedAt = now
 	}
 
 	if err := s.AgentRepo.Create(ctx, agent); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	s.logger.Info("agent registered successfully",
@@ -104,13 +316,233 @@ func (s *AgentService) RegisterAgent(ctx context.Context, ip string, info *proto
 		zap.String("name", info.Name),
 		zap.String("hostname", info.Hostname),
 		zap.String("ip", ip),
-		zap.String("version", info.Version))
-	return agent, nil
+		zap.String("version", info.Version),
+		zap.Int("protocolVersion", req.ProtocolVersion),
+		zap.Bool("credentialIssued", token != ""))
+	return agent, token, nil
 }
 
-// UpdateAgentStatus 更新探针状态
-func (s *AgentService) UpdateAgentStatus(ctx context.Context, agentID string, status int) error {
-	return s.AgentRepo.UpdateStatus(ctx, agentID, status, time.Now().UnixMilli())
+// verifyCertSubject 校验客户端证书与探针的绑定关系
+func (s *AgentService) verifyCertSubject(ctx context.Context, agentID, certSubject string, found bool, agent *models.Agent) error {
+	if certSubject == "" {
+		if s.agentTLS != nil && s.agentTLS.RequireClientCert {
+			return errors.New("探针需要使用客户端证书连接")
+		}
+		if found && agent.CertSubject != "" {
+			return errors.New("探针已绑定客户端证书，需要使用证书连接")
+		}
+		return nil
+	}
+
+	if found && agent.CertSubject != "" {
+		if agent.CertSubject != certSubject {
+			return errors.New("客户端证书与探针不匹配")
+		}
+		return nil
+	}
+	// 同一个证书不能用于多个探针
+	bound, err := s.AgentRepo.FindByCertSubject(ctx, certSubject)
+	if err != nil {
+		return err
+	}
+	if bound != nil && bound.ID != agentID {
+		return errors.New("客户端证书已绑定其他探针")
+	}
+	return nil
+}
+
+// RotateCredential 轮换探针凭证，返回新凭证
+// 旧凭证在探针确认新凭证或使用新凭证注册前仍然有效，避免探针离线时无法再连接；凭证泄露时应使用吊销
+func (s *AgentService) RotateCredential(ctx context.Context, agentID string) (string, error) {
+	agent, err := s.AgentRepo.FindById(ctx, agentID)
+	if err != nil {
+		return "", err
+	}
+	if agent.CredentialStatus != models.CredentialStatusActive {
+		return "", orz.NewError(400, "探针尚未签发凭证，无法轮换")
+	}
+
+	updates := make(map[string]interface{})
+	token, err := s.issueCredential(updates, models.CredentialStatusActive)
+	if err != nil {
+		return "", err
+	}
+	updates["previous_credential_hash"] = agent.CredentialHash
+	if err := s.AgentRepo.UpdateInfo(ctx, agentID, updates); err != nil {
+		return "", err
+	}
+
+	s.logger.Info("agent credential rotated", zap.String("agentID", agentID))
+	return token, nil
+}
+
+// ConfirmCredential 探针已保存签发或轮换后的凭证，首次签发的凭证开始生效，轮换前的凭证失效
+func (s *AgentService) ConfirmCredential(ctx context.Context, agentID string) error {
+	agent, err := s.AgentRepo.FindById(ctx, agentID)
+	if err != nil {
+		return err
+	}
+	updates := map[string]interface{}{
+		"previous_credential_hash": "",
+	}
+	if agent.CredentialStatus == models.CredentialStatusPending && agent.CredentialHash != "" {
+		updates["credential_status"] = models.CredentialStatusActive
+	}
+	return s.AgentRepo.UpdateInfo(ctx, agentID, updates)
+}
+
+// RevokeCredential 吊销探针凭证，吊销后该探针无法注册，直到管理员允许重新注册
+func (s *AgentService) RevokeCredential(ctx context.Context, agentID string) error {
+	if _, err := s.AgentRepo.FindById(ctx, agentID); err != nil {
+		return err
+	}
+	if err := s.AgentRepo.UpdateInfo(ctx, agentID, map[string]interface{}{
+		"credential_hash":          "",
+		"previous_credential_hash": "",
+		"credential_status":        models.CredentialStatusRevoked,
+	}); err != nil {
+		return err
+	}
+
+	s.logger.Info("agent credential revoked", zap.String("agentID", agentID))
+	return nil
+}
+
+// ResetCredential 清除探针凭证，允许探针从任意 IP 使用 API 密钥重新注册并签发新凭证
+func (s *AgentService) ResetCredential(ctx context.Context, agentID string) error {
+	if _, err := s.AgentRepo.FindById(ctx, agentID); err != nil {
+		return err
+	}
+	if err := s.AgentRepo.UpdateInfo(ctx, agentID, map[string]interface{}{
+		"credential_hash":          "",
+		"previous_credential_hash": "",
+		"credential_status":        models.CredentialStatusPending,
+		"credential_issued_at":     0,
+	}); err != nil {
+		return err
+	}
+
+	s.logger.Info("agent credential reset", zap.String("agentID", agentID))
+	return nil
+}
+
+// 探针能力对应的功能名称，用于提示信息
+var capabilityNames = map[string]string{
+	protocol.CapabilityGPU:         "GPU 监控",
+	protocol.CapabilityTamper:      "防篡改保护",
+	protocol.CapabilityDDNS:        "DDNS",
+	protocol.CapabilityAudit:       "安全审计",
+	protocol.CapabilityLogTail:     "日志监控",
+	protocol.CapabilityMonitorHTTP: "HTTP 监控",
+	protocol.CapabilityMonitorTCP:  "TCP 监控",
+	protocol.CapabilityMonitorICMP: "ICMP 监控",
+}
+
+// RequireCapability 检查探针是否支持指定功能，不支持时返回可直接展示给用户的错误
+func (s *AgentService) RequireCapability(ctx context.Context, agentID, capability string) error {
+	agent, err := s.AgentRepo.FindById(ctx, agentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return orz.NewError(404, "探针不存在")
+		}
+		return err
+	}
+	if agent.HasCapability(capability) {
+		return nil
+	}
+
+	name := capabilityNames[capability]
+	if name == "" {
+		name = capability
+	}
+	if agent.ProtocolVersion == 0 {
+		return orz.NewError(400, fmt.Sprintf("探针 %s 的版本 %s 过旧，不支持%s，请升级探针", agent.Name, agent.Version, name))
+	}
+	return orz.NewError(400, fmt.Sprintf("探针 %s 不支持%s（操作系统 %s）", agent.Name, name, agent.OS))
+}
+
+// issueCredential 生成新凭证，需要保存的字段写入 updates，轮换前的凭证由调用方处理
+func (s *AgentService) issueCredential(updates map[string]interface{}, status string) (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(bytes)
+
+	updates["credential_hash"] = hashCredential(token)
+	updates["credential_status"] = status
+	updates["credential_issued_at"] = time.Now().UnixMilli()
+	return token, nil
+}
+
+// matchCredential 校验探针凭证，分别返回是否匹配当前凭证和轮换前的凭证
+func matchCredential(agent *models.Agent, token string) (current, previous bool) {
+	return matchCredentialHash(agent.CredentialHash, token), matchCredentialHash(agent.PreviousCredentialHash, token)
+}
+
+func matchCredentialHash(hash, token string) bool {
+	if hash == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashCredential(token)), []byte(hash)) == 1
+}
+
+func hashCredential(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// handlePresence 探针上线/离线时更新状态
+func (s *AgentService) handlePresence(ctx context.Context, event websocket.PresenceEvent) {
+	status := 0
+	if event.Online {
+		status = 1
+	} else if s.wsManager.IsOnline(ctx, event.AgentID) {
+		// 探针已重新连接到其他节点，仍然在线
+		return
+	}
+	if err := s.AgentRepo.UpdateStatus(ctx, event.AgentID, status, event.At); err != nil {
+		s.logger.Error("failed to update agent status",
+			zap.String("agentID", event.AgentID),
+			zap.Bool("online", event.Online),
+			zap.Error(err))
+	}
+	if s.onPresence != nil {
+		s.onPresence(ctx, event)
+	}
+}
+
+// SetPresenceHandler 设置探针上线/离线回调，不包含探针重新连接到其他节点时的离线事件
+func (s *AgentService) SetPresenceHandler(handler websocket.PresenceHandler) {
+	s.onPresence = handler
+}
+
+// RunPresence 定时将在线探针的最后活跃时间批量写入数据库，并按实际连接校正在线状态
+func (s *AgentService) RunPresence(ctx context.Context) {
+	ticker := time.NewTicker(presenceFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// 退出前写入剩余数据
+			s.flushPresence(context.Background())
+			return
+		case <-ticker.C:
+			s.flushPresence(ctx)
+		}
+	}
+}
+
+func (s *AgentService) flushPresence(ctx context.Context) {
+	onlineIDs, err := s.wsManager.OnlineAgents(ctx)
+	if err != nil {
+		s.logger.Error("failed to list online agents", zap.Error(err))
+		return
+	}
+	if err := s.AgentRepo.SyncPresence(ctx, s.wsManager.TakeLastSeen(), onlineIDs); err != nil {
+		s.logger.Error("failed to flush agent presence", zap.Error(err))
+	}
 }
 
 // GetAgent 获取探针信息
@@ -127,9 +559,20 @@ func (s *AgentService) ListAgents(ctx context.Context) ([]models.Agent, error) {
 	return s.AgentRepo.FindAll(ctx)
 }
 
-// ListOnlineAgents 列出所有在线探针
-func (s *AgentService) ListOnlineAgents(ctx context.Context) ([]models.Agent, error) {
-	return s.AgentRepo.FindOnlineAgents(ctx)
+// ListConnectedAgents 列出连接在本节点的在线探针，以当前 WebSocket 连接为准
+// 多节点部署时每个节点只处理本节点探针的定时任务
+func (s *AgentService) ListConnectedAgents(ctx context.Context) ([]models.Agent, error) {
+	agents, err := s.AgentRepo.ListByIDs(ctx, s.wsManager.GetAllClients())
+	if err != nil {
+		return nil, err
+	}
+	for i := range agents {
+		agents[i].Status = 1
+		if lastSeen, ok := s.wsManager.LastSeen(agents[i].ID); ok {
+			agents[i].LastSeenAt = lastSeen
+		}
+	}
+	return agents, nil
 }
 
 // HandleCommandResponse 处理指令响应
@@ -344,6 +787,12 @@ func (s *AgentService) DeleteAgent(ctx context.Context, agentID string) error {
 			return err
 		}
 
+		// 3. 删除探针的配置下发状态
+		if err := s.agentConfigRepo.DeleteByAgentID(ctx, agentID); err != nil {
+			s.logger.Error("删除探针配置下发状态失败", zap.String("agentId", agentID), zap.Error(err))
+			return err
+		}
+
 		// 4. 最后删除探针本身
 		if err := s.AgentRepo.DeleteById(ctx, agentID); err != nil {
 			s.logger.Error("删除探针失败", zap.String("agentId", agentID), zap.Error(err))
@@ -455,17 +904,13 @@ func (s *AgentService) BatchUpdateTags(ctx context.Context, agentIDs []string, t
 	})
 }
 
+// InitStatus 启动时按当前连接初始化探针状态，没有连接到任何节点的探针全部标记为离线
 func (s *AgentService) InitStatus(ctx context.Context) error {
-	agents, err := s.AgentRepo.FindAll(ctx)
+	onlineIDs, err := s.wsManager.OnlineAgents(ctx)
 	if err != nil {
 		return err
 	}
-	for _, agent := range agents {
-		if err := s.AgentRepo.UpdateStatus(ctx, agent.ID, 0, 0); err != nil {
-			return err
-		}
-	}
-	return nil
+	return s.AgentRepo.SyncPresence(ctx, nil, onlineIDs)
 }
 
 // UpdateTrafficConfig 更新流量配置
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/dushixiang/pika/internal/models"
@@ -19,10 +20,13 @@ type AlertService struct {
 	AlertRecordRepo *repo.AlertRecordRepo
 	AlertStateRepo  *repo.AlertStateRepo
 	agentRepo       *repo.AgentRepo
+	logRuleRepo     *repo.LogRuleRepo
 	monitorService  *MonitorService
 	propertyService *PropertyService
 	notifier        *Notifier
 	logger          *zap.Logger
+
+	onRecord func(record *models.AlertRecord) // 告警触发/恢复后回调，用于向浏览器推送
 }
 
 func NewAlertService(logger *zap.Logger, db *gorm.DB, propertyService *PropertyService, monitorService *MonitorService, notifier *Notifier) *AlertService {
@@ -31,6 +35,7 @@ func NewAlertService(logger *zap.Logger, db *gorm.DB, propertyService *PropertyS
 		AlertRecordRepo: repo.NewAlertRecordRepo(db),
 		AlertStateRepo:  repo.NewAlertStateRepo(db),
 		agentRepo:       repo.NewAgentRepo(db),
+		logRuleRepo:     repo.NewLogRuleRepo(db),
 		monitorService:  monitorService,
 		propertyService: propertyService,
 		notifier:        notifier,
@@ -58,7 +63,7 @@ func (s *AlertService) Clear(ctx context.Context) error {
 }
 
 // CheckMetrics 检查指标并触发告警
-func (s *AlertService) CheckMetrics(ctx context.Context, agentID string, cpu, memory, disk, networkSpeed float64) error {
+func (s *AlertService) CheckMetrics(ctx context.Context, agentID string, cpu, memory, disk, inode, networkSpeed float64) error {
 	// 获取全局告警配置
 	alertConfig, err := s.propertyService.GetAlertConfig(ctx)
 	if err != nil {
@@ -95,6 +100,11 @@ func (s *AlertService) CheckMetrics(ctx context.Context, agentID string, cpu, me
 		s.checkAlert(ctx, alertConfig, &agent, "disk", disk, alertConfig.Rules.DiskThreshold, alertConfig.Rules.DiskDuration, now)
 	}
 
+	// 检查 inode 告警
+	if alertConfig.Rules.InodeEnabled {
+		s.checkAlert(ctx, alertConfig, &agent, "inode", inode, alertConfig.Rules.InodeThreshold, alertConfig.Rules.InodeDuration, now)
+	}
+
 	// 检查网速告警
 	if alertConfig.Rules.NetworkEnabled {
 		s.checkAlert(ctx, alertConfig, &agent, "network", networkSpeed, alertConfig.Rules.NetworkThreshold, alertConfig.Rules.NetworkDuration, now)
@@ -259,6 +269,8 @@ func (s *AlertService) buildAlertMessage(state *models.AlertState) string {
 		alertTypeName = "内存使用率"
 	case "disk":
 		alertTypeName = "磁盘使用率"
+	case "inode":
+		alertTypeName = "inode使用率"
 	case "network":
 		return fmt.Sprintf("网速持续%d秒超过%.2fMB/s，当前值%.2fMB/s",
 			state.Duration,
@@ -294,6 +306,11 @@ func (s *AlertService) calculateLevel(value, threshold float64) string {
 	}
 }
 
+// SetRecordHandler 设置告警触发/恢复回调，与告警通知一致，被抑制的告警不回调
+func (s *AlertService) SetRecordHandler(handler func(record *models.AlertRecord)) {
+	s.onRecord = handler
+}
+
 // sendAlertNotification 发送告警通知(带panic恢复)
 func (s *AlertService) sendAlertNotification(record *models.AlertRecord, agent *models.Agent) {
 	defer func() {
@@ -305,6 +322,10 @@ func (s *AlertService) sendAlertNotification(record *models.AlertRecord, agent *
 		}
 	}()
 
+	if s.onRecord != nil {
+		s.onRecord(record)
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
@@ -549,6 +570,12 @@ func (s *AlertService) checkServiceDownAlerts(ctx context.Context, config *model
 		return err
 	}
 
+	// 依赖关系用于根因抑制，加载失败时不抑制
+	graph, err := s.monitorService.BuildDependencyGraph(ctx)
+	if err != nil {
+		s.logger.Error("加载监控依赖关系失败", zap.Error(err))
+	}
+
 	for _, monitor := range monitors {
 		// 获取探针信息
 		agent, err := s.agentRepo.FindById(ctx, monitor.AgentId)
@@ -559,7 +586,7 @@ func (s *AlertService) checkServiceDownAlerts(ctx context.Context, config *model
 
 		stateKey := fmt.Sprintf("%s:global:service:%s", agent.ID, monitor.MonitorId)
 
-		var shouldFire, shouldResolve bool
+		var shouldFire, shouldNotify, shouldResolve bool
 
 		// 从数据库加载状态
 		state, err := s.AlertStateRepo.GetAlertState(ctx, stateKey)
@@ -585,6 +612,12 @@ func (s *AlertService) checkServiceDownAlerts(ctx context.Context, config *model
 			if elapsedSeconds >= int64(config.Rules.ServiceDuration) && !state.IsFiring {
 				shouldFire = true
 				state.IsFiring = true
+			} else if state.IsFiring && state.Suppressed {
+				// 已抑制的告警在抑制原因消除后补发通知
+				if suppressed, _ := s.serviceAlertSuppression(graph, &agent, &monitor); suppressed == "" {
+					shouldNotify = true
+					state.Suppressed = false
+				}
 			}
 		} else {
 			if state.IsFiring {
@@ -599,7 +632,12 @@ func (s *AlertService) checkServiceDownAlerts(ctx context.Context, config *model
 		}
 
 		if shouldFire {
-			s.fireServiceDownAlert(ctx, config, &agent, &monitor, state, now)
+			suppressed, reason := s.serviceAlertSuppression(graph, &agent, &monitor)
+			s.fireServiceDownAlert(ctx, config, &agent, &monitor, state, now, suppressed, reason)
+		}
+
+		if shouldNotify {
+			s.notifySuppressedServiceDownAlert(ctx, &agent, &monitor, state)
 		}
 
 		if shouldResolve {
@@ -610,25 +648,47 @@ func (s *AlertService) checkServiceDownAlerts(ctx context.Context, config *model
 	return nil
 }
 
-// fireServiceDownAlert 触发服务下线告警
-func (s *AlertService) fireServiceDownAlert(ctx context.Context, config *models.AlertConfig, agent *models.Agent, monitor *protocol.MonitorData, state *models.AlertState, now int64) {
+// serviceAlertSuppression 判断服务下线告警是否需要抑制，返回抑制原因和说明
+// 执行监控的探针已离线，或上游依赖的监控任务异常时，只记录告警不发送通知
+func (s *AlertService) serviceAlertSuppression(graph *MonitorDependencyGraph, agent *models.Agent, monitor *protocol.MonitorData) (string, string) {
+	if agent.Status != 1 {
+		return models.AlertSuppressedByAgentOffline, fmt.Sprintf("探针 %s 已离线", agent.Name)
+	}
+	if graph == nil {
+		return "", ""
+	}
+	if upstream, ok := graph.DownUpstream(monitor.MonitorId, agent.ID); ok {
+		return models.AlertSuppressedByDependency, fmt.Sprintf("上游监控 %s 异常", upstream.Name)
+	}
+	return "", ""
+}
+
+// fireServiceDownAlert 触发服务下线告警，suppressed 不为空时只记录不通知
+func (s *AlertService) fireServiceDownAlert(ctx context.Context, config *models.AlertConfig, agent *models.Agent, monitor *protocol.MonitorData, state *models.AlertState, now int64, suppressed, reason string) {
 	s.logger.Info("触发服务下线告警",
 		zap.String("agentId", agent.ID),
 		zap.String("monitorId", monitor.MonitorId),
 		zap.String("target", monitor.Target),
 		zap.Int("duration", state.Duration),
+		zap.String("suppressed", suppressed),
 	)
 
+	message := fmt.Sprintf("监控项 %s 持续离线%d秒", monitor.Target, state.Duration)
+	if suppressed != "" {
+		message = fmt.Sprintf("%s（%s，已抑制通知）", message, reason)
+	}
+
 	// 创建告警记录
 	record := &models.AlertRecord{
 		AgentID:     agent.ID,
 		AgentName:   agent.Name,
 		AlertType:   "service",
-		Message:     fmt.Sprintf("监控项 %s 持续离线%d秒", monitor.Target, state.Duration),
+		Message:     message,
 		Threshold:   0,
 		ActualValue: float64(state.Duration),
 		Level:       "critical",
 		Status:      "firing",
+		Suppressed:  suppressed,
 		FiredAt:     now,
 		CreatedAt:   now,
 	}
@@ -640,14 +700,52 @@ func (s *AlertService) fireServiceDownAlert(ctx context.Context, config *models.
 	}
 
 	state.LastRecordID = record.ID
+	state.Suppressed = suppressed != ""
 	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
 		s.logger.Error("保存告警状态失败", zap.Error(err))
 	}
 
+	if suppressed != "" {
+		return
+	}
+
 	// 发送通知
 	go s.sendAlertNotification(record, agent)
 }
 
+// notifySuppressedServiceDownAlert 抑制原因消除后服务仍处于离线状态，取消告警记录的抑制并发送通知
+func (s *AlertService) notifySuppressedServiceDownAlert(ctx context.Context, agent *models.Agent, monitor *protocol.MonitorData, state *models.AlertState) {
+	if state.LastRecordID == 0 {
+		return
+	}
+	record, err := s.AlertRecordRepo.GetAlertRecordByID(ctx, state.LastRecordID)
+	if err != nil || record == nil || record.Status != "firing" {
+		return
+	}
+
+	s.logger.Info("服务下线告警抑制原因已消除，发送通知",
+		zap.String("agentId", agent.ID),
+		zap.String("monitorId", monitor.MonitorId),
+		zap.String("suppressed", record.Suppressed),
+	)
+
+	now := time.Now().UnixMilli()
+	record.Message = fmt.Sprintf("监控项 %s 持续离线%d秒（抑制原因已消除）", monitor.Target, state.Duration)
+	record.Suppressed = ""
+	record.UpdatedAt = now
+	if err := s.AlertRecordRepo.UpdateAlertRecord(ctx, record); err != nil {
+		s.logger.Error("更新服务下线告警记录失败", zap.Error(err))
+		// 下次检查时重试
+		state.Suppressed = true
+		if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+			s.logger.Error("保存告警状态失败", zap.Error(err))
+		}
+		return
+	}
+
+	go s.sendAlertNotification(record, agent)
+}
+
 // resolveServiceDownAlert 恢复服务下线告警
 func (s *AlertService) resolveServiceDownAlert(ctx context.Context, config *models.AlertConfig, agent *models.Agent, monitor *protocol.MonitorData, state *models.AlertState) {
 	s.logger.Info("服务下线告警恢复",
@@ -669,8 +767,8 @@ func (s *AlertService) resolveServiceDownAlert(ctx context.Context, config *mode
 			err = s.AlertRecordRepo.UpdateAlertRecord(ctx, existingRecord)
 			if err != nil {
 				s.logger.Error("更新服务下线告警记录失败", zap.Error(err))
-			} else {
-				// 发送恢复通知
+			} else if existingRecord.Suppressed == "" {
+				// 发送恢复通知（被抑制的告警未通知过，恢复时也不通知）
 				go s.sendAlertNotification(existingRecord, agent)
 			}
 		}
@@ -678,6 +776,7 @@ func (s *AlertService) resolveServiceDownAlert(ctx context.Context, config *mode
 
 	state.IsFiring = false
 	state.LastRecordID = 0
+	state.Suppressed = false
 	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
 		s.logger.Error("保存告警状态失败", zap.Error(err))
 	}
@@ -694,9 +793,10 @@ func (s *AlertService) checkAgentOfflineAlerts(ctx context.Context, config *mode
 	for _, agent := range agents {
 		stateKey := fmt.Sprintf("%s:global:agent_offline:%s", agent.ID, agent.ID)
 
+		// 在线探针的最后活跃时间批量写入，可能有延迟，在线时不计算离线时长
 		// 防止时钟回拨导致负数
 		offlineSeconds := int64(0)
-		if now > agent.LastSeenAt {
+		if agent.Status != 1 && now > agent.LastSeenAt {
 			offlineSeconds = (now - agent.LastSeenAt) / 1000
 		}
 
@@ -820,3 +920,789 @@ func (s *AlertService) resolveAgentOfflineAlert(ctx context.Context, config *mod
 		s.logger.Error("保存告警状态失败", zap.Error(err))
 	}
 }
+
+// CheckProcessAlerts 检查关注进程消失告警
+func (s *AlertService) CheckProcessAlerts(ctx context.Context, agentID string, watched []protocol.WatchedProcessData) error {
+	if len(watched) == 0 {
+		return nil
+	}
+
+	// 获取全局告警配置
+	alertConfig, err := s.propertyService.GetAlertConfig(ctx)
+	if err != nil {
+		s.logger.Error("获取全局告警配置失败", zap.Error(err))
+		return err
+	}
+
+	if !alertConfig.Enabled || !alertConfig.Rules.ProcessEnabled {
+		return nil
+	}
+
+	agent, err := s.agentRepo.FindById(ctx, agentID)
+	if err != nil {
+		s.logger.Error("获取探针信息失败", zap.Error(err))
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	for _, process := range watched {
+		s.checkProcessAlert(ctx, alertConfig, &agent, process, now)
+	}
+	return nil
+}
+
+// checkProcessAlert 检查单个关注进程
+func (s *AlertService) checkProcessAlert(ctx context.Context, config *models.AlertConfig, agent *models.Agent, process protocol.WatchedProcessData, now int64) {
+	stateKey := fmt.Sprintf("%s:global:process:%s", agent.ID, process.Name)
+
+	// 从数据库加载状态
+	state, err := s.AlertStateRepo.GetAlertState(ctx, stateKey)
+	if err != nil {
+		// 状态不存在，创建新状态
+		state = &models.AlertState{
+			ID:        stateKey,
+			AgentID:   agent.ID,
+			AlertType: "process",
+		}
+	}
+
+	state.AgentID = agent.ID
+	state.AlertType = "process"
+	state.Duration = config.Rules.ProcessDuration
+	state.Threshold = float64(config.Rules.ProcessDuration)
+	state.LastCheckTime = now
+
+	var shouldFire, shouldResolve bool
+	var missingSeconds int64
+
+	if !process.Running {
+		if state.StartTime == 0 {
+			state.StartTime = now
+		}
+		missingSeconds = (now - state.StartTime) / 1000
+		state.Value = float64(missingSeconds)
+
+		if missingSeconds >= int64(config.Rules.ProcessDuration) && !state.IsFiring {
+			shouldFire = true
+			state.IsFiring = true
+		}
+	} else {
+		if state.IsFiring {
+			shouldResolve = true
+		}
+		state.StartTime = 0
+		state.Value = 0
+	}
+
+	// 保存状态到数据库
+	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+		s.logger.Error("保存告警状态失败", zap.Error(err))
+	}
+
+	if shouldFire {
+		s.fireProcessAlert(ctx, agent, state, process.Name, missingSeconds, now)
+	}
+
+	if shouldResolve {
+		s.resolveProcessAlert(ctx, agent, state, process.Name)
+	}
+}
+
+// fireProcessAlert 触发进程消失告警
+func (s *AlertService) fireProcessAlert(ctx context.Context, agent *models.Agent, state *models.AlertState, processName string, missingSeconds int64, now int64) {
+	s.logger.Info("触发进程消失告警",
+		zap.String("agentId", agent.ID),
+		zap.String("agentName", agent.Name),
+		zap.String("process", processName),
+		zap.Int64("missingSeconds", missingSeconds),
+	)
+
+	// 创建告警记录
+	record := &models.AlertRecord{
+		AgentID:     agent.ID,
+		AgentName:   agent.Name,
+		AlertType:   "process",
+		Message:     fmt.Sprintf("关注进程 %s 已消失%d秒", processName, missingSeconds),
+		Threshold:   float64(state.Duration),
+		ActualValue: float64(missingSeconds),
+		Level:       "critical",
+		Status:      "firing",
+		FiredAt:     now,
+		CreatedAt:   now,
+	}
+
+	if err := s.AlertRecordRepo.CreateAlertRecord(ctx, record); err != nil {
+		s.logger.Error("创建进程消失告警记录失败", zap.Error(err))
+		return
+	}
+
+	state.LastRecordID = record.ID
+	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+		s.logger.Error("保存告警状态失败", zap.Error(err))
+	}
+
+	// 发送通知
+	go s.sendAlertNotification(record, agent)
+}
+
+// resolveProcessAlert 恢复进程消失告警
+func (s *AlertService) resolveProcessAlert(ctx context.Context, agent *models.Agent, state *models.AlertState, processName string) {
+	s.logger.Info("进程消失告警恢复",
+		zap.String("agentId", agent.ID),
+		zap.String("agentName", agent.Name),
+		zap.String("process", processName),
+	)
+
+	if state.LastRecordID > 0 {
+		existingRecord, err := s.AlertRecordRepo.GetAlertRecordByID(ctx, state.LastRecordID)
+		if err != nil {
+			s.logger.Error("获取进程消失告警记录失败", zap.Error(err))
+		} else if existingRecord != nil && existingRecord.Status == "firing" {
+			now := time.Now().UnixMilli()
+			existingRecord.Status = "resolved"
+			existingRecord.ActualValue = 0
+			existingRecord.ResolvedAt = now
+			existingRecord.UpdatedAt = now
+
+			if err := s.AlertRecordRepo.UpdateAlertRecord(ctx, existingRecord); err != nil {
+				s.logger.Error("更新进程消失告警记录失败", zap.Error(err))
+			} else {
+				// 发送恢复通知
+				go s.sendAlertNotification(existingRecord, agent)
+			}
+		}
+	}
+
+	state.IsFiring = false
+	state.LastRecordID = 0
+	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+		s.logger.Error("保存告警状态失败", zap.Error(err))
+	}
+}
+
+// CheckSystemdAlerts 检查 systemd 单元告警（进入 failed 状态、自动重启）
+func (s *AlertService) CheckSystemdAlerts(ctx context.Context, agentID string, units []protocol.SystemdUnitData) error {
+	// 获取全局告警配置
+	alertConfig, err := s.propertyService.GetAlertConfig(ctx)
+	if err != nil {
+		s.logger.Error("获取全局告警配置失败", zap.Error(err))
+		return err
+	}
+
+	if !alertConfig.Enabled || !alertConfig.Rules.SystemdEnabled {
+		return nil
+	}
+
+	agent, err := s.agentRepo.FindById(ctx, agentID)
+	if err != nil {
+		s.logger.Error("获取探针信息失败", zap.Error(err))
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	reported := make(map[string]bool, len(units))
+	for _, unit := range units {
+		reported[unit.Name] = true
+		s.checkSystemdFailedAlert(ctx, &agent, unit, now)
+		s.checkSystemdRestartAlert(ctx, alertConfig, &agent, unit, now)
+	}
+	s.resolveAbsentSystemdAlerts(ctx, &agent, reported, now)
+	return nil
+}
+
+// resolveAbsentSystemdAlerts 恢复本次上报中已不存在的单元的告警。
+// 仅因 failed 而上报的单元恢复后只会再上报一次，检查时很可能错过这次上报，
+// 单元被移出监控列表或停用后同样不会再上报
+func (s *AlertService) resolveAbsentSystemdAlerts(ctx context.Context, agent *models.Agent, reported map[string]bool, now int64) {
+	states, err := s.AlertStateRepo.FindFiringStates(ctx, agent.ID, "systemd", "systemd_restart")
+	if err != nil {
+		s.logger.Error("获取systemd单元告警状态失败", zap.Error(err))
+		return
+	}
+
+	for i := range states {
+		state := &states[i]
+		unitName := strings.TrimPrefix(state.ID, fmt.Sprintf("%s:global:%s:", agent.ID, state.AlertType))
+		if reported[unitName] {
+			continue
+		}
+		state.LastCheckTime = now
+		if state.AlertType == "systemd" {
+			state.StartTime = 0
+			state.Value = 0
+		}
+		s.resolveSystemdAlert(ctx, agent, state, unitName)
+	}
+}
+
+// checkSystemdFailedAlert 检查单元是否处于 failed 状态，进入 failed 立即告警
+func (s *AlertService) checkSystemdFailedAlert(ctx context.Context, agent *models.Agent, unit protocol.SystemdUnitData, now int64) {
+	stateKey := fmt.Sprintf("%s:global:systemd:%s", agent.ID, unit.Name)
+
+	// 从数据库加载状态
+	state, err := s.AlertStateRepo.GetAlertState(ctx, stateKey)
+	if err != nil {
+		// 状态不存在，创建新状态
+		state = &models.AlertState{
+			ID:        stateKey,
+			AgentID:   agent.ID,
+			AlertType: "systemd",
+		}
+	}
+
+	state.AgentID = agent.ID
+	state.AlertType = "systemd"
+	state.LastCheckTime = now
+
+	failed := unit.ActiveState == "failed"
+	var shouldFire, shouldResolve bool
+	if failed {
+		if state.StartTime == 0 {
+			state.StartTime = now
+		}
+		state.Value = 1
+		if !state.IsFiring {
+			shouldFire = true
+			state.IsFiring = true
+		}
+	} else {
+		if state.IsFiring {
+			shouldResolve = true
+		}
+		state.StartTime = 0
+		state.Value = 0
+	}
+
+	// 保存状态到数据库
+	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+		s.logger.Error("保存告警状态失败", zap.Error(err))
+	}
+
+	if shouldFire {
+		s.fireSystemdAlert(ctx, agent, state, "systemd",
+			fmt.Sprintf("systemd 单元 %s 进入 failed 状态（%s）", unit.Name, unit.SubState), 0, 1, now)
+	}
+
+	if shouldResolve {
+		s.resolveSystemdAlert(ctx, agent, state, unit.Name)
+	}
+}
+
+// checkSystemdRestartAlert 检查 service 单元的自动重启次数（NRestarts），次数增加时告警，
+// 在恢复时间内没有再次重启则恢复
+func (s *AlertService) checkSystemdRestartAlert(ctx context.Context, config *models.AlertConfig, agent *models.Agent, unit protocol.SystemdUnitData, now int64) {
+	stateKey := fmt.Sprintf("%s:global:systemd_restart:%s", agent.ID, unit.Name)
+
+	// 从数据库加载状态
+	state, err := s.AlertStateRepo.GetAlertState(ctx, stateKey)
+	if err != nil {
+		// 首次检查只记录当前重启次数作为基准
+		state = &models.AlertState{
+			ID:            stateKey,
+			AgentID:       agent.ID,
+			AlertType:     "systemd_restart",
+			Value:         float64(unit.Restarts),
+			LastCheckTime: now,
+		}
+		if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+			s.logger.Error("保存告警状态失败", zap.Error(err))
+		}
+		return
+	}
+
+	state.AgentID = agent.ID
+	state.AlertType = "systemd_restart"
+	state.Duration = config.Rules.SystemdRestartWindow
+	state.LastCheckTime = now
+
+	restarts := float64(unit.Restarts)
+	var shouldFire, shouldResolve bool
+	var increased float64
+
+	switch {
+	case restarts > state.Value:
+		increased = restarts - state.Value
+		state.Threshold = state.Value
+		state.StartTime = now
+		if !state.IsFiring {
+			shouldFire = true
+			state.IsFiring = true
+		}
+	case restarts < state.Value:
+		// 单元被重新加载或主机重启后计数清零，重新记录基准
+		state.StartTime = 0
+	}
+	state.Value = restarts
+
+	if state.IsFiring && !shouldFire && now-state.StartTime >= int64(config.Rules.SystemdRestartWindow)*1000 {
+		shouldResolve = true
+	}
+
+	// 保存状态到数据库
+	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+		s.logger.Error("保存告警状态失败", zap.Error(err))
+	}
+
+	if shouldFire {
+		s.fireSystemdAlert(ctx, agent, state, "systemd_restart",
+			fmt.Sprintf("systemd 单元 %s 自动重启了%d次（累计%d次）", unit.Name, int64(increased), unit.Restarts),
+			state.Threshold, increased, now)
+	}
+
+	if shouldResolve {
+		s.resolveSystemdAlert(ctx, agent, state, unit.Name)
+	}
+}
+
+// fireSystemdAlert 触发 systemd 单元告警
+func (s *AlertService) fireSystemdAlert(ctx context.Context, agent *models.Agent, state *models.AlertState, alertType, message string, threshold, value float64, now int64) {
+	s.logger.Info("触发systemd单元告警",
+		zap.String("agentId", agent.ID),
+		zap.String("agentName", agent.Name),
+		zap.String("alertType", alertType),
+		zap.String("message", message),
+	)
+
+	// 创建告警记录
+	record := &models.AlertRecord{
+		AgentID:     agent.ID,
+		AgentName:   agent.Name,
+		AlertType:   alertType,
+		Message:     message,
+		Threshold:   threshold,
+		ActualValue: value,
+		Level:       "critical",
+		Status:      "firing",
+		FiredAt:     now,
+		CreatedAt:   now,
+	}
+
+	if err := s.AlertRecordRepo.CreateAlertRecord(ctx, record); err != nil {
+		s.logger.Error("创建systemd单元告警记录失败", zap.Error(err))
+		return
+	}
+
+	state.LastRecordID = record.ID
+	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+		s.logger.Error("保存告警状态失败", zap.Error(err))
+	}
+
+	// 发送通知
+	go s.sendAlertNotification(record, agent)
+}
+
+// resolveSystemdAlert 恢复 systemd 单元告警
+func (s *AlertService) resolveSystemdAlert(ctx context.Context, agent *models.Agent, state *models.AlertState, unitName string) {
+	s.logger.Info("systemd单元告警恢复",
+		zap.String("agentId", agent.ID),
+		zap.String("agentName", agent.Name),
+		zap.String("alertType", state.AlertType),
+		zap.String("unit", unitName),
+	)
+
+	if state.LastRecordID > 0 {
+		existingRecord, err := s.AlertRecordRepo.GetAlertRecordByID(ctx, state.LastRecordID)
+		if err != nil {
+			s.logger.Error("获取systemd单元告警记录失败", zap.Error(err))
+		} else if existingRecord != nil && existingRecord.Status == "firing" {
+			now := time.Now().UnixMilli()
+			existingRecord.Status = "resolved"
+			existingRecord.ActualValue = 0
+			existingRecord.ResolvedAt = now
+			existingRecord.UpdatedAt = now
+
+			if err := s.AlertRecordRepo.UpdateAlertRecord(ctx, existingRecord); err != nil {
+				s.logger.Error("更新systemd单元告警记录失败", zap.Error(err))
+			} else {
+				// 发送恢复通知
+				go s.sendAlertNotification(existingRecord, agent)
+			}
+		}
+	}
+
+	state.IsFiring = false
+	state.LastRecordID = 0
+	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+		s.logger.Error("保存告警状态失败", zap.Error(err))
+	}
+}
+
+// CheckDiskHealthAlerts 检查磁盘健康告警，磁盘出现故障迹象时立即告警
+func (s *AlertService) CheckDiskHealthAlerts(ctx context.Context, agentID string, disks []protocol.DiskHealthData) error {
+	if len(disks) == 0 {
+		return nil
+	}
+
+	// 获取全局告警配置
+	alertConfig, err := s.propertyService.GetAlertConfig(ctx)
+	if err != nil {
+		s.logger.Error("获取全局告警配置失败", zap.Error(err))
+		return err
+	}
+
+	if !alertConfig.Enabled || !alertConfig.Rules.DiskHealthEnabled {
+		return nil
+	}
+
+	agent, err := s.agentRepo.FindById(ctx, agentID)
+	if err != nil {
+		s.logger.Error("获取探针信息失败", zap.Error(err))
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	for _, disk := range disks {
+		s.checkDiskHealthAlert(ctx, &agent, disk, now)
+	}
+	return nil
+}
+
+// diskHealthProblems 返回磁盘的故障迹象，没有问题时返回空
+func diskHealthProblems(disk protocol.DiskHealthData) []string {
+	var problems []string
+	if !disk.Passed {
+		problems = append(problems, "SMART 健康评估未通过")
+	}
+	if disk.CriticalWarning != 0 {
+		problems = append(problems, fmt.Sprintf("NVMe 严重警告 0x%02x", disk.CriticalWarning))
+	}
+	if disk.PendingSectors > 0 {
+		problems = append(problems, fmt.Sprintf("%d 个待映射扇区", disk.PendingSectors))
+	}
+	if disk.UncorrectableSectors > 0 {
+		problems = append(problems, fmt.Sprintf("%d 个不可修复扇区", disk.UncorrectableSectors))
+	}
+	if disk.AvailableSpareThresh > 0 && disk.AvailableSpare < disk.AvailableSpareThresh {
+		problems = append(problems, fmt.Sprintf("可用备用空间 %.0f%% 低于阈值 %.0f%%", disk.AvailableSpare, disk.AvailableSpareThresh))
+	}
+	if disk.PercentageUsed >= 100 {
+		problems = append(problems, fmt.Sprintf("寿命已使用 %.0f%%", disk.PercentageUsed))
+	}
+	return problems
+}
+
+// checkDiskHealthAlert 检查单块磁盘的健康状态
+func (s *AlertService) checkDiskHealthAlert(ctx context.Context, agent *models.Agent, disk protocol.DiskHealthData, now int64) {
+	stateKey := fmt.Sprintf("%s:global:disk_health:%s", agent.ID, disk.Device)
+
+	// 从数据库加载状态
+	state, err := s.AlertStateRepo.GetAlertState(ctx, stateKey)
+	if err != nil {
+		// 状态不存在，创建新状态
+		state = &models.AlertState{
+			ID:        stateKey,
+			AgentID:   agent.ID,
+			AlertType: "disk_health",
+		}
+	}
+
+	state.AgentID = agent.ID
+	state.AlertType = "disk_health"
+	state.LastCheckTime = now
+
+	problems := diskHealthProblems(disk)
+	var shouldFire, shouldResolve bool
+	if len(problems) > 0 {
+		if state.StartTime == 0 {
+			state.StartTime = now
+		}
+		state.Value = float64(len(problems))
+		if !state.IsFiring {
+			shouldFire = true
+			state.IsFiring = true
+		}
+	} else {
+		if state.IsFiring {
+			shouldResolve = true
+		}
+		state.StartTime = 0
+		state.Value = 0
+	}
+
+	// 保存状态到数据库
+	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+		s.logger.Error("保存告警状态失败", zap.Error(err))
+	}
+
+	if shouldFire {
+		name := disk.Device
+		if disk.Model != "" {
+			name = fmt.Sprintf("%s（%s）", disk.Device, disk.Model)
+		}
+		s.fireDiskHealthAlert(ctx, agent, state,
+			fmt.Sprintf("磁盘 %s 存在故障迹象：%s", name, strings.Join(problems, "，")), now)
+	}
+
+	if shouldResolve {
+		s.resolveDiskHealthAlert(ctx, agent, state, disk.Device)
+	}
+}
+
+// fireDiskHealthAlert 触发磁盘健康告警
+func (s *AlertService) fireDiskHealthAlert(ctx context.Context, agent *models.Agent, state *models.AlertState, message string, now int64) {
+	s.logger.Info("触发磁盘健康告警",
+		zap.String("agentId", agent.ID),
+		zap.String("agentName", agent.Name),
+		zap.String("message", message),
+	)
+
+	// 创建告警记录
+	record := &models.AlertRecord{
+		AgentID:     agent.ID,
+		AgentName:   agent.Name,
+		AlertType:   "disk_health",
+		Message:     message,
+		Threshold:   0,
+		ActualValue: state.Value,
+		Level:       "critical",
+		Status:      "firing",
+		FiredAt:     now,
+		CreatedAt:   now,
+	}
+
+	if err := s.AlertRecordRepo.CreateAlertRecord(ctx, record); err != nil {
+		s.logger.Error("创建磁盘健康告警记录失败", zap.Error(err))
+		return
+	}
+
+	state.LastRecordID = record.ID
+	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+		s.logger.Error("保存告警状态失败", zap.Error(err))
+	}
+
+	// 发送通知
+	go s.sendAlertNotification(record, agent)
+}
+
+// resolveDiskHealthAlert 恢复磁盘健康告警
+func (s *AlertService) resolveDiskHealthAlert(ctx context.Context, agent *models.Agent, state *models.AlertState, device string) {
+	s.logger.Info("磁盘健康告警恢复",
+		zap.String("agentId", agent.ID),
+		zap.String("agentName", agent.Name),
+		zap.String("device", device),
+	)
+
+	if state.LastRecordID > 0 {
+		existingRecord, err := s.AlertRecordRepo.GetAlertRecordByID(ctx, state.LastRecordID)
+		if err != nil {
+			s.logger.Error("获取磁盘健康告警记录失败", zap.Error(err))
+		} else if existingRecord != nil && existingRecord.Status == "firing" {
+			now := time.Now().UnixMilli()
+			existingRecord.Status = "resolved"
+			existingRecord.ActualValue = 0
+			existingRecord.ResolvedAt = now
+			existingRecord.UpdatedAt = now
+
+			if err := s.AlertRecordRepo.UpdateAlertRecord(ctx, existingRecord); err != nil {
+				s.logger.Error("更新磁盘健康告警记录失败", zap.Error(err))
+			} else {
+				// 发送恢复通知
+				go s.sendAlertNotification(existingRecord, agent)
+			}
+		}
+	}
+
+	state.IsFiring = false
+	state.LastRecordID = 0
+	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+		s.logger.Error("保存告警状态失败", zap.Error(err))
+	}
+}
+
+// logAlertStateKey 日志告警状态ID
+func logAlertStateKey(agentID, ruleID string) string {
+	return fmt.Sprintf("%s:global:log:%s", agentID, ruleID)
+}
+
+// HandleLogMatches 处理日志规则匹配到的日志行，未在告警中时立即告警
+// 告警状态的 LastCheckTime 记录最近一次匹配的时间，由 CheckLogAlerts 判断是否恢复
+func (s *AlertService) HandleLogMatches(ctx context.Context, agentID string, rule *models.LogRule, count int, line string) error {
+	// 获取全局告警配置
+	alertConfig, err := s.propertyService.GetAlertConfig(ctx)
+	if err != nil {
+		s.logger.Error("获取全局告警配置失败", zap.Error(err))
+		return err
+	}
+
+	if !alertConfig.Enabled || !alertConfig.Rules.LogEnabled {
+		return nil
+	}
+
+	agent, err := s.agentRepo.FindById(ctx, agentID)
+	if err != nil {
+		s.logger.Error("获取探针信息失败", zap.Error(err))
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	stateKey := logAlertStateKey(agentID, rule.ID)
+
+	// 从数据库加载状态
+	state, err := s.AlertStateRepo.GetAlertState(ctx, stateKey)
+	if err != nil {
+		// 状态不存在，创建新状态
+		state = &models.AlertState{
+			ID:        stateKey,
+			AgentID:   agentID,
+			AlertType: "log",
+		}
+	}
+
+	state.AgentID = agentID
+	state.AlertType = "log"
+	state.Duration = rule.AlertWindow
+	state.LastCheckTime = now
+
+	shouldFire := false
+	if !state.IsFiring {
+		shouldFire = true
+		state.IsFiring = true
+		state.StartTime = now
+		state.Value = 0
+	}
+	state.Value += float64(count)
+
+	// 保存状态到数据库
+	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+		s.logger.Error("保存告警状态失败", zap.Error(err))
+	}
+
+	if shouldFire {
+		s.fireLogAlert(ctx, &agent, state, fmt.Sprintf("日志规则「%s」匹配到日志：%s", rule.Name, line), now)
+	}
+	return nil
+}
+
+// CheckLogAlerts 检查日志告警是否恢复，超过规则的恢复时间没有新的匹配时恢复
+func (s *AlertService) CheckLogAlerts(ctx context.Context, agentID string) error {
+	rules, err := s.logRuleRepo.ListByAgentID(ctx, agentID)
+	if err != nil {
+		s.logger.Error("获取日志规则失败", zap.Error(err))
+		return err
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	alertConfig, err := s.propertyService.GetAlertConfig(ctx)
+	if err != nil {
+		s.logger.Error("获取全局告警配置失败", zap.Error(err))
+		return err
+	}
+	alertsEnabled := alertConfig.Enabled && alertConfig.Rules.LogEnabled
+
+	var agent *models.Agent
+	now := time.Now().UnixMilli()
+	for _, rule := range rules {
+		state, err := s.AlertStateRepo.GetAlertState(ctx, logAlertStateKey(agentID, rule.ID))
+		if err != nil || !state.IsFiring {
+			continue
+		}
+
+		// 关闭告警或规则后直接恢复
+		active := alertsEnabled && rule.Enabled && rule.AlertEnabled
+		if active && now-state.LastCheckTime < int64(rule.AlertWindow)*1000 {
+			continue
+		}
+
+		if agent == nil {
+			found, err := s.agentRepo.FindById(ctx, agentID)
+			if err != nil {
+				s.logger.Error("获取探针信息失败", zap.Error(err))
+				return err
+			}
+			agent = &found
+		}
+		s.resolveLogAlert(ctx, agent, state, rule.Name)
+	}
+	return nil
+}
+
+// ResolveLogAlert 恢复日志规则的告警，用于删除规则时
+func (s *AlertService) ResolveLogAlert(ctx context.Context, agentID string, rule *models.LogRule) error {
+	state, err := s.AlertStateRepo.GetAlertState(ctx, logAlertStateKey(agentID, rule.ID))
+	if err != nil || !state.IsFiring {
+		return nil
+	}
+
+	agent, err := s.agentRepo.FindById(ctx, agentID)
+	if err != nil {
+		s.logger.Error("获取探针信息失败", zap.Error(err))
+		return err
+	}
+	s.resolveLogAlert(ctx, &agent, state, rule.Name)
+	return s.AlertStateRepo.DeleteAlertState(ctx, state.ID)
+}
+
+// fireLogAlert 触发日志关键字告警
+func (s *AlertService) fireLogAlert(ctx context.Context, agent *models.Agent, state *models.AlertState, message string, now int64) {
+	s.logger.Info("触发日志关键字告警",
+		zap.String("agentId", agent.ID),
+		zap.String("agentName", agent.Name),
+		zap.String("message", message),
+	)
+
+	// 创建告警记录
+	record := &models.AlertRecord{
+		AgentID:     agent.ID,
+		AgentName:   agent.Name,
+		AlertType:   "log",
+		Message:     message,
+		Threshold:   0,
+		ActualValue: state.Value,
+		Level:       "warning",
+		Status:      "firing",
+		FiredAt:     now,
+		CreatedAt:   now,
+	}
+
+	if err := s.AlertRecordRepo.CreateAlertRecord(ctx, record); err != nil {
+		s.logger.Error("创建日志关键字告警记录失败", zap.Error(err))
+		return
+	}
+
+	state.LastRecordID = record.ID
+	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+		s.logger.Error("保存告警状态失败", zap.Error(err))
+	}
+
+	// 发送通知
+	go s.sendAlertNotification(record, agent)
+}
+
+// resolveLogAlert 恢复日志关键字告警，告警记录的实际值为告警期间匹配到的行数
+func (s *AlertService) resolveLogAlert(ctx context.Context, agent *models.Agent, state *models.AlertState, ruleName string) {
+	s.logger.Info("日志关键字告警恢复",
+		zap.String("agentId", agent.ID),
+		zap.String("agentName", agent.Name),
+		zap.String("rule", ruleName),
+	)
+
+	if state.LastRecordID > 0 {
+		existingRecord, err := s.AlertRecordRepo.GetAlertRecordByID(ctx, state.LastRecordID)
+		if err != nil {
+			s.logger.Error("获取日志关键字告警记录失败", zap.Error(err))
+		} else if existingRecord != nil && existingRecord.Status == "firing" {
+			now := time.Now().UnixMilli()
+			existingRecord.Status = "resolved"
+			existingRecord.ActualValue = state.Value
+			existingRecord.ResolvedAt = now
+			existingRecord.UpdatedAt = now
+
+			if err := s.AlertRecordRepo.UpdateAlertRecord(ctx, existingRecord); err != nil {
+				s.logger.Error("更新日志关键字告警记录失败", zap.Error(err))
+			} else {
+				// 发送恢复通知
+				go s.sendAlertNotification(existingRecord, agent)
+			}
+		}
+	}
+
+	state.IsFiring = false
+	state.LastRecordID = 0
+	state.StartTime = 0
+	state.Value = 0
+	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+		s.logger.Error("保存告警状态失败", zap.Error(err))
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestAlertService 创建使用临时数据库的告警服务，启用 systemd 单元告警
+func newTestAlertService(t *testing.T) (*AlertService, <-chan *models.AlertRecord) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "alert.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.Agent{}, &models.Property{}, &models.AlertRecord{}, &models.AlertState{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	propertyService := NewPropertyService(zap.NewNop(), db)
+	alertConfig := models.AlertConfig{Enabled: true}
+	alertConfig.Rules.SystemdEnabled = true
+	if err := propertyService.SetAlertConfig(ctx, alertConfig); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.Agent{ID: "a1", Name: "agent"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewAlertService(zap.NewNop(), db, propertyService, nil, NewNotifier(zap.NewNop()))
+	records := make(chan *models.AlertRecord, 10)
+	s.SetRecordHandler(func(record *models.AlertRecord) {
+		records <- record
+	})
+	return s, records
+}
+
+// waitRecord 等待异步发送的告警通知
+func waitRecord(t *testing.T, records <-chan *models.AlertRecord, status string) {
+	t.Helper()
+	select {
+	case record := <-records:
+		if record.Status != status {
+			t.Fatalf("告警状态为 %s，应为 %s", record.Status, status)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("没有收到 %s 通知", status)
+	}
+}
+
+func TestSystemdAlertResolvedWhenUnitAbsent(t *testing.T) {
+	ctx := context.Background()
+	s, records := newTestAlertService(t)
+
+	units := []protocol.SystemdUnitData{
+		{Name: "nginx.service", ActiveState: "failed", SubState: "failed"},
+		{Name: "redis.service", ActiveState: "failed", SubState: "failed"},
+	}
+	if err := s.CheckSystemdAlerts(ctx, "a1", units); err != nil {
+		t.Fatal(err)
+	}
+	waitRecord(t, records, "firing")
+	waitRecord(t, records, "firing")
+
+	// nginx 恢复后不再上报，redis 仍处于 failed
+	if err := s.CheckSystemdAlerts(ctx, "a1", units[1:]); err != nil {
+		t.Fatal(err)
+	}
+	waitRecord(t, records, "resolved")
+	state, err := s.AlertStateRepo.GetAlertState(ctx, "a1:global:systemd:nginx.service")
+	if err != nil || state.IsFiring || state.LastRecordID != 0 {
+		t.Fatalf("不再上报的单元应恢复告警: %+v %v", state, err)
+	}
+	if state, _ := s.AlertStateRepo.GetAlertState(ctx, "a1:global:systemd:redis.service"); state == nil || !state.IsFiring {
+		t.Fatalf("仍处于 failed 的单元应保持告警: %+v", state)
+	}
+
+	// 上报为空时恢复所有单元的告警
+	if err := s.CheckSystemdAlerts(ctx, "a1", []protocol.SystemdUnitData{}); err != nil {
+		t.Fatal(err)
+	}
+	waitRecord(t, records, "resolved")
+	firing, err := s.AlertStateRepo.FindFiringStates(ctx, "a1", "systemd", "systemd_restart")
+	if err != nil || len(firing) != 0 {
+		t.Errorf("上报为空后不应有正在告警的状态: %+v %v", firing, err)
+	}
+
+	var count int64
+	if err := s.AlertRecordRepo.GetDB(ctx).Model(&models.AlertRecord{}).Where("status = ?", "resolved").Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("已恢复的告警记录为 %d 条，应为 2", count)
+	}
+}
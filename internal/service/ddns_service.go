@@ -2,7 +2,6 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -30,8 +29,11 @@ type DDNSService struct {
 	ConfigRepo      *repo.DDNSConfigRepo // 导出用于 handler 的 PageBuilder
 	recordRepo      *repo.DDNSRecordRepo
 	propertyService *PropertyService
+	agentService    *AgentService
 	wsManager       *websocket.Manager
 	ipCache         *syncx.SafeMap[string, *ipCacheData] // 使用内存缓存存储 IP
+
+	agentConfigService *AgentConfigService // 跟踪配置下发结果
 }
 
 func NewDDNSService(
@@ -39,17 +41,25 @@ func NewDDNSService(
 	configRepo *repo.DDNSConfigRepo,
 	recordRepo *repo.DDNSRecordRepo,
 	propertyService *PropertyService,
+	agentService *AgentService,
 	wsManager *websocket.Manager,
+	agentConfigService *AgentConfigService,
 ) *DDNSService {
 	s := &DDNSService{
 		logger:          logger,
 		ConfigRepo:      configRepo,
 		recordRepo:      recordRepo,
 		propertyService: propertyService,
+		agentService:    agentService,
 		wsManager:       wsManager,
 		ipCache:         syncx.NewSafeMap[string, *ipCacheData](),
+
+		agentConfigService: agentConfigService,
 	}
 
+	// 探针重连后重新下发未应用的 DDNS 配置
+	agentConfigService.RegisterKind(ConfigKindDDNS, protocol.CapabilityDDNS, false, s.buildConfigMessage)
+
 	// 初始化 IP 缓存：从 DNS 服务商查询当前记录
 	go s.initIPCache()
 
@@ -353,6 +363,9 @@ func (s *DDNSService) DeleteConfig(ctx context.Context, id string) error {
 	if err := s.recordRepo.DeleteByConfigID(ctx, id); err != nil {
 		return err
 	}
+	if err := s.agentConfigService.Forget(ctx, ConfigKindDDNS, id); err != nil {
+		return err
+	}
 	// 删除配置
 	return s.ConfigRepo.DeleteById(ctx, id)
 }
@@ -387,34 +400,80 @@ func (s *DDNSService) checkDDNS() {
 		return
 	}
 
+	// 每个节点只下发给连接在本节点的探针，避免多节点重复下发
+	var agentIDs []string
+	for _, config := range configs {
+		if _, ok := s.wsManager.GetClient(config.AgentID); ok {
+			agentIDs = append(agentIDs, config.AgentID)
+		}
+	}
+	if len(agentIDs) == 0 {
+		return
+	}
+	agents, err := s.agentService.AgentRepo.ListByIDs(ctx, agentIDs)
+	if err != nil {
+		s.logger.Error("查询 DDNS 配置对应的探针失败", zap.Error(err))
+		return
+	}
+	agentMap := make(map[string]*models.Agent, len(agents))
+	for i := range agents {
+		agentMap[agents[i].ID] = &agents[i]
+	}
+
 	// 并发向每个配置对应的在线探针发送 DDNS 配置
 	for _, config := range configs {
-		agentID := config.AgentID
-		go func(id string) {
-			if err := s.sendDDNSConfigToAgent(&config); err != nil {
+		agent, ok := agentMap[config.AgentID]
+		if !ok {
+			continue
+		}
+		// 不支持 DDNS 的探针会忽略配置，不再下发
+		if !agent.HasCapability(protocol.CapabilityDDNS) {
+			s.logger.Debug("探针不支持 DDNS，跳过下发", zap.String("agentID", agent.ID))
+			continue
+		}
+		go func(config models.DDNSConfig) {
+			if err := s.sendDDNSConfigToAgent(agent, &config); err != nil {
 				s.logger.Debug("发送 DDNS 配置失败",
-					zap.String("agentID", id),
+					zap.String("agentID", config.AgentID),
 					zap.Error(err))
 			}
-		}(agentID)
+		}(config)
 	}
 }
 
-// sendDDNSConfigToAgent 向指定探针发送 DDNS 配置
-func (s *DDNSService) sendDDNSConfigToAgent(config *models.DDNSConfig) error {
+// sendDDNSConfigToAgent 向指定探针发送 DDNS 配置，配置没有变化时不再记录下发状态
+func (s *DDNSService) sendDDNSConfigToAgent(agent *models.Agent, config *models.DDNSConfig) error {
 	// 获取探针的 DDNS 配置
 	configData, err := s.GetDDNSConfig(config)
 	if err != nil {
 		return err
 	}
 
-	msgData, err := json.Marshal(protocol.OutboundMessage{
+	return s.agentConfigService.Refresh(context.Background(), agent, ConfigKindDDNS, config.ID, ConfigMessage{
 		Type: protocol.MessageTypeDDNSConfig,
 		Data: configData,
 	})
+}
+
+// buildConfigMessage 生成探针重连后重新下发的 DDNS 配置，配置已删除或禁用时不再下发
+func (s *DDNSService) buildConfigMessage(ctx context.Context, agent *models.Agent, ref string) (*ConfigMessage, error) {
+	config, err := s.ConfigRepo.FindById(ctx, ref)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if !config.Enabled || config.AgentID != agent.ID {
+		return nil, nil
 	}
 
-	return s.wsManager.SendToClient(config.AgentID, msgData)
+	configData, err := s.GetDDNSConfig(&config)
+	if err != nil {
+		return nil, err
+	}
+	return &ConfigMessage{
+		Type: protocol.MessageTypeDDNSConfig,
+		Data: configData,
+	}, nil
 }
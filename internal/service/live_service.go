@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/pika/internal/cluster"
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/repo"
+	"github.com/dushixiang/pika/internal/websocket"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 推送给浏览器的实时事件类型
+const (
+	LiveEventMetrics  = "metrics"  // 探针最新指标，数据为 metric.LatestMetrics
+	LiveEventPresence = "presence" // 探针上线/离线，数据为 LivePresence
+	LiveEventAlert    = "alert"    // 告警触发/恢复，数据为 models.AlertRecord，只推送给已登录用户
+)
+
+// LiveEventTypes 支持订阅的实时事件类型
+var LiveEventTypes = []string{LiveEventMetrics, LiveEventPresence, LiveEventAlert}
+
+const (
+	// 合并同一探针在该时间内的多次指标更新
+	liveFlushInterval = time.Second
+	// 公开探针列表的缓存时间
+	livePublicTTL = 10 * time.Second
+	// 每个订阅缓存的事件数，浏览器处理不过来时丢弃新事件
+	liveBufferSize = 64
+)
+
+// LiveEvent 推送给浏览器的实时事件
+type LiveEvent struct {
+	Type    string          `json:"type"`
+	AgentID string          `json:"agentId"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// LivePresence 探针上线/离线事件数据
+type LivePresence struct {
+	Online bool  `json:"online"`
+	At     int64 `json:"at"` // 事件时间（时间戳毫秒）
+}
+
+// LiveFilter 订阅条件，AgentIDs 和 Types 为空时不过滤
+type LiveFilter struct {
+	AgentIDs      []string
+	Types         []string
+	Authenticated bool // 未登录时只推送公开探针的指标和上线/离线事件
+}
+
+// LiveSubscription 浏览器的实时事件订阅
+type LiveSubscription struct {
+	events chan LiveEvent
+	filter LiveFilter
+}
+
+// Events 返回事件通道，取消订阅后关闭
+func (sub *LiveSubscription) Events() <-chan LiveEvent {
+	return sub.events
+}
+
+func (sub *LiveSubscription) watches(agentID string) bool {
+	return len(sub.filter.AgentIDs) == 0 || slices.Contains(sub.filter.AgentIDs, agentID)
+}
+
+func (sub *LiveSubscription) match(eventType, agentID string) bool {
+	if len(sub.filter.Types) > 0 && !slices.Contains(sub.filter.Types, eventType) {
+		return false
+	}
+	if eventType == LiveEventAlert && !sub.filter.Authenticated {
+		return false
+	}
+	return sub.watches(agentID)
+}
+
+// LiveService 向浏览器推送探针最新指标、上线/离线和告警事件
+type LiveService struct {
+	logger        *zap.Logger
+	agentRepo     *repo.AgentRepo
+	metricService *MetricService
+	wsManager     *websocket.Manager
+	cluster       cluster.Backend
+
+	mu   sync.RWMutex
+	subs map[*LiveSubscription]struct{}
+
+	dirtyMu sync.Mutex
+	dirty   map[string]struct{} // 最新指标有变化、尚未推送的探针
+
+	publicMu sync.Mutex
+	public   map[string]struct{} // 公开可见的探针
+	publicAt time.Time
+
+	online map[string]struct{} // 多节点部署时上次同步的在线探针，只在 Run 协程中使用
+}
+
+func NewLiveService(logger *zap.Logger, db *gorm.DB, metricService *MetricService, agentService *AgentService, alertService *AlertService, wsManager *websocket.Manager, backend cluster.Backend) *LiveService {
+	s := &LiveService{
+		logger:        logger,
+		agentRepo:     repo.NewAgentRepo(db),
+		metricService: metricService,
+		wsManager:     wsManager,
+		cluster:       backend,
+		subs:          make(map[*LiveSubscription]struct{}),
+		dirty:         make(map[string]struct{}),
+	}
+
+	metricService.SetLatestHandler(s.markMetrics)
+	agentService.SetPresenceHandler(s.handlePresence)
+	alertService.SetRecordHandler(s.handleAlertRecord)
+
+	return s
+}
+
+// Subscribe 订阅实时事件，订阅了指定探针时立即推送这些探针当前的最新指标
+func (s *LiveService) Subscribe(ctx context.Context, filter LiveFilter) *LiveSubscription {
+	sub := &LiveSubscription{
+		events: make(chan LiveEvent, liveBufferSize),
+		filter: filter,
+	}
+
+	s.mu.Lock()
+	s.subs[sub] = struct{}{}
+	s.mu.Unlock()
+
+	if len(filter.AgentIDs) == 0 || (len(filter.Types) > 0 && !slices.Contains(filter.Types, LiveEventMetrics)) {
+		// 订阅全部探针时由浏览器自行查询初始数据
+		return sub
+	}
+	for _, agentID := range filter.AgentIDs {
+		if !filter.Authenticated && !s.isPublic(ctx, agentID) {
+			continue
+		}
+		latestMetrics, ok := s.metricService.GetLatestMetrics(agentID)
+		if !ok {
+			continue
+		}
+		event, ok := s.newEvent(LiveEventMetrics, agentID, latestMetrics)
+		if !ok {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+		}
+	}
+	return sub
+}
+
+// Unsubscribe 取消订阅并关闭事件通道
+func (s *LiveService) Unsubscribe(sub *LiveSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subs[sub]; !ok {
+		return
+	}
+	delete(s.subs, sub)
+	close(sub.events)
+}
+
+// Run 定时推送有变化的最新指标，多节点部署时同步其他节点探针的状态
+func (s *LiveService) Run(ctx context.Context) {
+	ticker := time.NewTicker(liveFlushInterval)
+	defer ticker.Stop()
+
+	var remoteC <-chan time.Time
+	if s.cluster.Distributed() {
+		remoteTicker := time.NewTicker(latestSyncInterval)
+		defer remoteTicker.Stop()
+		remoteC = remoteTicker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.flushMetrics(ctx)
+		case <-remoteC:
+			s.syncRemote(ctx)
+		}
+	}
+}
+
+func (s *LiveService) hasSubscribers() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.subs) > 0
+}
+
+// markMetrics 记录最新指标有变化的探针，由 Run 合并后推送
+func (s *LiveService) markMetrics(agentID string) {
+	if !s.hasSubscribers() {
+		return
+	}
+	s.dirtyMu.Lock()
+	s.dirty[agentID] = struct{}{}
+	s.dirtyMu.Unlock()
+}
+
+func (s *LiveService) flushMetrics(ctx context.Context) {
+	s.dirtyMu.Lock()
+	dirty := s.dirty
+	s.dirty = make(map[string]struct{}, len(dirty))
+	s.dirtyMu.Unlock()
+
+	for agentID := range dirty {
+		latestMetrics, ok := s.metricService.GetLatestMetrics(agentID)
+		if !ok {
+			continue
+		}
+		s.publish(ctx, LiveEventMetrics, agentID, latestMetrics)
+	}
+}
+
+// syncRemote 推送连接在其他节点的探针的上线/离线事件和最新指标
+// 本节点探针的事件在发生时直接推送
+func (s *LiveService) syncRemote(ctx context.Context) {
+	onlineIDs, err := s.wsManager.OnlineAgents(ctx)
+	if err != nil {
+		s.logger.Warn("获取在线探针失败", zap.Error(err))
+		return
+	}
+	current := make(map[string]struct{}, len(onlineIDs))
+	for _, agentID := range onlineIDs {
+		current[agentID] = struct{}{}
+	}
+
+	previous := s.online
+	s.online = current
+	if previous == nil || !s.hasSubscribers() {
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	for agentID := range current {
+		if _, ok := previous[agentID]; !ok && !s.isLocal(agentID) {
+			s.publish(ctx, LiveEventPresence, agentID, LivePresence{Online: true, At: now})
+		}
+	}
+	for agentID := range previous {
+		if _, ok := current[agentID]; !ok && !s.isLocal(agentID) {
+			s.publish(ctx, LiveEventPresence, agentID, LivePresence{Online: false, At: now})
+		}
+	}
+
+	for agentID := range current {
+		if s.isLocal(agentID) || !s.watched(agentID) {
+			continue
+		}
+		latestMetrics, ok := s.metricService.GetLatestMetrics(agentID)
+		if !ok {
+			continue
+		}
+		s.publish(ctx, LiveEventMetrics, agentID, latestMetrics)
+	}
+}
+
+func (s *LiveService) isLocal(agentID string) bool {
+	_, ok := s.wsManager.GetClient(agentID)
+	return ok
+}
+
+// watched 判断是否有订阅关注该探针的指标
+func (s *LiveService) watched(agentID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for sub := range s.subs {
+		if sub.match(LiveEventMetrics, agentID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *LiveService) handlePresence(ctx context.Context, event websocket.PresenceEvent) {
+	s.publish(ctx, LiveEventPresence, event.AgentID, LivePresence{Online: event.Online, At: event.At})
+}
+
+func (s *LiveService) handleAlertRecord(record *models.AlertRecord) {
+	s.publish(context.Background(), LiveEventAlert, record.AgentID, record)
+}
+
+func (s *LiveService) newEvent(eventType, agentID string, data interface{}) (LiveEvent, bool) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		s.logger.Warn("序列化实时事件失败", zap.String("type", eventType), zap.String("agentID", agentID), zap.Error(err))
+		return LiveEvent{}, false
+	}
+	return LiveEvent{Type: eventType, AgentID: agentID, Data: payload}, true
+}
+
+// publish 将事件推送给匹配的订阅，事件数据只序列化一次
+func (s *LiveService) publish(ctx context.Context, eventType, agentID string, data interface{}) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var (
+		event    LiveEvent
+		prepared bool
+		public   *bool
+	)
+	for sub := range s.subs {
+		if !sub.match(eventType, agentID) {
+			continue
+		}
+		if !sub.filter.Authenticated {
+			if public == nil {
+				visible := s.isPublic(ctx, agentID)
+				public = &visible
+			}
+			if !*public {
+				continue
+			}
+		}
+		if !prepared {
+			var ok bool
+			if event, ok = s.newEvent(eventType, agentID, data); !ok {
+				return
+			}
+			prepared = true
+		}
+		select {
+		case sub.events <- event:
+		default:
+			s.logger.Debug("实时事件订阅缓冲区已满，丢弃事件", zap.String("type", eventType), zap.String("agentID", agentID))
+		}
+	}
+}
+
+// isPublic 判断探针是否公开可见，公开探针列表缓存 livePublicTTL
+func (s *LiveService) isPublic(ctx context.Context, agentID string) bool {
+	s.publicMu.Lock()
+	defer s.publicMu.Unlock()
+
+	if s.public == nil || time.Since(s.publicAt) > livePublicTTL {
+		agents, err := s.agentRepo.FindPublicAgents(ctx)
+		if err != nil {
+			s.logger.Warn("获取公开探针失败", zap.Error(err))
+		} else {
+			public := make(map[string]struct{}, len(agents))
+			for _, agent := range agents {
+				public[agent.ID] = struct{}{}
+			}
+			s.public = public
+			s.publicAt = time.Now()
+		}
+	}
+	_, ok := s.public[agentID]
+	return ok
+}
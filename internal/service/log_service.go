@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/internal/repo"
+	"github.com/go-orz/orz"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// 日志规则每分钟上报行数的默认值和上限，避免日志刷屏占满 WebSocket 连接
+	logDefaultMaxEventsPerMinute = 10
+	logMaxEventsPerMinute        = 120
+	// 日志告警默认恢复时间（秒）
+	logDefaultAlertWindow = 300
+	// 保存的单行日志最大长度（字节）
+	logMaxLineLength = 1024
+	// 日志事件保留天数
+	logEventRetentionDays = 7
+)
+
+type LogService struct {
+	*orz.Service
+	logger             *zap.Logger
+	LogRuleRepo        *repo.LogRuleRepo  // 导出用于 handler 的 PageBuilder
+	LogEventRepo       *repo.LogEventRepo // 导出用于 handler 的 PageBuilder
+	alertService       *AlertService
+	agentConfigService *AgentConfigService
+}
+
+func NewLogService(logger *zap.Logger, db *gorm.DB, alertService *AlertService, agentConfigService *AgentConfigService) *LogService {
+	s := &LogService{
+		Service:            orz.NewService(db),
+		logger:             logger,
+		LogRuleRepo:        repo.NewLogRuleRepo(db),
+		LogEventRepo:       repo.NewLogEventRepo(db),
+		alertService:       alertService,
+		agentConfigService: agentConfigService,
+	}
+	// 探针重启后不保留规则，每次连接都下发完整配置
+	agentConfigService.RegisterKind(ConfigKindLog, protocol.CapabilityLogTail, true, s.buildConfigMessage)
+	return s
+}
+
+// Run 定时清理过期的日志事件，阻塞直到 ctx 取消
+func (s *LogService) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		threshold := time.Now().AddDate(0, 0, -logEventRetentionDays).UnixMilli()
+		if err := s.LogEventRepo.DeleteBefore(ctx, threshold); err != nil {
+			s.logger.Error("清理日志事件失败", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ValidateRule 校验日志规则并填充默认值
+func (s *LogService) ValidateRule(rule *models.LogRule) error {
+	if len(rule.Paths) == 0 && !rule.Journald {
+		return orz.NewError(400, "日志文件路径和 journald 至少需要配置一项")
+	}
+	if rule.Pattern == "" {
+		return orz.NewError(400, "匹配规则不能为空")
+	}
+	if _, err := regexp.Compile(rule.Pattern); err != nil {
+		return orz.NewError(400, fmt.Sprintf("匹配规则不是有效的正则表达式: %v", err))
+	}
+
+	if rule.MaxEventsPerMinute <= 0 {
+		rule.MaxEventsPerMinute = logDefaultMaxEventsPerMinute
+	}
+	if rule.MaxEventsPerMinute > logMaxEventsPerMinute {
+		return orz.NewError(400, fmt.Sprintf("每分钟上报行数不能超过 %d", logMaxEventsPerMinute))
+	}
+	if rule.AlertWindow <= 0 {
+		rule.AlertWindow = logDefaultAlertWindow
+	}
+	return nil
+}
+
+// CreateRule 创建日志规则
+func (s *LogService) CreateRule(ctx context.Context, rule *models.LogRule) error {
+	if err := s.ValidateRule(rule); err != nil {
+		return err
+	}
+	if err := s.LogRuleRepo.Create(ctx, rule); err != nil {
+		return err
+	}
+	s.notifyAgent(ctx, rule.AgentID)
+	return nil
+}
+
+// GetRule 获取日志规则
+func (s *LogService) GetRule(ctx context.Context, id string) (*models.LogRule, error) {
+	rule, err := s.LogRuleRepo.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, orz.NewError(404, "日志规则不存在")
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ListRules 列出探针的所有日志规则
+func (s *LogService) ListRules(ctx context.Context, agentID string) ([]models.LogRule, error) {
+	return s.LogRuleRepo.ListByAgentID(ctx, agentID)
+}
+
+// UpdateRule 更新日志规则
+func (s *LogService) UpdateRule(ctx context.Context, rule *models.LogRule) error {
+	if err := s.ValidateRule(rule); err != nil {
+		return err
+	}
+	if err := s.LogRuleRepo.Save(ctx, rule); err != nil {
+		return err
+	}
+	s.notifyAgent(ctx, rule.AgentID)
+	return nil
+}
+
+// DeleteRule 删除日志规则及其事件，正在告警时同时恢复
+func (s *LogService) DeleteRule(ctx context.Context, id string) error {
+	rule, err := s.GetRule(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.LogEventRepo.DeleteByRuleID(ctx, id); err != nil {
+			return err
+		}
+		return s.LogRuleRepo.DeleteById(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.alertService.ResolveLogAlert(ctx, rule.AgentID, rule); err != nil {
+		s.logger.Warn("恢复日志告警失败", zap.String("ruleId", id), zap.Error(err))
+	}
+	s.notifyAgent(ctx, rule.AgentID)
+	return nil
+}
+
+// GetLogConfig 获取下发给探针的日志监控配置
+func (s *LogService) GetLogConfig(ctx context.Context, agentID string) (*protocol.LogConfigData, error) {
+	rules, err := s.LogRuleRepo.ListEnabledByAgentID(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &protocol.LogConfigData{
+		Rules: make([]protocol.LogRuleData, 0, len(rules)),
+	}
+	for _, rule := range rules {
+		config.Rules = append(config.Rules, protocol.LogRuleData{
+			ID:                 rule.ID,
+			Name:               rule.Name,
+			Paths:              rule.Paths,
+			Journald:           rule.Journald,
+			JournalUnits:       rule.JournalUnits,
+			Pattern:            rule.Pattern,
+			Ship:               rule.ShipLines || rule.AlertEnabled,
+			MaxEventsPerMinute: rule.MaxEventsPerMinute,
+		})
+	}
+	return config, nil
+}
+
+// notifyAgent 规则变更后向在线探针下发完整配置，探针离线时在下次连接时下发
+func (s *LogService) notifyAgent(ctx context.Context, agentID string) {
+	if err := s.SendConfigToAgent(ctx, agentID); err != nil {
+		s.logger.Warn("下发日志监控配置到探针失败",
+			zap.String("agentId", agentID),
+			zap.Error(err))
+	}
+}
+
+// SendConfigToAgent 下发日志监控配置到探针
+func (s *LogService) SendConfigToAgent(ctx context.Context, agentID string) error {
+	config, err := s.GetLogConfig(ctx, agentID)
+	if err != nil {
+		return err
+	}
+
+	return s.agentConfigService.Deliver(ctx, agentID, ConfigKindLog, "", ConfigMessage{
+		Type: protocol.MessageTypeLogConfig,
+		Data: config,
+	})
+}
+
+// buildConfigMessage 生成探针连接时下发的日志监控配置
+func (s *LogService) buildConfigMessage(ctx context.Context, agent *models.Agent, _ string) (*ConfigMessage, error) {
+	config, err := s.GetLogConfig(ctx, agent.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &ConfigMessage{
+		Type: protocol.MessageTypeLogConfig,
+		Data: config,
+	}, nil
+}
+
+// HandleEvents 处理探针上报的日志行：保存事件并触发告警
+func (s *LogService) HandleEvents(ctx context.Context, agentID string, events []protocol.LogEventData) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	rules, err := s.LogRuleRepo.ListByAgentID(ctx, agentID)
+	if err != nil {
+		return err
+	}
+	ruleMap := make(map[string]*models.LogRule, len(rules))
+	for i := range rules {
+		ruleMap[rules[i].ID] = &rules[i]
+	}
+
+	now := time.Now().UnixMilli()
+	records := make([]models.LogEvent, 0, len(events))
+	matched := make(map[string][]protocol.LogEventData)
+	for _, event := range events {
+		rule, ok := ruleMap[event.RuleID]
+		if !ok {
+			// 规则已删除，探针还未收到新配置
+			continue
+		}
+
+		event.Line = truncateLogLine(event.Line)
+		if event.Timestamp == 0 {
+			event.Timestamp = now
+		}
+		if rule.ShipLines {
+			records = append(records, models.LogEvent{
+				ID:        uuid.New().String(),
+				AgentID:   agentID,
+				RuleID:    rule.ID,
+				RuleName:  rule.Name,
+				Source:    event.Source,
+				Line:      event.Line,
+				Timestamp: event.Timestamp,
+				CreatedAt: now,
+			})
+		}
+		if rule.AlertEnabled {
+			matched[rule.ID] = append(matched[rule.ID], event)
+		}
+	}
+
+	if len(records) > 0 {
+		if err := s.LogEventRepo.CreateBatch(ctx, records); err != nil {
+			return err
+		}
+	}
+
+	for ruleID, ruleEvents := range matched {
+		if err := s.alertService.HandleLogMatches(ctx, agentID, ruleMap[ruleID], len(ruleEvents), ruleEvents[0].Line); err != nil {
+			s.logger.Error("处理日志告警失败",
+				zap.String("agentId", agentID),
+				zap.String("ruleId", ruleID),
+				zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// truncateLogLine 截断过长的日志行，保证不截断在 UTF-8 字符中间
+func truncateLogLine(line string) string {
+	if len(line) <= logMaxLineLength {
+		return line
+	}
+	end := logMaxLineLength
+	for end > 0 && !utf8.RuneStart(line[end]) {
+		end--
+	}
+	return line[:end] + "..."
+}
@@ -2,11 +2,22 @@ package service
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/dushixiang/pika/internal/protocol"
 	"github.com/dushixiang/pika/internal/vmclient"
 )
 
+// 自定义指标写入 VictoriaMetrics 时使用的名称前缀，避免与内置指标冲突
+const customMetricPrefix = "pika_custom_"
+
+var (
+	customMetricNameRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	customLabelNameRegex  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
 // convertToMetrics 将指标数据转换为 VictoriaMetrics Metric 对象
 func (s *MetricService) convertToMetrics(agentID string, metricType string, data interface{}, timestamp int64) []vmclient.Metric {
 	var metrics []vmclient.Metric
@@ -17,6 +28,18 @@ func (s *MetricService) convertToMetrics(agentID string, metricType string, data
 		metrics = append(metrics, createMetric("pika_cpu_usage_percent", agentID, nil, cpuData.UsagePercent, timestamp))
 		metrics = append(metrics, createMetric("pika_cpu_cores_logical", agentID, nil, float64(cpuData.LogicalCores), timestamp))
 		metrics = append(metrics, createMetric("pika_cpu_cores_physical", agentID, nil, float64(cpuData.PhysicalCores), timestamp))
+		if cpuData.Modes != nil {
+			for mode, value := range cpuModeValues(cpuData.Modes) {
+				labels := map[string]string{"mode": mode}
+				metrics = append(metrics, createMetric("pika_cpu_mode_percent", agentID, labels, value, timestamp))
+			}
+		}
+		for i := range cpuData.PerCoreModes {
+			for mode, value := range cpuModeValues(&cpuData.PerCoreModes[i]) {
+				labels := map[string]string{"cpu": strconv.Itoa(i), "mode": mode}
+				metrics = append(metrics, createMetric("pika_cpu_core_mode_percent", agentID, labels, value, timestamp))
+			}
+		}
 
 	case protocol.MetricTypeMemory:
 		memData := data.(*protocol.MemoryData)
@@ -35,6 +58,25 @@ func (s *MetricService) convertToMetrics(agentID string, metricType string, data
 			metrics = append(metrics, createMetric("pika_disk_total_bytes", agentID, labels, float64(diskData.Total), timestamp))
 			metrics = append(metrics, createMetric("pika_disk_used_bytes", agentID, labels, float64(diskData.Used), timestamp))
 			metrics = append(metrics, createMetric("pika_disk_free_bytes", agentID, labels, float64(diskData.Free), timestamp))
+			metrics = append(metrics, createMetric("pika_disk_inodes_total", agentID, labels, float64(diskData.InodesTotal), timestamp))
+			metrics = append(metrics, createMetric("pika_disk_inodes_used", agentID, labels, float64(diskData.InodesUsed), timestamp))
+			metrics = append(metrics, createMetric("pika_disk_inodes_free", agentID, labels, float64(diskData.InodesFree), timestamp))
+			metrics = append(metrics, createMetric("pika_disk_inodes_usage_percent", agentID, labels, diskData.InodesUsagePercent, timestamp))
+
+			var readOnly float64
+			if diskData.ReadOnly {
+				readOnly = 1
+			}
+			metrics = append(metrics, createMetric("pika_disk_read_only", agentID, map[string]string{
+				"mount_point": diskData.MountPoint,
+				"device":      diskData.Device,
+				"fstype":      diskData.Fstype,
+			}, readOnly, timestamp))
+			// 挂载选项在 ro/rw 重新挂载时会变化，单独作为信息指标发布，避免只读指标产生新的序列
+			metrics = append(metrics, createMetric("pika_disk_mount_info", agentID, map[string]string{
+				"mount_point": diskData.MountPoint,
+				"options":     strings.Join(diskData.Options, ","),
+			}, 1, timestamp))
 		}
 
 	case protocol.MetricTypeNetwork:
@@ -68,6 +110,78 @@ func (s *MetricService) convertToMetrics(agentID string, metricType string, data
 		metrics = append(metrics, createMetric("pika_disk_read_bytes_rate", agentID, nil, float64(totalReadRate), timestamp))
 		metrics = append(metrics, createMetric("pika_disk_write_bytes_rate", agentID, nil, float64(totalWriteRate), timestamp))
 
+	case protocol.MetricTypeCustom:
+		samples := data.([]protocol.CustomMetricData)
+		for _, sample := range samples {
+			if metric, ok := convertExternalSample(agentID, customMetricPrefix+sample.Name, sample, timestamp); ok {
+				metrics = append(metrics, metric)
+			}
+		}
+
+	case protocol.MetricTypePrometheus:
+		results := data.([]protocol.PrometheusScrapeData)
+		for _, result := range results {
+			labels := map[string]string{
+				"job":      result.Job,
+				"instance": result.Instance,
+			}
+			var up float64
+			if result.Up {
+				up = 1
+			}
+			ts := result.Timestamp
+			if ts <= 0 {
+				ts = timestamp
+			}
+			metrics = append(metrics, createMetric("pika_scrape_up", agentID, labels, up, ts))
+			metrics = append(metrics, createMetric("pika_scrape_duration_seconds", agentID, labels, result.ScrapeDuration, ts))
+			metrics = append(metrics, createMetric("pika_scrape_samples", agentID, labels, float64(len(result.Samples)), ts))
+
+			for _, sample := range result.Samples {
+				// 抓取的指标保留原名，但不允许冒充内置指标
+				if strings.HasPrefix(sample.Name, "pika_") {
+					continue
+				}
+				if metric, ok := convertExternalSample(agentID, sample.Name, sample, ts); ok {
+					metrics = append(metrics, metric)
+				}
+			}
+		}
+
+	case protocol.MetricTypeDiskHealth:
+		healthDataList := data.([]protocol.DiskHealthData)
+		for _, healthData := range healthDataList {
+			labels := map[string]string{
+				"device": healthData.Device,
+				"model":  healthData.Model,
+			}
+			var passed float64
+			if healthData.Passed {
+				passed = 1
+			}
+			metrics = append(metrics, createMetric("pika_disk_health_passed", agentID, labels, passed, timestamp))
+			metrics = append(metrics, createMetric("pika_disk_health_temperature_celsius", agentID, labels, healthData.Temperature, timestamp))
+			metrics = append(metrics, createMetric("pika_disk_health_power_on_hours", agentID, labels, float64(healthData.PowerOnHours), timestamp))
+			metrics = append(metrics, createMetric("pika_disk_health_reallocated_sectors", agentID, labels, float64(healthData.ReallocatedSectors), timestamp))
+			metrics = append(metrics, createMetric("pika_disk_health_pending_sectors", agentID, labels, float64(healthData.PendingSectors), timestamp))
+			metrics = append(metrics, createMetric("pika_disk_health_uncorrectable_sectors", agentID, labels, float64(healthData.UncorrectableSectors), timestamp))
+			metrics = append(metrics, createMetric("pika_disk_health_percentage_used", agentID, labels, healthData.PercentageUsed, timestamp))
+			metrics = append(metrics, createMetric("pika_disk_health_available_spare", agentID, labels, healthData.AvailableSpare, timestamp))
+			metrics = append(metrics, createMetric("pika_disk_health_media_errors", agentID, labels, float64(healthData.MediaErrors), timestamp))
+		}
+
+	case protocol.MetricTypeLog:
+		matches := data.([]protocol.LogMatchData)
+		for _, match := range matches {
+			labels := map[string]string{
+				"rule_id": match.RuleID,
+				"source":  match.Source,
+			}
+			// 探针启动以来的累计值，查询时使用 increase 计算区间内的匹配数
+			metrics = append(metrics, createMetric("pika_log_matches_total", agentID, labels, float64(match.Matches), timestamp))
+			metrics = append(metrics, createMetric("pika_log_dropped_total", agentID, labels, float64(match.Dropped), timestamp))
+		}
+
 	case protocol.MetricTypeGPU:
 		gpuDataList := data.([]protocol.GPUData)
 		for _, gpuData := range gpuDataList {
@@ -93,6 +207,102 @@ func (s *MetricService) convertToMetrics(agentID string, metricType string, data
 			metrics = append(metrics, createMetric("pika_temperature_celsius", agentID, labels, tempData.Temperature, timestamp))
 		}
 
+	case protocol.MetricTypeKernel:
+		kernelData := data.(*protocol.KernelData)
+		if load := kernelData.Load; load != nil {
+			metrics = append(metrics, createMetric("pika_load1", agentID, nil, load.Load1, timestamp))
+			metrics = append(metrics, createMetric("pika_load5", agentID, nil, load.Load5, timestamp))
+			metrics = append(metrics, createMetric("pika_load15", agentID, nil, load.Load15, timestamp))
+		}
+		if pressure := kernelData.Pressure; pressure != nil {
+			metrics = append(metrics, createMetric("pika_pressure_cpu_some_percent", agentID, nil, pressure.CPUSome, timestamp))
+			metrics = append(metrics, createMetric("pika_pressure_memory_some_percent", agentID, nil, pressure.MemorySome, timestamp))
+			metrics = append(metrics, createMetric("pika_pressure_memory_full_percent", agentID, nil, pressure.MemoryFull, timestamp))
+			metrics = append(metrics, createMetric("pika_pressure_io_some_percent", agentID, nil, pressure.IOSome, timestamp))
+			metrics = append(metrics, createMetric("pika_pressure_io_full_percent", agentID, nil, pressure.IOFull, timestamp))
+		}
+		if fd := kernelData.FileDescriptors; fd != nil {
+			metrics = append(metrics, createMetric("pika_file_descriptors_allocated", agentID, nil, float64(fd.Allocated), timestamp))
+			metrics = append(metrics, createMetric("pika_file_descriptors_max", agentID, nil, float64(fd.Max), timestamp))
+		}
+		if conntrack := kernelData.Conntrack; conntrack != nil {
+			metrics = append(metrics, createMetric("pika_conntrack_entries", agentID, nil, float64(conntrack.Entries), timestamp))
+			metrics = append(metrics, createMetric("pika_conntrack_max", agentID, nil, float64(conntrack.Max), timestamp))
+		}
+		if kernelData.EntropyAvailable != nil {
+			metrics = append(metrics, createMetric("pika_entropy_available_bits", agentID, nil, float64(*kernelData.EntropyAvailable), timestamp))
+		}
+		metrics = append(metrics, createMetric("pika_context_switches_rate", agentID, nil, float64(kernelData.ContextSwitchesRate), timestamp))
+		metrics = append(metrics, createMetric("pika_interrupts_rate", agentID, nil, float64(kernelData.InterruptsRate), timestamp))
+		metrics = append(metrics, createMetric("pika_forks_rate", agentID, nil, float64(kernelData.ForksRate), timestamp))
+		metrics = append(metrics, createMetric("pika_procs_running", agentID, nil, float64(kernelData.ProcsRunning), timestamp))
+		metrics = append(metrics, createMetric("pika_procs_blocked", agentID, nil, float64(kernelData.ProcsBlocked), timestamp))
+
+	case protocol.MetricTypeProcess:
+		processData := data.(*protocol.ProcessData)
+		for _, watched := range processData.Watched {
+			labels := map[string]string{"process": watched.Name}
+			var up float64
+			if watched.Running {
+				up = 1
+			}
+			metrics = append(metrics, createMetric("pika_process_up", agentID, labels, up, timestamp))
+			metrics = append(metrics, createMetric("pika_process_count", agentID, labels, float64(watched.Count), timestamp))
+			metrics = append(metrics, createMetric("pika_process_cpu_percent", agentID, labels, watched.CPUPercent, timestamp))
+			metrics = append(metrics, createMetric("pika_process_rss_bytes", agentID, labels, float64(watched.RSS), timestamp))
+			metrics = append(metrics, createMetric("pika_process_fds", agentID, labels, float64(watched.NumFDs), timestamp))
+			metrics = append(metrics, createMetric("pika_process_threads", agentID, labels, float64(watched.NumThreads), timestamp))
+			metrics = append(metrics, createMetric("pika_process_restarts", agentID, labels, float64(watched.Restarts), timestamp))
+		}
+
+		// Top 进程按进程名汇总，不使用 PID 作为标签，避免时间序列数量失控
+		topCPU := make(map[string]float64)
+		topRSS := make(map[string]float64)
+		for _, stat := range processData.Top {
+			topCPU[stat.Name] += stat.CPUPercent
+			topRSS[stat.Name] += float64(stat.RSS)
+		}
+		for name, cpuPercent := range topCPU {
+			labels := map[string]string{"process_name": name}
+			metrics = append(metrics, createMetric("pika_process_top_cpu_percent", agentID, labels, cpuPercent, timestamp))
+			metrics = append(metrics, createMetric("pika_process_top_rss_bytes", agentID, labels, topRSS[name], timestamp))
+		}
+
+	case protocol.MetricTypeContainer:
+		containerDataList := data.([]protocol.ContainerData)
+		for _, containerData := range containerDataList {
+			// 不使用容器 ID 作为标签，容器重建后沿用同一时间序列
+			labels := map[string]string{
+				"container_name": containerData.Name,
+				"image":          containerData.Image,
+			}
+			metrics = append(metrics, createMetric("pika_container_cpu_percent", agentID, labels, containerData.CPUPercent, timestamp))
+			metrics = append(metrics, createMetric("pika_container_memory_usage_bytes", agentID, labels, float64(containerData.MemoryUsage), timestamp))
+			metrics = append(metrics, createMetric("pika_container_memory_limit_bytes", agentID, labels, float64(containerData.MemoryLimit), timestamp))
+			metrics = append(metrics, createMetric("pika_container_memory_percent", agentID, labels, containerData.MemoryPercent, timestamp))
+			metrics = append(metrics, createMetric("pika_container_blkio_read_bytes_rate", agentID, labels, float64(containerData.BlkioReadBytesRate), timestamp))
+			metrics = append(metrics, createMetric("pika_container_blkio_write_bytes_rate", agentID, labels, float64(containerData.BlkioWriteBytesRate), timestamp))
+			metrics = append(metrics, createMetric("pika_container_network_rx_bytes_rate", agentID, labels, float64(containerData.NetRxBytesRate), timestamp))
+			metrics = append(metrics, createMetric("pika_container_network_tx_bytes_rate", agentID, labels, float64(containerData.NetTxBytesRate), timestamp))
+			metrics = append(metrics, createMetric("pika_container_pids", agentID, labels, float64(containerData.Pids), timestamp))
+		}
+
+	case protocol.MetricTypeSystemd:
+		unitDataList := data.([]protocol.SystemdUnitData)
+		for _, unitData := range unitDataList {
+			labels := map[string]string{"unit": unitData.Name}
+			var active, failed float64
+			if unitData.ActiveState == "active" {
+				active = 1
+			}
+			if unitData.ActiveState == "failed" {
+				failed = 1
+			}
+			metrics = append(metrics, createMetric("pika_systemd_unit_active", agentID, labels, active, timestamp))
+			metrics = append(metrics, createMetric("pika_systemd_unit_failed", agentID, labels, failed, timestamp))
+			metrics = append(metrics, createMetric("pika_systemd_unit_restarts", agentID, labels, float64(unitData.Restarts), timestamp))
+		}
+
 	case protocol.MetricTypeMonitor:
 		monitorDataList := data.([]protocol.MonitorData)
 		for _, monitorData := range monitorDataList {
@@ -102,12 +312,54 @@ func (s *MetricService) convertToMetrics(agentID string, metricType string, data
 				"target":       monitorData.Target,
 			}
 			metrics = append(metrics, createMetric("pika_monitor_response_time_ms", agentID, labels, float64(monitorData.ResponseTime), timestamp))
+
+			// 可用状态：1 表示正常，0 表示异常，用于计算可用率
+			var status float64
+			if monitorData.Status == "up" {
+				status = 1
+			}
+			metrics = append(metrics, createMetric("pika_monitor_status", agentID, labels, status, timestamp))
 		}
 	}
 
 	return metrics
 }
 
+// cpuModeValues 将各模式 CPU 时间占比转换为 mode 标签到值的映射
+func cpuModeValues(modes *protocol.CPUModes) map[string]float64 {
+	return map[string]float64{
+		"user":    modes.User,
+		"nice":    modes.Nice,
+		"system":  modes.System,
+		"idle":    modes.Idle,
+		"iowait":  modes.Iowait,
+		"irq":     modes.Irq,
+		"softirq": modes.Softirq,
+		"steal":   modes.Steal,
+		"guest":   modes.Guest,
+	}
+}
+
+// convertExternalSample 转换探针转发的外部样本（自定义指标、Prometheus 抓取），
+// 校验指标名和标签名，并移除 __name__、agent_id 等保留标签，样本没有时间戳时使用 defaultTimestamp
+func convertExternalSample(agentID, metricName string, sample protocol.CustomMetricData, defaultTimestamp int64) (vmclient.Metric, bool) {
+	if !customMetricNameRegex.MatchString(sample.Name) {
+		return vmclient.Metric{}, false
+	}
+	labels := make(map[string]string, len(sample.Labels))
+	for name, value := range sample.Labels {
+		if strings.HasPrefix(name, "__") || name == "agent_id" || !customLabelNameRegex.MatchString(name) {
+			continue
+		}
+		labels[name] = value
+	}
+	ts := sample.Timestamp
+	if ts <= 0 {
+		ts = defaultTimestamp
+	}
+	return createMetric(metricName, agentID, labels, sample.Value, ts), true
+}
+
 // createMetric 创建 VictoriaMetrics Metric 对象
 func createMetric(metricName, agentID string, extraLabels map[string]string, value float64, timestamp int64) vmclient.Metric {
 	// 创建 metric labels，包含 __name__ 和 agent_id
@@ -1,12 +1,18 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/gob"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/dushixiang/pika/internal/cluster"
 	"github.com/dushixiang/pika/internal/metric"
 	"github.com/dushixiang/pika/internal/models"
 	"github.com/dushixiang/pika/internal/protocol"
@@ -28,14 +34,24 @@ type MetricService struct {
 	propertyService *PropertyService
 	trafficService  *TrafficService // 流量统计服务
 	vmClient        *vmclient.VMClient
+	vmWriter        *vmclient.BatchWriter // 合并多个探针的写入
 
 	latestCache cache.Cache[string, *metric.LatestMetrics] // Agent 最新指标缓存
 
-	monitorLatestCache cache.Cache[string, *metric.LatestMonitorMetrics] // 监控最新指标缓存
+	// 多节点部署时共享最新指标
+	cluster           cluster.Backend
+	remoteLatestCache cache.Cache[string, *metric.LatestMetrics] // 其他节点探针的最新指标缓存
+	latestDirtyMu     sync.Mutex
+	latestDirty       map[string]struct{} // 最新指标有变化、尚未共享给其他节点的探针
+
+	monitorLatestCache cache.Cache[string, *metric.LatestMonitorMetrics]      // 监控最新指标缓存
+	remoteMonitorCache cache.Cache[string, map[string][]protocol.MonitorData] // 其他节点探针的监控最新指标缓存
+
+	onLatest func(agentID string) // 最新指标更新后回调，用于向浏览器推送
 }
 
 // NewMetricService 创建指标服务
-func NewMetricService(logger *zap.Logger, db *gorm.DB, propertyService *PropertyService, trafficService *TrafficService, vmClient *vmclient.VMClient) *MetricService {
+func NewMetricService(logger *zap.Logger, db *gorm.DB, propertyService *PropertyService, trafficService *TrafficService, vmClient *vmclient.VMClient, vmWriter *vmclient.BatchWriter, backend cluster.Backend) *MetricService {
 	return &MetricService{
 		logger:             logger,
 		metricRepo:         repo.NewMetricRepo(db),
@@ -44,20 +60,42 @@ func NewMetricService(logger *zap.Logger, db *gorm.DB, propertyService *Property
 		propertyService:    propertyService,
 		trafficService:     trafficService,
 		vmClient:           vmClient,
+		vmWriter:           vmWriter,
 		latestCache:        cache.New[string, *metric.LatestMetrics](time.Minute),
+		cluster:            backend,
+		remoteLatestCache:  cache.New[string, *metric.LatestMetrics](time.Minute),
+		latestDirty:        make(map[string]struct{}),
 		monitorLatestCache: cache.New[string, *metric.LatestMonitorMetrics](5 * time.Minute), // 监控数据缓存 5 分钟
+		remoteMonitorCache: cache.New[string, map[string][]protocol.MonitorData](time.Minute),
 	}
 }
 
 // HandleMetricData 处理指标数据
-func (s *MetricService) HandleMetricData(ctx context.Context, agentID string, metricType string, data json.RawMessage) error {
+// timestamp 为采集时间（时间戳毫秒），为 0 时是实时指标，按当前时间写入并更新最新指标缓存；
+// 大于 0 时是探针断线期间缓存后补发的历史指标，只按采集时间写入 VictoriaMetrics
+func (s *MetricService) HandleMetricData(ctx context.Context, agentID string, metricType string, timestamp int64, data json.RawMessage) error {
 	now := time.Now().UnixMilli()
+	historical := timestamp > 0
+	if historical {
+		now = timestamp
+	}
 
-	// 更新内存缓存
-	latestMetrics, ok := s.latestCache.Get(agentID)
-	if !ok {
+	var latestMetrics *metric.LatestMetrics
+	if historical {
+		// 历史指标不覆盖最新指标缓存，使用临时对象承接各分支的缓存更新
 		latestMetrics = &metric.LatestMetrics{}
-		s.latestCache.Set(agentID, latestMetrics, time.Hour)
+	} else {
+		// 更新内存缓存
+		var ok bool
+		latestMetrics, ok = s.latestCache.Get(agentID)
+		if !ok {
+			latestMetrics = &metric.LatestMetrics{}
+			s.latestCache.Set(agentID, latestMetrics, time.Hour)
+		}
+		s.markLatestDirty(agentID)
+		if s.onLatest != nil {
+			s.onLatest(agentID)
+		}
 	}
 
 	// 解析数据并写入 VictoriaMetrics
@@ -69,7 +107,7 @@ func (s *MetricService) HandleMetricData(ctx context.Context, agentID string, me
 		}
 		latestMetrics.CPU = &cpuData
 		metrics := s.convertToMetrics(agentID, metricType, &cpuData, now)
-		return s.vmClient.Write(ctx, metrics)
+		return s.vmWriter.Write(ctx, metrics)
 
 	case protocol.MetricTypeMemory:
 		var memData protocol.MemoryData
@@ -78,7 +116,7 @@ func (s *MetricService) HandleMetricData(ctx context.Context, agentID string, me
 		}
 		latestMetrics.Memory = &memData
 		metrics := s.convertToMetrics(agentID, metricType, &memData, now)
-		return s.vmClient.Write(ctx, metrics)
+		return s.vmWriter.Write(ctx, metrics)
 
 	case protocol.MetricTypeDisk:
 		var diskDataList []protocol.DiskData
@@ -87,10 +125,19 @@ func (s *MetricService) HandleMetricData(ctx context.Context, agentID string, me
 		}
 		// 计算汇总数据用于缓存
 		var totalTotal, totalUsed, totalFree uint64
+		var inodesUsagePercent float64
+		var readOnlyDisks int
 		for _, diskData := range diskDataList {
 			totalTotal += diskData.Total
 			totalUsed += diskData.Used
 			totalFree += diskData.Free
+			// inode 耗尽发生在单个分区上，取最高值而不是平均值
+			if diskData.InodesUsagePercent > inodesUsagePercent {
+				inodesUsagePercent = diskData.InodesUsagePercent
+			}
+			if diskData.ReadOnly {
+				readOnlyDisks++
+			}
 		}
 		var usagePercent float64
 		if totalTotal > 0 {
@@ -102,9 +149,12 @@ func (s *MetricService) HandleMetricData(ctx context.Context, agentID string, me
 			Total:        totalTotal,
 			Used:         totalUsed,
 			Free:         totalFree,
+
+			InodesUsagePercent: inodesUsagePercent,
+			ReadOnlyDisks:      readOnlyDisks,
 		}
 		metrics := s.convertToMetrics(agentID, metricType, diskDataList, now)
-		return s.vmClient.Write(ctx, metrics)
+		return s.vmWriter.Write(ctx, metrics)
 
 	case protocol.MetricTypeNetwork:
 		var networkDataList []protocol.NetworkData
@@ -127,14 +177,16 @@ func (s *MetricService) HandleMetricData(ctx context.Context, agentID string, me
 			TotalBytesRecvTotal: totalRecvTotal,
 			TotalInterfaces:     len(networkDataList),
 		}
-		// 更新流量统计
-		if err := s.trafficService.UpdateAgentTraffic(ctx, agentID, totalRecvTotal); err != nil {
-			s.logger.Error("更新探针流量统计失败",
-				zap.String("agentId", agentID),
-				zap.Error(err))
+		// 更新流量统计，历史指标的累计流量已被之后的实时指标覆盖
+		if !historical {
+			if err := s.trafficService.UpdateAgentTraffic(ctx, agentID, totalRecvTotal); err != nil {
+				s.logger.Error("更新探针流量统计失败",
+					zap.String("agentId", agentID),
+					zap.Error(err))
+			}
 		}
 		metrics := s.convertToMetrics(agentID, metricType, networkDataList, now)
-		return s.vmClient.Write(ctx, metrics)
+		return s.vmWriter.Write(ctx, metrics)
 
 	case protocol.MetricTypeNetworkConnection:
 		var connData protocol.NetworkConnectionData
@@ -143,7 +195,7 @@ func (s *MetricService) HandleMetricData(ctx context.Context, agentID string, me
 		}
 		latestMetrics.NetworkConnection = &connData
 		metrics := s.convertToMetrics(agentID, metricType, &connData, now)
-		return s.vmClient.Write(ctx, metrics)
+		return s.vmWriter.Write(ctx, metrics)
 
 	case protocol.MetricTypeDiskIO:
 		var diskIODataList []*protocol.DiskIOData
@@ -151,13 +203,17 @@ func (s *MetricService) HandleMetricData(ctx context.Context, agentID string, me
 			return err
 		}
 		metrics := s.convertToMetrics(agentID, metricType, diskIODataList, now)
-		return s.vmClient.Write(ctx, metrics)
+		return s.vmWriter.Write(ctx, metrics)
 
 	case protocol.MetricTypeHost:
 		var hostData protocol.HostInfoData
 		if err := json.Unmarshal(data, &hostData); err != nil {
 			return err
 		}
+		if historical {
+			// 主机信息只保留最新状态，历史数据无需保存
+			return nil
+		}
 		// Host 信息仍然保存到 PostgreSQL（静态信息，不频繁变化）
 		hostMetric := &models.HostMetric{
 			AgentID:         agentID,
@@ -182,7 +238,7 @@ func (s *MetricService) HandleMetricData(ctx context.Context, agentID string, me
 		// 更新缓存
 		latestMetrics.GPU = gpuDataList
 		metrics := s.convertToMetrics(agentID, metricType, gpuDataList, now)
-		return s.vmClient.Write(ctx, metrics)
+		return s.vmWriter.Write(ctx, metrics)
 
 	case protocol.MetricTypeTemperature:
 		var tempDataList []protocol.TemperatureData
@@ -192,7 +248,7 @@ func (s *MetricService) HandleMetricData(ctx context.Context, agentID string, me
 		// 更新缓存
 		latestMetrics.Temp = tempDataList
 		metrics := s.convertToMetrics(agentID, metricType, tempDataList, now)
-		return s.vmClient.Write(ctx, metrics)
+		return s.vmWriter.Write(ctx, metrics)
 
 	case protocol.MetricTypeMonitor:
 		var monitorDataList []protocol.MonitorData
@@ -204,12 +260,97 @@ func (s *MetricService) HandleMetricData(ctx context.Context, agentID string, me
 		}
 		// 更新缓存
 		latestMetrics.Monitors = monitorDataList
-		for _, monitorData := range monitorDataList {
-			s.updateMonitorCache(agentID, &monitorData, now)
+		if !historical {
+			for _, monitorData := range monitorDataList {
+				s.updateMonitorCache(agentID, &monitorData, now)
+			}
 		}
 
 		metrics := s.convertToMetrics(agentID, metricType, monitorDataList, now)
-		return s.vmClient.Write(ctx, metrics)
+		return s.vmWriter.Write(ctx, metrics)
+
+	case protocol.MetricTypeProcess:
+		var processData protocol.ProcessData
+		if err := json.Unmarshal(data, &processData); err != nil {
+			return err
+		}
+		// 更新缓存
+		latestMetrics.Process = &processData
+		metrics := s.convertToMetrics(agentID, metricType, &processData, now)
+		return s.vmWriter.Write(ctx, metrics)
+
+	case protocol.MetricTypeKernel:
+		var kernelData protocol.KernelData
+		if err := json.Unmarshal(data, &kernelData); err != nil {
+			return err
+		}
+		// 更新缓存
+		latestMetrics.Kernel = &kernelData
+		metrics := s.convertToMetrics(agentID, metricType, &kernelData, now)
+		return s.vmWriter.Write(ctx, metrics)
+
+	case protocol.MetricTypeCustom:
+		var samples []protocol.CustomMetricData
+		if err := json.Unmarshal(data, &samples); err != nil {
+			return err
+		}
+		// 自定义指标只写入 VictoriaMetrics，不进入最新指标缓存
+		metrics := s.convertToMetrics(agentID, metricType, samples, now)
+		return s.vmWriter.Write(ctx, metrics)
+
+	case protocol.MetricTypePrometheus:
+		var results []protocol.PrometheusScrapeData
+		if err := json.Unmarshal(data, &results); err != nil {
+			return err
+		}
+		// 缓存抓取目标状态，样本只写入 VictoriaMetrics
+		targets := make([]protocol.PrometheusScrapeData, 0, len(results))
+		for _, result := range results {
+			result.Samples = nil
+			targets = append(targets, result)
+		}
+		latestMetrics.PrometheusTargets = mergePrometheusTargets(latestMetrics.PrometheusTargets, targets)
+		metrics := s.convertToMetrics(agentID, metricType, results, now)
+		return s.vmWriter.Write(ctx, metrics)
+
+	case protocol.MetricTypeLog:
+		var matches []protocol.LogMatchData
+		if err := json.Unmarshal(data, &matches); err != nil {
+			return err
+		}
+		// 日志匹配计数只写入 VictoriaMetrics
+		metrics := s.convertToMetrics(agentID, metricType, matches, now)
+		return s.vmWriter.Write(ctx, metrics)
+
+	case protocol.MetricTypeDiskHealth:
+		var healthDataList []protocol.DiskHealthData
+		if err := json.Unmarshal(data, &healthDataList); err != nil {
+			return err
+		}
+		// 更新缓存
+		latestMetrics.DiskHealth = healthDataList
+		metrics := s.convertToMetrics(agentID, metricType, healthDataList, now)
+		return s.vmWriter.Write(ctx, metrics)
+
+	case protocol.MetricTypeContainer:
+		var containerDataList []protocol.ContainerData
+		if err := json.Unmarshal(data, &containerDataList); err != nil {
+			return err
+		}
+		// 更新缓存
+		latestMetrics.Containers = containerDataList
+		metrics := s.convertToMetrics(agentID, metricType, containerDataList, now)
+		return s.vmWriter.Write(ctx, metrics)
+
+	case protocol.MetricTypeSystemd:
+		var unitDataList []protocol.SystemdUnitData
+		if err := json.Unmarshal(data, &unitDataList); err != nil {
+			return err
+		}
+		// 更新缓存
+		latestMetrics.Systemd = unitDataList
+		metrics := s.convertToMetrics(agentID, metricType, unitDataList, now)
+		return s.vmWriter.Write(ctx, metrics)
 
 	default:
 		s.logger.Warn("unknown cpiMetric type", zap.String("type", metricType))
@@ -229,25 +370,7 @@ func (s *MetricService) GetMetrics(ctx context.Context, agentID, metricType stri
 	}
 
 	// 执行查询并转换结果
-	// step 设为 0，让 VictoriaMetrics 自动选择合适的步长
-	var series []metric.Series
-
-	for _, q := range queries {
-		result, err := s.vmClient.QueryRange(ctx, q.Query,
-			time.UnixMilli(start),
-			time.UnixMilli(end),
-			step)
-		if err != nil {
-			s.logger.Error("查询 VictoriaMetrics 失败",
-				zap.String("query", q.Query),
-				zap.Error(err))
-			continue // 跳过失败的查询，继续处理其他查询
-		}
-
-		// 转换查询结果为 MetricSeries
-		convertedSeries := s.convertQueryResultToSeries(result, q.Name, q.Labels)
-		series = append(series, convertedSeries...)
-	}
+	series := s.queryRangeSeries(ctx, queries, start, end, step)
 
 	// 如果是监控类型，添加监控任务名称到标签中
 	if metricType == "monitor" && len(series) > 0 {
@@ -296,6 +419,89 @@ func (s *MetricService) GetMetrics(ctx context.Context, agentID, metricType stri
 	}, nil
 }
 
+// mergePrometheusTargets 合并抓取目标状态，探针每次只上报有新结果的目标
+func mergePrometheusTargets(previous, updated []protocol.PrometheusScrapeData) []protocol.PrometheusScrapeData {
+	targets := make(map[string]protocol.PrometheusScrapeData, len(previous)+len(updated))
+	for _, target := range previous {
+		targets[target.Job] = target
+	}
+	for _, target := range updated {
+		targets[target.Job] = target
+	}
+
+	merged := make([]protocol.PrometheusScrapeData, 0, len(targets))
+	for _, target := range targets {
+		merged = append(merged, target)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Job < merged[j].Job
+	})
+	return merged
+}
+
+// queryRangeSeries 执行查询列表并转换为 MetricSeries，失败的查询会被跳过
+func (s *MetricService) queryRangeSeries(ctx context.Context, queries []metric.QueryDefinition, start, end int64, step time.Duration) []metric.Series {
+	var series []metric.Series
+
+	for _, q := range queries {
+		result, err := s.vmClient.QueryRange(ctx, q.Query,
+			time.UnixMilli(start),
+			time.UnixMilli(end),
+			step)
+		if err != nil {
+			s.logger.Error("查询 VictoriaMetrics 失败",
+				zap.String("query", q.Query),
+				zap.Error(err))
+			continue // 跳过失败的查询，继续处理其他查询
+		}
+
+		// 转换查询结果为 MetricSeries
+		convertedSeries := s.convertQueryResultToSeries(result, q.Name, q.Labels)
+		series = append(series, convertedSeries...)
+	}
+
+	return series
+}
+
+// GetCustomMetricNames 获取探针上报过的自定义指标名称（从 VictoriaMetrics 查询）
+func (s *MetricService) GetCustomMetricNames(ctx context.Context, agentID string) ([]string, error) {
+	match := []string{fmt.Sprintf(`{__name__=~"%s.+",agent_id="%s"}`, customMetricPrefix, agentID)}
+	metricNames, err := s.vmClient.GetLabelValues(ctx, "__name__", match)
+	if err != nil {
+		s.logger.Error("查询自定义指标列表失败",
+			zap.String("agentID", agentID),
+			zap.Error(err))
+		return []string{}, nil // 返回空列表而不是错误
+	}
+
+	names := make([]string, 0, len(metricNames))
+	for _, metricName := range metricNames {
+		names = append(names, strings.TrimPrefix(metricName, customMetricPrefix))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// GetCustomMetrics 获取自定义指标数据，每组标签返回一个系列
+func (s *MetricService) GetCustomMetrics(ctx context.Context, agentID, name string, start, end int64) (*metric.GetMetricsResponse, error) {
+	if !customMetricNameRegex.MatchString(name) {
+		return nil, fmt.Errorf("invalid custom metric name: %s", name)
+	}
+
+	step := vmclient.AutoStep(time.UnixMilli(start), time.UnixMilli(end))
+	queries := []metric.QueryDefinition{{
+		Name:  name,
+		Query: fmt.Sprintf(`%s%s{agent_id="%s"}`, customMetricPrefix, name, agentID),
+	}}
+
+	return &metric.GetMetricsResponse{
+		AgentID: agentID,
+		Type:    string(protocol.MetricTypeCustom),
+		Range:   fmt.Sprintf("%d-%d", start, end),
+		Series:  s.queryRangeSeries(ctx, queries, start, end, step),
+	}, nil
+}
+
 // updateMonitorCache 更新监控数据缓存
 func (s *MetricService) updateMonitorCache(agentID string, monitorData *protocol.MonitorData, timestamp int64) {
 	monitorID := monitorData.MonitorId
@@ -317,10 +523,192 @@ func (s *MetricService) updateMonitorCache(agentID string, monitorData *protocol
 	s.monitorLatestCache.Set(monitorID, latestMetrics, 5*time.Minute)
 }
 
-// GetLatestMetrics 获取最新指标
+// SetLatestHandler 设置最新指标更新回调，回调在处理指标数据的协程中执行，不能阻塞
+func (s *MetricService) SetLatestHandler(handler func(agentID string)) {
+	s.onLatest = handler
+}
+
+// GetLatestMetrics 获取最新指标，探针连接在其他节点时读取该节点共享的快照
 func (s *MetricService) GetLatestMetrics(agentID string) (*metric.LatestMetrics, bool) {
-	metrics, ok := s.latestCache.Get(agentID)
-	return metrics, ok
+	if metrics, ok := s.latestCache.Get(agentID); ok {
+		return metrics, true
+	}
+	if !s.cluster.Distributed() {
+		return nil, false
+	}
+	if metrics, ok := s.remoteLatestCache.Get(agentID); ok {
+		return metrics, true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	data, ok, err := s.cluster.GetLatest(ctx, agentID)
+	if err != nil {
+		s.logger.Warn("获取其他节点的最新指标失败", zap.String("agentID", agentID), zap.Error(err))
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	var metrics metric.LatestMetrics
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&metrics); err != nil {
+		s.logger.Warn("解析其他节点的最新指标失败", zap.String("agentID", agentID), zap.Error(err))
+		return nil, false
+	}
+	// 快照按 latestSyncInterval 更新，短时间内重复读取不再访问后端
+	s.remoteLatestCache.Set(agentID, &metrics, latestSyncInterval)
+	return &metrics, true
+}
+
+const (
+	// 最新指标共享给其他节点的间隔
+	latestSyncInterval = 5 * time.Second
+	// 各节点的监控最新指标快照的键前缀，后接节点标识
+	monitorLatestPrefix = "monitor@"
+)
+
+func (s *MetricService) markLatestDirty(agentID string) {
+	if !s.cluster.Distributed() {
+		return
+	}
+	s.latestDirtyMu.Lock()
+	s.latestDirty[agentID] = struct{}{}
+	s.latestDirtyMu.Unlock()
+}
+
+// RunLatestSync 定时将本节点探针的最新指标共享给其他节点，单节点部署时直接返回
+func (s *MetricService) RunLatestSync(ctx context.Context) {
+	if !s.cluster.Distributed() {
+		return
+	}
+
+	ticker := time.NewTicker(latestSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.syncLatest(ctx)
+		}
+	}
+}
+
+func (s *MetricService) syncLatest(ctx context.Context) {
+	s.latestDirtyMu.Lock()
+	dirty := s.latestDirty
+	s.latestDirty = make(map[string]struct{}, len(dirty))
+	s.latestDirtyMu.Unlock()
+
+	snapshots := make(map[string][]byte, len(dirty))
+	for agentID := range dirty {
+		latestMetrics, ok := s.latestCache.Get(agentID)
+		if !ok {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(latestMetrics); err != nil {
+			s.logger.Warn("序列化最新指标失败", zap.String("agentID", agentID), zap.Error(err))
+			continue
+		}
+		snapshots[agentID] = buf.Bytes()
+	}
+
+	// 监控最新指标每次都整体共享，没有变化时也需要续期
+	monitorKey := monitorLatestPrefix + s.cluster.NodeID()
+	if data, err := s.encodeMonitorLatest(); err != nil {
+		s.logger.Warn("序列化监控最新指标失败", zap.Error(err))
+	} else {
+		snapshots[monitorKey] = data
+	}
+
+	if err := s.cluster.PutLatest(ctx, snapshots); err != nil {
+		s.logger.Warn("共享最新指标失败", zap.Error(err))
+		// 下次重新共享
+		s.latestDirtyMu.Lock()
+		for agentID := range snapshots {
+			if agentID != monitorKey {
+				s.latestDirty[agentID] = struct{}{}
+			}
+		}
+		s.latestDirtyMu.Unlock()
+	}
+}
+
+// encodeMonitorLatest 序列化本节点探针上报的监控最新指标，按监控任务分组
+func (s *MetricService) encodeMonitorLatest() ([]byte, error) {
+	items := s.monitorLatestCache.Items()
+	snapshot := make(map[string][]protocol.MonitorData, len(items))
+	for monitorID, item := range items {
+		for stat := range item.Value.Agents.Values() {
+			snapshot[monitorID] = append(snapshot[monitorID], *stat)
+		}
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(snapshot); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// remoteMonitorLatest 读取其他节点共享的监控最新指标，按监控任务分组
+func (s *MetricService) remoteMonitorLatest() map[string][]protocol.MonitorData {
+	if !s.cluster.Distributed() {
+		return nil
+	}
+	if remote, ok := s.remoteMonitorCache.Get(monitorLatestPrefix); ok {
+		return remote
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	snapshots, err := s.cluster.ListLatest(ctx, monitorLatestPrefix)
+	if err != nil {
+		s.logger.Warn("获取其他节点的监控最新指标失败", zap.Error(err))
+		return nil
+	}
+
+	remote := make(map[string][]protocol.MonitorData)
+	for key, data := range snapshots {
+		if key == monitorLatestPrefix+s.cluster.NodeID() {
+			continue
+		}
+		var snapshot map[string][]protocol.MonitorData
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&snapshot); err != nil {
+			s.logger.Warn("解析其他节点的监控最新指标失败", zap.String("key", key), zap.Error(err))
+			continue
+		}
+		for monitorID, stats := range snapshot {
+			remote[monitorID] = append(remote[monitorID], stats...)
+		}
+	}
+	// 快照按 latestSyncInterval 更新，短时间内重复读取不再访问后端
+	s.remoteMonitorCache.Set(monitorLatestPrefix, remote, latestSyncInterval)
+	return remote
+}
+
+// monitorAgentStats 获取监控任务各探针的最新检测结果，多节点部署时合并其他节点探针的结果
+// 探针切换节点后两个节点可能都有该探针的结果，以检测时间较新的为准
+func (s *MetricService) monitorAgentStats(monitorID string) []protocol.MonitorData {
+	stats := make(map[string]protocol.MonitorData)
+	if latestMetrics, ok := s.monitorLatestCache.Get(monitorID); ok {
+		for stat := range latestMetrics.Agents.Values() {
+			stats[stat.AgentId] = *stat
+		}
+	}
+	for _, stat := range s.remoteMonitorLatest()[monitorID] {
+		if local, ok := stats[stat.AgentId]; ok && local.CheckedAt >= stat.CheckedAt {
+			continue
+		}
+		stats[stat.AgentId] = stat
+	}
+
+	result := make([]protocol.MonitorData, 0, len(stats))
+	for _, stat := range stats {
+		result = append(result, stat)
+	}
+	return result
 }
 
 // DeleteAgentMetrics 删除探针的所有指标数据
@@ -371,6 +759,26 @@ func (s *MetricService) buildPromQLQueries(agentID, metricType string, interface
 			Query: fmt.Sprintf(`pika_cpu_usage_percent{agent_id="%s"}`, agentID),
 		}}
 
+	case "cpu_mode":
+		// CPU 各模式占比（按 mode 分组），不含 idle，便于观察 steal 和 iowait
+		queries = []metric.QueryDefinition{{
+			Name:  "mode",
+			Query: fmt.Sprintf(`pika_cpu_mode_percent{agent_id="%s",mode!="idle"}`, agentID),
+		}}
+
+	case "cpu_core":
+		// 每个核心的使用率，以及 steal 和 iowait（按 cpu、mode 分组）
+		queries = []metric.QueryDefinition{
+			{
+				Name:  "usage",
+				Query: fmt.Sprintf(`100 - sum(pika_cpu_core_mode_percent{agent_id="%s",mode=~"idle|iowait"}) by (cpu)`, agentID),
+			},
+			{
+				Name:  "mode",
+				Query: fmt.Sprintf(`pika_cpu_core_mode_percent{agent_id="%s",mode=~"steal|iowait"}`, agentID),
+			},
+		}
+
 	case "memory":
 		queries = []metric.QueryDefinition{{
 			Name:  "usage",
@@ -383,6 +791,13 @@ func (s *MetricService) buildPromQLQueries(agentID, metricType string, interface
 			Query: fmt.Sprintf(`pika_disk_usage_percent{agent_id="%s",mount_point=""}`, agentID),
 		}}
 
+	case "inode":
+		// inode 使用率：按挂载点分组
+		queries = []metric.QueryDefinition{{
+			Name:  "usage",
+			Query: fmt.Sprintf(`pika_disk_inodes_usage_percent{agent_id="%s"} and pika_disk_inodes_total{agent_id="%s"} > 0`, agentID, agentID),
+		}}
+
 	case "network":
 		// 网络流量：上行和下行
 		if interfaceName != "" && interfaceName != "all" {
@@ -429,6 +844,24 @@ func (s *MetricService) buildPromQLQueries(agentID, metricType string, interface
 			{Name: "write", Query: fmt.Sprintf(`pika_disk_write_bytes_rate{agent_id="%s"}`, agentID)},
 		}
 
+	case "disk_health":
+		// 磁盘健康：按设备分组
+		queries = []metric.QueryDefinition{
+			{Name: "temperature", Query: fmt.Sprintf(`pika_disk_health_temperature_celsius{agent_id="%s"}`, agentID)},
+			{Name: "percentage_used", Query: fmt.Sprintf(`pika_disk_health_percentage_used{agent_id="%s"}`, agentID)},
+			{Name: "reallocated_sectors", Query: fmt.Sprintf(`pika_disk_health_reallocated_sectors{agent_id="%s"}`, agentID)},
+			{Name: "pending_sectors", Query: fmt.Sprintf(`pika_disk_health_pending_sectors{agent_id="%s"}`, agentID)},
+			{Name: "media_errors", Query: fmt.Sprintf(`pika_disk_health_media_errors{agent_id="%s"}`, agentID)},
+		}
+
+	case "log":
+		// 日志：每个规则在采样间隔内的匹配行数和因限流未上报的行数
+		window := fmt.Sprintf("%ds", max(int(step.Seconds()), 1))
+		queries = []metric.QueryDefinition{
+			{Name: "matches", Query: fmt.Sprintf(`sum by (rule_id) (increase(pika_log_matches_total{agent_id="%s"}[%s]))`, agentID, window)},
+			{Name: "dropped", Query: fmt.Sprintf(`sum by (rule_id) (increase(pika_log_dropped_total{agent_id="%s"}[%s]))`, agentID, window)},
+		}
+
 	case "gpu":
 		// GPU：利用率和温度（按 GPU 分组）
 		queries = []metric.QueryDefinition{
@@ -449,6 +882,82 @@ func (s *MetricService) buildPromQLQueries(agentID, metricType string, interface
 			Query: fmt.Sprintf(`pika_temperature_celsius{agent_id="%s"}`, agentID),
 		}}
 
+	case "load":
+		// 系统负载
+		queries = []metric.QueryDefinition{
+			{Name: "load1", Query: fmt.Sprintf(`pika_load1{agent_id="%s"}`, agentID)},
+			{Name: "load5", Query: fmt.Sprintf(`pika_load5{agent_id="%s"}`, agentID)},
+			{Name: "load15", Query: fmt.Sprintf(`pika_load15{agent_id="%s"}`, agentID)},
+		}
+
+	case "pressure":
+		// 压力阻塞信息（PSI）
+		queries = []metric.QueryDefinition{
+			{Name: "cpu_some", Query: fmt.Sprintf(`pika_pressure_cpu_some_percent{agent_id="%s"}`, agentID)},
+			{Name: "memory_some", Query: fmt.Sprintf(`pika_pressure_memory_some_percent{agent_id="%s"}`, agentID)},
+			{Name: "memory_full", Query: fmt.Sprintf(`pika_pressure_memory_full_percent{agent_id="%s"}`, agentID)},
+			{Name: "io_some", Query: fmt.Sprintf(`pika_pressure_io_some_percent{agent_id="%s"}`, agentID)},
+			{Name: "io_full", Query: fmt.Sprintf(`pika_pressure_io_full_percent{agent_id="%s"}`, agentID)},
+		}
+
+	case "context_switch":
+		// 上下文切换、中断和进程创建速率
+		queries = []metric.QueryDefinition{
+			{Name: "context_switches", Query: fmt.Sprintf(`pika_context_switches_rate{agent_id="%s"}`, agentID)},
+			{Name: "interrupts", Query: fmt.Sprintf(`pika_interrupts_rate{agent_id="%s"}`, agentID)},
+			{Name: "forks", Query: fmt.Sprintf(`pika_forks_rate{agent_id="%s"}`, agentID)},
+		}
+
+	case "file_descriptor":
+		// 文件描述符和连接跟踪表使用率
+		queries = []metric.QueryDefinition{
+			{
+				Name:  "file_descriptor",
+				Query: fmt.Sprintf(`pika_file_descriptors_allocated{agent_id="%s"} / pika_file_descriptors_max{agent_id="%s"} * 100`, agentID, agentID),
+			},
+			{
+				Name:  "conntrack",
+				Query: fmt.Sprintf(`pika_conntrack_entries{agent_id="%s"} / pika_conntrack_max{agent_id="%s"} * 100`, agentID, agentID),
+			},
+		}
+
+	case "entropy":
+		// 可用熵
+		queries = []metric.QueryDefinition{{
+			Name:  "entropy",
+			Query: fmt.Sprintf(`pika_entropy_available_bits{agent_id="%s"}`, agentID),
+		}}
+
+	case "process":
+		// 进程：关注进程的 CPU 和内存，以及 CPU 占用最高的进程
+		queries = []metric.QueryDefinition{
+			{Name: "watched_cpu", Query: fmt.Sprintf(`pika_process_cpu_percent{agent_id="%s"}`, agentID)},
+			{Name: "watched_rss", Query: fmt.Sprintf(`pika_process_rss_bytes{agent_id="%s"}`, agentID)},
+			{Name: "watched_up", Query: fmt.Sprintf(`pika_process_up{agent_id="%s"}`, agentID)},
+			{Name: "top_cpu", Query: fmt.Sprintf(`topk(10, pika_process_top_cpu_percent{agent_id="%s"})`, agentID)},
+			{Name: "top_rss", Query: fmt.Sprintf(`topk(10, pika_process_top_rss_bytes{agent_id="%s"})`, agentID)},
+		}
+
+	case "container":
+		// 容器：按容器名称分组
+		queries = []metric.QueryDefinition{
+			{Name: "cpu", Query: fmt.Sprintf(`pika_container_cpu_percent{agent_id="%s"}`, agentID)},
+			{Name: "memory", Query: fmt.Sprintf(`pika_container_memory_usage_bytes{agent_id="%s"}`, agentID)},
+			{Name: "memory_percent", Query: fmt.Sprintf(`pika_container_memory_percent{agent_id="%s"}`, agentID)},
+			{Name: "blkio_read", Query: fmt.Sprintf(`pika_container_blkio_read_bytes_rate{agent_id="%s"}`, agentID)},
+			{Name: "blkio_write", Query: fmt.Sprintf(`pika_container_blkio_write_bytes_rate{agent_id="%s"}`, agentID)},
+			{Name: "network_rx", Query: fmt.Sprintf(`pika_container_network_rx_bytes_rate{agent_id="%s"}`, agentID)},
+			{Name: "network_tx", Query: fmt.Sprintf(`pika_container_network_tx_bytes_rate{agent_id="%s"}`, agentID)},
+		}
+
+	case "systemd":
+		// systemd 单元：按单元分组
+		queries = []metric.QueryDefinition{
+			{Name: "active", Query: fmt.Sprintf(`pika_systemd_unit_active{agent_id="%s"}`, agentID)},
+			{Name: "failed", Query: fmt.Sprintf(`pika_systemd_unit_failed{agent_id="%s"}`, agentID)},
+			{Name: "restarts", Query: fmt.Sprintf(`pika_systemd_unit_restarts{agent_id="%s"}`, agentID)},
+		}
+
 	case "monitor":
 		// 监控：响应时间（该探针参与的所有监控任务）
 		queries = []metric.QueryDefinition{{
@@ -639,16 +1148,16 @@ func (s *MetricService) GetMonitorHistory(ctx context.Context, monitorID string,
 // GetMonitorAgentStats 获取监控任务各探针的统计数据（只从缓存读取）
 func (s *MetricService) GetMonitorAgentStats(monitorID string) []protocol.MonitorData {
 	// 从缓存读取监控数据
-	latestMetrics, ok := s.monitorLatestCache.Get(monitorID)
-	if !ok {
+	stats := s.monitorAgentStats(monitorID)
+	if len(stats) == 0 {
 		// 缓存不存在，返回空列表
 		return []protocol.MonitorData{}
 	}
 
 	// 收集所有 agentId
-	agentIds := make([]string, 0, latestMetrics.Agents.Len())
-	for agentId := range latestMetrics.Agents.Keys() {
-		agentIds = append(agentIds, agentId)
+	agentIds := make([]string, 0, len(stats))
+	for _, stat := range stats {
+		agentIds = append(agentIds, stat.AgentId)
 	}
 
 	// 查询 agent 信息
@@ -664,38 +1173,37 @@ func (s *MetricService) GetMonitorAgentStats(monitorID string) []protocol.Monito
 		agentNameMap[agent.ID] = agent.Name
 	}
 
-	// 转换为数组并填充 agent 名称
-	result := make([]protocol.MonitorData, 0, latestMetrics.Agents.Len())
-	for stat := range latestMetrics.Agents.Values() {
-		stat.AgentName = agentNameMap[stat.AgentId] // 填充 agent 名称
-		result = append(result, *stat)
+	// 填充 agent 名称
+	for i := range stats {
+		stats[i].AgentName = agentNameMap[stats[i].AgentId]
 	}
 
-	return result
+	return stats
 }
 
-// GetMonitorStats 获取监控任务的聚合统计数据（只从缓存读取）
-func (s *MetricService) GetMonitorStats(monitorID string) *metric.MonitorStatsResult {
-	// 从缓存读取监控数据
-	latestMetrics, ok := s.monitorLatestCache.Get(monitorID)
-	if !ok {
-		// 缓存不存在，返回默认值
-		return &metric.MonitorStatsResult{
-			Status: "unknown",
+// GetMonitorAgentStatus 获取指定探针对监控任务的最新检测状态（只从缓存读取）
+func (s *MetricService) GetMonitorAgentStatus(monitorID, agentID string) (string, bool) {
+	for _, stat := range s.monitorAgentStats(monitorID) {
+		if stat.AgentId == agentID {
+			return stat.Status, true
 		}
 	}
+	return "", false
+}
 
-	// 聚合各探针数据
-	return s.aggregateMonitorStats(latestMetrics)
+// GetMonitorStats 获取监控任务的聚合统计数据（只从缓存读取）
+func (s *MetricService) GetMonitorStats(monitorID string) *metric.MonitorStatsResult {
+	// 从缓存读取监控数据并聚合各探针数据
+	return s.aggregateMonitorStats(s.monitorAgentStats(monitorID))
 }
 
 // aggregateMonitorStats 聚合各探针的监控数据
-func (s *MetricService) aggregateMonitorStats(latestMetrics *metric.LatestMonitorMetrics) *metric.MonitorStatsResult {
+func (s *MetricService) aggregateMonitorStats(stats []protocol.MonitorData) *metric.MonitorStatsResult {
 	result := &metric.MonitorStatsResult{
 		Status: "unknown",
 	}
 
-	if latestMetrics.Agents.Len() == 0 {
+	if len(stats) == 0 {
 		return result
 	}
 
@@ -708,7 +1216,7 @@ func (s *MetricService) aggregateMonitorStats(latestMetrics *metric.LatestMonito
 	var minCertExpiryTime int64
 	var minCertDaysLeft int
 
-	for stat := range latestMetrics.Agents.Values() {
+	for _, stat := range stats {
 		totalResponseTime += stat.ResponseTime
 
 		// 计算响应时间的最小值和最大值
@@ -742,7 +1250,7 @@ func (s *MetricService) aggregateMonitorStats(latestMetrics *metric.LatestMonito
 		}
 	}
 
-	count := latestMetrics.Agents.Len()
+	count := len(stats)
 	result.AgentCount = count
 	if count > 0 {
 		result.ResponseTime = totalResponseTime / int64(count)
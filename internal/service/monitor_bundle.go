@@ -0,0 +1,587 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/internal/schedule"
+	"github.com/go-orz/orz"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// MonitorBundleVersion 导入导出文件的格式版本
+const MonitorBundleVersion = 1
+
+// 导入时每个监控任务的处理动作
+const (
+	MonitorImportCreate    = "create"
+	MonitorImportUpdate    = "update"
+	MonitorImportDelete    = "delete"
+	MonitorImportUnchanged = "unchanged"
+)
+
+// MonitorBundle 监控任务导入导出文件
+type MonitorBundle struct {
+	Version  int                 `json:"version"`
+	Monitors []MonitorDefinition `json:"monitors"`
+}
+
+// MonitorDefinition 可移植的监控任务定义，以名称作为唯一标识
+type MonitorDefinition struct {
+	Name             string                      `json:"name"`
+	Type             string                      `json:"type"`
+	Target           string                      `json:"target"`
+	Description      string                      `json:"description,omitempty"`
+	Enabled          *bool                       `json:"enabled,omitempty"` // 未填写时默认启用
+	ShowTargetPublic bool                        `json:"showTargetPublic,omitempty"`
+	Visibility       string                      `json:"visibility,omitempty"`
+	Interval         int                         `json:"interval,omitempty"`
+	CronExpr         string                      `json:"cronExpr,omitempty"`
+	Timezone         string                      `json:"timezone,omitempty"`
+	ActiveWindows    []models.MonitorTimeWindow  `json:"activeWindows,omitempty"`
+	Jitter           int                         `json:"jitter,omitempty"`
+	GroupName        string                      `json:"groupName,omitempty"`
+	SLOTarget        float64                     `json:"sloTarget,omitempty"`
+	AgentIds         []string                    `json:"agentIds,omitempty"`
+	Tags             []string                    `json:"tags,omitempty"`
+	DependsOn        []string                    `json:"dependsOn,omitempty"` // 上游监控任务名称
+	HTTPConfig       *protocol.HTTPMonitorConfig `json:"httpConfig,omitempty"`
+	TCPConfig        *protocol.TCPMonitorConfig  `json:"tcpConfig,omitempty"`
+	ICMPConfig       *protocol.ICMPMonitorConfig `json:"icmpConfig,omitempty"`
+}
+
+// MonitorFieldDiff 单个字段的变更
+type MonitorFieldDiff struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// MonitorImportChange 单个监控任务的导入结果
+type MonitorImportChange struct {
+	Name   string             `json:"name"`
+	Action string             `json:"action"` // create, update, delete, unchanged
+	Fields []MonitorFieldDiff `json:"fields,omitempty"`
+}
+
+// MonitorImportResult 导入结果
+type MonitorImportResult struct {
+	DryRun    bool                  `json:"dryRun"`
+	Created   int                   `json:"created"`
+	Updated   int                   `json:"updated"`
+	Deleted   int                   `json:"deleted"`
+	Unchanged int                   `json:"unchanged"`
+	Changes   []MonitorImportChange `json:"changes"`
+}
+
+// ExportMonitors 导出所有监控任务，format 为 yaml 或 json
+func (s *MonitorService) ExportMonitors(ctx context.Context, format string) ([]byte, error) {
+	monitors, err := s.MonitorRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(monitors, func(i, j int) bool {
+		return monitors[i].Name < monitors[j].Name
+	})
+
+	nameByID := make(map[string]string, len(monitors))
+	for _, monitor := range monitors {
+		nameByID[monitor.ID] = monitor.Name
+	}
+
+	bundle := MonitorBundle{
+		Version:  MonitorBundleVersion,
+		Monitors: make([]MonitorDefinition, 0, len(monitors)),
+	}
+	for _, monitor := range monitors {
+		bundle.Monitors = append(bundle.Monitors, toMonitorDefinition(&monitor, nameByID))
+	}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if format == "json" {
+		return data, nil
+	}
+
+	// 经由 JSON 中转，使 YAML 字段名与 JSON 保持一致
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	clearYAMLStyle(&node)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ImportMonitors 按名称幂等导入监控任务，YAML 和 JSON 均可解析
+// dryRun 为 true 时只计算差异不落库，prune 为 true 时删除文件中不存在的监控任务
+func (s *MonitorService) ImportMonitors(ctx context.Context, data []byte, dryRun, prune bool) (*MonitorImportResult, error) {
+	bundle, err := parseMonitorBundle(data)
+	if err != nil {
+		return nil, err
+	}
+
+	monitors, err := s.MonitorRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]*models.MonitorTask, len(monitors))
+	nameByID := make(map[string]string, len(monitors))
+	for i := range monitors {
+		existing[monitors[i].Name] = &monitors[i]
+		nameByID[monitors[i].ID] = monitors[i].Name
+	}
+
+	if err := validateMonitorBundle(bundle, existing, prune); err != nil {
+		return nil, err
+	}
+
+	result := &MonitorImportResult{DryRun: dryRun, Changes: []MonitorImportChange{}}
+	inBundle := make(map[string]bool, len(bundle.Monitors))
+	for _, def := range bundle.Monitors {
+		inBundle[def.Name] = true
+
+		current, ok := existing[def.Name]
+		if !ok {
+			result.Created++
+			result.Changes = append(result.Changes, MonitorImportChange{Name: def.Name, Action: MonitorImportCreate})
+			continue
+		}
+
+		fields := diffMonitorDefinition(toMonitorDefinition(current, nameByID), def)
+		if len(fields) == 0 {
+			result.Unchanged++
+			result.Changes = append(result.Changes, MonitorImportChange{Name: def.Name, Action: MonitorImportUnchanged})
+			continue
+		}
+		result.Updated++
+		result.Changes = append(result.Changes, MonitorImportChange{Name: def.Name, Action: MonitorImportUpdate, Fields: fields})
+	}
+
+	if prune {
+		for _, monitor := range monitors {
+			if inBundle[monitor.Name] {
+				continue
+			}
+			result.Deleted++
+			result.Changes = append(result.Changes, MonitorImportChange{Name: monitor.Name, Action: MonitorImportDelete})
+		}
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	// 在同一事务中写入，失败时不会留下导入了一半的任务
+	var touched []string
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		return s.applyMonitorImport(ctx, bundle, existing, result, &touched)
+	})
+	if err != nil {
+		// 调度器在写入过程中已经更新，按回滚后的数据恢复
+		s.resyncScheduler(ctx, touched)
+		return nil, err
+	}
+	s.logger.Info("导入监控任务完成",
+		zap.Int("created", result.Created),
+		zap.Int("updated", result.Updated),
+		zap.Int("deleted", result.Deleted),
+		zap.Int("unchanged", result.Unchanged))
+	return result, nil
+}
+
+// resyncScheduler 按数据库中的任务重新同步调度器
+func (s *MonitorService) resyncScheduler(ctx context.Context, ids []string) {
+	if s.scheduler == nil {
+		return
+	}
+	for _, id := range ids {
+		task, err := s.MonitorRepo.FindById(ctx, id)
+		if err != nil || !task.Enabled {
+			s.scheduler.RemoveTask(id)
+			continue
+		}
+		if err := s.scheduler.UpdateTask(&task); err != nil {
+			s.logger.Error("恢复监控任务调度失败", zap.String("taskID", id), zap.Error(err))
+		}
+	}
+}
+
+// applyMonitorImport 按差异结果写入监控任务，touched 记录写入过的任务 ID
+// 依赖关系可能引用本次新建的任务，因此先创建任务，再更新并补齐依赖，最后删除多余的任务
+func (s *MonitorService) applyMonitorImport(ctx context.Context, bundle *MonitorBundle, existing map[string]*models.MonitorTask, result *MonitorImportResult, touched *[]string) error {
+	defs := make(map[string]MonitorDefinition, len(bundle.Monitors))
+	for _, def := range bundle.Monitors {
+		defs[def.Name] = def
+	}
+
+	idByName := make(map[string]string, len(existing))
+	for name, monitor := range existing {
+		idByName[name] = monitor.ID
+	}
+	resolveDeps := func(def MonitorDefinition) []string {
+		dependsOn := make([]string, 0, len(def.DependsOn))
+		for _, upstream := range def.DependsOn {
+			dependsOn = append(dependsOn, idByName[upstream])
+		}
+		return dependsOn
+	}
+
+	// 创建新任务
+	var updates []string
+	for _, change := range result.Changes {
+		switch change.Action {
+		case MonitorImportCreate:
+			def := defs[change.Name]
+			task, err := s.CreateMonitor(ctx, def.toRequest(nil))
+			if err != nil {
+				return fmt.Errorf("创建监控任务 %s 失败: %w", def.Name, err)
+			}
+			idByName[def.Name] = task.ID
+			*touched = append(*touched, task.ID)
+			if len(def.DependsOn) > 0 {
+				updates = append(updates, def.Name)
+			}
+		case MonitorImportUpdate:
+			updates = append(updates, change.Name)
+		}
+	}
+
+	// 更新已有任务，并补齐新建任务的依赖
+	for _, name := range updates {
+		def := defs[name]
+		*touched = append(*touched, idByName[name])
+		if _, err := s.UpdateMonitor(ctx, idByName[name], def.toRequest(resolveDeps(def))); err != nil {
+			return fmt.Errorf("更新监控任务 %s 失败: %w", name, err)
+		}
+	}
+
+	// 删除文件中不存在的任务
+	for _, change := range result.Changes {
+		if change.Action != MonitorImportDelete {
+			continue
+		}
+		*touched = append(*touched, idByName[change.Name])
+		if err := s.DeleteMonitor(ctx, idByName[change.Name]); err != nil {
+			return fmt.Errorf("删除监控任务 %s 失败: %w", change.Name, err)
+		}
+	}
+	return nil
+}
+
+// toRequest 转换为创建/更新请求，dependsOn 为已解析的上游监控任务 ID
+func (d MonitorDefinition) toRequest(dependsOn []string) *MonitorTaskRequest {
+	req := &MonitorTaskRequest{
+		Name:             d.Name,
+		Type:             d.Type,
+		Target:           d.Target,
+		Description:      d.Description,
+		Enabled:          d.Enabled == nil || *d.Enabled,
+		ShowTargetPublic: d.ShowTargetPublic,
+		Visibility:       d.Visibility,
+		Interval:         d.Interval,
+		CronExpr:         d.CronExpr,
+		Timezone:         d.Timezone,
+		ActiveWindows:    d.ActiveWindows,
+		Jitter:           d.Jitter,
+		AgentIds:         d.AgentIds,
+		Tags:             d.Tags,
+		SLOTarget:        d.SLOTarget,
+		GroupName:        d.GroupName,
+		DependsOn:        dependsOn,
+	}
+	if d.HTTPConfig != nil {
+		req.HTTPConfig = *d.HTTPConfig
+	}
+	if d.TCPConfig != nil {
+		req.TCPConfig = *d.TCPConfig
+	}
+	if d.ICMPConfig != nil {
+		req.ICMPConfig = *d.ICMPConfig
+	}
+	return req
+}
+
+// toMonitorDefinition 将监控任务转换为可移植定义，只保留与类型匹配的检测配置
+func toMonitorDefinition(task *models.MonitorTask, nameByID map[string]string) MonitorDefinition {
+	enabled := task.Enabled
+	def := MonitorDefinition{
+		Name:             task.Name,
+		Type:             task.Type,
+		Target:           task.Target,
+		Description:      task.Description,
+		Enabled:          &enabled,
+		ShowTargetPublic: task.ShowTargetPublic,
+		Visibility:       task.Visibility,
+		Interval:         task.Interval,
+		CronExpr:         task.CronExpr,
+		Timezone:         task.Timezone,
+		ActiveWindows:    task.ActiveWindows,
+		Jitter:           task.Jitter,
+		GroupName:        task.GroupName,
+		SLOTarget:        task.SLOTarget,
+		AgentIds:         task.AgentIds,
+		Tags:             task.Tags,
+	}
+	for _, id := range task.DependsOn {
+		if name, ok := nameByID[id]; ok {
+			def.DependsOn = append(def.DependsOn, name)
+		}
+	}
+
+	switch task.Type {
+	case "http", "https":
+		config := task.HTTPConfig.Data()
+		def.HTTPConfig = &config
+	case "tcp":
+		config := task.TCPConfig.Data()
+		def.TCPConfig = &config
+	case "icmp", "ping":
+		config := task.ICMPConfig.Data()
+		def.ICMPConfig = &config
+	}
+	return def.normalize()
+}
+
+// normalize 补齐默认值，使导入文件与数据库中的任务可以直接比较
+func (d MonitorDefinition) normalize() MonitorDefinition {
+	d.Name = strings.TrimSpace(d.Name)
+	d.Target = strings.TrimSpace(d.Target)
+	d.CronExpr = strings.TrimSpace(d.CronExpr)
+	d.Timezone = strings.TrimSpace(d.Timezone)
+	d.GroupName = strings.TrimSpace(d.GroupName)
+	if d.Enabled == nil {
+		enabled := true
+		d.Enabled = &enabled
+	}
+	if d.Visibility == "" {
+		d.Visibility = "public"
+	}
+	if d.Interval <= 0 {
+		d.Interval = schedule.DefaultInterval
+	}
+	if len(d.ActiveWindows) == 0 {
+		d.ActiveWindows = nil
+	}
+	if len(d.AgentIds) == 0 {
+		d.AgentIds = nil
+	}
+	if len(d.Tags) == 0 {
+		d.Tags = nil
+	}
+	if len(d.DependsOn) == 0 {
+		d.DependsOn = nil
+	}
+
+	// 只保留与类型匹配的检测配置，未填写时使用零值，与数据库中的任务保持一致
+	httpConfig, tcpConfig, icmpConfig := d.HTTPConfig, d.TCPConfig, d.ICMPConfig
+	d.HTTPConfig, d.TCPConfig, d.ICMPConfig = nil, nil, nil
+	switch d.Type {
+	case "http", "https":
+		d.HTTPConfig = httpConfig
+		if d.HTTPConfig == nil {
+			d.HTTPConfig = &protocol.HTTPMonitorConfig{}
+		}
+	case "tcp":
+		d.TCPConfig = tcpConfig
+		if d.TCPConfig == nil {
+			d.TCPConfig = &protocol.TCPMonitorConfig{}
+		}
+	case "icmp", "ping":
+		d.ICMPConfig = icmpConfig
+		if d.ICMPConfig == nil {
+			d.ICMPConfig = &protocol.ICMPMonitorConfig{}
+		}
+	}
+	return d
+}
+
+func parseMonitorBundle(data []byte) (*MonitorBundle, error) {
+	// YAML 是 JSON 的超集，统一按 YAML 解析后经由 JSON 映射到结构体
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, orz.NewError(400, fmt.Sprintf("解析导入文件失败: %v", err))
+	}
+	jsonData, err := json.Marshal(raw)
+	if err != nil {
+		return nil, orz.NewError(400, fmt.Sprintf("解析导入文件失败: %v", err))
+	}
+
+	var bundle MonitorBundle
+	if err := json.Unmarshal(jsonData, &bundle); err != nil {
+		return nil, orz.NewError(400, fmt.Sprintf("解析导入文件失败: %v", err))
+	}
+	if bundle.Version != 0 && bundle.Version != MonitorBundleVersion {
+		return nil, orz.NewError(400, fmt.Sprintf("不支持的文件版本: %d", bundle.Version))
+	}
+	for i := range bundle.Monitors {
+		bundle.Monitors[i] = bundle.Monitors[i].normalize()
+	}
+	return &bundle, nil
+}
+
+// validateMonitorBundle 校验导入文件，保证落库前即可发现问题
+// prune 时文件外的任务会被删除，因此依赖只能引用文件内的任务
+func validateMonitorBundle(bundle *MonitorBundle, existing map[string]*models.MonitorTask, prune bool) error {
+	names := make(map[string]bool, len(bundle.Monitors))
+	for _, def := range bundle.Monitors {
+		if def.Name == "" {
+			return orz.NewError(400, "监控任务名称不能为空")
+		}
+		if names[def.Name] {
+			return orz.NewError(400, fmt.Sprintf("监控任务名称重复: %s", def.Name))
+		}
+		names[def.Name] = true
+	}
+
+	for _, def := range bundle.Monitors {
+		switch def.Type {
+		case "http", "https", "tcp", "icmp", "ping":
+		default:
+			return orz.NewError(400, fmt.Sprintf("监控任务 %s 的类型无效: %s", def.Name, def.Type))
+		}
+		if def.Target == "" {
+			return orz.NewError(400, fmt.Sprintf("监控任务 %s 的目标地址不能为空", def.Name))
+		}
+		if def.SLOTarget < 0 || def.SLOTarget >= 100 {
+			return orz.NewError(400, fmt.Sprintf("监控任务 %s 的 SLO 目标可用率必须在 0 到 100 之间", def.Name))
+		}
+		task := &models.MonitorTask{
+			Name:          def.Name,
+			Interval:      def.Interval,
+			CronExpr:      def.CronExpr,
+			Timezone:      def.Timezone,
+			ActiveWindows: def.ActiveWindows,
+			Jitter:        def.Jitter,
+		}
+		if _, err := schedule.New(task); err != nil {
+			return orz.NewError(400, fmt.Sprintf("监控任务 %s 的调度配置无效: %v", def.Name, err))
+		}
+		for _, upstream := range def.DependsOn {
+			if upstream == def.Name {
+				return orz.NewError(400, fmt.Sprintf("监控任务 %s 不能依赖自身", def.Name))
+			}
+			if !names[upstream] && (prune || existing[upstream] == nil) {
+				return orz.NewError(400, fmt.Sprintf("监控任务 %s 依赖的 %s 不存在", def.Name, upstream))
+			}
+		}
+	}
+	return validateBundleDependencies(bundle, existing, prune)
+}
+
+// validateBundleDependencies 按导入后的依赖关系检查是否存在环，
+// 文件外保留的任务仍按数据库中的依赖参与检查
+func validateBundleDependencies(bundle *MonitorBundle, existing map[string]*models.MonitorTask, prune bool) error {
+	nameByID := make(map[string]string, len(existing))
+	for name, monitor := range existing {
+		nameByID[monitor.ID] = name
+	}
+	edges := make(map[string][]string, len(existing)+len(bundle.Monitors))
+	if !prune {
+		for name, monitor := range existing {
+			for _, id := range monitor.DependsOn {
+				if upstream, ok := nameByID[id]; ok {
+					edges[name] = append(edges[name], upstream)
+				}
+			}
+		}
+	}
+	for _, def := range bundle.Monitors {
+		edges[def.Name] = def.DependsOn
+	}
+
+	// 深度优先遍历，遇到仍在当前路径上的任务即存在环
+	const (
+		visiting = 1
+		done     = 2
+	)
+	states := make(map[string]int, len(edges))
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		switch states[name] {
+		case visiting:
+			return false
+		case done:
+			return true
+		}
+		states[name] = visiting
+		for _, upstream := range edges[name] {
+			if !visit(upstream) {
+				return false
+			}
+		}
+		states[name] = done
+		return true
+	}
+	for _, def := range bundle.Monitors {
+		if !visit(def.Name) {
+			return orz.NewError(400, fmt.Sprintf("监控任务 %s 的依赖关系存在循环", def.Name))
+		}
+	}
+	return nil
+}
+
+// diffMonitorDefinition 按 JSON 字段比较两个定义，返回发生变化的字段
+func diffMonitorDefinition(current, desired MonitorDefinition) []MonitorFieldDiff {
+	oldFields := definitionFields(current)
+	newFields := definitionFields(desired)
+
+	keys := make(map[string]struct{}, len(oldFields)+len(newFields))
+	for key := range oldFields {
+		keys[key] = struct{}{}
+	}
+	for key := range newFields {
+		keys[key] = struct{}{}
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	var diffs []MonitorFieldDiff
+	for _, key := range sorted {
+		if reflect.DeepEqual(oldFields[key], newFields[key]) {
+			continue
+		}
+		diffs = append(diffs, MonitorFieldDiff{Field: key, Old: oldFields[key], New: newFields[key]})
+	}
+	return diffs
+}
+
+func definitionFields(def MonitorDefinition) map[string]interface{} {
+	fields := make(map[string]interface{})
+	data, err := json.Marshal(def)
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(data, &fields)
+	return fields
+}
+
+// clearYAMLStyle 去掉从 JSON 解析带来的流式风格，输出常规的块状 YAML
+func clearYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearYAMLStyle(child)
+	}
+}
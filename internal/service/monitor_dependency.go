@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/go-orz/orz"
+)
+
+// MonitorDependencyGraph 监控任务依赖关系，用于告警的根因抑制
+type MonitorDependencyGraph struct {
+	monitors      map[string]models.MonitorTask
+	metricService *MetricService
+}
+
+// BuildDependencyGraph 加载所有监控任务构建依赖关系
+func (s *MonitorService) BuildDependencyGraph(ctx context.Context) (*MonitorDependencyGraph, error) {
+	monitors, err := s.MonitorRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &MonitorDependencyGraph{
+		monitors:      make(map[string]models.MonitorTask, len(monitors)),
+		metricService: s.metricService,
+	}
+	for _, monitor := range monitors {
+		graph.monitors[monitor.ID] = monitor
+	}
+	return graph, nil
+}
+
+// DownUpstream 查找处于异常状态的上游监控任务（包含间接依赖）
+// 优先使用同一探针的检测结果判断上游状态，该探针未执行上游任务时使用聚合状态
+func (g *MonitorDependencyGraph) DownUpstream(monitorID, agentID string) (*models.MonitorTask, bool) {
+	visited := map[string]bool{monitorID: true}
+	queue := append([]string{}, g.monitors[monitorID].DependsOn...)
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+
+		upstream, ok := g.monitors[id]
+		if !ok || !upstream.Enabled {
+			continue
+		}
+
+		status, ok := g.metricService.GetMonitorAgentStatus(id, agentID)
+		if !ok {
+			status = g.metricService.GetMonitorStats(id).Status
+		}
+		if status == "down" {
+			return &upstream, true
+		}
+
+		queue = append(queue, upstream.DependsOn...)
+	}
+	return nil, false
+}
+
+// validateDependencies 校验上游依赖存在且不会形成环
+func (s *MonitorService) validateDependencies(ctx context.Context, monitorID string, dependsOn []string) error {
+	if len(dependsOn) == 0 {
+		return nil
+	}
+
+	monitors, err := s.MonitorRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	edges := make(map[string][]string, len(monitors)+1)
+	for _, monitor := range monitors {
+		edges[monitor.ID] = monitor.DependsOn
+	}
+
+	for _, id := range dependsOn {
+		if id == monitorID {
+			return orz.NewError(400, "监控任务不能依赖自身")
+		}
+		if _, ok := edges[id]; !ok {
+			return orz.NewError(400, "依赖的监控任务不存在")
+		}
+	}
+	edges[monitorID] = dependsOn
+
+	// 从新的依赖出发，若能回到自身则存在环
+	visited := make(map[string]bool)
+	stack := append([]string{}, dependsOn...)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == monitorID {
+			return orz.NewError(400, "监控任务依赖关系存在循环")
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		stack = append(stack, edges[id]...)
+	}
+	return nil
+}
+
+// GetAllGroupNames 获取所有监控分组名称
+func (s *MonitorService) GetAllGroupNames(ctx context.Context) ([]string, error) {
+	names, err := s.MonitorRepo.FindAllGroupNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
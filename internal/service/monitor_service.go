@@ -4,12 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/dushixiang/pika/internal/metric"
 	"github.com/dushixiang/pika/internal/models"
 	"github.com/dushixiang/pika/internal/protocol"
 	"github.com/dushixiang/pika/internal/repo"
+	"github.com/dushixiang/pika/internal/schedule"
 	ws "github.com/dushixiang/pika/internal/websocket"
 	"github.com/go-orz/orz"
 	"github.com/google/uuid"
@@ -33,8 +35,8 @@ type MonitorService struct {
 
 // MonitorScheduler 调度器接口（避免循环依赖）
 type MonitorScheduler interface {
-	AddTask(monitorID string, interval int) error
-	UpdateTask(monitorID string, interval int) error
+	AddTask(monitor *models.MonitorTask) error
+	UpdateTask(monitor *models.MonitorTask) error
 	RemoveTask(monitorID string)
 }
 
@@ -64,14 +66,30 @@ type MonitorTaskRequest struct {
 	ShowTargetPublic bool                       `json:"showTargetPublic,omitempty"` // 在公开页面是否显示目标地址
 	Visibility       string                     `json:"visibility,omitempty"`       // 可见性: public-匿名可见, private-登录可见
 	Interval         int                        `json:"interval"`                   // 检测频率（秒）
+	CronExpr         string                     `json:"cronExpr,omitempty"`         // Cron 表达式，设置后忽略检测频率
+	Timezone         string                     `json:"timezone,omitempty"`         // 调度时区
+	ActiveWindows    []models.MonitorTimeWindow `json:"activeWindows,omitempty"`    // 生效时间窗口
+	Jitter           int                        `json:"jitter,omitempty"`           // Cron 调度的错峰范围（秒）
 	HTTPConfig       protocol.HTTPMonitorConfig `json:"httpConfig,omitempty"`
 	TCPConfig        protocol.TCPMonitorConfig  `json:"tcpConfig,omitempty"`
 	ICMPConfig       protocol.ICMPMonitorConfig `json:"icmpConfig,omitempty"`
 	AgentIds         []string                   `json:"agentIds,omitempty"`
 	Tags             []string                   `json:"tags"`
+	SLOTarget        float64                    `json:"sloTarget,omitempty"` // SLO 目标可用率（百分比）
+	GroupName        string                     `json:"groupName,omitempty"` // 分组名称
+	DependsOn        []string                   `json:"dependsOn,omitempty"` // 依赖的上游监控任务 ID
 }
 
 func (s *MonitorService) CreateMonitor(ctx context.Context, req *MonitorTaskRequest) (*models.MonitorTask, error) {
+	if req.SLOTarget < 0 || req.SLOTarget >= 100 {
+		return nil, orz.NewError(400, "SLO 目标可用率必须在 0 到 100 之间")
+	}
+
+	id := uuid.NewString()
+	if err := s.validateDependencies(ctx, id, req.DependsOn); err != nil {
+		return nil, err
+	}
+
 	// 设置默认检测频率
 	interval := req.Interval
 	if interval <= 0 {
@@ -85,7 +103,7 @@ func (s *MonitorService) CreateMonitor(ctx context.Context, req *MonitorTaskRequ
 	}
 
 	task := &models.MonitorTask{
-		ID:               uuid.NewString(),
+		ID:               id,
 		Name:             strings.TrimSpace(req.Name),
 		Type:             req.Type,
 		Target:           strings.TrimSpace(req.Target),
@@ -94,22 +112,33 @@ func (s *MonitorService) CreateMonitor(ctx context.Context, req *MonitorTaskRequ
 		ShowTargetPublic: req.ShowTargetPublic,
 		Visibility:       visibility,
 		Interval:         interval,
+		CronExpr:         strings.TrimSpace(req.CronExpr),
+		Timezone:         strings.TrimSpace(req.Timezone),
+		ActiveWindows:    datatypes.JSONSlice[models.MonitorTimeWindow](req.ActiveWindows),
+		Jitter:           req.Jitter,
 		AgentIds:         datatypes.JSONSlice[string](req.AgentIds),
 		Tags:             datatypes.JSONSlice[string](req.Tags),
 		HTTPConfig:       datatypes.NewJSONType(req.HTTPConfig),
 		TCPConfig:        datatypes.NewJSONType(req.TCPConfig),
 		ICMPConfig:       datatypes.NewJSONType(req.ICMPConfig),
+		SLOTarget:        req.SLOTarget,
+		GroupName:        strings.TrimSpace(req.GroupName),
+		DependsOn:        datatypes.JSONSlice[string](req.DependsOn),
 		CreatedAt:        0,
 		UpdatedAt:        0,
 	}
 
+	if _, err := schedule.New(task); err != nil {
+		return nil, orz.NewError(400, err.Error())
+	}
+
 	if err := s.MonitorRepo.Create(ctx, task); err != nil {
 		return nil, err
 	}
 
 	// 如果任务启用，添加到调度器
 	if task.Enabled && s.scheduler != nil {
-		if err := s.scheduler.AddTask(task.ID, task.Interval); err != nil {
+		if err := s.scheduler.AddTask(task); err != nil {
 			s.logger.Error("添加监控任务到调度器失败",
 				zap.String("taskID", task.ID),
 				zap.Error(err))
@@ -120,14 +149,21 @@ func (s *MonitorService) CreateMonitor(ctx context.Context, req *MonitorTaskRequ
 }
 
 func (s *MonitorService) UpdateMonitor(ctx context.Context, id string, req *MonitorTaskRequest) (*models.MonitorTask, error) {
+	if req.SLOTarget < 0 || req.SLOTarget >= 100 {
+		return nil, orz.NewError(400, "SLO 目标可用率必须在 0 到 100 之间")
+	}
+
 	task, err := s.MonitorRepo.FindById(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.validateDependencies(ctx, id, req.DependsOn); err != nil {
+		return nil, err
+	}
 
 	// 记录旧状态，用于判断是否需要更新调度器
 	oldEnabled := task.Enabled
-	oldInterval := task.Interval
+	oldSpec := schedule.Describe(&task)
 
 	task.Enabled = req.Enabled
 	task.Name = strings.TrimSpace(req.Name)
@@ -144,11 +180,22 @@ func (s *MonitorService) UpdateMonitor(ctx context.Context, id string, req *Moni
 		interval = 60 // 默认 60 秒
 	}
 	task.Interval = interval
+	task.CronExpr = strings.TrimSpace(req.CronExpr)
+	task.Timezone = strings.TrimSpace(req.Timezone)
+	task.ActiveWindows = req.ActiveWindows
+	task.Jitter = req.Jitter
 
 	task.AgentIds = req.AgentIds
 	task.HTTPConfig = datatypes.NewJSONType(req.HTTPConfig)
 	task.TCPConfig = datatypes.NewJSONType(req.TCPConfig)
 	task.ICMPConfig = datatypes.NewJSONType(req.ICMPConfig)
+	task.SLOTarget = req.SLOTarget
+	task.GroupName = strings.TrimSpace(req.GroupName)
+	task.DependsOn = req.DependsOn
+
+	if _, err := schedule.New(&task); err != nil {
+		return nil, orz.NewError(400, err.Error())
+	}
 
 	if err := s.MonitorRepo.Save(ctx, &task); err != nil {
 		return nil, err
@@ -156,10 +203,10 @@ func (s *MonitorService) UpdateMonitor(ctx context.Context, id string, req *Moni
 
 	// 更新调度器
 	if s.scheduler != nil {
-		// 如果从禁用变为启用，或者间隔时间改变
+		// 如果从禁用变为启用，或者调度配置改变
 		if !oldEnabled && task.Enabled {
 			// 添加任务到调度器
-			if err := s.scheduler.AddTask(task.ID, task.Interval); err != nil {
+			if err := s.scheduler.AddTask(&task); err != nil {
 				s.logger.Error("添加监控任务到调度器失败",
 					zap.String("taskID", task.ID),
 					zap.Error(err))
@@ -167,9 +214,9 @@ func (s *MonitorService) UpdateMonitor(ctx context.Context, id string, req *Moni
 		} else if oldEnabled && !task.Enabled {
 			// 从调度器中移除任务
 			s.scheduler.RemoveTask(task.ID)
-		} else if task.Enabled && oldInterval != task.Interval {
-			// 更新任务间隔
-			if err := s.scheduler.UpdateTask(task.ID, task.Interval); err != nil {
+		} else if task.Enabled && oldSpec != schedule.Describe(&task) {
+			// 更新任务调度
+			if err := s.scheduler.UpdateTask(&task); err != nil {
 				s.logger.Error("更新监控任务调度器失败",
 					zap.String("taskID", task.ID),
 					zap.Error(err))
@@ -186,6 +233,10 @@ func (s *MonitorService) DeleteMonitor(ctx context.Context, id string) error {
 		if err := s.MonitorRepo.DeleteById(ctx, id); err != nil {
 			return err
 		}
+		// 从下游任务的依赖中移除，否则下游任务之后无法通过依赖校验
+		if err := s.removeDependency(ctx, id); err != nil {
+			return err
+		}
 		return nil
 	})
 
@@ -201,6 +252,28 @@ func (s *MonitorService) DeleteMonitor(ctx context.Context, id string) error {
 	return nil
 }
 
+// removeDependency 从所有依赖 upstreamID 的监控任务中移除该依赖
+func (s *MonitorService) removeDependency(ctx context.Context, upstreamID string) error {
+	monitors, err := s.MonitorRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, monitor := range monitors {
+		if !slices.Contains(monitor.DependsOn, upstreamID) {
+			continue
+		}
+		dependsOn := slices.DeleteFunc(slices.Clone(monitor.DependsOn), func(id string) bool {
+			return id == upstreamID
+		})
+		if err := s.MonitorRepo.UpdateColumnsById(ctx, monitor.ID, map[string]interface{}{
+			"depends_on": datatypes.JSONSlice[string](dependsOn),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ListByAuth 返回公开展示所需的监控配置和汇总统计
 func (s *MonitorService) ListByAuth(ctx context.Context, isAuthenticated bool) ([]metric.PublicMonitorOverview, error) {
 	// 获取符合权限的监控任务列表
@@ -237,6 +310,7 @@ func (s *MonitorService) buildMonitorOverview(monitor models.MonitorTask, stats
 		Target:           target,
 		ShowTargetPublic: monitor.ShowTargetPublic,
 		Description:      monitor.Description,
+		GroupName:        monitor.GroupName,
 		Enabled:          monitor.Enabled,
 		Interval:         monitor.Interval,
 		AgentCount:       stats.AgentCount,
@@ -307,6 +381,7 @@ func (s *MonitorService) resolveTargetAgents(monitor models.MonitorTask, availab
 }
 
 // sendMonitorConfigToAgent 向指定探针发送监控配置（内部方法）
+// 监控任务是调度器每次到期时下发的一次性检测，不跟踪下发状态
 func (s *MonitorService) sendMonitorConfigToAgent(agentID string, payload protocol.MonitorConfigPayload) error {
 	msgData, err := json.Marshal(protocol.OutboundMessage{
 		Type: protocol.MessageTypeMonitorConfig,
@@ -319,6 +394,31 @@ func (s *MonitorService) sendMonitorConfigToAgent(agentID string, payload protoc
 	return s.wsManager.SendToClient(agentID, msgData)
 }
 
+// buildMonitorPayload 构建单个监控任务的下发配置
+func (s *MonitorService) buildMonitorPayload(monitor models.MonitorTask) protocol.MonitorConfigPayload {
+	item := protocol.MonitorItem{
+		ID:     monitor.ID,
+		Type:   monitor.Type,
+		Target: monitor.Target,
+	}
+
+	if monitor.Type == "http" || monitor.Type == "https" {
+		httpConfig := monitor.HTTPConfig.Data()
+		item.HTTPConfig = &httpConfig
+	} else if monitor.Type == "tcp" {
+		var tcpConfig = monitor.TCPConfig.Data()
+		item.TCPConfig = &tcpConfig
+	} else if monitor.Type == "icmp" || monitor.Type == "ping" {
+		var icmpConfig = monitor.ICMPConfig.Data()
+		item.ICMPConfig = &icmpConfig
+	}
+
+	return protocol.MonitorConfigPayload{
+		Interval: 0,
+		Items:    []protocol.MonitorItem{item},
+	}
+}
+
 // SendMonitorTaskToAgents 向指定探针发送单个监控任务（公开方法）
 func (s *MonitorService) SendMonitorTaskToAgents(ctx context.Context, monitor models.MonitorTask) error {
 	// 实时获取所有在线探针，避免依赖数据库状态
@@ -343,29 +443,21 @@ func (s *MonitorService) SendMonitorTaskToAgents(ctx context.Context, monitor mo
 		return nil
 	}
 
-	// 构建监控项
-	item := protocol.MonitorItem{
-		ID:     monitor.ID,
-		Type:   monitor.Type,
-		Target: monitor.Target,
-	}
-
-	if monitor.Type == "http" || monitor.Type == "https" {
-		httpConfig := monitor.HTTPConfig.Data()
-		item.HTTPConfig = &httpConfig
-	} else if monitor.Type == "tcp" {
-		var tcpConfig = monitor.TCPConfig.Data()
-		item.TCPConfig = &tcpConfig
-	} else if monitor.Type == "icmp" || monitor.Type == "ping" {
-		var icmpConfig = monitor.ICMPConfig.Data()
-		item.ICMPConfig = &icmpConfig
-	}
+	// 跳过不支持该监控类型的探针
+	capability := protocol.MonitorCapability(monitor.Type)
+	targetAgents = slices.DeleteFunc(targetAgents, func(agent models.Agent) bool {
+		if capability == "" || agent.HasCapability(capability) {
+			return false
+		}
+		s.logger.Debug("探针不支持该监控类型，跳过下发",
+			zap.String("taskID", monitor.ID),
+			zap.String("agentID", agent.ID),
+			zap.String("type", monitor.Type))
+		return true
+	})
 
 	// 构建 payload
-	payload := protocol.MonitorConfigPayload{
-		Interval: 0,
-		Items:    []protocol.MonitorItem{item},
-	}
+	payload := s.buildMonitorPayload(monitor)
 
 	// 向每个目标探针发送
 	for _, agent := range targetAgents {
@@ -48,6 +48,11 @@ var alertTypeMetadataMap = map[string]AlertTypeMetadata{
 		ThresholdUnit: "%",
 		ValueUnit:     "%",
 	},
+	"inode": {
+		Name:          "inode告警",
+		ThresholdUnit: "%",
+		ValueUnit:     "%",
+	},
 	"network": {
 		Name:          "网络告警",
 		ThresholdUnit: "MB/s",
@@ -68,6 +73,31 @@ var alertTypeMetadataMap = map[string]AlertTypeMetadata{
 		ThresholdUnit: "秒",
 		ValueUnit:     "秒",
 	},
+	"process": {
+		Name:          "进程告警",
+		ThresholdUnit: "秒",
+		ValueUnit:     "秒",
+	},
+	"systemd": {
+		Name:          "systemd单元告警",
+		ThresholdUnit: "",
+		ValueUnit:     "",
+	},
+	"systemd_restart": {
+		Name:          "systemd单元重启告警",
+		ThresholdUnit: "次",
+		ValueUnit:     "次",
+	},
+	"disk_health": {
+		Name:          "磁盘健康告警",
+		ThresholdUnit: "",
+		ValueUnit:     "",
+	},
+	"log": {
+		Name:          "日志关键字告警",
+		ThresholdUnit: "",
+		ValueUnit:     "行",
+	},
 }
 
 // 告警级别图标映射
@@ -251,6 +251,9 @@ func (s *PropertyService) InitializeDefaultConfigs(ctx context.Context) error {
 					DiskEnabled:          true,
 					DiskThreshold:        85,
 					DiskDuration:         300, // 5分钟
+					InodeEnabled:         true,
+					InodeThreshold:       90,
+					InodeDuration:        300, // 5分钟
 					NetworkEnabled:       false,
 					NetworkThreshold:     100,
 					NetworkDuration:      300, // 5分钟
@@ -260,6 +263,12 @@ func (s *PropertyService) InitializeDefaultConfigs(ctx context.Context) error {
 					ServiceDuration:      300, // 5分钟
 					AgentOfflineEnabled:  true,
 					AgentOfflineDuration: 300, // 5分钟
+					ProcessEnabled:       true,
+					ProcessDuration:      60, // 1分钟
+					SystemdEnabled:       true,
+					SystemdRestartWindow: 600, // 10分钟
+					DiskHealthEnabled:    true,
+					LogEnabled:           true,
 				},
 			},
 		},
@@ -0,0 +1,599 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/pika/internal/metric"
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/repo"
+	"github.com/dushixiang/pika/internal/utils"
+	"github.com/dushixiang/pika/internal/vmclient"
+	"github.com/go-orz/orz"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// SLAWindow SLA 统计的固定时间窗口
+type SLAWindow struct {
+	Name     string
+	Duration time.Duration
+}
+
+// SLAWindows 支持的固定时间窗口
+var SLAWindows = []SLAWindow{
+	{Name: "24h", Duration: 24 * time.Hour},
+	{Name: "7d", Duration: 7 * 24 * time.Hour},
+	{Name: "30d", Duration: 30 * 24 * time.Hour},
+	{Name: "90d", Duration: 90 * 24 * time.Hour},
+}
+
+// slaDownThreshold 采样区间内检测成功比例低于该值时，该区间计为停机
+const slaDownThreshold = 0.5
+
+// SLAService 基于 pika_monitor_status 序列计算可用率、停机事件和 SLO 错误预算
+type SLAService struct {
+	logger      *zap.Logger
+	monitorRepo *repo.MonitorRepo
+	agentRepo   *repo.AgentRepo
+	vmClient    *vmclient.VMClient
+}
+
+func NewSLAService(logger *zap.Logger, db *gorm.DB, vmClient *vmclient.VMClient) *SLAService {
+	return &SLAService{
+		logger:      logger,
+		monitorRepo: repo.NewMonitorRepo(db),
+		agentRepo:   repo.NewAgentRepo(db),
+		vmClient:    vmClient,
+	}
+}
+
+// availability 单条状态序列的可用性计算结果
+type availability struct {
+	hasData   bool
+	uptime    float64 // 可用比例 0~1
+	observed  int64   // 有数据覆盖的时长(毫秒)
+	downtime  int64   // 停机时长(毫秒)
+	incidents []metric.SLAIncident
+}
+
+// slaStep 根据时间范围选择采样步长，步长即停机事件的时间精度
+func slaStep(start, end time.Time) time.Duration {
+	r := end.Sub(start)
+
+	switch {
+	case r <= 24*time.Hour:
+		return time.Minute
+	case r <= 7*24*time.Hour:
+		return 5 * time.Minute
+	case r <= 31*24*time.Hour:
+		return 15 * time.Minute
+	default:
+		return time.Hour
+	}
+}
+
+// GetMonitorSLA 获取监控任务在指定时间范围内的 SLA 统计
+func (s *SLAService) GetMonitorSLA(ctx context.Context, monitorID string, start, end int64) (*metric.MonitorSLA, error) {
+	monitor, err := s.monitorRepo.FindById(ctx, monitorID)
+	if err != nil {
+		return nil, err
+	}
+
+	startTime, endTime := time.UnixMilli(start), time.UnixMilli(end)
+	step := slaStep(startTime, endTime)
+
+	agentSeries, err := s.queryStatusSeries(ctx, fmt.Sprintf(`monitor_id="%s"`, monitorID), "agent_id", startTime, endTime, step)
+	if err != nil {
+		return nil, err
+	}
+
+	overall := calculateAvailability(mergeAnyUp(agentSeries), step, endTime)
+
+	result := &metric.MonitorSLA{
+		MonitorID:     monitor.ID,
+		MonitorName:   monitor.Name,
+		Type:          monitor.Type,
+		Start:         start,
+		End:           end,
+		HasData:       overall.hasData,
+		Uptime:        roundPercent(overall.uptime),
+		Observed:      overall.observed,
+		Downtime:      overall.downtime,
+		IncidentCount: len(overall.incidents),
+		SLO:           buildSLOBudget(monitor.SLOTarget, overall, end-start),
+		Agents:        s.buildAgentUptimes(ctx, agentSeries, step, endTime),
+	}
+
+	windows, err := s.GetMonitorUptimeWindows(ctx, monitorID)
+	if err != nil {
+		s.logger.Warn("查询可用率窗口失败", zap.String("monitorId", monitorID), zap.Error(err))
+	}
+	result.Windows = windows
+
+	return result, nil
+}
+
+// GetMonitorUptimeWindows 获取监控任务在 24h/7d/30d/90d 窗口内的可用率
+func (s *SLAService) GetMonitorUptimeWindows(ctx context.Context, monitorID string) ([]metric.UptimeWindow, error) {
+	now := time.Now()
+	windows := make([]metric.UptimeWindow, 0, len(SLAWindows))
+	for _, w := range SLAWindows {
+		startTime := now.Add(-w.Duration)
+		step := slaStep(startTime, now)
+
+		agentSeries, err := s.queryStatusSeries(ctx, fmt.Sprintf(`monitor_id="%s"`, monitorID), "agent_id", startTime, now, step)
+		if err != nil {
+			return windows, err
+		}
+		a := calculateAvailability(mergeAnyUp(agentSeries), step, now)
+		windows = append(windows, metric.UptimeWindow{
+			Window:  w.Name,
+			Start:   startTime.UnixMilli(),
+			End:     now.UnixMilli(),
+			Uptime:  roundPercent(a.uptime),
+			HasData: a.hasData,
+		})
+	}
+	return windows, nil
+}
+
+// GetMonitorIncidents 获取监控任务在指定时间范围内的停机事件（按开始时间倒序）
+func (s *SLAService) GetMonitorIncidents(ctx context.Context, monitorID string, start, end int64) ([]metric.SLAIncident, error) {
+	monitor, err := s.monitorRepo.FindById(ctx, monitorID)
+	if err != nil {
+		return nil, err
+	}
+
+	startTime, endTime := time.UnixMilli(start), time.UnixMilli(end)
+	step := slaStep(startTime, endTime)
+
+	agentSeries, err := s.queryStatusSeries(ctx, fmt.Sprintf(`monitor_id="%s"`, monitorID), "agent_id", startTime, endTime, step)
+	if err != nil {
+		return nil, err
+	}
+
+	incidents := calculateAvailability(mergeAnyUp(agentSeries), step, endTime).incidents
+	for i := range incidents {
+		incidents[i].MonitorID = monitor.ID
+		incidents[i].MonitorName = monitor.Name
+	}
+	sort.Slice(incidents, func(i, j int) bool {
+		return incidents[i].Start > incidents[j].Start
+	})
+	return incidents, nil
+}
+
+// GetAgentSLA 获取探针上各监控任务的可用率
+func (s *SLAService) GetAgentSLA(ctx context.Context, agentID string, start, end int64) (*metric.AgentSLA, error) {
+	agent, err := s.agentRepo.FindById(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	startTime, endTime := time.UnixMilli(start), time.UnixMilli(end)
+	step := slaStep(startTime, endTime)
+
+	monitorSeries, err := s.queryStatusSeries(ctx, fmt.Sprintf(`agent_id="%s"`, agentID), "monitor_id", startTime, endTime, step)
+	if err != nil {
+		return nil, err
+	}
+
+	monitorIDs := make([]string, 0, len(monitorSeries))
+	for monitorID := range monitorSeries {
+		monitorIDs = append(monitorIDs, monitorID)
+	}
+	monitors, err := s.monitorRepo.FindByIdIn(ctx, monitorIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &metric.AgentSLA{
+		AgentID:   agent.ID,
+		AgentName: agent.Name,
+		Start:     start,
+		End:       end,
+		Monitors:  make([]metric.MonitorUptime, 0, len(monitors)),
+	}
+
+	// 已删除的监控任务不再统计
+	var uptimeSum float64
+	for _, monitor := range monitors {
+		a := calculateAvailability(monitorSeries[monitor.ID], step, endTime)
+		if !a.hasData {
+			continue
+		}
+		uptimeSum += a.uptime
+		result.Monitors = append(result.Monitors, metric.MonitorUptime{
+			MonitorID:     monitor.ID,
+			MonitorName:   monitor.Name,
+			Uptime:        roundPercent(a.uptime),
+			Downtime:      a.downtime,
+			IncidentCount: len(a.incidents),
+		})
+	}
+	if len(result.Monitors) > 0 {
+		result.Uptime = roundPercent(uptimeSum / float64(len(result.Monitors)))
+	}
+
+	sort.Slice(result.Monitors, func(i, j int) bool {
+		return result.Monitors[i].MonitorName < result.Monitors[j].MonitorName
+	})
+
+	return result, nil
+}
+
+// GetMonthlyReport 生成月度 SLA 报告，month 格式为 2006-01，为空时使用当月
+func (s *SLAService) GetMonthlyReport(ctx context.Context, month string) (*metric.SLAReport, error) {
+	now := time.Now()
+	if month == "" {
+		month = now.Format("2006-01")
+	}
+
+	startTime, err := time.ParseInLocation("2006-01", month, time.Local)
+	if err != nil {
+		return nil, orz.NewError(400, "无效的月份格式，应为 YYYY-MM")
+	}
+	if startTime.After(now) {
+		return nil, orz.NewError(400, "报告月份不能晚于当前月份")
+	}
+	endTime := startTime.AddDate(0, 1, 0)
+	if endTime.After(now) {
+		endTime = now
+	}
+	step := slaStep(startTime, endTime)
+
+	monitors, err := s.monitorRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(monitors, func(i, j int) bool {
+		return monitors[i].Name < monitors[j].Name
+	})
+
+	// 一次查询所有监控任务，同一时刻取各探针的最大值（任一探针正常即视为可用）
+	query := fmt.Sprintf(`max by (monitor_id) (avg by (monitor_id, agent_id) (avg_over_time(pika_monitor_status[%ds])))`, int(step.Seconds()))
+	series, err := s.queryRange(ctx, query, "monitor_id", startTime, endTime, step)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &metric.SLAReport{
+		Month:       month,
+		Start:       startTime.UnixMilli(),
+		End:         endTime.UnixMilli(),
+		GeneratedAt: now.UnixMilli(),
+		Items:       make([]metric.SLAReportItem, 0, len(monitors)),
+	}
+
+	var uptimeSum float64
+	var withData int
+	for _, monitor := range monitors {
+		item := buildReportItem(monitor, calculateAvailability(series[monitor.ID], step, endTime), report.End-report.Start)
+
+		if item.HasData {
+			uptimeSum += item.Uptime
+			withData++
+		}
+		report.Summary.TotalIncidents += item.IncidentCount
+		if item.SLO != nil {
+			report.Summary.SLOTotal++
+			if item.SLO.Met {
+				report.Summary.SLOMet++
+			}
+		}
+		report.Items = append(report.Items, item)
+	}
+
+	report.Summary.MonitorCount = len(report.Items)
+	if withData > 0 {
+		report.Summary.AvgUptime = math.Round(uptimeSum/float64(withData)*1000) / 1000
+	}
+
+	return report, nil
+}
+
+// GetDailyUptime 获取监控任务最近 days 天（含今天）每天的可用率，按日期正序
+func (s *SLAService) GetDailyUptime(ctx context.Context, monitorIDs []string, days int) (map[string][]metric.DailyUptime, error) {
+	result := make(map[string][]metric.DailyUptime, len(monitorIDs))
+	if len(monitorIDs) == 0 || days <= 0 {
+		return result, nil
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	startTime := today.AddDate(0, 0, -(days - 1))
+	step := time.Hour
+
+	// 按小时采样，再按本地日期汇总
+	query := fmt.Sprintf(`max by (monitor_id) (avg by (monitor_id, agent_id) (avg_over_time(pika_monitor_status{monitor_id=~"%s"}[1h])))`,
+		labelValueRegex(monitorIDs))
+	series, err := s.queryRange(ctx, query, "monitor_id", startTime, now, step)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, monitorID := range monitorIDs {
+		sums := make([]float64, days)
+		counts := make([]int, days)
+		for _, p := range series[monitorID] {
+			// 采样点代表 (ts-1h, ts] 区间，归属到区间所在的日期
+			t := time.UnixMilli(p.Timestamp - 1)
+			day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+			idx := int(math.Round(day.Sub(startTime).Hours() / 24))
+			if idx < 0 || idx >= days {
+				continue
+			}
+			sums[idx] += math.Max(0, math.Min(1, p.Value))
+			counts[idx]++
+		}
+
+		items := make([]metric.DailyUptime, days)
+		for i := range items {
+			items[i].Date = startTime.AddDate(0, 0, i).Format("2006-01-02")
+			if counts[i] == 0 {
+				continue
+			}
+			ratio := sums[i] / float64(counts[i])
+			items[i].HasData = true
+			items[i].Uptime = roundPercent(ratio)
+			items[i].Downtime = int64(math.Round((1 - ratio) * float64(counts[i]) * float64(step.Milliseconds())))
+		}
+		result[monitorID] = items
+	}
+
+	return result, nil
+}
+
+// WriteReportCSV 将月度报告以 CSV 格式写出
+func (s *SLAService) WriteReportCSV(w io.Writer, report *metric.SLAReport) error {
+	// 写入 UTF-8 BOM，避免 Excel 打开中文乱码
+	if _, err := w.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	header := []string{"监控名称", "类型", "可用率", "停机时长", "停机次数", "最长停机", "SLO目标(%)", "是否达标", "错误预算消耗(%)"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, item := range report.Items {
+		sloTarget, sloMet, budgetConsumed := "-", "-", "-"
+		if item.SLO != nil {
+			sloTarget = strconv.FormatFloat(item.SLO.Target, 'f', -1, 64)
+			sloMet = "否"
+			if item.SLO.Met {
+				sloMet = "是"
+			}
+			budgetConsumed = strconv.FormatFloat(item.SLO.BudgetConsumed, 'f', 2, 64)
+		}
+
+		record := []string{
+			item.MonitorName,
+			item.Type,
+			item.UptimeText,
+			item.DowntimeText,
+			strconv.Itoa(item.IncidentCount),
+			formatDowntime(item.LongestIncident),
+			sloTarget,
+			sloMet,
+			budgetConsumed,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// buildReportItem 构建月度报告中的单个监控任务数据
+func buildReportItem(monitor models.MonitorTask, a availability, periodMs int64) metric.SLAReportItem {
+	item := metric.SLAReportItem{
+		MonitorID:     monitor.ID,
+		MonitorName:   monitor.Name,
+		Type:          monitor.Type,
+		HasData:       a.hasData,
+		Uptime:        roundPercent(a.uptime),
+		UptimeText:    "-",
+		Downtime:      a.downtime,
+		DowntimeText:  formatDowntime(a.downtime),
+		IncidentCount: len(a.incidents),
+		SLO:           buildSLOBudget(monitor.SLOTarget, a, periodMs),
+		Incidents:     a.incidents,
+	}
+	if a.hasData {
+		item.UptimeText = fmt.Sprintf("%.3f%%", item.Uptime)
+	}
+	for i := range item.Incidents {
+		item.Incidents[i].MonitorID = monitor.ID
+		item.Incidents[i].MonitorName = monitor.Name
+		if item.Incidents[i].Duration > item.LongestIncident {
+			item.LongestIncident = item.Incidents[i].Duration
+		}
+	}
+	return item
+}
+
+// buildAgentUptimes 计算各探针的可用率
+func (s *SLAService) buildAgentUptimes(ctx context.Context, agentSeries map[string][]metric.DataPoint, step time.Duration, end time.Time) []metric.AgentUptime {
+	agentIDs := make([]string, 0, len(agentSeries))
+	for agentID := range agentSeries {
+		agentIDs = append(agentIDs, agentID)
+	}
+
+	agentNameMap := make(map[string]string)
+	if len(agentIDs) > 0 {
+		agents, err := s.agentRepo.FindByIdIn(ctx, agentIDs)
+		if err != nil {
+			s.logger.Error("查询 agent 信息失败", zap.Error(err))
+		}
+		for _, agent := range agents {
+			agentNameMap[agent.ID] = agent.Name
+		}
+	}
+
+	result := make([]metric.AgentUptime, 0, len(agentSeries))
+	for agentID, points := range agentSeries {
+		a := calculateAvailability(points, step, end)
+		result = append(result, metric.AgentUptime{
+			AgentID:   agentID,
+			AgentName: agentNameMap[agentID],
+			Uptime:    roundPercent(a.uptime),
+			Downtime:  a.downtime,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].AgentName < result[j].AgentName
+	})
+	return result
+}
+
+// queryStatusSeries 按 groupBy 标签查询每个采样区间内的检测成功比例
+func (s *SLAService) queryStatusSeries(ctx context.Context, selector, groupBy string, start, end time.Time, step time.Duration) (map[string][]metric.DataPoint, error) {
+	query := fmt.Sprintf(`avg by (%s) (avg_over_time(pika_monitor_status{%s}[%ds]))`, groupBy, selector, int(step.Seconds()))
+	return s.queryRange(ctx, query, groupBy, start, end, step)
+}
+
+// queryRange 执行范围查询，返回 groupBy 标签值 -> 按时间排序的数据点
+func (s *SLAService) queryRange(ctx context.Context, query, groupBy string, start, end time.Time, step time.Duration) (map[string][]metric.DataPoint, error) {
+	result, err := s.vmClient.QueryRange(ctx, query, start, end, step)
+	if err != nil {
+		return nil, fmt.Errorf("查询可用状态失败: %w", err)
+	}
+
+	series := make(map[string][]metric.DataPoint)
+	for _, p := range vmclient.ConvertToDataPoints(result) {
+		key := p.Labels[groupBy]
+		series[key] = append(series[key], metric.DataPoint{Timestamp: p.Timestamp, Value: p.Value})
+	}
+	for key := range series {
+		points := series[key]
+		sort.Slice(points, func(i, j int) bool {
+			return points[i].Timestamp < points[j].Timestamp
+		})
+	}
+	return series, nil
+}
+
+// mergeAnyUp 合并多个探针的序列，同一时刻取最大值（任一探针检测正常即视为可用）
+func mergeAnyUp(series map[string][]metric.DataPoint) []metric.DataPoint {
+	merged := make(map[int64]float64)
+	for _, points := range series {
+		for _, p := range points {
+			if v, ok := merged[p.Timestamp]; !ok || p.Value > v {
+				merged[p.Timestamp] = p.Value
+			}
+		}
+	}
+
+	result := make([]metric.DataPoint, 0, len(merged))
+	for ts, v := range merged {
+		result = append(result, metric.DataPoint{Timestamp: ts, Value: v})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Timestamp < result[j].Timestamp
+	})
+	return result
+}
+
+// calculateAvailability 根据按时间排序的采样点计算可用率和停机事件
+// 每个采样点代表 (ts-step, ts] 区间内的检测成功比例；停机时长按比例加权，
+// 停机事件由连续低于 slaDownThreshold 的区间合并而成，数据缺失会截断事件
+func calculateAvailability(points []metric.DataPoint, step time.Duration, end time.Time) availability {
+	var a availability
+	if len(points) == 0 {
+		return a
+	}
+	a.hasData = true
+
+	stepMs := step.Milliseconds()
+	var upSum float64
+	var current *metric.SLAIncident
+	var prevTs int64
+
+	for i, p := range points {
+		v := math.Max(0, math.Min(1, p.Value))
+		upSum += v
+
+		down := v < slaDownThreshold
+		gap := i > 0 && p.Timestamp-prevTs > stepMs
+		if current != nil && (!down || gap) {
+			a.incidents = append(a.incidents, *current)
+			current = nil
+		}
+		if down {
+			if current == nil {
+				current = &metric.SLAIncident{Start: p.Timestamp - stepMs}
+			}
+			current.End = p.Timestamp
+			current.Duration = current.End - current.Start
+		}
+		prevTs = p.Timestamp
+	}
+	if current != nil {
+		// 最后一个采样点仍处于停机且接近查询结束时间，视为进行中
+		current.Ongoing = end.UnixMilli()-current.End <= 2*stepMs
+		a.incidents = append(a.incidents, *current)
+	}
+
+	a.observed = int64(len(points)) * stepMs
+	a.uptime = upSum / float64(len(points))
+	a.downtime = int64(math.Round((1 - a.uptime) * float64(a.observed)))
+	return a
+}
+
+// buildSLOBudget 计算错误预算，target 为 0 表示未设置 SLO
+func buildSLOBudget(target float64, a availability, periodMs int64) *metric.SLOBudget {
+	if target <= 0 || target >= 100 || !a.hasData {
+		return nil
+	}
+
+	allowedRatio := 1 - target/100
+	allowed := int64(allowedRatio * float64(periodMs))
+
+	budget := &metric.SLOBudget{
+		Target:            target,
+		Met:               a.uptime*100 >= target,
+		AllowedDowntime:   allowed,
+		ConsumedDowntime:  a.downtime,
+		RemainingDowntime: allowed - a.downtime,
+		BurnRate:          math.Round((1-a.uptime)/allowedRatio*100) / 100,
+	}
+	if allowed > 0 {
+		budget.BudgetConsumed = math.Round(float64(a.downtime)/float64(allowed)*10000) / 100
+	}
+	return budget
+}
+
+// roundPercent 将 0~1 的比例转换为保留三位小数的百分比
+func roundPercent(ratio float64) float64 {
+	return math.Round(ratio*100*1000) / 1000
+}
+
+// formatDowntime 格式化停机时长
+func formatDowntime(durationMs int64) string {
+	if durationMs <= 0 {
+		return "0秒"
+	}
+	return utils.FormatDuration(durationMs)
+}
+
+// labelValueRegex 将标签值拼接为精确匹配其中任意一个的正则表达式，并转义为 PromQL 双引号字符串的内容
+func labelValueRegex(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, regexp.QuoteMeta(value))
+	}
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(strings.Join(quoted, "|"))
+}
@@ -0,0 +1,656 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/pika/internal/metric"
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/repo"
+	"github.com/go-orz/cache"
+	"github.com/go-orz/orz"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	// statusPageUptimeDays 状态页可用率条展示的天数
+	statusPageUptimeDays = 90
+	// statusPageResolvedIncidentDays 已恢复的故障事件在状态页上保留展示的天数
+	statusPageResolvedIncidentDays = 7
+	// statusPageViewTTL 公开状态页数据的缓存时间，避免每次匿名访问都查询 90 天的可用率
+	statusPageViewTTL = 30 * time.Second
+)
+
+var (
+	statusPageSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+	incidentStatuses = map[string]bool{"investigating": true, "identified": true, "monitoring": true, "resolved": true}
+	incidentImpacts  = map[string]bool{"none": true, "minor": true, "major": true, "critical": true}
+)
+
+// StatusPageService 公开状态页、故障事件和计划维护
+type StatusPageService struct {
+	logger *zap.Logger
+	*orz.Service
+	StatusPageRepo  *repo.StatusPageRepo
+	incidentRepo    *repo.StatusIncidentRepo
+	updateRepo      *repo.StatusIncidentUpdateRepo
+	maintenanceRepo *repo.StatusMaintenanceRepo
+	monitorRepo     *repo.MonitorRepo
+
+	propertyService *PropertyService
+	metricService   *MetricService
+	slaService      *SLAService
+
+	viewCache cache.Cache[string, *metric.StatusPageView] // 状态页ID -> 公开状态页数据，修改状态页、故障事件、计划维护后清除
+}
+
+func NewStatusPageService(logger *zap.Logger, db *gorm.DB, propertyService *PropertyService, metricService *MetricService, slaService *SLAService) *StatusPageService {
+	return &StatusPageService{
+		logger:          logger,
+		Service:         orz.NewService(db),
+		StatusPageRepo:  repo.NewStatusPageRepo(db),
+		incidentRepo:    repo.NewStatusIncidentRepo(db),
+		updateRepo:      repo.NewStatusIncidentUpdateRepo(db),
+		maintenanceRepo: repo.NewStatusMaintenanceRepo(db),
+		monitorRepo:     repo.NewMonitorRepo(db),
+		propertyService: propertyService,
+		metricService:   metricService,
+		slaService:      slaService,
+		viewCache:       cache.New[string, *metric.StatusPageView](time.Minute),
+	}
+}
+
+type StatusPageRequest struct {
+	Slug        string                   `json:"slug"`
+	Title       string                   `json:"title"`
+	Description string                   `json:"description"`
+	Domain      string                   `json:"domain"`
+	Enabled     bool                     `json:"enabled"`
+	Groups      []models.StatusPageGroup `json:"groups"`
+}
+
+type StatusIncidentRequest struct {
+	Title      string   `json:"title"`
+	Status     string   `json:"status"`
+	Impact     string   `json:"impact"`
+	MonitorIds []string `json:"monitorIds"`
+	Message    string   `json:"message"` // 首条更新内容（仅创建时使用）
+}
+
+type StatusIncidentUpdateRequest struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+type StatusMaintenanceRequest struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	MonitorIds  []string `json:"monitorIds"`
+	StartAt     int64    `json:"startAt"`
+	EndAt       int64    `json:"endAt"`
+}
+
+// CreateStatusPage 创建状态页
+func (s *StatusPageService) CreateStatusPage(ctx context.Context, req *StatusPageRequest) (*models.StatusPage, error) {
+	page := &models.StatusPage{ID: uuid.NewString()}
+	if err := s.applyStatusPageRequest(ctx, page, req); err != nil {
+		return nil, err
+	}
+	if err := s.StatusPageRepo.Create(ctx, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// UpdateStatusPage 更新状态页
+func (s *StatusPageService) UpdateStatusPage(ctx context.Context, id string, req *StatusPageRequest) (*models.StatusPage, error) {
+	page, err := s.StatusPageRepo.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyStatusPageRequest(ctx, &page, req); err != nil {
+		return nil, err
+	}
+	if err := s.StatusPageRepo.Save(ctx, &page); err != nil {
+		return nil, err
+	}
+	s.viewCache.Delete(id)
+	return &page, nil
+}
+
+// DeleteStatusPage 删除状态页及其故障事件、计划维护
+func (s *StatusPageService) DeleteStatusPage(ctx context.Context, id string) error {
+	incidents, err := s.incidentRepo.ListByStatusPageID(ctx, id, 0)
+	if err != nil {
+		return err
+	}
+
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		for _, incident := range incidents {
+			if err := s.updateRepo.DeleteByIncidentID(ctx, incident.ID); err != nil {
+				return err
+			}
+		}
+		if err := s.incidentRepo.DeleteByStatusPageID(ctx, id); err != nil {
+			return err
+		}
+		if err := s.maintenanceRepo.DeleteByStatusPageID(ctx, id); err != nil {
+			return err
+		}
+		return s.StatusPageRepo.DeleteById(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+	s.viewCache.Delete(id)
+	return nil
+}
+
+// applyStatusPageRequest 校验并填充状态页配置
+func (s *StatusPageService) applyStatusPageRequest(ctx context.Context, page *models.StatusPage, req *StatusPageRequest) error {
+	slug := strings.ToLower(strings.TrimSpace(req.Slug))
+	if !statusPageSlugPattern.MatchString(slug) {
+		return orz.NewError(400, "slug 只能包含小写字母、数字和连字符")
+	}
+	exists, err := s.StatusPageRepo.ExistsBySlug(ctx, slug, page.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return orz.NewError(400, "slug 已被使用")
+	}
+
+	domain := normalizeHost(req.Domain)
+	if domain != "" {
+		other, err := s.StatusPageRepo.FindEnabledByDomain(ctx, domain)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if other != nil && other.ID != page.ID {
+			return orz.NewError(400, "域名已被其他状态页使用")
+		}
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		return orz.NewError(400, "标题不能为空")
+	}
+
+	groups := make([]models.StatusPageGroup, 0, len(req.Groups))
+	for _, group := range req.Groups {
+		if group.MonitorIds == nil {
+			group.MonitorIds = []string{}
+		}
+		groups = append(groups, group)
+	}
+
+	page.Slug = slug
+	page.Title = title
+	page.Description = req.Description
+	page.Domain = domain
+	page.Enabled = req.Enabled
+	page.Groups = datatypes.NewJSONType(groups)
+	return nil
+}
+
+// ListIncidents 列出状态页的故障事件（包含更新时间线）
+func (s *StatusPageService) ListIncidents(ctx context.Context, statusPageID string, limit int) ([]models.StatusIncident, error) {
+	incidents, err := s.incidentRepo.ListByStatusPageID(ctx, statusPageID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fillIncidentUpdates(ctx, incidents); err != nil {
+		return nil, err
+	}
+	return incidents, nil
+}
+
+// CreateIncident 发布故障事件，message 作为时间线的第一条更新
+func (s *StatusPageService) CreateIncident(ctx context.Context, statusPageID string, req *StatusIncidentRequest) (*models.StatusIncident, error) {
+	if _, err := s.StatusPageRepo.FindById(ctx, statusPageID); err != nil {
+		return nil, err
+	}
+	if err := validateIncident(req.Title, req.Status, req.Impact); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()
+	incident := &models.StatusIncident{
+		ID:           uuid.NewString(),
+		StatusPageID: statusPageID,
+		Title:        strings.TrimSpace(req.Title),
+		Status:       req.Status,
+		Impact:       req.Impact,
+		MonitorIds:   req.MonitorIds,
+	}
+	if incident.Status == "resolved" {
+		incident.ResolvedAt = now
+	}
+
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.incidentRepo.Create(ctx, incident); err != nil {
+			return err
+		}
+		if strings.TrimSpace(req.Message) == "" {
+			return nil
+		}
+		update := models.StatusIncidentUpdate{
+			ID:         uuid.NewString(),
+			IncidentID: incident.ID,
+			Status:     incident.Status,
+			Message:    req.Message,
+		}
+		if err := s.updateRepo.Create(ctx, &update); err != nil {
+			return err
+		}
+		incident.Updates = []models.StatusIncidentUpdate{update}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.viewCache.Delete(statusPageID)
+	return incident, nil
+}
+
+// UpdateIncident 修改故障事件的基本信息
+func (s *StatusPageService) UpdateIncident(ctx context.Context, id string, req *StatusIncidentRequest) (*models.StatusIncident, error) {
+	incident, err := s.incidentRepo.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateIncident(req.Title, req.Status, req.Impact); err != nil {
+		return nil, err
+	}
+
+	incident.Title = strings.TrimSpace(req.Title)
+	incident.Impact = req.Impact
+	incident.MonitorIds = req.MonitorIds
+	setIncidentStatus(&incident, req.Status)
+
+	if err := s.incidentRepo.Save(ctx, &incident); err != nil {
+		return nil, err
+	}
+	s.viewCache.Delete(incident.StatusPageID)
+	return &incident, nil
+}
+
+// AddIncidentUpdate 追加故障事件的进展更新，并同步事件状态
+func (s *StatusPageService) AddIncidentUpdate(ctx context.Context, incidentID string, req *StatusIncidentUpdateRequest) (*models.StatusIncidentUpdate, error) {
+	incident, err := s.incidentRepo.FindById(ctx, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	if !incidentStatuses[req.Status] {
+		return nil, orz.NewError(400, "无效的事件状态")
+	}
+	if strings.TrimSpace(req.Message) == "" {
+		return nil, orz.NewError(400, "更新内容不能为空")
+	}
+
+	update := &models.StatusIncidentUpdate{
+		ID:         uuid.NewString(),
+		IncidentID: incident.ID,
+		Status:     req.Status,
+		Message:    req.Message,
+	}
+	setIncidentStatus(&incident, req.Status)
+
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.updateRepo.Create(ctx, update); err != nil {
+			return err
+		}
+		return s.incidentRepo.Save(ctx, &incident)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.viewCache.Delete(incident.StatusPageID)
+	return update, nil
+}
+
+// DeleteIncident 删除故障事件及其更新记录
+func (s *StatusPageService) DeleteIncident(ctx context.Context, id string) error {
+	incident, err := s.incidentRepo.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.updateRepo.DeleteByIncidentID(ctx, id); err != nil {
+			return err
+		}
+		return s.incidentRepo.DeleteById(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+	s.viewCache.Delete(incident.StatusPageID)
+	return nil
+}
+
+// ListMaintenances 列出状态页的计划维护
+func (s *StatusPageService) ListMaintenances(ctx context.Context, statusPageID string) ([]models.StatusMaintenance, error) {
+	return s.maintenanceRepo.ListByStatusPageID(ctx, statusPageID)
+}
+
+// CreateMaintenance 发布计划维护
+func (s *StatusPageService) CreateMaintenance(ctx context.Context, statusPageID string, req *StatusMaintenanceRequest) (*models.StatusMaintenance, error) {
+	if _, err := s.StatusPageRepo.FindById(ctx, statusPageID); err != nil {
+		return nil, err
+	}
+	if err := validateMaintenance(req); err != nil {
+		return nil, err
+	}
+
+	item := &models.StatusMaintenance{
+		ID:           uuid.NewString(),
+		StatusPageID: statusPageID,
+		Title:        strings.TrimSpace(req.Title),
+		Description:  req.Description,
+		MonitorIds:   req.MonitorIds,
+		StartAt:      req.StartAt,
+		EndAt:        req.EndAt,
+	}
+	if err := s.maintenanceRepo.Create(ctx, item); err != nil {
+		return nil, err
+	}
+	s.viewCache.Delete(statusPageID)
+	return item, nil
+}
+
+// UpdateMaintenance 修改计划维护
+func (s *StatusPageService) UpdateMaintenance(ctx context.Context, id string, req *StatusMaintenanceRequest) (*models.StatusMaintenance, error) {
+	item, err := s.maintenanceRepo.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateMaintenance(req); err != nil {
+		return nil, err
+	}
+
+	item.Title = strings.TrimSpace(req.Title)
+	item.Description = req.Description
+	item.MonitorIds = req.MonitorIds
+	item.StartAt = req.StartAt
+	item.EndAt = req.EndAt
+	if err := s.maintenanceRepo.Save(ctx, &item); err != nil {
+		return nil, err
+	}
+	s.viewCache.Delete(item.StatusPageID)
+	return &item, nil
+}
+
+// DeleteMaintenance 删除计划维护
+func (s *StatusPageService) DeleteMaintenance(ctx context.Context, id string) error {
+	item, err := s.maintenanceRepo.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if err := s.maintenanceRepo.DeleteById(ctx, id); err != nil {
+		return err
+	}
+	s.viewCache.Delete(item.StatusPageID)
+	return nil
+}
+
+// GetPublicViewBySlug 根据 slug 获取公开状态页
+func (s *StatusPageService) GetPublicViewBySlug(ctx context.Context, slug string) (*metric.StatusPageView, error) {
+	page, err := s.StatusPageRepo.FindEnabledBySlug(ctx, strings.ToLower(slug))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, orz.NewError(404, "状态页不存在")
+		}
+		return nil, err
+	}
+	return s.getPublicView(ctx, page)
+}
+
+// GetPublicViewByHost 根据请求 Host 匹配自定义域名的状态页
+func (s *StatusPageService) GetPublicViewByHost(ctx context.Context, host string) (*metric.StatusPageView, error) {
+	domain := normalizeHost(host)
+	if domain == "" {
+		return nil, orz.NewError(404, "状态页不存在")
+	}
+	page, err := s.StatusPageRepo.FindEnabledByDomain(ctx, domain)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, orz.NewError(404, "状态页不存在")
+		}
+		return nil, err
+	}
+	return s.getPublicView(ctx, page)
+}
+
+// getPublicView 获取公开状态页数据，缓存 statusPageViewTTL
+func (s *StatusPageService) getPublicView(ctx context.Context, page *models.StatusPage) (*metric.StatusPageView, error) {
+	if view, ok := s.viewCache.Get(page.ID); ok {
+		return view, nil
+	}
+	view, err := s.buildPublicView(ctx, page)
+	if err != nil {
+		return nil, err
+	}
+	s.viewCache.Set(page.ID, view, statusPageViewTTL)
+	return view, nil
+}
+
+// buildPublicView 组装公开状态页数据，不包含监控目标地址
+func (s *StatusPageService) buildPublicView(ctx context.Context, page *models.StatusPage) (*metric.StatusPageView, error) {
+	now := time.Now()
+
+	view := &metric.StatusPageView{
+		ID:          page.ID,
+		Slug:        page.Slug,
+		Title:       page.Title,
+		Description: page.Description,
+		Groups:      []metric.StatusPageGroupView{},
+		UpdatedAt:   now.UnixMilli(),
+	}
+
+	if systemConfig, err := s.propertyService.GetSystemConfig(ctx); err == nil {
+		view.SystemNameZh = systemConfig.SystemNameZh
+		view.SystemNameEn = systemConfig.SystemNameEn
+		if systemConfig.LogoBase64 != "" {
+			view.Logo = "/api/logo"
+		}
+	}
+
+	incidents, err := s.incidentRepo.ListVisible(ctx, page.ID, now.AddDate(0, 0, -statusPageResolvedIncidentDays).UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fillIncidentUpdates(ctx, incidents); err != nil {
+		return nil, err
+	}
+	view.Incidents = incidents
+
+	maintenances, err := s.maintenanceRepo.ListNotEnded(ctx, page.ID, now.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	view.Maintenances = maintenances
+
+	// 正在维护中的监控任务
+	inMaintenance := make(map[string]bool)
+	for _, m := range maintenances {
+		if m.StartAt <= now.UnixMilli() {
+			for _, id := range m.MonitorIds {
+				inMaintenance[id] = true
+			}
+		}
+	}
+
+	monitorIDs := page.MonitorIds()
+	monitors, err := s.monitorRepo.FindByIdIn(ctx, monitorIDs)
+	if err != nil {
+		return nil, err
+	}
+	monitorMap := make(map[string]models.MonitorTask, len(monitors))
+	visibleIDs := make([]string, 0, len(monitors))
+	for _, monitor := range monitors {
+		// 状态页是匿名访问的，不展示仅登录可见的监控任务
+		if monitor.Enabled && monitor.Visibility != "private" {
+			monitorMap[monitor.ID] = monitor
+			visibleIDs = append(visibleIDs, monitor.ID)
+		}
+	}
+
+	days, err := s.slaService.GetDailyUptime(ctx, visibleIDs, statusPageUptimeDays)
+	if err != nil {
+		// 可用率查询失败不影响状态页展示
+		s.logger.Warn("查询状态页可用率失败", zap.String("statusPageId", page.ID), zap.Error(err))
+		days = map[string][]metric.DailyUptime{}
+	}
+
+	var total, down, maintenance int
+	for _, group := range page.Groups.Data() {
+		groupView := metric.StatusPageGroupView{
+			Name:     group.Name,
+			Monitors: make([]metric.StatusPageMonitorView, 0, len(group.MonitorIds)),
+		}
+		for _, id := range group.MonitorIds {
+			monitor, ok := monitorMap[id]
+			if !ok {
+				continue
+			}
+
+			stats := s.metricService.GetMonitorStats(id)
+			item := metric.StatusPageMonitorView{
+				ID:           monitor.ID,
+				Name:         monitor.Name,
+				Type:         monitor.Type,
+				Status:       stats.Status,
+				ResponseTime: stats.ResponseTime,
+				Days:         days[id],
+			}
+			if inMaintenance[id] {
+				item.Status = "maintenance"
+			}
+			item.Uptime = averageDailyUptime(item.Days)
+
+			total++
+			switch item.Status {
+			case "down":
+				down++
+			case "maintenance":
+				maintenance++
+			}
+			groupView.Monitors = append(groupView.Monitors, item)
+		}
+		view.Groups = append(view.Groups, groupView)
+	}
+
+	view.Status = overallStatus(total, down, maintenance)
+	return view, nil
+}
+
+// fillIncidentUpdates 填充故障事件的更新时间线
+func (s *StatusPageService) fillIncidentUpdates(ctx context.Context, incidents []models.StatusIncident) error {
+	ids := make([]string, 0, len(incidents))
+	for _, incident := range incidents {
+		ids = append(ids, incident.ID)
+	}
+	updates, err := s.updateRepo.ListByIncidentIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	grouped := make(map[string][]models.StatusIncidentUpdate)
+	for _, update := range updates {
+		grouped[update.IncidentID] = append(grouped[update.IncidentID], update)
+	}
+	for i := range incidents {
+		incidents[i].Updates = grouped[incidents[i].ID]
+		if incidents[i].Updates == nil {
+			incidents[i].Updates = []models.StatusIncidentUpdate{}
+		}
+	}
+	return nil
+}
+
+// setIncidentStatus 设置事件状态，并维护恢复时间
+func setIncidentStatus(incident *models.StatusIncident, status string) {
+	if status == "resolved" && incident.Status != "resolved" {
+		incident.ResolvedAt = time.Now().UnixMilli()
+	} else if status != "resolved" {
+		incident.ResolvedAt = 0
+	}
+	incident.Status = status
+}
+
+func validateIncident(title, status, impact string) error {
+	if strings.TrimSpace(title) == "" {
+		return orz.NewError(400, "标题不能为空")
+	}
+	if !incidentStatuses[status] {
+		return orz.NewError(400, "无效的事件状态")
+	}
+	if !incidentImpacts[impact] {
+		return orz.NewError(400, "无效的影响程度")
+	}
+	return nil
+}
+
+func validateMaintenance(req *StatusMaintenanceRequest) error {
+	if strings.TrimSpace(req.Title) == "" {
+		return orz.NewError(400, "标题不能为空")
+	}
+	if req.StartAt <= 0 || req.EndAt <= req.StartAt {
+		return orz.NewError(400, "结束时间必须晚于开始时间")
+	}
+	return nil
+}
+
+// averageDailyUptime 计算有数据的日期的平均可用率
+func averageDailyUptime(days []metric.DailyUptime) float64 {
+	var sum float64
+	var count int
+	for _, day := range days {
+		if day.HasData {
+			sum += day.Uptime
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return roundPercent(sum / float64(count) / 100)
+}
+
+// overallStatus 根据监控状态计算状态页整体状态
+func overallStatus(total, down, maintenance int) string {
+	switch {
+	case total > 0 && down == total:
+		return "major_outage"
+	case down > 0:
+		return "degraded"
+	case maintenance > 0:
+		return "maintenance"
+	default:
+		return "operational"
+	}
+}
+
+// normalizeHost 去掉端口并转为小写
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
@@ -1,14 +1,13 @@
 package service
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"time"
 
 	"github.com/dushixiang/pika/internal/models"
 	"github.com/dushixiang/pika/internal/protocol"
 	"github.com/dushixiang/pika/internal/repo"
-	"github.com/dushixiang/pika/internal/websocket"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"gorm.io/datatypes"
@@ -16,17 +15,20 @@ import (
 )
 
 type TamperService struct {
-	logger     *zap.Logger
-	tamperRepo *repo.TamperRepo
-	wsManager  *websocket.Manager
+	logger             *zap.Logger
+	tamperRepo         *repo.TamperRepo
+	agentConfigService *AgentConfigService
 }
 
-func NewTamperService(logger *zap.Logger, tamperRepo *repo.TamperRepo, wsManager *websocket.Manager) *TamperService {
-	return &TamperService{
-		logger:     logger,
-		tamperRepo: tamperRepo,
-		wsManager:  wsManager,
+func NewTamperService(logger *zap.Logger, tamperRepo *repo.TamperRepo, agentConfigService *AgentConfigService) *TamperService {
+	s := &TamperService{
+		logger:             logger,
+		tamperRepo:         tamperRepo,
+		agentConfigService: agentConfigService,
 	}
+	// 探针重启后不保留保护目录，每次连接都下发完整配置
+	agentConfigService.RegisterKind(ConfigKindTamper, protocol.CapabilityTamper, true, s.buildFullConfig)
+	return s
 }
 
 // GetConfigByAgentID 获取探针的防篡改配置
@@ -129,7 +131,7 @@ func (s *TamperService) calculatePathDiff(oldPaths, newPaths []string) (added, r
 	return added, removed
 }
 
-// sendConfigToAgent 通过WebSocket下发配置到探针（增量更新）
+// sendConfigToAgent 下发配置到探针（增量更新）
 func (s *TamperService) sendConfigToAgent(agentID string, added, removed []string) error {
 	// 如果没有任何变更，不需要下发
 	if len(added) == 0 && len(removed) == 0 {
@@ -142,16 +144,31 @@ func (s *TamperService) sendConfigToAgent(agentID string, added, removed []strin
 		Removed: removed,
 	}
 
-	msgBytes, err := json.Marshal(protocol.OutboundMessage{
+	return s.agentConfigService.Deliver(context.Background(), agentID, ConfigKindTamper, "", ConfigMessage{
 		Type: protocol.MessageTypeTamperProtect,
 		Data: configData,
 	})
+}
+
+// buildFullConfig 生成探针连接时下发的完整配置（所有路径都作为新增）
+func (s *TamperService) buildFullConfig(_ context.Context, agent *models.Agent, _ string) (*ConfigMessage, error) {
+	config, err := s.GetConfigByAgentID(agent.ID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// 通过WebSocket管理器发送到探针
-	return s.wsManager.SendToClient(agentID, msgBytes)
+	paths := []string{}
+	if config != nil && len(config.Paths) > 0 {
+		paths = config.Paths
+	}
+
+	return &ConfigMessage{
+		Type: protocol.MessageTypeTamperProtect,
+		Data: protocol.TamperProtectConfig{
+			Added:   paths,
+			Removed: []string{}, // 初始化时没有需要移除的
+		},
+	}, nil
 }
 
 // DeleteConfig 删除探针的防篡改配置
@@ -0,0 +1,115 @@
+package vmclient
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// BatchWriter 合并多个探针的指标写入，按数量或时间间隔批量导入 VictoriaMetrics
+// 缓冲区有上限，VictoriaMetrics 不可用时丢弃最旧的数据，避免占用过多内存
+type BatchWriter struct {
+	client        *VMClient
+	logger        *zap.Logger
+	batchSize     int
+	flushInterval time.Duration
+	maxBuffered   int
+
+	mu      sync.Mutex
+	pending []Metric
+	dropped int64
+	flushCh chan struct{}
+}
+
+// NewBatchWriter 创建批量写入器
+func NewBatchWriter(client *VMClient, logger *zap.Logger, batchSize int, flushInterval time.Duration, maxBuffered int) *BatchWriter {
+	if maxBuffered < batchSize {
+		maxBuffered = batchSize
+	}
+	return &BatchWriter{
+		client:        client,
+		logger:        logger,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		maxBuffered:   maxBuffered,
+		flushCh:       make(chan struct{}, 1),
+	}
+}
+
+// Write 将指标加入缓冲区，达到批量大小时触发写入，不会阻塞调用方
+func (w *BatchWriter) Write(_ context.Context, metrics []Metric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	w.mu.Lock()
+	w.pending = append(w.pending, metrics...)
+	w.trimLocked()
+	full := len(w.pending) >= w.batchSize
+	w.mu.Unlock()
+
+	if full {
+		select {
+		case w.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Run 定时写入缓冲区中的指标，阻塞直到 ctx 取消，取消时写入剩余数据
+func (w *BatchWriter) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), w.client.writeTimeout)
+			w.flush(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+		case <-w.flushCh:
+		}
+		w.flush(ctx)
+	}
+}
+
+// flush 按批量大小写入所有缓冲的指标，失败时将未写入的指标放回缓冲区等待下次重试
+func (w *BatchWriter) flush(ctx context.Context) {
+	w.mu.Lock()
+	pending := w.pending
+	w.pending = nil
+	w.mu.Unlock()
+
+	for len(pending) > 0 {
+		n := min(len(pending), w.batchSize)
+		if err := w.client.Write(ctx, pending[:n]); err != nil {
+			w.mu.Lock()
+			w.pending = append(pending, w.pending...)
+			w.trimLocked()
+			buffered, dropped := len(w.pending), w.dropped
+			w.mu.Unlock()
+
+			w.logger.Warn("failed to write metrics to VictoriaMetrics, will retry",
+				zap.Int("buffered", buffered),
+				zap.Int64("dropped", dropped),
+				zap.Error(err))
+			return
+		}
+		pending = pending[n:]
+	}
+}
+
+// trimLocked 超过缓冲上限时丢弃最旧的指标
+func (w *BatchWriter) trimLocked() {
+	overflow := len(w.pending) - w.maxBuffered
+	if overflow <= 0 {
+		return
+	}
+	w.pending = append(w.pending[:0:0], w.pending[overflow:]...)
+	w.dropped += int64(overflow)
+}
@@ -0,0 +1,117 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dushixiang/pika/internal/cluster"
+	"go.uber.org/zap"
+)
+
+// 访问集群后端的超时时间，避免数据库缓慢时阻塞连接管理
+const clusterTimeout = 5 * time.Second
+
+// claim 在集群中记录探针连接在本节点
+func (m *Manager) claim(probeID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), clusterTimeout)
+	defer cancel()
+	if err := m.backend.Claim(ctx, probeID); err != nil {
+		m.logger.Warn("failed to claim agent session", zap.String("agentID", probeID), zap.Error(err))
+	}
+}
+
+// release 在集群中移除本节点的探针会话
+func (m *Manager) release(probeID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), clusterTimeout)
+	defer cancel()
+	if err := m.backend.Release(ctx, probeID); err != nil {
+		m.logger.Warn("failed to release agent session", zap.String("agentID", probeID), zap.Error(err))
+	}
+}
+
+// forward 将消息转发给持有探针连接的节点
+func (m *Manager) forward(probeID string, kind string, data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), clusterTimeout)
+	defer cancel()
+
+	nodeID, err := m.backend.Lookup(ctx, probeID)
+	if errors.Is(err, cluster.ErrNoSession) || (err == nil && nodeID == m.backend.NodeID()) {
+		// 会话记录在本节点但连接已不存在，说明会话尚未清理
+		return ErrClientNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return m.backend.Send(ctx, nodeID, cluster.Message{AgentID: probeID, Kind: kind, Data: data})
+}
+
+// handleClusterMessage 处理其他节点转发来的消息
+func (m *Manager) handleClusterMessage(_ context.Context, msg cluster.Message) {
+	client, exists := m.GetClient(msg.AgentID)
+	if !exists {
+		m.logger.Debug("forwarded message dropped, agent not connected", zap.String("agentID", msg.AgentID), zap.String("kind", msg.Kind))
+		return
+	}
+
+	switch msg.Kind {
+	case cluster.KindSend:
+		select {
+		case client.Send <- msg.Data:
+		default:
+			m.logger.Warn("failed to send forwarded message, client may be disconnected", zap.String("agentID", msg.AgentID))
+		}
+	case cluster.KindDisconnect:
+		client.Conn.Close()
+	default:
+		m.logger.Warn("unknown forwarded message kind", zap.String("agentID", msg.AgentID), zap.String("kind", msg.Kind))
+	}
+}
+
+// IsOnline 探针是否连接在任意节点
+func (m *Manager) IsOnline(ctx context.Context, probeID string) bool {
+	if _, exists := m.GetClient(probeID); exists {
+		return true
+	}
+	nodeID, err := m.backend.Lookup(ctx, probeID)
+	return err == nil && nodeID != m.backend.NodeID()
+}
+
+// Disconnect 断开探针连接，连接在其他节点时通知该节点断开
+func (m *Manager) Disconnect(ctx context.Context, probeID string) error {
+	if client, exists := m.GetClient(probeID); exists {
+		return client.Conn.Close()
+	}
+	nodeID, err := m.backend.Lookup(ctx, probeID)
+	if errors.Is(err, cluster.ErrNoSession) || (err == nil && nodeID == m.backend.NodeID()) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return m.backend.Send(ctx, nodeID, cluster.Message{AgentID: probeID, Kind: cluster.KindDisconnect})
+}
+
+// OnlineAgents 获取所有节点的在线探针ID
+func (m *Manager) OnlineAgents(ctx context.Context) ([]string, error) {
+	ids := m.GetAllClients()
+	if !m.backend.Distributed() {
+		return ids, nil
+	}
+
+	sessions, err := m.backend.Sessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]struct{}, len(ids)+len(sessions))
+	for _, id := range ids {
+		seen[id] = struct{}{}
+	}
+	for _, id := range sessions {
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/dushixiang/pika/internal/cluster"
 	"github.com/dushixiang/pika/internal/protocol"
 	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
@@ -31,20 +32,34 @@ type Manager struct {
 	mu         sync.RWMutex       // 读写锁
 	logger     *zap.Logger        // 日志
 	onMessage  MessageHandler     // 消息处理器
+
+	presence       *presence          // 在线探针的最后活跃时间
+	presenceEvents chan PresenceEvent // 上线/离线事件
+	onPresence     PresenceHandler    // 上线/离线事件处理器
+
+	backend cluster.Backend // 多节点共享探针会话的后端
 }
 
 // MessageHandler 消息处理器接口
 type MessageHandler func(ctx context.Context, probeID string, messageType string, data json.RawMessage) error
 
 // NewManager 创建新的WebSocket管理器
-func NewManager(logger *zap.Logger) *Manager {
-	return &Manager{
+func NewManager(logger *zap.Logger, backend cluster.Backend) *Manager {
+	m := &Manager{
 		clients:    make(map[string]*Client),
 		register:   make(chan *Client, 10),
 		unregister: make(chan *Client, 10),
 		broadcast:  make(chan []byte, 256),
 		logger:     logger,
+
+		presence:       newPresence(),
+		presenceEvents: make(chan PresenceEvent, 1024),
+
+		backend: backend,
 	}
+	backend.SetMessageHandler(m.handleClusterMessage)
+	backend.SetSessionSource(m.GetAllClients)
+	return m
 }
 
 // SetMessageHandler 设置消息处理器
@@ -57,6 +72,8 @@ func (m *Manager) Run(ctx context.Context) {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
+	go m.dispatchPresence(ctx)
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -82,10 +99,10 @@ func (m *Manager) Register(client *Client) {
 // registerClient 注册客户端
 func (m *Manager) registerClient(client *Client) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
 
-	// 如果已存在该探针的连接，先关闭旧连接
-	if oldClient, exists := m.clients[client.ID]; exists {
+	// 如果已存在该探针的连接，先关闭旧连接，探针仍然在线，不发布上线事件
+	oldClient, reconnected := m.clients[client.ID]
+	if reconnected {
 		m.logger.Info("agent reconnected, closing old connection", zap.String("agentID", client.ID))
 		oldClient.closeChannel()
 		oldClient.Conn.Close()
@@ -93,18 +110,40 @@ func (m *Manager) registerClient(client *Client) {
 
 	m.clients[client.ID] = client
 	m.logger.Info("agent connected", zap.String("agentID", client.ID), zap.Int("totalClients", len(m.clients)))
+	m.mu.Unlock()
+
+	m.claim(client.ID)
+
+	now := time.Now().UnixMilli()
+	m.presence.touch(client.ID, now)
+	if !reconnected {
+		m.emitPresence(client.ID, true, now)
+	}
 }
 
 // unregisterClient 注销客户端
 func (m *Manager) unregisterClient(client *Client) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
 
-	if _, exists := m.clients[client.ID]; exists {
-		delete(m.clients, client.ID)
-		client.closeChannel()
-		m.logger.Info("agent disconnected", zap.String("agentID", client.ID), zap.Int("totalClients", len(m.clients)))
+	// 重连后旧连接的注销不能移除新连接
+	current, exists := m.clients[client.ID]
+	if !exists || current != client {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.clients, client.ID)
+	client.closeChannel()
+	m.logger.Info("agent disconnected", zap.String("agentID", client.ID), zap.Int("totalClients", len(m.clients)))
+	m.mu.Unlock()
+
+	m.release(client.ID)
+
+	lastSeen, ok := m.LastSeen(client.ID)
+	if !ok {
+		lastSeen = time.Now().UnixMilli()
 	}
+	m.presence.remove(client.ID)
+	m.emitPresence(client.ID, false, lastSeen)
 }
 
 // broadcastMessage 广播消息
@@ -139,10 +178,10 @@ func (m *Manager) checkInactiveClients() {
 	for _, client := range inactiveClients {
 		// 再次检查客户端是否仍然存在（避免竞态条件）
 		m.mu.RLock()
-		_, exists := m.clients[client.ID]
+		current, exists := m.clients[client.ID]
 		m.mu.RUnlock()
 
-		if exists {
+		if exists && current == client {
 			m.logger.Warn("agent inactive timeout, disconnecting", zap.String("agentID", client.ID))
 			client.Conn.Close()
 			m.unregister <- client
@@ -150,14 +189,14 @@ func (m *Manager) checkInactiveClients() {
 	}
 }
 
-// SendToClient 发送消息给指定客户端
+// SendToClient 发送消息给指定客户端，探针连接在其他节点时转发给该节点
 func (m *Manager) SendToClient(probeID string, message []byte) error {
 	m.mu.RLock()
 	client, exists := m.clients[probeID]
 	m.mu.RUnlock()
 
 	if !exists {
-		return ErrClientNotFound
+		return m.forward(probeID, cluster.KindSend, message)
 	}
 
 	select {
@@ -168,7 +207,7 @@ func (m *Manager) SendToClient(probeID string, message []byte) error {
 	}
 }
 
-// GetClient 获取客户端
+// GetClient 获取连接在本节点的客户端
 func (m *Manager) GetClient(probeID string) (*Client, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -176,7 +215,7 @@ func (m *Manager) GetClient(probeID string) (*Client, bool) {
 	return client, exists
 }
 
-// GetAllClients 获取所有客户端ID
+// GetAllClients 获取连接在本节点的所有客户端ID，所有节点的在线探针使用 OnlineAgents
 func (m *Manager) GetAllClients() []string {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -188,7 +227,7 @@ func (m *Manager) GetAllClients() []string {
 	return ids
 }
 
-// ClientCount 获取客户端数量
+// ClientCount 获取连接在本节点的客户端数量
 func (m *Manager) ClientCount() int {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -206,6 +245,7 @@ func (c *Client) ReadPump(ctx context.Context) {
 	c.Conn.SetPongHandler(func(string) error {
 		c.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 		c.LastActive = time.Now()
+		c.Manager.Touch(c.ID)
 		return nil
 	})
 
@@ -219,6 +259,7 @@ func (c *Client) ReadPump(ctx context.Context) {
 		}
 
 		c.LastActive = time.Now()
+		c.Manager.Touch(c.ID)
 
 		// 解析消息
 		var msg protocol.InputMessage
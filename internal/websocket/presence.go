@@ -0,0 +1,95 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// PresenceEvent 探针上线/离线事件
+type PresenceEvent struct {
+	AgentID string
+	Online  bool
+	At      int64 // 事件时间（时间戳毫秒）
+}
+
+// PresenceHandler 上线/离线事件处理器，事件按发生顺序逐个处理
+type PresenceHandler func(ctx context.Context, event PresenceEvent)
+
+// presence 在内存中记录探针的最后活跃时间，避免每条消息都写数据库
+type presence struct {
+	mu       sync.Mutex
+	lastSeen map[string]int64    // probeID -> 最后活跃时间（时间戳毫秒）
+	dirty    map[string]struct{} // 最后活跃时间尚未写入数据库的探针
+}
+
+func newPresence() *presence {
+	return &presence{
+		lastSeen: make(map[string]int64),
+		dirty:    make(map[string]struct{}),
+	}
+}
+
+func (p *presence) touch(probeID string, at int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastSeen[probeID] = at
+	p.dirty[probeID] = struct{}{}
+}
+
+func (p *presence) remove(probeID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.lastSeen, probeID)
+	delete(p.dirty, probeID)
+}
+
+// SetPresenceHandler 设置上线/离线事件处理器
+func (m *Manager) SetPresenceHandler(handler PresenceHandler) {
+	m.onPresence = handler
+}
+
+// Touch 记录探针活跃
+func (m *Manager) Touch(probeID string) {
+	m.presence.touch(probeID, time.Now().UnixMilli())
+}
+
+// LastSeen 获取在线探针的最后活跃时间
+func (m *Manager) LastSeen(probeID string) (int64, bool) {
+	m.presence.mu.Lock()
+	defer m.presence.mu.Unlock()
+	at, ok := m.presence.lastSeen[probeID]
+	return at, ok
+}
+
+// TakeLastSeen 取出上次调用后有变化的最后活跃时间，用于批量写入数据库
+func (m *Manager) TakeLastSeen() map[string]int64 {
+	m.presence.mu.Lock()
+	defer m.presence.mu.Unlock()
+
+	changed := make(map[string]int64, len(m.presence.dirty))
+	for id := range m.presence.dirty {
+		changed[id] = m.presence.lastSeen[id]
+	}
+	clear(m.presence.dirty)
+	return changed
+}
+
+// emitPresence 发布上线/离线事件，由 dispatchPresence 按顺序处理
+func (m *Manager) emitPresence(probeID string, online bool, at int64) {
+	m.presenceEvents <- PresenceEvent{AgentID: probeID, Online: online, At: at}
+}
+
+// dispatchPresence 处理上线/离线事件
+func (m *Manager) dispatchPresence(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-m.presenceEvents:
+			if m.onPresence != nil {
+				m.onPresence(ctx, event)
+			}
+		}
+	}
+}
@@ -4,8 +4,10 @@
 package internal
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/dushixiang/pika/internal/cluster"
 	"github.com/dushixiang/pika/internal/config"
 	"github.com/dushixiang/pika/internal/handler"
 	"github.com/dushixiang/pika/internal/repo"
@@ -22,6 +24,9 @@ func InitializeApp(logger *zap.Logger, db *gorm.DB, cfg *config.AppConfig) (*App
 	wire.Build(
 		// VictoriaMetrics Client
 		provideVMClient,
+		provideVMWriter,
+		// 多节点共享探针会话
+		provideClusterBackend,
 
 		service.NewAccountService,
 		service.NewAgentService,
@@ -37,6 +42,11 @@ func InitializeApp(logger *zap.Logger, db *gorm.DB, cfg *config.AppConfig) (*App
 		service.NewMetricService,
 		service.NewGeoIPService,
 		service.NewDDNSService,
+		service.NewSLAService,
+		service.NewStatusPageService,
+		service.NewLogService,
+		service.NewAgentConfigService,
+		service.NewLiveService,
 
 		service.NewNotifier,
 		// WebSocket Manager
@@ -57,6 +67,10 @@ func InitializeApp(logger *zap.Logger, db *gorm.DB, cfg *config.AppConfig) (*App
 		handler.NewTamperHandler,
 		handler.NewDNSProviderHandler,
 		handler.NewDDNSHandler,
+		handler.NewSLAHandler,
+		handler.NewStatusPageHandler,
+		handler.NewLogHandler,
+		handler.NewLiveHandler,
 
 		// App Components
 		wire.Struct(new(AppComponents), "*"),
@@ -75,18 +89,30 @@ type AppComponents struct {
 	TamperHandler      *handler.TamperHandler
 	DNSProviderHandler *handler.DNSProviderHandler
 	DDNSHandler        *handler.DDNSHandler
-
-	AgentService    *service.AgentService
-	MetricService   *service.MetricService
-	AlertService    *service.AlertService
-	PropertyService *service.PropertyService
-	MonitorService  *service.MonitorService
-	ApiKeyService   *service.ApiKeyService
-	TamperService   *service.TamperService
-	DDNSService     *service.DDNSService
+	SLAHandler         *handler.SLAHandler
+	StatusPageHandler  *handler.StatusPageHandler
+	LogHandler         *handler.LogHandler
+	LiveHandler        *handler.LiveHandler
+
+	AgentService      *service.AgentService
+	MetricService     *service.MetricService
+	AlertService      *service.AlertService
+	PropertyService   *service.PropertyService
+	MonitorService    *service.MonitorService
+	ApiKeyService     *service.ApiKeyService
+	TamperService     *service.TamperService
+	DDNSService       *service.DDNSService
+	SLAService        *service.SLAService
+	StatusPageService *service.StatusPageService
+	LogService        *service.LogService
+
+	AgentConfigService *service.AgentConfigService
+	LiveService        *service.LiveService
 
 	WSManager *websocket.Manager
 	VMClient  *vmclient.VMClient
+	VMWriter  *vmclient.BatchWriter
+	Cluster   cluster.Backend
 }
 
 // provideVMClient 提供 VictoriaMetrics 客户端
@@ -116,3 +142,41 @@ func provideVMClient(cfg *config.AppConfig, logger *zap.Logger) *vmclient.VMClie
 
 	return vmclient.NewVMClient(cfg.VictoriaMetrics.URL, writeTimeout, queryTimeout)
 }
+
+// provideVMWriter 提供 VictoriaMetrics 批量写入器
+func provideVMWriter(cfg *config.AppConfig, vmClient *vmclient.VMClient, logger *zap.Logger) *vmclient.BatchWriter {
+	batchSize, flushInterval, maxBuffered := 5000, time.Second, 500000
+	if vm := cfg.VictoriaMetrics; vm != nil {
+		if vm.BatchSize > 0 {
+			batchSize = vm.BatchSize
+		}
+		if vm.FlushInterval > 0 {
+			flushInterval = time.Duration(vm.FlushInterval) * time.Millisecond
+		}
+		if vm.MaxBuffered > 0 {
+			maxBuffered = vm.MaxBuffered
+		}
+	}
+	return vmclient.NewBatchWriter(vmClient, logger, batchSize, flushInterval, maxBuffered)
+}
+
+// provideClusterBackend 提供多节点共享探针会话的后端
+func provideClusterBackend(cfg *config.AppConfig, db *gorm.DB, logger *zap.Logger) (cluster.Backend, error) {
+	if cfg.Cluster == nil || cfg.Cluster.Backend == "" || cfg.Cluster.Backend == "local" {
+		return cluster.NewLocal(), nil
+	}
+	switch cfg.Cluster.Backend {
+	case "postgres":
+		ttl := time.Duration(cfg.Cluster.SessionTTL) * time.Second
+		backend, err := cluster.NewPostgres(db, logger, cfg.Cluster.NodeID, ttl)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("cluster backend initialized",
+			zap.String("backend", cfg.Cluster.Backend),
+			zap.String("nodeId", backend.NodeID()))
+		return backend, nil
+	default:
+		return nil, fmt.Errorf("unsupported cluster backend: %s", cfg.Cluster.Backend)
+	}
+}
@@ -7,6 +7,8 @@
 package internal
 
 import (
+	"fmt"
+	"github.com/dushixiang/pika/internal/cluster"
 	"github.com/dushixiang/pika/internal/config"
 	"github.com/dushixiang/pika/internal/handler"
 	"github.com/dushixiang/pika/internal/repo"
@@ -31,29 +33,43 @@ func InitializeApp(logger *zap.Logger, db *gorm.DB, cfg *config.AppConfig) (*App
 	propertyService := service.NewPropertyService(logger, db)
 	trafficService := service.NewTrafficService(logger, db)
 	vmClient := provideVMClient(cfg, logger)
-	metricService := service.NewMetricService(logger, db, propertyService, trafficService, vmClient)
+	batchWriter := provideVMWriter(cfg, vmClient, logger)
+	backend, err := provideClusterBackend(cfg, db, logger)
+	if err != nil {
+		return nil, err
+	}
+	metricService := service.NewMetricService(logger, db, propertyService, trafficService, vmClient, batchWriter, backend)
 	geoIPService, err := service.NewGeoIPService(logger, cfg)
 	if err != nil {
 		return nil, err
 	}
-	agentService := service.NewAgentService(logger, db, apiKeyService, metricService, geoIPService)
-	manager := websocket.NewManager(logger)
+	manager := websocket.NewManager(logger, backend)
+	agentService := service.NewAgentService(logger, db, apiKeyService, metricService, geoIPService, cfg, manager)
+	agentConfigService := service.NewAgentConfigService(logger, db, manager)
 	monitorService := service.NewMonitorService(logger, db, metricService, manager)
 	tamperRepo := repo.NewTamperRepo(db)
-	tamperService := service.NewTamperService(logger, tamperRepo, manager)
+	tamperService := service.NewTamperService(logger, tamperRepo, agentConfigService)
 	ddnsConfigRepo := repo.NewDDNSConfigRepo(db)
 	ddnsRecordRepo := repo.NewDDNSRecordRepo(db)
-	ddnsService := service.NewDDNSService(logger, ddnsConfigRepo, ddnsRecordRepo, propertyService, manager)
-	agentHandler := handler.NewAgentHandler(logger, agentService, metricService, monitorService, tamperService, ddnsService, manager)
-	apiKeyHandler := handler.NewApiKeyHandler(logger, apiKeyService)
+	ddnsService := service.NewDDNSService(logger, ddnsConfigRepo, ddnsRecordRepo, propertyService, agentService, manager, agentConfigService)
 	notifier := service.NewNotifier(logger)
 	alertService := service.NewAlertService(logger, db, propertyService, monitorService, notifier)
+	logService := service.NewLogService(logger, db, alertService, agentConfigService)
+	agentHandler := handler.NewAgentHandler(logger, agentService, metricService, monitorService, tamperService, ddnsService, logService, manager, agentConfigService, cfg)
+	apiKeyHandler := handler.NewApiKeyHandler(logger, apiKeyService)
 	alertHandler := handler.NewAlertHandler(logger, alertService)
 	propertyHandler := handler.NewPropertyHandler(logger, propertyService, notifier)
 	monitorHandler := handler.NewMonitorHandler(logger, monitorService, metricService, agentService)
-	tamperHandler := handler.NewTamperHandler(logger, tamperService)
+	tamperHandler := handler.NewTamperHandler(logger, tamperService, agentService)
 	dnsProviderHandler := handler.NewDNSProviderHandler(logger, propertyService)
-	ddnsHandler := handler.NewDDNSHandler(logger, ddnsService)
+	ddnsHandler := handler.NewDDNSHandler(logger, ddnsService, agentService)
+	slaService := service.NewSLAService(logger, db, vmClient)
+	slaHandler := handler.NewSLAHandler(logger, slaService)
+	statusPageService := service.NewStatusPageService(logger, db, propertyService, metricService, slaService)
+	statusPageHandler := handler.NewStatusPageHandler(logger, statusPageService)
+	logHandler := handler.NewLogHandler(logger, logService, agentService)
+	liveService := service.NewLiveService(logger, db, metricService, agentService, alertService, manager, backend)
+	liveHandler := handler.NewLiveHandler(logger, liveService)
 	appComponents := &AppComponents{
 		AccountHandler:     accountHandler,
 		AgentHandler:       agentHandler,
@@ -64,6 +80,10 @@ func InitializeApp(logger *zap.Logger, db *gorm.DB, cfg *config.AppConfig) (*App
 		TamperHandler:      tamperHandler,
 		DNSProviderHandler: dnsProviderHandler,
 		DDNSHandler:        ddnsHandler,
+		SLAHandler:         slaHandler,
+		StatusPageHandler:  statusPageHandler,
+		LogHandler:         logHandler,
+		LiveHandler:        liveHandler,
 		AgentService:       agentService,
 		MetricService:      metricService,
 		AlertService:       alertService,
@@ -72,8 +92,15 @@ func InitializeApp(logger *zap.Logger, db *gorm.DB, cfg *config.AppConfig) (*App
 		ApiKeyService:      apiKeyService,
 		TamperService:      tamperService,
 		DDNSService:        ddnsService,
+		SLAService:         slaService,
+		StatusPageService:  statusPageService,
+		LogService:         logService,
+		AgentConfigService: agentConfigService,
+		LiveService:        liveService,
 		WSManager:          manager,
 		VMClient:           vmClient,
+		VMWriter:           batchWriter,
+		Cluster:            backend,
 	}
 	return appComponents, nil
 }
@@ -91,18 +118,30 @@ type AppComponents struct {
 	TamperHandler      *handler.TamperHandler
 	DNSProviderHandler *handler.DNSProviderHandler
 	DDNSHandler        *handler.DDNSHandler
-
-	AgentService    *service.AgentService
-	MetricService   *service.MetricService
-	AlertService    *service.AlertService
-	PropertyService *service.PropertyService
-	MonitorService  *service.MonitorService
-	ApiKeyService   *service.ApiKeyService
-	TamperService   *service.TamperService
-	DDNSService     *service.DDNSService
+	SLAHandler         *handler.SLAHandler
+	StatusPageHandler  *handler.StatusPageHandler
+	LogHandler         *handler.LogHandler
+	LiveHandler        *handler.LiveHandler
+
+	AgentService      *service.AgentService
+	MetricService     *service.MetricService
+	AlertService      *service.AlertService
+	PropertyService   *service.PropertyService
+	MonitorService    *service.MonitorService
+	ApiKeyService     *service.ApiKeyService
+	TamperService     *service.TamperService
+	DDNSService       *service.DDNSService
+	SLAService        *service.SLAService
+	StatusPageService *service.StatusPageService
+	LogService        *service.LogService
+
+	AgentConfigService *service.AgentConfigService
+	LiveService        *service.LiveService
 
 	WSManager *websocket.Manager
 	VMClient  *vmclient.VMClient
+	VMWriter  *vmclient.BatchWriter
+	Cluster   cluster.Backend
 }
 
 // provideVMClient 提供 VictoriaMetrics 客户端
@@ -128,3 +167,39 @@ func provideVMClient(cfg *config.AppConfig, logger *zap.Logger) *vmclient.VMClie
 
 	return vmclient.NewVMClient(cfg.VictoriaMetrics.URL, writeTimeout, queryTimeout)
 }
+
+// provideVMWriter 提供 VictoriaMetrics 批量写入器
+func provideVMWriter(cfg *config.AppConfig, vmClient *vmclient.VMClient, logger *zap.Logger) *vmclient.BatchWriter {
+	batchSize, flushInterval, maxBuffered := 5000, time.Second, 500000
+	if vm := cfg.VictoriaMetrics; vm != nil {
+		if vm.BatchSize > 0 {
+			batchSize = vm.BatchSize
+		}
+		if vm.FlushInterval > 0 {
+			flushInterval = time.Duration(vm.FlushInterval) * time.Millisecond
+		}
+		if vm.MaxBuffered > 0 {
+			maxBuffered = vm.MaxBuffered
+		}
+	}
+	return vmclient.NewBatchWriter(vmClient, logger, batchSize, flushInterval, maxBuffered)
+}
+
+// provideClusterBackend 提供多节点共享探针会话的后端
+func provideClusterBackend(cfg *config.AppConfig, db *gorm.DB, logger *zap.Logger) (cluster.Backend, error) {
+	if cfg.Cluster == nil || cfg.Cluster.Backend == "" || cfg.Cluster.Backend == "local" {
+		return cluster.NewLocal(), nil
+	}
+	switch cfg.Cluster.Backend {
+	case "postgres":
+		ttl := time.Duration(cfg.Cluster.SessionTTL) * time.Second
+		backend, err := cluster.NewPostgres(db, logger, cfg.Cluster.NodeID, ttl)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("cluster backend initialized", zap.String("backend", cfg.Cluster.Backend), zap.String("nodeId", backend.NodeID()))
+		return backend, nil
+	default:
+		return nil, fmt.Errorf("unsupported cluster backend: %s", cfg.Cluster.Backend)
+	}
+}
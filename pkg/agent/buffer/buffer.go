@@ -0,0 +1,308 @@
+package buffer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+const (
+	// 缓存拆分为多个段文件，超出上限时按段丢弃最早的数据
+	segmentCount = 10
+	segmentExt   = ".jsonl"
+	// 单行的最大长度，超过时只跳过该行
+	maxLineSize = 16 * 1024 * 1024
+)
+
+// Buffer 断线期间的指标缓存，以 JSON Lines 格式写入磁盘上的段文件，每行为一次采集产生的指标
+// 总大小超过上限时删除最早的段，探针重启后未补发的数据仍然保留
+type Buffer struct {
+	dir        string
+	maxSize    int64
+	segmentMax int64
+
+	mu   sync.Mutex
+	file *os.File // 正在写入的段，为空时下次写入创建新段
+	size int64    // 正在写入的段的大小
+	seq  uint64   // 最后一个段的序号
+}
+
+// Open 打开缓存目录，maxSize 为缓存占用的最大磁盘空间（字节）
+func Open(dir string, maxSize int64) (*Buffer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建缓存目录失败: %w", err)
+	}
+
+	b := &Buffer{
+		dir:        dir,
+		maxSize:    maxSize,
+		segmentMax: max(maxSize/segmentCount, 1),
+	}
+	segments, err := b.segments()
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) > 0 {
+		b.seq = segments[len(segments)-1]
+	}
+	return b, nil
+}
+
+// Append 写入一次采集产生的指标
+func (b *Buffer) Append(samples []protocol.MetricsPayload) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	line, err := json.Marshal(samples)
+	if err != nil {
+		return fmt.Errorf("序列化指标失败: %w", err)
+	}
+	line = append(line, '\n')
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.file == nil || (b.size > 0 && b.size+int64(len(line)) > b.segmentMax) {
+		if err := b.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := b.file.Write(line)
+	b.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("写入缓存失败: %w", err)
+	}
+	return nil
+}
+
+// Drain 按写入顺序读取缓存的指标，每次最多 batchSize 条交给 send 发送
+// 每个段的数据全部发送成功后删除该段，send 返回错误时停止，未发送的段保留到下次补发
+// 返回成功发送的指标数量
+func (b *Buffer) Drain(batchSize int, send func(samples []protocol.MetricsPayload) error) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// 之后的写入使用新的段，避免读到写了一半的行
+	b.closeFile()
+
+	segments, err := b.segments()
+	if err != nil {
+		return 0, err
+	}
+
+	var sent int
+	for _, seq := range segments {
+		n, err := b.drainSegment(seq, batchSize, send)
+		sent += n
+		if err != nil {
+			return sent, err
+		}
+		if err := os.Remove(b.segmentPath(seq)); err != nil && !os.IsNotExist(err) {
+			return sent, fmt.Errorf("删除缓存文件失败: %w", err)
+		}
+	}
+	return sent, nil
+}
+
+// Clear 删除所有缓存的指标
+func (b *Buffer) Clear() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closeFile()
+	segments, err := b.segments()
+	if err != nil {
+		return err
+	}
+	for _, seq := range segments {
+		if err := os.Remove(b.segmentPath(seq)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("删除缓存文件失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close 关闭正在写入的段
+func (b *Buffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closeFile()
+	return nil
+}
+
+func (b *Buffer) drainSegment(seq uint64, batchSize int, send func(samples []protocol.MetricsPayload) error) (int, error) {
+	file, err := os.Open(b.segmentPath(seq))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("读取缓存文件失败: %w", err)
+	}
+	defer file.Close()
+
+	var (
+		sent    int
+		pending []protocol.MetricsPayload
+	)
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		if err := send(pending); err != nil {
+			return err
+		}
+		sent += len(pending)
+		pending = nil
+		return nil
+	}
+
+	reader := bufio.NewReaderSize(file, 64*1024)
+	for {
+		line, err := readLine(reader)
+		if err != nil && err != io.EOF {
+			return sent, fmt.Errorf("读取缓存文件失败: %w", err)
+		}
+		// 超长的行读取为空，探针异常退出时最后一行可能不完整，解析失败时跳过该行
+		if samples, parseErr := parseLine(line); parseErr == nil {
+			pending = append(pending, samples...)
+		}
+		if len(pending) >= batchSize {
+			if err := flush(); err != nil {
+				return sent, err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	return sent, flush()
+}
+
+// readLine 读取一行（不含换行符），超过 maxLineSize 的行只丢弃该行，返回空
+func readLine(reader *bufio.Reader) ([]byte, error) {
+	var (
+		line    []byte
+		tooLong bool
+	)
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		// chunk 包含换行符
+		if !tooLong && len(line)+len(chunk) > maxLineSize+1 {
+			tooLong = true
+			line = nil
+		}
+		if !tooLong {
+			line = append(line, chunk...)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		return bytes.TrimSuffix(line, []byte{'\n'}), err
+	}
+}
+
+// parseLine 解析一次采集产生的指标
+func parseLine(line []byte) ([]protocol.MetricsPayload, error) {
+	// 读取时保留原始数据，避免数值经过 interface{} 转换丢失精度
+	var samples []struct {
+		Type      protocol.MetricType `json:"type"`
+		Data      json.RawMessage     `json:"data"`
+		Timestamp int64               `json:"timestamp"`
+	}
+	if err := json.Unmarshal(line, &samples); err != nil {
+		return nil, err
+	}
+	payloads := make([]protocol.MetricsPayload, 0, len(samples))
+	for _, sample := range samples {
+		payloads = append(payloads, protocol.MetricsPayload{
+			Type:      sample.Type,
+			Data:      sample.Data,
+			Timestamp: sample.Timestamp,
+		})
+	}
+	return payloads, nil
+}
+
+// rotate 创建新的段并删除超出大小上限的最早的段
+func (b *Buffer) rotate() error {
+	b.closeFile()
+
+	b.seq++
+	file, err := os.OpenFile(b.segmentPath(b.seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("创建缓存文件失败: %w", err)
+	}
+	b.file = file
+	b.size = 0
+
+	segments, err := b.segments()
+	if err != nil {
+		return err
+	}
+	var total int64
+	sizes := make([]int64, len(segments))
+	for i, seq := range segments {
+		if info, err := os.Stat(b.segmentPath(seq)); err == nil {
+			sizes[i] = info.Size()
+			total += info.Size()
+		}
+	}
+	// 新段加入后总大小仍可能达到上限，为新段预留一个段的空间
+	for i, seq := range segments {
+		if seq == b.seq || total+b.segmentMax <= b.maxSize {
+			break
+		}
+		if err := os.Remove(b.segmentPath(seq)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("删除缓存文件失败: %w", err)
+		}
+		total -= sizes[i]
+	}
+	return nil
+}
+
+func (b *Buffer) closeFile() {
+	if b.file == nil {
+		return
+	}
+	_ = b.file.Close()
+	b.file = nil
+	b.size = 0
+}
+
+// segments 返回按序号升序排列的段
+func (b *Buffer) segments() ([]uint64, error) {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取缓存目录失败: %w", err)
+	}
+	var segments []uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, seq)
+	}
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i] < segments[j]
+	})
+	return segments, nil
+}
+
+func (b *Buffer) segmentPath(seq uint64) string {
+	return filepath.Join(b.dir, fmt.Sprintf("%020d%s", seq, segmentExt))
+}
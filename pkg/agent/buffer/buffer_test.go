@@ -0,0 +1,185 @@
+package buffer
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+// appendRange 每次采集写入一条指标，采集时间为 from 到 to
+func appendRange(t *testing.T, b *Buffer, from, to int64) {
+	t.Helper()
+	for ts := from; ts <= to; ts++ {
+		if err := b.Append([]protocol.MetricsPayload{{Type: protocol.MetricTypeCPU, Data: map[string]int64{"v": ts}, Timestamp: ts}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// drainAll 读取所有缓存的指标，返回采集时间和每批的数量
+func drainAll(t *testing.T, b *Buffer, batchSize int) ([]int64, []int) {
+	t.Helper()
+	var (
+		timestamps []int64
+		batches    []int
+	)
+	sent, err := b.Drain(batchSize, func(samples []protocol.MetricsPayload) error {
+		batches = append(batches, len(samples))
+		for _, sample := range samples {
+			timestamps = append(timestamps, sample.Timestamp)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("读取缓存失败: %v", err)
+	}
+	if sent != len(timestamps) {
+		t.Errorf("返回的数量为 %d，实际发送 %d", sent, len(timestamps))
+	}
+	return timestamps, batches
+}
+
+func TestAppendDrain(t *testing.T) {
+	b, err := Open(t.TempDir(), 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	appendRange(t, b, 1, 5)
+	timestamps, batches := drainAll(t, b, 2)
+	if !reflect.DeepEqual(timestamps, []int64{1, 2, 3, 4, 5}) {
+		t.Errorf("读取的指标为 %v", timestamps)
+	}
+	if !reflect.DeepEqual(batches, []int{2, 2, 1}) {
+		t.Errorf("每批数量为 %v，应为 [2 2 1]", batches)
+	}
+	if segments, _ := b.segments(); len(segments) != 0 {
+		t.Errorf("发送成功后应删除所有段: %v", segments)
+	}
+
+	// 读取后继续写入
+	appendRange(t, b, 6, 7)
+	if timestamps, _ := drainAll(t, b, 10); !reflect.DeepEqual(timestamps, []int64{6, 7}) {
+		t.Errorf("读取的指标为 %v", timestamps)
+	}
+}
+
+func TestDrainSendError(t *testing.T) {
+	b, err := Open(t.TempDir(), 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	appendRange(t, b, 1, 3)
+	sendErr := errors.New("connection closed")
+	calls := 0
+	sent, err := b.Drain(2, func(samples []protocol.MetricsPayload) error {
+		calls++
+		if calls == 2 {
+			return sendErr
+		}
+		return nil
+	})
+	if !errors.Is(err, sendErr) || sent != 2 {
+		t.Fatalf("应返回发送错误和已发送的数量，实际为 %d %v", sent, err)
+	}
+
+	// 未全部发送的段保留到下次补发
+	if timestamps, _ := drainAll(t, b, 10); !reflect.DeepEqual(timestamps, []int64{1, 2, 3}) {
+		t.Errorf("读取的指标为 %v", timestamps)
+	}
+}
+
+func TestRotateSizeLimit(t *testing.T) {
+	dir := t.TempDir()
+	b, err := Open(dir, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	appendRange(t, b, 1, 100)
+	segments, err := b.segments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total int64
+	for _, seq := range segments {
+		info, err := os.Stat(b.segmentPath(seq))
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += info.Size()
+	}
+	if total > 1000 {
+		t.Errorf("缓存占用 %d 字节，超过上限 1000", total)
+	}
+	if len(segments) > segmentCount {
+		t.Errorf("段数量为 %d，超过 %d", len(segments), segmentCount)
+	}
+
+	// 重新打开后继续在最后一个段之后写入
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b, err = Open(dir, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	appendRange(t, b, 101, 101)
+
+	// 丢弃最早的数据，保留的数据连续且按写入顺序读取
+	timestamps, _ := drainAll(t, b, 10)
+	if len(timestamps) == 0 || len(timestamps) >= 101 {
+		t.Fatalf("应只保留最近的部分指标，实际为 %d 条", len(timestamps))
+	}
+	for i, ts := range timestamps {
+		if want := int64(101 - len(timestamps) + 1 + i); ts != want {
+			t.Fatalf("读取的指标为 %v，应为连续的最近数据", timestamps)
+		}
+	}
+}
+
+func TestDrainSkipsBrokenLines(t *testing.T) {
+	dir := t.TempDir()
+	b, err := Open(dir, 64*1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendRange(t, b, 1, 1)
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 在段中写入超长的行和随后的正常数据，最后一行不完整（探针异常退出）
+	segments, _ := b.segments()
+	file, err := os.OpenFile(b.segmentPath(segments[0]), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	long := append(bytes.Repeat([]byte{'x'}, maxLineSize+10), '\n')
+	valid := []byte(`[{"type":"cpu","data":{"v":2},"timestamp":2}]` + "\n")
+	for _, data := range [][]byte{long, valid, []byte("\n"), []byte(`[{"type":"cpu","data":`)} {
+		if _, err := file.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file.Close()
+
+	b, err = Open(dir, 64*1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	appendRange(t, b, 3, 3)
+
+	if timestamps, _ := drainAll(t, b, 10); !reflect.DeepEqual(timestamps, []int64{1, 2, 3}) {
+		t.Errorf("应只跳过损坏的行，读取的指标为 %v", timestamps)
+	}
+}
@@ -0,0 +1,48 @@
+package collector
+
+import (
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+// MetricsBatch 收集一次采集产生的所有指标，Flush 时合并为一条 metrics_batch 消息发送
+// 指标以外的消息（如日志事件）直接写入底层连接
+type MetricsBatch struct {
+	conn     WebSocketWriter
+	payloads []protocol.MetricsPayload
+}
+
+// NewMetricsBatch 创建指标批量发送器
+func NewMetricsBatch(conn WebSocketWriter) *MetricsBatch {
+	return &MetricsBatch{conn: conn}
+}
+
+// WriteJSON 缓存指标消息，其他消息直接发送
+func (b *MetricsBatch) WriteJSON(v interface{}) error {
+	if msg, ok := v.(protocol.OutboundMessage); ok && msg.Type == protocol.MessageTypeMetrics {
+		if payload, ok := msg.Data.(protocol.MetricsPayload); ok {
+			b.payloads = append(b.payloads, payload)
+			return nil
+		}
+	}
+	return b.conn.WriteJSON(v)
+}
+
+// Flush 发送缓存的指标，没有指标时不发送
+func (b *MetricsBatch) Flush() error {
+	if len(b.payloads) == 0 {
+		return nil
+	}
+	payloads := b.payloads
+	b.payloads = nil
+	return b.conn.WriteJSON(protocol.OutboundMessage{
+		Type: protocol.MessageTypeMetricsBatch,
+		Data: payloads,
+	})
+}
+
+// Take 取出缓存的指标，用于不经过连接发送的场景（如断线缓存）
+func (b *MetricsBatch) Take() []protocol.MetricsPayload {
+	payloads := b.payloads
+	b.payloads = nil
+	return payloads
+}
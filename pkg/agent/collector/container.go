@@ -0,0 +1,287 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/shirou/gopsutil/v4/mem"
+)
+
+// 容器运行时 API 的 unix socket 地址
+var containerSockets = [][]string{
+	{"/var/run/docker.sock", "/run/docker.sock"},
+	{"/run/podman/podman.sock", "/var/run/podman/podman.sock"},
+}
+
+// containerd 的任务目录，config.json 中包含 CRI/nerdctl 写入的容器元数据
+const containerdTaskRoot = "/run/containerd/io.containerd.runtime.v2.task"
+
+// 元数据缓存时间，避免每次采集都请求运行时 API
+const containerMetaTTL = time.Minute
+
+// 请求运行时 API 的超时时间
+const containerAPITimeout = 2 * time.Second
+
+// containerMeta 容器元数据
+type containerMeta struct {
+	Name  string
+	Image string
+}
+
+// containerSample 上一次采集的累计值，用于计算速率
+type containerSample struct {
+	at         time.Time
+	cpuNanos   uint64
+	readBytes  uint64
+	writeBytes uint64
+	netRx      uint64
+	netTx      uint64
+	hasNet     bool
+}
+
+// ContainerCollector 容器采集器，从 cgroup 读取资源使用情况，从运行时读取容器名称和镜像
+type ContainerCollector struct {
+	samples map[string]containerSample
+	meta    map[string]containerMeta
+	metaAt  time.Time
+	mu      sync.Mutex
+}
+
+// NewContainerCollector 创建容器采集器
+func NewContainerCollector() *ContainerCollector {
+	return &ContainerCollector{
+		samples: make(map[string]containerSample),
+		meta:    make(map[string]containerMeta),
+	}
+}
+
+// Collect 采集容器数据，主机上没有容器时返回空列表
+func (c *ContainerCollector) Collect() ([]protocol.ContainerData, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v2 := isCgroupV2()
+	cgroups := discoverContainerCgroups(v2)
+	if len(cgroups) == 0 {
+		c.samples = make(map[string]containerSample)
+		return []protocol.ContainerData{}, nil
+	}
+
+	c.refreshMeta(cgroups)
+
+	var hostMemory uint64
+	if vmStat, err := mem.VirtualMemory(); err == nil {
+		hostMemory = vmStat.Total
+	}
+
+	now := time.Now()
+	alive := make(map[string]bool, len(cgroups))
+	result := make([]protocol.ContainerData, 0, len(cgroups))
+	for _, cg := range cgroups {
+		stats, ok := readCgroupStats(v2, cg.Path)
+		if !ok {
+			continue
+		}
+		alive[cg.ID] = true
+
+		sample := containerSample{
+			at:         now,
+			cpuNanos:   stats.CPUUsageNanos,
+			readBytes:  stats.ReadBytes,
+			writeBytes: stats.WriteBytes,
+		}
+		if pid := cgroupFirstPid(v2, cg.Path); pid > 0 {
+			sample.netRx, sample.netTx, sample.hasNet = readNetDev(pid)
+		}
+
+		data := protocol.ContainerData{
+			ID:          cg.ID[:12],
+			Name:        cg.ID[:12],
+			Runtime:     cg.Runtime,
+			MemoryUsage: stats.MemoryUsage,
+			MemoryLimit: stats.MemoryLimit,
+			Pids:        stats.Pids,
+		}
+		if meta, ok := c.meta[cg.ID]; ok {
+			if meta.Name != "" {
+				data.Name = meta.Name
+			}
+			data.Image = meta.Image
+		}
+
+		memLimit := stats.MemoryLimit
+		if memLimit == 0 || (hostMemory > 0 && memLimit > hostMemory) {
+			memLimit = hostMemory
+		}
+		if memLimit > 0 {
+			data.MemoryPercent = float64(stats.MemoryUsage) / float64(memLimit) * 100
+		}
+
+		// 首次采集只记录累计值，速率从第二次采集开始计算
+		if prev, ok := c.samples[cg.ID]; ok {
+			elapsed := now.Sub(prev.at).Seconds()
+			if elapsed > 0 {
+				data.CPUPercent = float64(counterDelta(stats.CPUUsageNanos, prev.cpuNanos)) / (elapsed * 1e9) * 100
+				data.BlkioReadBytesRate = uint64(float64(counterDelta(stats.ReadBytes, prev.readBytes)) / elapsed)
+				data.BlkioWriteBytesRate = uint64(float64(counterDelta(stats.WriteBytes, prev.writeBytes)) / elapsed)
+				if sample.hasNet && prev.hasNet {
+					data.NetRxBytesRate = uint64(float64(counterDelta(sample.netRx, prev.netRx)) / elapsed)
+					data.NetTxBytesRate = uint64(float64(counterDelta(sample.netTx, prev.netTx)) / elapsed)
+				}
+			}
+		}
+		c.samples[cg.ID] = sample
+
+		result = append(result, data)
+	}
+
+	// 清理已停止的容器
+	for id := range c.samples {
+		if !alive[id] {
+			delete(c.samples, id)
+		}
+	}
+
+	return result, nil
+}
+
+// counterDelta 计算累计值的增量，计数器重置时返回 0
+func counterDelta(current, previous uint64) uint64 {
+	if current < previous {
+		return 0
+	}
+	return current - previous
+}
+
+// refreshMeta 刷新容器元数据，出现未知容器或缓存过期时重新获取
+func (c *ContainerCollector) refreshMeta(cgroups []containerCgroup) {
+	stale := time.Since(c.metaAt) > containerMetaTTL
+	if !stale {
+		for _, cg := range cgroups {
+			if _, ok := c.meta[cg.ID]; !ok {
+				stale = true
+				break
+			}
+		}
+	}
+	if !stale {
+		return
+	}
+
+	meta := make(map[string]containerMeta)
+	for _, sockets := range containerSockets {
+		for _, socket := range sockets {
+			if _, err := os.Stat(socket); err != nil {
+				continue
+			}
+			list, err := listContainersFromSocket(socket)
+			if err != nil {
+				continue
+			}
+			for id, m := range list {
+				meta[id] = m
+			}
+			break
+		}
+	}
+
+	for _, cg := range cgroups {
+		if _, ok := meta[cg.ID]; ok {
+			continue
+		}
+		if m, ok := readContainerdMeta(cg.ID); ok {
+			meta[cg.ID] = m
+			continue
+		}
+		// 无法获取元数据的容器也记录下来，避免每次采集都重新请求
+		meta[cg.ID] = containerMeta{}
+	}
+
+	c.meta = meta
+	c.metaAt = time.Now()
+}
+
+// listContainersFromSocket 通过 Docker 兼容 API（Docker、Podman）获取运行中的容器列表
+func listContainersFromSocket(socket string) (map[string]containerMeta, error) {
+	client := &http.Client{
+		Timeout: containerAPITimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Get("http://localhost/containers/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("获取容器列表失败: %s", resp.Status)
+	}
+
+	var containers []struct {
+		ID    string   `json:"Id"`
+		Names []string `json:"Names"`
+		Image string   `json:"Image"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&containers); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]containerMeta, len(containers))
+	for _, ct := range containers {
+		m := containerMeta{Image: ct.Image}
+		if len(ct.Names) > 0 {
+			m.Name = strings.TrimPrefix(ct.Names[0], "/")
+		}
+		result[ct.ID] = m
+	}
+	return result, nil
+}
+
+// readContainerdMeta 从 containerd 任务的 OCI 配置注解中读取容器名称和镜像
+func readContainerdMeta(id string) (containerMeta, bool) {
+	matches, _ := filepath.Glob(filepath.Join(containerdTaskRoot, "*", id, "config.json"))
+	if len(matches) == 0 {
+		return containerMeta{}, false
+	}
+
+	data, err := os.ReadFile(matches[0])
+	if err != nil {
+		return containerMeta{}, false
+	}
+	var spec struct {
+		Annotations map[string]string `json:"annotations"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return containerMeta{}, false
+	}
+
+	a := spec.Annotations
+	m := containerMeta{
+		Name:  a["io.kubernetes.cri.container-name"],
+		Image: a["io.kubernetes.cri.image-name"],
+	}
+	if m.Name != "" {
+		// Kubernetes 容器名称只在 Pod 内唯一，带上 Pod 名称
+		if pod := a["io.kubernetes.cri.sandbox-name"]; pod != "" {
+			m.Name = pod + "/" + m.Name
+		}
+	} else {
+		m.Name = a["nerdctl/name"]
+	}
+	return m, m.Name != "" || m.Image != ""
+}
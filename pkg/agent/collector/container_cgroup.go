@@ -0,0 +1,298 @@
+package collector
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+// 遍历 cgroup 目录的最大深度，kubepods 下容器一般在 4 层以内
+const cgroupMaxDepth = 6
+
+// v1 中内存限制未设置时内核返回接近 int64 上限的值
+const cgroupV1UnlimitedMemory = uint64(1) << 62
+
+var (
+	// systemd cgroup 驱动: docker-<id>.scope、cri-containerd-<id>.scope、crio-<id>.scope、libpod-<id>.scope
+	containerScopePattern = regexp.MustCompile(`^(docker|cri-containerd|crio|libpod)-([0-9a-f]{64})\.scope$`)
+	// cgroupfs 驱动: /docker/<id>、/libpod_parent/libpod-<id>、/kubepods/.../<id>
+	containerIDPattern = regexp.MustCompile(`^([0-9a-f]{64})$`)
+)
+
+// containerCgroup 容器对应的 cgroup 路径
+type containerCgroup struct {
+	ID      string // 完整容器 ID
+	Runtime string
+	Path    string // 相对于 cgroup 挂载点的路径
+}
+
+// cgroupStats 从 cgroup 读取的原始计数
+type cgroupStats struct {
+	CPUUsageNanos uint64
+	MemoryUsage   uint64
+	MemoryLimit   uint64
+	ReadBytes     uint64
+	WriteBytes    uint64
+	Pids          uint64
+}
+
+// isCgroupV2 判断是否为 cgroup v2（unified）挂载
+func isCgroupV2() bool {
+	_, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers"))
+	return err == nil
+}
+
+// discoverContainerCgroups 遍历 cgroup 目录查找容器
+func discoverContainerCgroups(v2 bool) []containerCgroup {
+	// v1 以 memory 层级为准查找容器，其余子系统使用相同的相对路径
+	base := cgroupRoot
+	if !v2 {
+		base = filepath.Join(cgroupRoot, "memory")
+	}
+
+	var result []containerCgroup
+	var walk func(dir, rel string, depth int)
+	walk = func(dir, rel string, depth int) {
+		if depth > cgroupMaxDepth {
+			return
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			name := entry.Name()
+			childRel := rel + "/" + name
+
+			if m := containerScopePattern.FindStringSubmatch(name); m != nil {
+				result = append(result, containerCgroup{ID: m[2], Runtime: scopeRuntime(m[1]), Path: childRel})
+				continue
+			}
+			if m := containerIDPattern.FindStringSubmatch(name); m != nil {
+				result = append(result, containerCgroup{ID: m[1], Runtime: parentRuntime(rel), Path: childRel})
+				continue
+			}
+			walk(filepath.Join(dir, name), childRel, depth+1)
+		}
+	}
+	walk(base, "", 0)
+	return result
+}
+
+func scopeRuntime(prefix string) string {
+	switch prefix {
+	case "cri-containerd":
+		return "containerd"
+	case "libpod":
+		return "podman"
+	default:
+		return prefix
+	}
+}
+
+func parentRuntime(parent string) string {
+	switch {
+	case strings.Contains(parent, "docker"):
+		return "docker"
+	case strings.Contains(parent, "libpod"):
+		return "podman"
+	case strings.Contains(parent, "crio"):
+		return "crio"
+	default:
+		// kubepods 下的 cgroupfs 驱动，一般为 containerd
+		return "containerd"
+	}
+}
+
+// readCgroupStats 读取容器的 CPU、内存、块设备 IO 和进程数
+func readCgroupStats(v2 bool, path string) (cgroupStats, bool) {
+	if v2 {
+		return readCgroupV2Stats(filepath.Join(cgroupRoot, path))
+	}
+	return readCgroupV1Stats(path)
+}
+
+func readCgroupV2Stats(dir string) (cgroupStats, bool) {
+	var stats cgroupStats
+
+	cpuStat := readKeyValueFile(filepath.Join(dir, "cpu.stat"))
+	usageUsec, ok := cpuStat["usage_usec"]
+	if !ok {
+		return stats, false
+	}
+	stats.CPUUsageNanos = usageUsec * 1000
+
+	// 与 docker stats 一致，内存使用量扣除可回收的 inactive_file
+	usage := readUintFile(filepath.Join(dir, "memory.current"))
+	memStat := readKeyValueFile(filepath.Join(dir, "memory.stat"))
+	if inactive := memStat["inactive_file"]; inactive < usage {
+		usage -= inactive
+	}
+	stats.MemoryUsage = usage
+	if limit := readStringFile(filepath.Join(dir, "memory.max")); limit != "max" {
+		stats.MemoryLimit, _ = strconv.ParseUint(limit, 10, 64)
+	}
+
+	// io.stat: 8:0 rbytes=1024 wbytes=2048 rios=1 wios=2 ...
+	for _, line := range readLines(filepath.Join(dir, "io.stat")) {
+		for _, field := range strings.Fields(line)[1:] {
+			key, value, found := strings.Cut(field, "=")
+			if !found {
+				continue
+			}
+			n, _ := strconv.ParseUint(value, 10, 64)
+			switch key {
+			case "rbytes":
+				stats.ReadBytes += n
+			case "wbytes":
+				stats.WriteBytes += n
+			}
+		}
+	}
+
+	stats.Pids = readUintFile(filepath.Join(dir, "pids.current"))
+	return stats, true
+}
+
+func readCgroupV1Stats(path string) (cgroupStats, bool) {
+	var stats cgroupStats
+
+	cpuDir := firstExistingDir(
+		filepath.Join(cgroupRoot, "cpu,cpuacct", path),
+		filepath.Join(cgroupRoot, "cpuacct", path),
+	)
+	if cpuDir == "" {
+		return stats, false
+	}
+	stats.CPUUsageNanos = readUintFile(filepath.Join(cpuDir, "cpuacct.usage"))
+
+	memDir := filepath.Join(cgroupRoot, "memory", path)
+	usage := readUintFile(filepath.Join(memDir, "memory.usage_in_bytes"))
+	memStat := readKeyValueFile(filepath.Join(memDir, "memory.stat"))
+	if inactive := memStat["total_inactive_file"]; inactive < usage {
+		usage -= inactive
+	}
+	stats.MemoryUsage = usage
+	if limit := readUintFile(filepath.Join(memDir, "memory.limit_in_bytes")); limit < cgroupV1UnlimitedMemory {
+		stats.MemoryLimit = limit
+	}
+
+	// blkio.throttle.io_service_bytes: 8:0 Read 1024
+	for _, line := range readLines(filepath.Join(cgroupRoot, "blkio", path, "blkio.throttle.io_service_bytes")) {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		n, _ := strconv.ParseUint(fields[2], 10, 64)
+		switch fields[1] {
+		case "Read":
+			stats.ReadBytes += n
+		case "Write":
+			stats.WriteBytes += n
+		}
+	}
+
+	stats.Pids = readUintFile(filepath.Join(cgroupRoot, "pids", path, "pids.current"))
+	return stats, true
+}
+
+// cgroupFirstPid 返回容器内的任一进程 PID，用于读取容器网络命名空间的统计
+func cgroupFirstPid(v2 bool, path string) int {
+	dir := filepath.Join(cgroupRoot, path)
+	if !v2 {
+		dir = filepath.Join(cgroupRoot, "memory", path)
+	}
+	for _, line := range readLines(filepath.Join(dir, "cgroup.procs")) {
+		if pid, err := strconv.Atoi(strings.TrimSpace(line)); err == nil && pid > 0 {
+			return pid
+		}
+	}
+	return 0
+}
+
+// readNetDev 读取 /proc/<pid>/net/dev，返回除回环网卡外的收发字节数合计
+func readNetDev(pid int) (rx, tx uint64, ok bool) {
+	lines := readLines(filepath.Join("/proc", strconv.Itoa(pid), "net", "dev"))
+	if len(lines) == 0 {
+		return 0, 0, false
+	}
+	for _, line := range lines {
+		iface, data, found := strings.Cut(line, ":")
+		if !found || strings.TrimSpace(iface) == "lo" {
+			continue
+		}
+		fields := strings.Fields(data)
+		if len(fields) < 9 {
+			continue
+		}
+		r, _ := strconv.ParseUint(fields[0], 10, 64)
+		t, _ := strconv.ParseUint(fields[8], 10, 64)
+		rx += r
+		tx += t
+	}
+	return rx, tx, true
+}
+
+func firstExistingDir(dirs ...string) string {
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return ""
+}
+
+func readStringFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func readUintFile(path string) uint64 {
+	n, _ := strconv.ParseUint(readStringFile(path), 10, 64)
+	return n
+}
+
+// readKeyValueFile 读取 "key value" 格式的文件
+func readKeyValueFile(path string) map[string]uint64 {
+	result := make(map[string]uint64)
+	for _, line := range readLines(path) {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		n, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		result[fields[0]] = n
+	}
+	return result
+}
+
+func readLines(path string) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"math"
 	"runtime"
 	"sync"
 	"time"
@@ -17,6 +18,11 @@ type CPUCollector struct {
 	physicalCores int
 	modelName     string
 	initOnce      sync.Once
+
+	// 上一次采集的 CPU 时间，用于计算各模式占比
+	lastTotal   *cpu.TimesStat
+	lastPerCore []cpu.TimesStat
+	mu          sync.Mutex
 }
 
 // NewCPUCollector 创建 CPU 采集器
@@ -64,10 +70,95 @@ func (c *CPUCollector) Collect() (*protocol.CPUData, error) {
 		cpuPercent = percentages[0]
 	}
 
-	return &protocol.CPUData{
+	cpuData := &protocol.CPUData{
 		LogicalCores:  c.logicalCores,
 		PhysicalCores: c.physicalCores,
 		ModelName:     c.modelName,
 		UsagePercent:  cpuPercent,
-	}, nil
+	}
+	c.collectModes(cpuData)
+
+	return cpuData, nil
+}
+
+// collectModes 根据两次采集之间的 CPU 时间差计算总体和每个核心的各模式占比
+func (c *CPUCollector) collectModes(cpuData *protocol.CPUData) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	total, err := cpu.Times(false)
+	if err != nil || len(total) == 0 {
+		return
+	}
+	perCore, err := cpu.Times(true)
+	if err != nil {
+		perCore = nil
+	}
+
+	if c.lastTotal != nil {
+		cpuData.Modes = cpuModes(*c.lastTotal, total[0])
+	}
+	// 核心数量变化（CPU 热插拔）时跳过本次每核心数据
+	if len(c.lastPerCore) > 0 && len(c.lastPerCore) == len(perCore) {
+		cpuData.PerCore = make([]float64, 0, len(perCore))
+		cpuData.PerCoreModes = make([]protocol.CPUModes, 0, len(perCore))
+		for i := range perCore {
+			modes := cpuModes(c.lastPerCore[i], perCore[i])
+			if modes == nil {
+				modes = &protocol.CPUModes{}
+			}
+			cpuData.PerCore = append(cpuData.PerCore, cpuBusy(c.lastPerCore[i], perCore[i]))
+			cpuData.PerCoreModes = append(cpuData.PerCoreModes, *modes)
+		}
+	}
+
+	c.lastTotal = &total[0]
+	c.lastPerCore = perCore
+}
+
+// cpuTotal 返回 CPU 总时间，Linux 上 user/nice 已包含 guest/guest_nice，需要去掉避免重复计算
+func cpuTotal(t cpu.TimesStat) float64 {
+	total := t.Total()
+	if runtime.GOOS == "linux" {
+		total -= t.Guest + t.GuestNice
+	}
+	return total
+}
+
+// cpuBusy 计算两次 CPU 时间之间的使用率，与 cpu.Percent 的计算方式一致
+func cpuBusy(prev, cur cpu.TimesStat) float64 {
+	prevTotal, curTotal := cpuTotal(prev), cpuTotal(cur)
+	prevBusy, curBusy := prevTotal-prev.Idle-prev.Iowait, curTotal-cur.Idle-cur.Iowait
+	if curBusy <= prevBusy {
+		return 0
+	}
+	if curTotal <= prevTotal {
+		return 100
+	}
+	return math.Min(100, math.Max(0, (curBusy-prevBusy)/(curTotal-prevTotal)*100))
+}
+
+// cpuModes 计算两次 CPU 时间之间各模式的占比，时间没有变化时返回 nil
+func cpuModes(prev, cur cpu.TimesStat) *protocol.CPUModes {
+	delta := cpuTotal(cur) - cpuTotal(prev)
+	if delta <= 0 {
+		return nil
+	}
+	percent := func(prev, cur float64) float64 {
+		if cur < prev {
+			return 0
+		}
+		return (cur - prev) / delta * 100
+	}
+	return &protocol.CPUModes{
+		User:    percent(prev.User, cur.User),
+		Nice:    percent(prev.Nice, cur.Nice),
+		System:  percent(prev.System, cur.System),
+		Idle:    percent(prev.Idle, cur.Idle),
+		Iowait:  percent(prev.Iowait, cur.Iowait),
+		Irq:     percent(prev.Irq, cur.Irq),
+		Softirq: percent(prev.Softirq, cur.Softirq),
+		Steal:   percent(prev.Steal, cur.Steal),
+		Guest:   percent(prev.Guest, cur.Guest),
+	}
 }
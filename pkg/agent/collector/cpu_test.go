@@ -0,0 +1,43 @@
+package collector
+
+import (
+	"math"
+	"runtime"
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+)
+
+func TestCPUModesGuest(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("guest 时间只在 Linux 上包含在 user/nice 中")
+	}
+
+	prev := cpu.TimesStat{User: 100, System: 50, Idle: 800, Iowait: 50, Guest: 40}
+	// 间隔内共 100 个时间片：user 60（其中 guest 40）、system 10、idle 20、iowait 10
+	cur := cpu.TimesStat{User: 160, System: 60, Idle: 820, Iowait: 60, Guest: 80}
+
+	modes := cpuModes(prev, cur)
+	if modes == nil {
+		t.Fatal("时间有变化时应返回各模式占比")
+	}
+	want := map[string][2]float64{
+		"user":   {modes.User, 60},
+		"system": {modes.System, 10},
+		"idle":   {modes.Idle, 20},
+		"iowait": {modes.Iowait, 10},
+		"guest":  {modes.Guest, 40},
+	}
+	for mode, v := range want {
+		if math.Abs(v[0]-v[1]) > 1e-9 {
+			t.Errorf("%s 为 %v，应为 %v", mode, v[0], v[1])
+		}
+	}
+
+	if busy := cpuBusy(prev, cur); math.Abs(busy-70) > 1e-9 {
+		t.Errorf("使用率为 %v，应为 70", busy)
+	}
+	if cpuModes(cur, cur) != nil {
+		t.Error("时间没有变化时应返回 nil")
+	}
+}
@@ -0,0 +1,261 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/pkg/agent/config"
+	"github.com/sourcegraph/conc"
+)
+
+const (
+	// 待发送的自定义指标序列上限，防止脚本输出高基数标签占满内存
+	customMaxSeries = 10000
+	// 推送接口请求体大小上限
+	customPushMaxBodySize = 1 << 20
+
+	customDefaultInterval = 60 * time.Second
+	customDefaultTimeout  = 10 * time.Second
+)
+
+// CustomCollector 自定义指标采集器，定时执行脚本并接收本地推送的指标
+// 脚本和推送接口独立于 WebSocket 连接运行，断线重连期间的数据会保留到下次发送
+type CustomCollector struct {
+	config *config.CustomConfig
+
+	mu      sync.Mutex
+	pending map[string]protocol.CustomMetricData // 按序列去重，只保留最新值
+}
+
+// NewCustomCollector 创建自定义指标采集器
+func NewCustomCollector(cfg *config.Config) *CustomCollector {
+	return &CustomCollector{
+		config:  &cfg.Collector.Custom,
+		pending: make(map[string]protocol.CustomMetricData),
+	}
+}
+
+// Run 启动脚本执行和本地推送接口，阻塞直到 ctx 取消
+func (c *CustomCollector) Run(ctx context.Context) {
+	var wg conc.WaitGroup
+	for _, script := range c.config.Scripts {
+		script := script
+		wg.Go(func() {
+			c.runScript(ctx, script)
+		})
+	}
+	if c.config.Listen != "" {
+		wg.Go(func() {
+			if err := c.serve(ctx); err != nil {
+				log.Printf("⚠️  自定义指标推送接口启动失败: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// Collect 取出上次发送之后新产生的自定义指标
+func (c *CustomCollector) Collect() []protocol.CustomMetricData {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.pending) == 0 {
+		return nil
+	}
+	samples := make([]protocol.CustomMetricData, 0, len(c.pending))
+	for _, sample := range c.pending {
+		samples = append(samples, sample)
+	}
+	c.pending = make(map[string]protocol.CustomMetricData)
+	return samples
+}
+
+// requeue 将发送失败的指标放回待发送队列，期间产生了新值的序列保留新值
+func (c *CustomCollector) requeue(samples []protocol.CustomMetricData) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, sample := range samples {
+		key := customSeriesKey(sample)
+		if _, ok := c.pending[key]; ok || len(c.pending) >= customMaxSeries {
+			continue
+		}
+		c.pending[key] = sample
+	}
+}
+
+// add 加入待发送的指标，extraLabels 会覆盖指标自身的同名标签
+func (c *CustomCollector) add(samples []protocol.CustomMetricData, extraLabels map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, sample := range samples {
+		if len(extraLabels) > 0 {
+			labels := make(map[string]string, len(sample.Labels)+len(extraLabels))
+			for k, v := range sample.Labels {
+				labels[k] = v
+			}
+			for k, v := range extraLabels {
+				labels[k] = v
+			}
+			sample.Labels = labels
+		}
+
+		key := customSeriesKey(sample)
+		if _, ok := c.pending[key]; !ok && len(c.pending) >= customMaxSeries {
+			log.Printf("⚠️  待发送的自定义指标超过 %d 个序列，丢弃 %s", customMaxSeries, sample.Name)
+			continue
+		}
+		c.pending[key] = sample
+	}
+}
+
+// customSeriesKey 由指标名和排序后的标签组成序列标识
+func customSeriesKey(sample protocol.CustomMetricData) string {
+	names := make([]string, 0, len(sample.Labels))
+	for name := range sample.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(sample.Name)
+	for _, name := range names {
+		b.WriteString("\x00")
+		b.WriteString(name)
+		b.WriteString("=")
+		b.WriteString(sample.Labels[name])
+	}
+	return b.String()
+}
+
+// runScript 按间隔执行脚本
+func (c *CustomCollector) runScript(ctx context.Context, script config.CustomScriptConfig) {
+	interval := customDefaultInterval
+	if script.Interval > 0 {
+		interval = time.Duration(script.Interval) * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.executeScript(ctx, script); err != nil && ctx.Err() == nil {
+			log.Printf("⚠️  自定义指标脚本 %s 执行失败: %v", script.Name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// executeScript 执行一次脚本并解析输出
+func (c *CustomCollector) executeScript(ctx context.Context, script config.CustomScriptConfig) error {
+	timeout := customDefaultTimeout
+	if script.Timeout > 0 {
+		timeout = time.Duration(script.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, script.Command, script.Args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return err
+	}
+
+	samples, err := parseCustomMetrics(script.Format, output, time.Now().UnixMilli())
+	if err != nil {
+		return fmt.Errorf("解析输出失败: %w", err)
+	}
+
+	labels := map[string]string{"script": script.Name}
+	for k, v := range script.Labels {
+		labels[k] = v
+	}
+	c.add(samples, labels)
+	return nil
+}
+
+// serve 启动本地推送接口，POST /metrics 接收 Prometheus 文本格式或 JSON（Content-Type 为 application/json）
+func (c *CustomCollector) serve(ctx context.Context) error {
+	var listener net.Listener
+	var err error
+	if socketPath, ok := strings.CutPrefix(c.config.Listen, "unix://"); ok {
+		// 清理上次异常退出残留的 socket 文件
+		_ = os.Remove(socketPath)
+		listener, err = net.Listen("unix", socketPath)
+		if err == nil {
+			defer os.Remove(socketPath)
+		}
+	} else {
+		listener, err = net.Listen("tcp", c.config.Listen)
+	}
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", c.handlePush)
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("📥 自定义指标推送接口已启动: %s", c.config.Listen)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (c *CustomCollector) handlePush(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		w.Header().Set("Allow", "POST, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, customPushMaxBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	format := "prometheus"
+	if strings.Contains(r.Header.Get("Content-Type"), "json") {
+		format = "json"
+	}
+	samples, err := parseCustomMetrics(format, body, time.Now().UnixMilli())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.add(samples, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
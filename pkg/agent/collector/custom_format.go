@@ -0,0 +1,198 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+var (
+	customMetricNameRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	customLabelNameRegex  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// 服务端使用的保留标签，自定义指标不能覆盖
+var customReservedLabels = map[string]bool{
+	"__name__": true,
+	"agent_id": true,
+}
+
+// parseCustomMetrics 根据格式解析自定义指标，now 为没有时间戳时使用的毫秒时间戳
+func parseCustomMetrics(format string, data []byte, now int64) ([]protocol.CustomMetricData, error) {
+	if format == "json" {
+		return parseCustomJSON(data, now)
+	}
+	return parsePrometheusText(data, now)
+}
+
+// parsePrometheusText 解析 Prometheus 文本格式（忽略注释、HELP 和 TYPE 行）
+func parsePrometheusText(data []byte, now int64) ([]protocol.CustomMetricData, error) {
+	var result []protocol.CustomMetricData
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		sample, err := parsePrometheusLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行格式错误: %w", i+1, err)
+		}
+		if sample == nil {
+			continue
+		}
+		if sample.Timestamp == 0 {
+			sample.Timestamp = now
+		}
+		result = append(result, *sample)
+	}
+	return result, nil
+}
+
+// parsePrometheusLine 解析单行样本，格式: name{label="value",...} value [timestamp]
+// 值为 NaN 或 Inf 时返回 nil
+func parsePrometheusLine(line string) (*protocol.CustomMetricData, error) {
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return nil, fmt.Errorf("缺少指标值")
+	}
+	sample := &protocol.CustomMetricData{Name: line[:end]}
+	rest := line[end:]
+
+	if strings.HasPrefix(rest, "{") {
+		labels, remaining, err := parsePrometheusLabels(rest[1:])
+		if err != nil {
+			return nil, err
+		}
+		sample.Labels = labels
+		rest = remaining
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("指标值格式错误")
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("指标值 %q 无效", fields[0])
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, nil
+	}
+	sample.Value = value
+	if len(fields) == 2 {
+		timestamp, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("时间戳 %q 无效", fields[1])
+		}
+		sample.Timestamp = timestamp
+	}
+
+	if err := validateCustomMetric(sample); err != nil {
+		return nil, err
+	}
+	return sample, nil
+}
+
+// parsePrometheusLabels 解析 { 之后的标签列表，返回标签和 } 之后的剩余内容
+func parsePrometheusLabels(s string) (map[string]string, string, error) {
+	labels := make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if strings.HasPrefix(s, "}") {
+			return labels, s[1:], nil
+		}
+
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return nil, "", fmt.Errorf("标签格式错误")
+		}
+		name := strings.TrimSpace(s[:eq])
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		if !strings.HasPrefix(s, `"`) {
+			return nil, "", fmt.Errorf("标签 %s 的值缺少引号", name)
+		}
+
+		// 读取带转义的标签值
+		var value strings.Builder
+		i := 1
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i])
+				}
+				continue
+			}
+			value.WriteByte(s[i])
+		}
+		if i >= len(s) {
+			return nil, "", fmt.Errorf("标签 %s 的值缺少结束引号", name)
+		}
+		labels[name] = value.String()
+
+		s = strings.TrimLeft(s[i+1:], " \t")
+		if strings.HasPrefix(s, ",") {
+			s = s[1:]
+		} else if !strings.HasPrefix(s, "}") {
+			return nil, "", fmt.Errorf("标签列表格式错误")
+		}
+	}
+}
+
+// parseCustomJSON 解析 JSON 格式，支持两种形式：
+//
+//	[{"name": "queue_depth", "labels": {"queue": "mail"}, "value": 12}]
+//	{"queue_depth": 12, "active_users": 40}
+func parseCustomJSON(data []byte, now int64) ([]protocol.CustomMetricData, error) {
+	data = bytes.TrimSpace(data)
+
+	var result []protocol.CustomMetricData
+	if bytes.HasPrefix(data, []byte("{")) {
+		var values map[string]float64
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, err
+		}
+		for name, value := range values {
+			result = append(result, protocol.CustomMetricData{Name: name, Value: value})
+		}
+	} else if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+
+	for i := range result {
+		if err := validateCustomMetric(&result[i]); err != nil {
+			return nil, err
+		}
+		if result[i].Timestamp == 0 {
+			result[i].Timestamp = now
+		}
+	}
+	return result, nil
+}
+
+// validateCustomMetric 校验指标名和标签名，并移除保留标签
+func validateCustomMetric(sample *protocol.CustomMetricData) error {
+	if !customMetricNameRegex.MatchString(sample.Name) {
+		return fmt.Errorf("指标名 %q 无效", sample.Name)
+	}
+	for name := range sample.Labels {
+		if customReservedLabels[name] {
+			delete(sample.Labels, name)
+			continue
+		}
+		if !customLabelNameRegex.MatchString(name) {
+			return fmt.Errorf("指标 %s 的标签名 %q 无效", sample.Name, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package collector
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/pkg/agent/config"
+)
+
+func TestParsePrometheusText(t *testing.T) {
+	input := `# HELP queue_depth 队列长度
+# TYPE queue_depth gauge
+queue_depth{queue="mail",host="a"} 12
+queue_depth{queue="sms"} 3 1700000000000
+
+active_users 40
+temperature{sensor="x"} NaN
+free_bytes +Inf
+latency_seconds{path="/a,b",quote="say \"hi\"",slash="c:\\tmp",multi="a\nb"} 1.5e-3
+spaces{ a = "1" , b="2" , } 7
+`
+	samples, err := parsePrometheusText([]byte(input), 1000)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+
+	want := []protocol.CustomMetricData{
+		{Name: "queue_depth", Labels: map[string]string{"queue": "mail", "host": "a"}, Value: 12, Timestamp: 1000},
+		{Name: "queue_depth", Labels: map[string]string{"queue": "sms"}, Value: 3, Timestamp: 1700000000000},
+		{Name: "active_users", Value: 40, Timestamp: 1000},
+		{Name: "latency_seconds", Labels: map[string]string{
+			"path":  "/a,b",
+			"quote": `say "hi"`,
+			"slash": `c:\tmp`,
+			"multi": "a\nb",
+		}, Value: 0.0015, Timestamp: 1000},
+		{Name: "spaces", Labels: map[string]string{"a": "1", "b": "2"}, Value: 7, Timestamp: 1000},
+	}
+	if !reflect.DeepEqual(samples, want) {
+		t.Errorf("解析结果为 %#v，应为 %#v", samples, want)
+	}
+}
+
+func TestParsePrometheusTextReservedLabels(t *testing.T) {
+	samples, err := parsePrometheusText([]byte(`up{agent_id="other",__name__="x",job="a"} 1`), 1000)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if len(samples) != 1 || !reflect.DeepEqual(samples[0].Labels, map[string]string{"job": "a"}) {
+		t.Errorf("保留标签应被移除: %#v", samples)
+	}
+}
+
+func TestParsePrometheusTextInvalid(t *testing.T) {
+	tests := []string{
+		"no_value",
+		"1abc 1",
+		"name abc",
+		"name 1 2 3",
+		"name 1 abc",
+		`name{job} 1`,
+		`name{job=a} 1`,
+		`name{job="a} 1`,
+		`name{job="a" x="b"} 1`,
+		`name{1job="a"} 1`,
+		`{job="a"} 1`,
+	}
+	for _, input := range tests {
+		if _, err := parsePrometheusText([]byte("ok 1\n"+input), 1000); err == nil {
+			t.Errorf("%q 应返回错误", input)
+		}
+	}
+}
+
+func TestParsePrometheusLabels(t *testing.T) {
+	labels, rest, err := parsePrometheusLabels(`a="1",b="x\"y"} 5 100`)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if !reflect.DeepEqual(labels, map[string]string{"a": "1", "b": `x"y`}) {
+		t.Errorf("标签为 %#v", labels)
+	}
+	if rest != " 5 100" {
+		t.Errorf("剩余内容为 %q，应为 %q", rest, " 5 100")
+	}
+
+	labels, rest, err = parsePrometheusLabels(`} 1`)
+	if err != nil || len(labels) != 0 || rest != " 1" {
+		t.Errorf("空标签列表解析错误: %#v %q %v", labels, rest, err)
+	}
+
+	// 末尾的反斜杠没有可转义的字符
+	if _, _, err := parsePrometheusLabels(`a="1\`); err == nil {
+		t.Error("缺少结束引号时应返回错误")
+	}
+}
+
+type failingWriter struct {
+	err error
+}
+
+func (w *failingWriter) WriteJSON(v interface{}) error {
+	return w.err
+}
+
+func TestCollectAndSendCustomRequeue(t *testing.T) {
+	c := NewCustomCollector(&config.Config{})
+	c.add([]protocol.CustomMetricData{
+		{Name: "queue_depth", Labels: map[string]string{"queue": "mail"}, Value: 1},
+		{Name: "active_users", Value: 2},
+	}, nil)
+
+	m := &Manager{}
+	sendErr := errors.New("connection closed")
+	if err := m.CollectAndSendCustom(&failingWriter{err: sendErr}, c); !errors.Is(err, sendErr) {
+		t.Fatalf("应返回发送错误，实际为 %v", err)
+	}
+
+	// 发送失败期间产生的新值优先于放回的旧值
+	c.add([]protocol.CustomMetricData{{Name: "active_users", Value: 3}}, nil)
+	c.requeue([]protocol.CustomMetricData{{Name: "active_users", Value: 2}})
+
+	samples := c.Collect()
+	got := make(map[string]float64, len(samples))
+	for _, sample := range samples {
+		got[sample.Name] = sample.Value
+	}
+	want := map[string]float64{"queue_depth": 1, "active_users": 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("待发送的指标为 %v，应为 %v", got, want)
+	}
+
+	c.requeue(samples)
+	if err := m.CollectAndSendCustom(&failingWriter{}, c); err != nil {
+		t.Fatalf("发送失败: %v", err)
+	}
+	if samples := c.Collect(); len(samples) != 0 {
+		t.Errorf("发送成功后不应保留指标: %#v", samples)
+	}
+}
@@ -1,6 +1,8 @@
 package collector
 
 import (
+	"slices"
+
 	"github.com/dushixiang/pika/internal/protocol"
 	"github.com/dushixiang/pika/pkg/agent/config"
 	"github.com/shirou/gopsutil/v4/disk"
@@ -52,6 +54,14 @@ func (d *DiskCollector) Collect() ([]protocol.DiskData, error) {
 			Used:         usage.Used,
 			Free:         usage.Free,
 			UsagePercent: usage.UsedPercent,
+
+			InodesTotal:        usage.InodesTotal,
+			InodesUsed:         usage.InodesUsed,
+			InodesFree:         usage.InodesFree,
+			InodesUsagePercent: usage.InodesUsedPercent,
+
+			ReadOnly: slices.Contains(partition.Opts, "ro"),
+			Options:  partition.Opts,
 		}
 
 		diskDataList = append(diskDataList, diskData)
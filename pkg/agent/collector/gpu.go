@@ -32,6 +32,12 @@ func NewGPUCollector() *GPUCollector {
 	}
 }
 
+// GPUAvailable 检查是否可以采集 GPU 数据
+func GPUAvailable() bool {
+	_, err := exec.LookPath("nvidia-smi")
+	return err == nil
+}
+
 // initStatic 初始化静态数据(只执行一次)
 func (g *GPUCollector) initStatic() {
 	g.staticInitOnce.Do(func() {
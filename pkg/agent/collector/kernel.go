@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/shirou/gopsutil/v4/load"
+)
+
+// kernelCounters /proc/stat 中的累计计数，用于计算速率
+type kernelCounters struct {
+	ContextSwitches uint64
+	Interrupts      uint64
+	Forks           uint64
+	ProcsRunning    uint64
+	ProcsBlocked    uint64
+}
+
+// KernelCollector 内核与操作系统指标采集器
+type KernelCollector struct {
+	last     *kernelCounters
+	lastTime time.Time
+	mu       sync.Mutex
+}
+
+// NewKernelCollector 创建内核指标采集器
+func NewKernelCollector() *KernelCollector {
+	return &KernelCollector{}
+}
+
+// Collect 采集内核指标
+func (k *KernelCollector) Collect() (*protocol.KernelData, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	data := &protocol.KernelData{}
+
+	// Windows 不支持负载，忽略错误
+	if avg, err := load.Avg(); err == nil {
+		data.Load = &protocol.LoadData{
+			Load1:  avg.Load1,
+			Load5:  avg.Load5,
+			Load15: avg.Load15,
+		}
+	}
+
+	data.Pressure = readPressure()
+	data.FileDescriptors = readFileDescriptors()
+	data.Conntrack = readConntrack()
+	data.EntropyAvailable = readEntropyAvailable()
+
+	if counters := readKernelCounters(); counters != nil {
+		now := time.Now()
+		data.ProcsRunning = counters.ProcsRunning
+		data.ProcsBlocked = counters.ProcsBlocked
+
+		// 首次采集只记录累计值，速率从第二次采集开始计算
+		if k.last != nil {
+			if elapsed := now.Sub(k.lastTime).Seconds(); elapsed > 0 {
+				data.ContextSwitchesRate = uint64(float64(counterDelta(counters.ContextSwitches, k.last.ContextSwitches)) / elapsed)
+				data.InterruptsRate = uint64(float64(counterDelta(counters.Interrupts, k.last.Interrupts)) / elapsed)
+				data.ForksRate = uint64(float64(counterDelta(counters.Forks, k.last.Forks)) / elapsed)
+			}
+		}
+		k.last = counters
+		k.lastTime = now
+	}
+
+	return data, nil
+}
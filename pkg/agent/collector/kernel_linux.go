@@ -0,0 +1,127 @@
+//go:build linux
+
+package collector
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+// readPressure 读取 /proc/pressure 下的 PSI 信息，内核未开启 PSI 时返回 nil
+func readPressure() *protocol.PressureData {
+	cpuSome, _, ok := readPressureFile("/proc/pressure/cpu")
+	if !ok {
+		return nil
+	}
+	memorySome, memoryFull, _ := readPressureFile("/proc/pressure/memory")
+	ioSome, ioFull, _ := readPressureFile("/proc/pressure/io")
+	return &protocol.PressureData{
+		CPUSome:    cpuSome,
+		MemorySome: memorySome,
+		MemoryFull: memoryFull,
+		IOSome:     ioSome,
+		IOFull:     ioFull,
+	}
+}
+
+// readPressureFile 解析 PSI 文件的 avg10
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func readPressureFile(path string) (some, full float64, ok bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0, false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		for _, field := range fields[1:] {
+			value, found := strings.CutPrefix(field, "avg10=")
+			if !found {
+				continue
+			}
+			avg, _ := strconv.ParseFloat(value, 64)
+			switch fields[0] {
+			case "some":
+				some = avg
+			case "full":
+				full = avg
+			}
+		}
+	}
+	return some, full, true
+}
+
+// readFileDescriptors 读取 /proc/sys/fs/file-nr: 已分配 空闲(始终为0) 上限
+func readFileDescriptors() *protocol.FileDescriptor {
+	fields := strings.Fields(readStringFile("/proc/sys/fs/file-nr"))
+	if len(fields) != 3 {
+		return nil
+	}
+	allocated, _ := strconv.ParseUint(fields[0], 10, 64)
+	limit, _ := strconv.ParseUint(fields[2], 10, 64)
+	return &protocol.FileDescriptor{Allocated: allocated, Max: limit}
+}
+
+// readConntrack 读取连接跟踪表使用情况，未加载 nf_conntrack 模块时返回 nil
+func readConntrack() *protocol.ConntrackData {
+	count := readStringFile("/proc/sys/net/netfilter/nf_conntrack_count")
+	if count == "" {
+		return nil
+	}
+	entries, _ := strconv.ParseUint(count, 10, 64)
+	return &protocol.ConntrackData{
+		Entries: entries,
+		Max:     readUintFile("/proc/sys/net/netfilter/nf_conntrack_max"),
+	}
+}
+
+// readEntropyAvailable 读取可用熵
+func readEntropyAvailable() *uint64 {
+	value := readStringFile("/proc/sys/kernel/random/entropy_avail")
+	if value == "" {
+		return nil
+	}
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+// readKernelCounters 读取 /proc/stat 中的上下文切换、中断、进程创建次数和进程状态
+func readKernelCounters() *kernelCounters {
+	lines := readLines("/proc/stat")
+	if len(lines) == 0 {
+		return nil
+	}
+
+	counters := &kernelCounters{}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		// intr 行第一个数为中断总数，后面是各中断号的计数
+		n, _ := strconv.ParseUint(fields[1], 10, 64)
+		switch fields[0] {
+		case "ctxt":
+			counters.ContextSwitches = n
+		case "intr":
+			counters.Interrupts = n
+		case "processes":
+			counters.Forks = n
+		case "procs_running":
+			counters.ProcsRunning = n
+		case "procs_blocked":
+			counters.ProcsBlocked = n
+		}
+	}
+	return counters
+}
@@ -0,0 +1,27 @@
+//go:build !linux
+
+package collector
+
+import "github.com/dushixiang/pika/internal/protocol"
+
+// 非 Linux 平台不支持以下指标
+
+func readPressure() *protocol.PressureData {
+	return nil
+}
+
+func readFileDescriptors() *protocol.FileDescriptor {
+	return nil
+}
+
+func readConntrack() *protocol.ConntrackData {
+	return nil
+}
+
+func readEntropyAvailable() *uint64 {
+	return nil
+}
+
+func readKernelCounters() *kernelCounters {
+	return nil
+}
@@ -3,6 +3,7 @@ package collector
 import (
 	"github.com/dushixiang/pika/internal/protocol"
 	"github.com/dushixiang/pika/pkg/agent/config"
+	"github.com/dushixiang/pika/pkg/agent/logtail"
 )
 
 // WebSocketWriter 定义 WebSocket 写入接口
@@ -22,6 +23,11 @@ type Manager struct {
 	temperatureCollector       *TemperatureCollector
 	gpuCollector               *GPUCollector
 	monitorCollector           *MonitorCollector
+	processCollector           *ProcessCollector
+	containerCollector         *ContainerCollector
+	systemdCollector           *SystemdCollector
+	kernelCollector            *KernelCollector
+	smartCollector             *SmartCollector
 	ddnsCollector              *DDNSCollector
 }
 
@@ -38,6 +44,11 @@ func NewManager(cfg *config.Config) *Manager {
 		temperatureCollector:       NewTemperatureCollector(),
 		gpuCollector:               NewGPUCollector(),
 		monitorCollector:           NewMonitorCollector(),
+		processCollector:           NewProcessCollector(cfg),
+		containerCollector:         NewContainerCollector(),
+		systemdCollector:           NewSystemdCollector(cfg),
+		kernelCollector:            NewKernelCollector(),
+		smartCollector:             NewSmartCollector(),
 		ddnsCollector:              nil, // DDNS 采集器需要配置后才能初始化
 	}
 }
@@ -108,6 +119,16 @@ func (m *Manager) CollectAndSendHost(conn WebSocketWriter) error {
 	return m.sendMetrics(conn, protocol.MetricTypeHost, hostData)
 }
 
+// CollectAndSendKernel 采集并发送内核指标（负载、PSI、文件描述符等）
+func (m *Manager) CollectAndSendKernel(conn WebSocketWriter) error {
+	kernelData, err := m.kernelCollector.Collect()
+	if err != nil {
+		return err
+	}
+
+	return m.sendMetrics(conn, protocol.MetricTypeKernel, kernelData)
+}
+
 // CollectAndSendGPU 采集并发送 GPU 指标
 func (m *Manager) CollectAndSendGPU(conn WebSocketWriter) error {
 	gpuDataList, err := m.gpuCollector.Collect()
@@ -130,12 +151,109 @@ func (m *Manager) CollectAndSendTemperature(conn WebSocketWriter) error {
 	return m.sendMetrics(conn, protocol.MetricTypeTemperature, tempDataList)
 }
 
+// CollectAndSendDiskHealth 发送磁盘健康数据（SMART / NVMe）
+func (m *Manager) CollectAndSendDiskHealth(conn WebSocketWriter) error {
+	healthDataList := m.smartCollector.Collect()
+	if len(healthDataList) == 0 {
+		// 没有 smartctl/nvme-cli、无权限或数据尚未读取完成
+		return nil
+	}
+
+	return m.sendMetrics(conn, protocol.MetricTypeDiskHealth, healthDataList)
+}
+
+// CollectAndSendProcess 采集并发送进程指标
+func (m *Manager) CollectAndSendProcess(conn WebSocketWriter) error {
+	if !m.processCollector.Enabled() {
+		// 未配置 top_n 和关注进程时不采集
+		return nil
+	}
+
+	processData, err := m.processCollector.Collect()
+	if err != nil {
+		return err
+	}
+
+	return m.sendMetrics(conn, protocol.MetricTypeProcess, processData)
+}
+
+// CollectAndSendContainer 采集并发送容器指标
+func (m *Manager) CollectAndSendContainer(conn WebSocketWriter) error {
+	containerDataList, err := m.containerCollector.Collect()
+	if err != nil {
+		return err
+	}
+	if len(containerDataList) == 0 {
+		// 主机上没有运行中的容器
+		return nil
+	}
+
+	return m.sendMetrics(conn, protocol.MetricTypeContainer, containerDataList)
+}
+
+// CollectAndSendSystemd 采集并发送 systemd 单元状态
+func (m *Manager) CollectAndSendSystemd(conn WebSocketWriter) error {
+	if !m.systemdCollector.Enabled() {
+		return nil
+	}
+
+	unitDataList, err := m.systemdCollector.Collect()
+	if err != nil {
+		return err
+	}
+
+	return m.sendMetrics(conn, protocol.MetricTypeSystemd, unitDataList)
+}
+
 // CollectAndSendMonitor 采集并发送监控数据
 func (m *Manager) CollectAndSendMonitor(conn WebSocketWriter, items []protocol.MonitorItem) error {
 	monitorDataList := m.monitorCollector.Collect(items)
 	return m.sendMetrics(conn, protocol.MetricTypeMonitor, monitorDataList)
 }
 
+// CollectAndSendCustom 发送自定义指标（脚本输出和本地推送）
+func (m *Manager) CollectAndSendCustom(conn WebSocketWriter, customCollector *CustomCollector) error {
+	samples := customCollector.Collect()
+	if len(samples) == 0 {
+		return nil
+	}
+
+	if err := m.sendMetrics(conn, protocol.MetricTypeCustom, samples); err != nil {
+		// 发送失败时放回队列，重连后再次发送
+		customCollector.requeue(samples)
+		return err
+	}
+	return nil
+}
+
+// CollectAndSendPrometheus 发送 Prometheus 目标的抓取结果
+func (m *Manager) CollectAndSendPrometheus(conn WebSocketWriter, prometheusCollector *PrometheusCollector) error {
+	results := prometheusCollector.Collect()
+	if len(results) == 0 {
+		return nil
+	}
+
+	return m.sendMetrics(conn, protocol.MetricTypePrometheus, results)
+}
+
+// CollectAndSendLog 发送日志规则的匹配计数和匹配到的日志行
+func (m *Manager) CollectAndSendLog(conn WebSocketWriter, tailer *logtail.Tailer) error {
+	matches, events := tailer.Collect()
+	if len(events) > 0 {
+		if err := conn.WriteJSON(protocol.OutboundMessage{
+			Type: protocol.MessageTypeLogEvent,
+			Data: events,
+		}); err != nil {
+			return err
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+
+	return m.sendMetrics(conn, protocol.MetricTypeLog, matches)
+}
+
 // UpdateDDNSConfig 更新 DDNS 配置
 func (m *Manager) UpdateDDNSConfig(config *protocol.DDNSConfigData) {
 	if config == nil || !config.Enabled {
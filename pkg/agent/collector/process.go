@@ -0,0 +1,319 @@
+package collector
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/pkg/agent/config"
+	"github.com/shirou/gopsutil/v4/mem"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// 上报的命令行最大长度
+const maxCmdlineLength = 256
+
+// processWatcher 关注进程的匹配规则及重启状态
+type processWatcher struct {
+	config      config.ProcessWatchConfig
+	cmdline     *regexp.Regexp
+	mainPID     int32 // 上一次采集时的主进程（启动最早的进程）
+	mainCreated int64
+	restarts    int
+}
+
+// ProcessCollector 进程采集器
+type ProcessCollector struct {
+	topN     int
+	watchers []*processWatcher
+
+	// 缓存进程对象，gopsutil 依赖上一次的 CPU 时间计算使用率
+	procs map[int32]*process.Process
+	mu    sync.Mutex
+}
+
+// NewProcessCollector 创建进程采集器
+func NewProcessCollector(cfg *config.Config) *ProcessCollector {
+	c := &ProcessCollector{
+		topN:  cfg.Collector.Process.TopN,
+		procs: make(map[int32]*process.Process),
+	}
+	for _, watch := range cfg.Collector.Process.Watch {
+		w := &processWatcher{config: watch}
+		if watch.Cmdline != "" {
+			// 配置加载时已校验过正则表达式
+			w.cmdline, _ = regexp.Compile(watch.Cmdline)
+		}
+		c.watchers = append(c.watchers, w)
+	}
+	return c
+}
+
+// Enabled 是否需要采集
+func (c *ProcessCollector) Enabled() bool {
+	return c.topN > 0 || len(c.watchers) > 0
+}
+
+// Collect 采集进程数据
+func (c *ProcessCollector) Collect() (*protocol.ProcessData, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pids, err := process.Pids()
+	if err != nil {
+		return nil, err
+	}
+
+	// 内存占用率按 RSS 和物理内存总量计算，只读取一次内存信息
+	var totalMemory uint64
+	if vm, err := mem.VirtualMemory(); err == nil {
+		totalMemory = vm.Total
+	}
+
+	alive := make(map[int32]bool, len(pids))
+	infos := make([]protocol.ProcessStat, 0, len(pids))
+	for _, pid := range pids {
+		p, ok := c.procs[pid]
+		// PID 被复用时（启动时间不一致）需要重新创建进程对象，避免沿用旧的名称和 CPU 时间
+		if ok {
+			if running, err := p.IsRunning(); err != nil || !running {
+				ok = false
+			}
+		}
+		if !ok {
+			p, err = process.NewProcess(pid)
+			if err != nil {
+				continue
+			}
+			c.procs[pid] = p
+		}
+
+		info, ok := c.collectProcess(p, totalMemory)
+		if !ok {
+			continue
+		}
+		alive[pid] = true
+		infos = append(infos, info)
+	}
+
+	// 清理已退出的进程
+	for pid := range c.procs {
+		if !alive[pid] {
+			delete(c.procs, pid)
+		}
+	}
+
+	// 只为需要上报的进程读取用户、文件描述符等开销较大的信息
+	matched := c.matchWatchers(infos)
+	reported := make(map[int32]bool)
+	for _, info := range c.topProcesses(infos) {
+		reported[info.PID] = true
+	}
+	for _, indexes := range matched {
+		for _, i := range indexes {
+			reported[infos[i].PID] = true
+		}
+	}
+	for i := range infos {
+		if reported[infos[i].PID] {
+			c.collectDetails(c.procs[infos[i].PID], &infos[i])
+		}
+	}
+
+	return &protocol.ProcessData{
+		Top:     c.topProcesses(infos),
+		Watched: c.watchedProcesses(infos, matched),
+	}, nil
+}
+
+// needCmdline 是否有关注规则需要按命令行匹配
+func (c *ProcessCollector) needCmdline() bool {
+	for _, w := range c.watchers {
+		if w.cmdline != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// collectProcess 采集单个进程排序和匹配所需的信息，进程已退出或无权限读取时返回 false
+func (c *ProcessCollector) collectProcess(p *process.Process, totalMemory uint64) (protocol.ProcessStat, bool) {
+	name, err := p.Name()
+	if err != nil {
+		return protocol.ProcessStat{}, false
+	}
+
+	createTime, _ := p.CreateTime()
+	cpuPercent, _ := p.Percent(0)
+
+	var rss uint64
+	if memInfo, err := p.MemoryInfo(); err == nil && memInfo != nil {
+		rss = memInfo.RSS
+	}
+	var memPercent float64
+	if totalMemory > 0 {
+		memPercent = float64(rss) / float64(totalMemory) * 100
+	}
+
+	info := protocol.ProcessStat{
+		PID:           p.Pid,
+		Name:          name,
+		CPUPercent:    cpuPercent,
+		MemoryPercent: memPercent,
+		RSS:           rss,
+		CreateTime:    createTime,
+	}
+	if c.needCmdline() {
+		info.Cmdline = processCmdline(p)
+	}
+	return info, true
+}
+
+// collectDetails 补充需要上报的进程的详细信息
+func (c *ProcessCollector) collectDetails(p *process.Process, info *protocol.ProcessStat) {
+	if p == nil {
+		return
+	}
+	if info.Cmdline == "" {
+		info.Cmdline = processCmdline(p)
+	}
+	info.Username, _ = p.Username()
+	info.NumFDs, _ = p.NumFDs()
+	info.NumThreads, _ = p.NumThreads()
+}
+
+func processCmdline(p *process.Process) string {
+	cmdline, _ := p.Cmdline()
+	if len(cmdline) > maxCmdlineLength {
+		cmdline = cmdline[:maxCmdlineLength]
+	}
+	return cmdline
+}
+
+// matchWatchers 返回每条关注规则匹配到的进程在 infos 中的下标
+func (c *ProcessCollector) matchWatchers(infos []protocol.ProcessStat) [][]int {
+	matched := make([][]int, len(c.watchers))
+	for i, w := range c.watchers {
+		for j, info := range infos {
+			if w.match(info) {
+				matched[i] = append(matched[i], j)
+			}
+		}
+	}
+	return matched
+}
+
+// topProcesses 返回 CPU 占用最高和内存占用最高的进程（合并去重）
+func (c *ProcessCollector) topProcesses(infos []protocol.ProcessStat) []protocol.ProcessStat {
+	if c.topN <= 0 || len(infos) == 0 {
+		return []protocol.ProcessStat{}
+	}
+
+	selected := make(map[int32]bool)
+	var result []protocol.ProcessStat
+	pick := func(less func(a, b protocol.ProcessStat) bool) {
+		sorted := make([]protocol.ProcessStat, len(infos))
+		copy(sorted, infos)
+		sort.Slice(sorted, func(i, j int) bool {
+			return less(sorted[i], sorted[j])
+		})
+		for i := 0; i < len(sorted) && i < c.topN; i++ {
+			if selected[sorted[i].PID] {
+				continue
+			}
+			selected[sorted[i].PID] = true
+			result = append(result, sorted[i])
+		}
+	}
+
+	pick(func(a, b protocol.ProcessStat) bool { return a.CPUPercent > b.CPUPercent })
+	pick(func(a, b protocol.ProcessStat) bool { return a.RSS > b.RSS })
+	return result
+}
+
+// watchedProcesses 按关注规则汇总进程数据并检测重启，matched 为每条规则匹配到的进程下标
+func (c *ProcessCollector) watchedProcesses(infos []protocol.ProcessStat, matched [][]int) []protocol.WatchedProcessData {
+	result := make([]protocol.WatchedProcessData, 0, len(c.watchers))
+	for i, w := range c.watchers {
+		data := protocol.WatchedProcessData{
+			Name: w.config.Name,
+			PIDs: []int32{},
+		}
+
+		var mainPID int32
+		var mainCreated int64
+		for _, j := range matched[i] {
+			info := infos[j]
+			data.Count++
+			data.PIDs = append(data.PIDs, info.PID)
+			data.CPUPercent += info.CPUPercent
+			data.RSS += info.RSS
+			data.NumFDs += info.NumFDs
+			data.NumThreads += info.NumThreads
+			if mainPID == 0 || info.CreateTime < mainCreated {
+				mainPID = info.PID
+				mainCreated = info.CreateTime
+			}
+		}
+
+		if mainPID != 0 {
+			// 主进程发生变化（包括消失后重新出现）视为一次重启
+			if w.mainPID != 0 && (w.mainPID != mainPID || w.mainCreated != mainCreated) {
+				w.restarts++
+			}
+			w.mainPID = mainPID
+			w.mainCreated = mainCreated
+		}
+
+		data.Running = data.Count > 0
+		data.StartedAt = mainCreated
+		data.Restarts = w.restarts
+		result = append(result, data)
+	}
+	return result
+}
+
+// match 判断进程是否满足关注规则
+func (w *processWatcher) match(info protocol.ProcessStat) bool {
+	if w.config.Process != "" && info.Name != w.config.Process {
+		return false
+	}
+	if w.cmdline != nil && !w.cmdline.MatchString(info.Cmdline) {
+		return false
+	}
+	if w.config.SystemdUnit != "" && processSystemdUnit(info.PID) != w.config.SystemdUnit {
+		return false
+	}
+	return true
+}
+
+// processSystemdUnit 从 /proc/<pid>/cgroup 解析进程所属的 systemd 单元
+func processSystemdUnit(pid int32) string {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(int(pid)) + "/cgroup")
+	if err != nil {
+		return ""
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		// cgroup v2: 0::/system.slice/nginx.service
+		// cgroup v1: 1:name=systemd:/system.slice/nginx.service
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[1] != "" && parts[1] != "name=systemd" {
+			continue
+		}
+		segments := strings.Split(parts[2], "/")
+		for i := len(segments) - 1; i >= 0; i-- {
+			if strings.HasSuffix(segments[i], ".service") {
+				return segments[i]
+			}
+		}
+	}
+	return ""
+}
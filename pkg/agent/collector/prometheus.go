@@ -0,0 +1,264 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/pkg/agent/config"
+	"github.com/sourcegraph/conc"
+)
+
+const (
+	// 单个目标每次抓取的样本数上限，超过时视为抓取失败（与 Prometheus 的 sample_limit 行为一致）
+	prometheusSampleLimit = 50000
+	// 单次抓取的响应体大小上限
+	prometheusMaxBodySize = 32 << 20
+
+	prometheusDefaultInterval = 30 * time.Second
+	prometheusDefaultTimeout  = 10 * time.Second
+
+	// 只接受经典文本格式，解析器不支持 OpenMetrics 的秒级时间戳
+	prometheusAcceptHeader = "text/plain;version=0.0.4;q=1,*/*;q=0.1"
+)
+
+// PrometheusCollector 定时抓取本机的 Prometheus 目标，抓取结果通过 WebSocket 上报
+// 与自定义指标一样独立于 WebSocket 连接运行
+type PrometheusCollector struct {
+	config *config.PrometheusConfig
+	client *http.Client
+
+	mu      sync.Mutex
+	results map[string]protocol.PrometheusScrapeData // job -> 最近一次尚未发送的抓取结果
+}
+
+// prometheusTarget 预编译过滤规则的抓取目标
+type prometheusTarget struct {
+	config.PrometheusTargetConfig
+	instance string
+	include  []*regexp.Regexp
+	exclude  []*regexp.Regexp
+}
+
+// NewPrometheusCollector 创建 Prometheus 抓取器
+func NewPrometheusCollector(cfg *config.Config) *PrometheusCollector {
+	// 抓取本机目标，不使用环境变量中的代理
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+
+	return &PrometheusCollector{
+		config:  &cfg.Collector.Prometheus,
+		client:  &http.Client{Transport: transport},
+		results: make(map[string]protocol.PrometheusScrapeData),
+	}
+}
+
+// Run 启动所有目标的定时抓取，阻塞直到 ctx 取消
+func (c *PrometheusCollector) Run(ctx context.Context) {
+	var wg conc.WaitGroup
+	for _, targetConfig := range c.config.Targets {
+		target, err := newPrometheusTarget(targetConfig)
+		if err != nil {
+			log.Printf("⚠️  Prometheus 抓取目标 %s 配置无效: %v", targetConfig.Job, err)
+			continue
+		}
+		wg.Go(func() {
+			c.runTarget(ctx, target)
+		})
+	}
+	wg.Wait()
+}
+
+// Collect 取出上次发送之后的抓取结果，按 job 排序
+func (c *PrometheusCollector) Collect() []protocol.PrometheusScrapeData {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.results) == 0 {
+		return nil
+	}
+	results := make([]protocol.PrometheusScrapeData, 0, len(c.results))
+	for _, result := range c.results {
+		results = append(results, result)
+	}
+	c.results = make(map[string]protocol.PrometheusScrapeData)
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Job < results[j].Job
+	})
+	return results
+}
+
+func newPrometheusTarget(cfg config.PrometheusTargetConfig) (*prometheusTarget, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	target := &prometheusTarget{
+		PrometheusTargetConfig: cfg,
+		instance:               u.Host,
+	}
+	for _, pattern := range cfg.MetricInclude {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		target.include = append(target.include, re)
+	}
+	for _, pattern := range cfg.MetricExclude {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		target.exclude = append(target.exclude, re)
+	}
+	return target, nil
+}
+
+// keep 判断指标是否需要上报
+func (t *prometheusTarget) keep(name string) bool {
+	for _, re := range t.exclude {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+	if len(t.include) == 0 {
+		return true
+	}
+	for _, re := range t.include {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// runTarget 按间隔抓取单个目标
+func (c *PrometheusCollector) runTarget(ctx context.Context, target *prometheusTarget) {
+	interval := prometheusDefaultInterval
+	if target.Interval > 0 {
+		interval = time.Duration(target.Interval) * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastUp := true
+	for {
+		result := c.scrape(ctx, target)
+		if ctx.Err() != nil {
+			return
+		}
+		// 只在状态变化时打印日志，避免目标长时间不可用时刷屏
+		if !result.Up && lastUp {
+			log.Printf("⚠️  Prometheus 抓取目标 %s 失败: %s", target.Job, result.Error)
+		}
+		lastUp = result.Up
+
+		c.mu.Lock()
+		c.results[target.Job] = result
+		c.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scrape 抓取一次目标并附加 job、instance 及配置的标签
+func (c *PrometheusCollector) scrape(ctx context.Context, target *prometheusTarget) protocol.PrometheusScrapeData {
+	start := time.Now()
+	result := protocol.PrometheusScrapeData{
+		Job:       target.Job,
+		Instance:  target.instance,
+		Timestamp: start.UnixMilli(),
+	}
+
+	samples, err := c.fetch(ctx, target, start.UnixMilli())
+	result.ScrapeDuration = time.Since(start).Seconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Up = true
+	result.Samples = samples
+	return result
+}
+
+func (c *PrometheusCollector) fetch(ctx context.Context, target *prometheusTarget, now int64) ([]protocol.CustomMetricData, error) {
+	timeout := prometheusDefaultTimeout
+	if target.Timeout > 0 {
+		timeout = time.Duration(target.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", prometheusAcceptHeader)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP 状态码 %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, prometheusMaxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > prometheusMaxBodySize {
+		return nil, fmt.Errorf("响应体超过 %d 字节", prometheusMaxBodySize)
+	}
+
+	parsed, err := parsePrometheusText(body, now)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := parsed[:0]
+	for _, sample := range parsed {
+		if !target.keep(sample.Name) {
+			continue
+		}
+		sample.Labels = target.relabel(sample.Labels)
+		samples = append(samples, sample)
+	}
+	if len(samples) > prometheusSampleLimit {
+		return nil, fmt.Errorf("样本数 %d 超过上限 %d", len(samples), prometheusSampleLimit)
+	}
+	return samples, nil
+}
+
+// relabel 附加 job、instance 和配置的标签，exporter 自带的同名标签改名为 exported_*
+func (t *prometheusTarget) relabel(labels map[string]string) map[string]string {
+	result := make(map[string]string, len(labels)+len(t.Labels)+2)
+	for name, value := range labels {
+		if name == "job" || name == "instance" {
+			name = "exported_" + name
+		}
+		result[name] = value
+	}
+	for name, value := range t.Labels {
+		result[name] = value
+	}
+	result["job"] = t.Job
+	result["instance"] = t.instance
+	return result
+}
@@ -0,0 +1,277 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+// SMART 数据变化很慢，读取较为耗时且可能唤醒休眠的磁盘，间隔较长时间刷新一次
+const smartRefreshInterval = 10 * time.Minute
+
+// 单个设备读取的超时时间
+const smartCommandTimeout = 30 * time.Second
+
+// ATA SMART 属性 ID
+const (
+	ataAttrReallocatedSectors   = 5
+	ataAttrWearLevelingCount    = 177
+	ataAttrSSDLifeLeft          = 231
+	ataAttrMediaWearout         = 233
+	ataAttrPendingSectors       = 197
+	ataAttrUncorrectableSectors = 198
+)
+
+// SmartCollector 磁盘健康采集器，优先使用 smartctl，没有 smartctl 时使用 nvme-cli 读取 NVMe 健康日志
+type SmartCollector struct {
+	data       []protocol.DiskHealthData
+	lastUpdate time.Time
+	refreshing bool
+	mu         sync.Mutex
+}
+
+// NewSmartCollector 创建磁盘健康采集器
+func NewSmartCollector() *SmartCollector {
+	return &SmartCollector{}
+}
+
+// Collect 返回最近一次读取的磁盘健康数据，数据过期时在后台刷新，避免阻塞指标采集
+func (c *SmartCollector) Collect() []protocol.DiskHealthData {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.refreshing && time.Since(c.lastUpdate) >= smartRefreshInterval {
+		c.refreshing = true
+		go c.refresh()
+	}
+	return c.data
+}
+
+func (c *SmartCollector) refresh() {
+	data := readDiskHealth()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data = data
+	c.lastUpdate = time.Now()
+	c.refreshing = false
+}
+
+// readDiskHealth 读取所有磁盘的健康数据
+func readDiskHealth() []protocol.DiskHealthData {
+	var result []protocol.DiskHealthData
+	if _, err := exec.LookPath("smartctl"); err == nil {
+		result = readSmartctl()
+	} else if _, err := exec.LookPath("nvme"); err == nil {
+		result = readNvmeCli()
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Device < result[j].Device
+	})
+	return result
+}
+
+// readSmartctl 通过 smartctl --scan 发现设备并逐个读取
+func readSmartctl() []protocol.DiskHealthData {
+	output, _ := runSmartCommand("smartctl", "--scan", "--json")
+	var scan struct {
+		Devices []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		} `json:"devices"`
+	}
+	if err := json.Unmarshal(output, &scan); err != nil {
+		return nil
+	}
+
+	var result []protocol.DiskHealthData
+	for _, device := range scan.Devices {
+		// -n standby: 磁盘休眠时不读取，避免唤醒
+		args := []string{"--json", "-a", "-n", "standby"}
+		if device.Type != "" {
+			args = append(args, "-d", device.Type)
+		}
+		args = append(args, device.Name)
+
+		// smartctl 的退出码是位掩码，磁盘存在问题时也会返回非 0，以输出内容为准
+		output, _ := runSmartCommand("smartctl", args...)
+		health, err := parseSmartctlJSON(output)
+		if err != nil {
+			continue
+		}
+		result = append(result, *health)
+	}
+	return result
+}
+
+// readNvmeCli 通过 nvme-cli 读取 NVMe 控制器的健康日志
+func readNvmeCli() []protocol.DiskHealthData {
+	controllers, _ := filepath.Glob("/dev/nvme[0-9]")
+	more, _ := filepath.Glob("/dev/nvme[0-9][0-9]")
+	controllers = append(controllers, more...)
+
+	var result []protocol.DiskHealthData
+	for _, device := range controllers {
+		output, err := runSmartCommand("nvme", "smart-log", device, "-o", "json")
+		if err != nil {
+			continue
+		}
+		health, err := parseNvmeSmartLog(output)
+		if err != nil {
+			continue
+		}
+		health.Device = device
+		result = append(result, *health)
+	}
+	return result
+}
+
+func runSmartCommand(name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), smartCommandTimeout)
+	defer cancel()
+	return exec.CommandContext(ctx, name, args...).Output()
+}
+
+// smartctlOutput smartctl --json 输出中使用到的字段
+type smartctlOutput struct {
+	Device struct {
+		Name     string `json:"name"`
+		Protocol string `json:"protocol"`
+	} `json:"device"`
+	ModelName    string `json:"model_name"`
+	SerialNumber string `json:"serial_number"`
+	SmartStatus  *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature struct {
+		Current float64 `json:"current"`
+	} `json:"temperature"`
+	PowerOnTime struct {
+		Hours uint64 `json:"hours"`
+	} `json:"power_on_time"`
+	EnduranceUsed *struct {
+		CurrentPercent float64 `json:"current_percent"`
+	} `json:"endurance_used"`
+	ATASmartAttributes struct {
+		Table []struct {
+			ID    int     `json:"id"`
+			Name  string  `json:"name"`
+			Value float64 `json:"value"`
+			Raw   struct {
+				Value uint64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	NvmeLog *struct {
+		CriticalWarning         uint8   `json:"critical_warning"`
+		Temperature             float64 `json:"temperature"`
+		AvailableSpare          float64 `json:"available_spare"`
+		AvailableSpareThreshold float64 `json:"available_spare_threshold"`
+		PercentageUsed          float64 `json:"percentage_used"`
+		PowerOnHours            uint64  `json:"power_on_hours"`
+		MediaErrors             uint64  `json:"media_errors"`
+	} `json:"nvme_smart_health_information_log"`
+	SCSIGrownDefectList *uint64 `json:"scsi_grown_defect_list"`
+}
+
+// parseSmartctlJSON 解析 smartctl --json -a 的输出
+func parseSmartctlJSON(data []byte) (*protocol.DiskHealthData, error) {
+	var out smartctlOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	// 磁盘休眠或不支持 SMART 时没有健康评估结果
+	if out.Device.Name == "" || out.SmartStatus == nil {
+		return nil, fmt.Errorf("设备 %s 没有 SMART 数据", out.Device.Name)
+	}
+
+	health := &protocol.DiskHealthData{
+		Device:       out.Device.Name,
+		Model:        out.ModelName,
+		Serial:       out.SerialNumber,
+		Protocol:     out.Device.Protocol,
+		Passed:       out.SmartStatus.Passed,
+		Temperature:  out.Temperature.Current,
+		PowerOnHours: out.PowerOnTime.Hours,
+	}
+
+	for _, attr := range out.ATASmartAttributes.Table {
+		switch attr.ID {
+		case ataAttrReallocatedSectors:
+			health.ReallocatedSectors = attr.Raw.Value
+		case ataAttrPendingSectors:
+			health.PendingSectors = attr.Raw.Value
+		case ataAttrUncorrectableSectors:
+			health.UncorrectableSectors = attr.Raw.Value
+		case ataAttrWearLevelingCount, ataAttrSSDLifeLeft, ataAttrMediaWearout:
+			// 各厂商的寿命属性归一化值均为剩余寿命（100 为全新）
+			if used := 100 - attr.Value; used > health.PercentageUsed {
+				health.PercentageUsed = used
+			}
+		}
+	}
+	if out.SCSIGrownDefectList != nil {
+		health.ReallocatedSectors = *out.SCSIGrownDefectList
+	}
+
+	if log := out.NvmeLog; log != nil {
+		health.CriticalWarning = log.CriticalWarning
+		health.AvailableSpare = log.AvailableSpare
+		health.AvailableSpareThresh = log.AvailableSpareThreshold
+		health.PercentageUsed = log.PercentageUsed
+		health.MediaErrors = log.MediaErrors
+		if health.Temperature == 0 {
+			health.Temperature = log.Temperature
+		}
+		if health.PowerOnHours == 0 {
+			health.PowerOnHours = log.PowerOnHours
+		}
+	}
+	if out.EnduranceUsed != nil {
+		health.PercentageUsed = out.EnduranceUsed.CurrentPercent
+	}
+
+	return health, nil
+}
+
+// nvmeSmartLog nvme smart-log -o json 的输出，温度单位为开尔文
+type nvmeSmartLog struct {
+	CriticalWarning uint8   `json:"critical_warning"`
+	Temperature     float64 `json:"temperature"`
+	AvailSpare      float64 `json:"avail_spare"`
+	SpareThresh     float64 `json:"spare_thresh"`
+	PercentUsed     float64 `json:"percent_used"`
+	PowerOnHours    uint64  `json:"power_on_hours"`
+	MediaErrors     uint64  `json:"media_errors"`
+}
+
+// parseNvmeSmartLog 解析 nvme-cli 输出的 NVMe 健康日志
+func parseNvmeSmartLog(data []byte) (*protocol.DiskHealthData, error) {
+	var log nvmeSmartLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, err
+	}
+
+	temperature := log.Temperature
+	if temperature > 0 {
+		temperature -= 273
+	}
+	return &protocol.DiskHealthData{
+		Protocol:             "NVMe",
+		Passed:               log.CriticalWarning == 0,
+		Temperature:          temperature,
+		PowerOnHours:         log.PowerOnHours,
+		PercentageUsed:       log.PercentUsed,
+		AvailableSpare:       log.AvailSpare,
+		AvailableSpareThresh: log.SpareThresh,
+		MediaErrors:          log.MediaErrors,
+		CriticalWarning:      log.CriticalWarning,
+	}, nil
+}
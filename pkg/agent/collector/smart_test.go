@@ -0,0 +1,82 @@
+package collector
+
+import (
+	"os"
+	"testing"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("读取测试数据 %s 失败: %v", name, err)
+	}
+	return data
+}
+
+func TestParseSmartctlATA(t *testing.T) {
+	health, err := parseSmartctlJSON(readFixture(t, "smartctl_ata.json"))
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+
+	if health.Device != "/dev/sda" || health.Protocol != "ATA" || health.Model != "ST2000DM008-2FR102" {
+		t.Errorf("设备信息不正确: %+v", health)
+	}
+	if !health.Passed {
+		t.Error("SMART 总体评估应为通过")
+	}
+	if health.Temperature != 36 || health.PowerOnHours != 33814 {
+		t.Errorf("温度或通电时间不正确: %v, %v", health.Temperature, health.PowerOnHours)
+	}
+	if health.ReallocatedSectors != 1848 || health.PendingSectors != 16 || health.UncorrectableSectors != 16 {
+		t.Errorf("扇区计数不正确: %d, %d, %d", health.ReallocatedSectors, health.PendingSectors, health.UncorrectableSectors)
+	}
+	if health.PercentageUsed != 0 {
+		t.Errorf("机械硬盘没有寿命属性，寿命已使用应为 0，实际为 %v", health.PercentageUsed)
+	}
+}
+
+func TestParseSmartctlNVMe(t *testing.T) {
+	health, err := parseSmartctlJSON(readFixture(t, "smartctl_nvme.json"))
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+
+	if health.Device != "/dev/nvme0" || health.Protocol != "NVMe" {
+		t.Errorf("设备信息不正确: %+v", health)
+	}
+	if !health.Passed || health.CriticalWarning != 0 {
+		t.Errorf("健康状态不正确: passed=%v criticalWarning=%d", health.Passed, health.CriticalWarning)
+	}
+	if health.Temperature != 41 || health.PowerOnHours != 8765 {
+		t.Errorf("温度或通电时间不正确: %v, %v", health.Temperature, health.PowerOnHours)
+	}
+	if health.PercentageUsed != 4 || health.AvailableSpare != 100 || health.AvailableSpareThresh != 10 {
+		t.Errorf("寿命数据不正确: %+v", health)
+	}
+}
+
+func TestParseSmartctlStandby(t *testing.T) {
+	if _, err := parseSmartctlJSON(readFixture(t, "smartctl_standby.json")); err == nil {
+		t.Error("休眠的磁盘没有 SMART 数据，应返回错误")
+	}
+}
+
+func TestParseNvmeSmartLog(t *testing.T) {
+	health, err := parseNvmeSmartLog(readFixture(t, "nvme_smart_log.json"))
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+
+	if health.Passed || health.CriticalWarning != 4 {
+		t.Errorf("存在严重警告时健康评估不应通过: passed=%v criticalWarning=%d", health.Passed, health.CriticalWarning)
+	}
+	// nvme-cli 输出的温度单位为开尔文
+	if health.Temperature != 45 {
+		t.Errorf("温度应为 45，实际为 %v", health.Temperature)
+	}
+	if health.PercentageUsed != 112 || health.AvailableSpare != 3 || health.MediaErrors != 27 || health.PowerOnHours != 41022 {
+		t.Errorf("健康日志数据不正确: %+v", health)
+	}
+}
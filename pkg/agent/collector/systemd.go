@@ -0,0 +1,153 @@
+package collector
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/pkg/agent/config"
+)
+
+// 单次采集访问 D-Bus 的超时时间
+const systemdTimeout = 5 * time.Second
+
+// SystemdCollector systemd 单元采集器，通过 D-Bus 读取单元状态和重启次数
+type SystemdCollector struct {
+	enabled       bool
+	patterns      []string
+	includeFailed bool
+
+	// 上一次上报过的单元，单元被卸载（例如 reset-failed）后仍需上报一次，以便服务端恢复告警
+	reported map[string]bool
+	mu       sync.Mutex
+}
+
+// NewSystemdCollector 创建 systemd 单元采集器
+func NewSystemdCollector(cfg *config.Config) *SystemdCollector {
+	return &SystemdCollector{
+		enabled:       cfg.Collector.Systemd.Enabled,
+		patterns:      cfg.Collector.Systemd.Units,
+		includeFailed: cfg.Collector.Systemd.IncludeFailed,
+		reported:      make(map[string]bool),
+	}
+}
+
+// Enabled 是否需要采集
+func (c *SystemdCollector) Enabled() bool {
+	return runtime.GOOS == "linux" && c.enabled && (len(c.patterns) > 0 || c.includeFailed)
+}
+
+// Collect 采集关注的单元和处于 failed 状态的单元
+func (c *SystemdCollector) Collect() ([]protocol.SystemdUnitData, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	conn, err := dialSystemBus(systemdTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("连接 systemd 失败: %w", err)
+	}
+	defer conn.Close()
+
+	reply, err := conn.call("org.freedesktop.systemd1", "/org/freedesktop/systemd1",
+		"org.freedesktop.systemd1.Manager", "ListUnits", "")
+	if err != nil {
+		return nil, fmt.Errorf("获取 systemd 单元列表失败: %w", err)
+	}
+	if len(reply) == 0 {
+		return nil, fmt.Errorf("获取 systemd 单元列表失败: 返回为空")
+	}
+	units, ok := reply[0].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("获取 systemd 单元列表失败: 返回格式错误")
+	}
+
+	seen := make(map[string]bool)
+	reported := make(map[string]bool)
+	result := make([]protocol.SystemdUnitData, 0)
+	for _, item := range units {
+		// (ssssssouso): 名称、描述、加载状态、运行状态、子状态、following、对象路径、任务ID、任务类型、任务路径
+		fields, ok := item.([]interface{})
+		if !ok || len(fields) < 7 {
+			continue
+		}
+		unit := protocol.SystemdUnitData{}
+		unit.Name, _ = fields[0].(string)
+		unit.Description, _ = fields[1].(string)
+		unit.LoadState, _ = fields[2].(string)
+		unit.ActiveState, _ = fields[3].(string)
+		unit.SubState, _ = fields[4].(string)
+		objectPath, _ := fields[6].(string)
+
+		selected := c.match(unit.Name) || (c.includeFailed && unit.ActiveState == "failed")
+		// 不再满足条件的单元（例如从 failed 恢复）最后再上报一次
+		if !selected && !c.reported[unit.Name] {
+			continue
+		}
+
+		if strings.HasSuffix(unit.Name, ".service") && objectPath != "" {
+			unit.Restarts = c.restarts(conn, objectPath)
+		}
+
+		seen[unit.Name] = true
+		if selected {
+			reported[unit.Name] = true
+		}
+		result = append(result, unit)
+	}
+
+	// 未加载的关注单元和已卸载的单元按 inactive 状态上报
+	unloaded := make(map[string]bool)
+	for name := range c.reported {
+		unloaded[name] = true
+	}
+	for _, pattern := range c.patterns {
+		if !strings.ContainsAny(pattern, "*?[") {
+			unloaded[pattern] = true
+			reported[pattern] = true
+		}
+	}
+	for name := range unloaded {
+		if seen[name] {
+			continue
+		}
+		result = append(result, protocol.SystemdUnitData{
+			Name:        name,
+			LoadState:   "not-found",
+			ActiveState: "inactive",
+			SubState:    "dead",
+		})
+	}
+	c.reported = reported
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// restarts 读取 service 单元的 NRestarts 属性，旧版本 systemd（< 235）不支持时返回 0
+func (c *SystemdCollector) restarts(conn *dbusConn, objectPath string) uint32 {
+	reply, err := conn.call("org.freedesktop.systemd1", objectPath,
+		"org.freedesktop.DBus.Properties", "Get", "ss",
+		"org.freedesktop.systemd1.Service", "NRestarts")
+	if err != nil || len(reply) == 0 {
+		return 0
+	}
+	n, _ := reply[0].(uint32)
+	return n
+}
+
+// match 判断单元是否在关注列表中
+func (c *SystemdCollector) match(name string) bool {
+	for _, pattern := range c.patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
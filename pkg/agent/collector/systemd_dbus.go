@@ -0,0 +1,463 @@
+package collector
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 仅实现读取 systemd 状态所需的最小 D-Bus 客户端：EXTERNAL 认证、方法调用和返回值解析
+
+const (
+	dbusSystemBusSocket = "/run/dbus/system_bus_socket"
+	// systemd 私有总线，仅 root 可访问，dbus-daemon 不可用时使用
+	dbusSystemdPrivateSocket = "/run/systemd/private"
+
+	dbusMessageMethodCall   = 1
+	dbusMessageMethodReturn = 2
+	dbusMessageError        = 3
+
+	dbusFieldPath        = 1
+	dbusFieldInterface   = 2
+	dbusFieldMember      = 3
+	dbusFieldErrorName   = 4
+	dbusFieldReplySerial = 5
+	dbusFieldDestination = 6
+	dbusFieldSignature   = 8
+
+	// 单条消息的最大长度（D-Bus 规范为 128MB，这里收紧限制）
+	dbusMaxMessageSize = 64 << 20
+)
+
+// dbusConn D-Bus 连接
+type dbusConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	serial uint32
+}
+
+// dialSystemBus 连接系统总线，失败时尝试 systemd 私有总线
+func dialSystemBus(timeout time.Duration) (*dbusConn, error) {
+	if c, err := dialDBus(dbusSystemBusSocket, timeout, true); err == nil {
+		return c, nil
+	}
+	return dialDBus(dbusSystemdPrivateSocket, timeout, false)
+}
+
+func dialDBus(socket string, timeout time.Duration, hello bool) (*dbusConn, error) {
+	conn, err := net.DialTimeout("unix", socket, timeout)
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	c := &dbusConn{conn: conn, reader: bufio.NewReader(conn)}
+	if err := c.auth(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if hello {
+		if _, err := c.call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "Hello", ""); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Close 关闭连接
+func (c *dbusConn) Close() error {
+	return c.conn.Close()
+}
+
+// auth 使用当前用户的 UID 进行 EXTERNAL 认证
+func (c *dbusConn) auth() error {
+	uid := hex.EncodeToString([]byte(strconv.Itoa(os.Getuid())))
+	if _, err := c.conn.Write([]byte("\x00AUTH EXTERNAL " + uid + "\r\n")); err != nil {
+		return err
+	}
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "OK ") {
+		return fmt.Errorf("D-Bus 认证失败: %s", strings.TrimSpace(line))
+	}
+	_, err = c.conn.Write([]byte("BEGIN\r\n"))
+	return err
+}
+
+// call 调用方法并返回结果，参数仅支持字符串类型（s、o）
+func (c *dbusConn) call(dest, path, iface, member, signature string, args ...string) ([]interface{}, error) {
+	c.serial++
+	serial := c.serial
+
+	body := &dbusEncoder{}
+	for _, arg := range args {
+		body.string(arg)
+	}
+
+	header := &dbusEncoder{}
+	header.byte('l')
+	header.byte(dbusMessageMethodCall)
+	header.byte(0)
+	header.byte(1)
+	header.uint32(uint32(len(body.buf)))
+	header.uint32(serial)
+
+	lengthPos := len(header.buf)
+	header.uint32(0)
+	header.align(8)
+	fieldsStart := len(header.buf)
+	header.field(dbusFieldPath, "o", path)
+	header.field(dbusFieldInterface, "s", iface)
+	header.field(dbusFieldMember, "s", member)
+	header.field(dbusFieldDestination, "s", dest)
+	if signature != "" {
+		header.field(dbusFieldSignature, "g", signature)
+	}
+	binary.LittleEndian.PutUint32(header.buf[lengthPos:], uint32(len(header.buf)-fieldsStart))
+	header.align(8)
+
+	if _, err := c.conn.Write(append(header.buf, body.buf...)); err != nil {
+		return nil, err
+	}
+
+	// 跳过信号等无关消息，直到收到对应的返回
+	for {
+		msgType, fields, body, err := c.readMessage()
+		if err != nil {
+			return nil, err
+		}
+		replySerial, _ := fields[dbusFieldReplySerial].(uint32)
+		if replySerial != serial {
+			continue
+		}
+		switch msgType {
+		case dbusMessageMethodReturn:
+			return body, nil
+		case dbusMessageError:
+			name, _ := fields[dbusFieldErrorName].(string)
+			if len(body) > 0 {
+				if msg, ok := body[0].(string); ok {
+					return nil, fmt.Errorf("%s: %s", name, msg)
+				}
+			}
+			return nil, errors.New(name)
+		}
+	}
+}
+
+// readMessage 读取一条消息，返回消息类型、头部字段和解析后的消息体
+func (c *dbusConn) readMessage() (byte, map[byte]interface{}, []interface{}, error) {
+	fixed := make([]byte, 16)
+	if _, err := io.ReadFull(c.reader, fixed); err != nil {
+		return 0, nil, nil, err
+	}
+
+	var order binary.ByteOrder
+	switch fixed[0] {
+	case 'l':
+		order = binary.LittleEndian
+	case 'B':
+		order = binary.BigEndian
+	default:
+		return 0, nil, nil, fmt.Errorf("无效的 D-Bus 字节序标记: %q", fixed[0])
+	}
+
+	bodyLen := order.Uint32(fixed[4:])
+	fieldsLen := order.Uint32(fixed[12:])
+	headerLen := dbusAlign(16+int(fieldsLen), 8)
+	total := headerLen + int(bodyLen)
+	if total > dbusMaxMessageSize {
+		return 0, nil, nil, fmt.Errorf("D-Bus 消息过大: %d", total)
+	}
+
+	msg := make([]byte, total)
+	copy(msg, fixed)
+	if _, err := io.ReadFull(c.reader, msg[16:]); err != nil {
+		return 0, nil, nil, err
+	}
+
+	d := &dbusDecoder{buf: msg, order: order, pos: 12}
+	rawFields, err := d.value("a(yv)")
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	fields := make(map[byte]interface{})
+	for _, item := range rawFields.([]interface{}) {
+		field := item.([]interface{})
+		fields[field[0].(byte)] = field[1]
+	}
+
+	var body []interface{}
+	if signature, _ := fields[dbusFieldSignature].(string); signature != "" && bodyLen > 0 {
+		d.pos = headerLen
+		for rest := signature; rest != ""; {
+			var typ string
+			typ, rest, err = dbusNextType(rest)
+			if err != nil {
+				return 0, nil, nil, err
+			}
+			v, err := d.value(typ)
+			if err != nil {
+				return 0, nil, nil, err
+			}
+			body = append(body, v)
+		}
+	}
+	return fixed[1], fields, body, nil
+}
+
+// dbusEncoder 小端序编码
+type dbusEncoder struct {
+	buf []byte
+}
+
+func (e *dbusEncoder) align(n int) {
+	for len(e.buf)%n != 0 {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *dbusEncoder) byte(b byte) {
+	e.buf = append(e.buf, b)
+}
+
+func (e *dbusEncoder) uint32(v uint32) {
+	e.align(4)
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, v)
+}
+
+func (e *dbusEncoder) string(s string) {
+	e.uint32(uint32(len(s)))
+	e.buf = append(e.buf, s...)
+	e.buf = append(e.buf, 0)
+}
+
+func (e *dbusEncoder) signature(s string) {
+	e.buf = append(e.buf, byte(len(s)))
+	e.buf = append(e.buf, s...)
+	e.buf = append(e.buf, 0)
+}
+
+// field 编码一个头部字段 (yv)
+func (e *dbusEncoder) field(code byte, signature, value string) {
+	e.align(8)
+	e.byte(code)
+	e.signature(signature)
+	if signature == "g" {
+		e.signature(value)
+	} else {
+		e.string(value)
+	}
+}
+
+// dbusDecoder 按签名解析 D-Bus 数据，对齐以消息开头为基准
+type dbusDecoder struct {
+	buf   []byte
+	order binary.ByteOrder
+	pos   int
+}
+
+func (d *dbusDecoder) take(n, alignment int) ([]byte, error) {
+	d.pos = dbusAlign(d.pos, alignment)
+	if d.pos+n > len(d.buf) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// value 解析单个完整类型
+func (d *dbusDecoder) value(sig string) (interface{}, error) {
+	switch sig[0] {
+	case 'y':
+		b, err := d.take(1, 1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0], nil
+	case 'b':
+		b, err := d.take(4, 4)
+		if err != nil {
+			return nil, err
+		}
+		return d.order.Uint32(b) != 0, nil
+	case 'n':
+		b, err := d.take(2, 2)
+		if err != nil {
+			return nil, err
+		}
+		return int16(d.order.Uint16(b)), nil
+	case 'q':
+		b, err := d.take(2, 2)
+		if err != nil {
+			return nil, err
+		}
+		return d.order.Uint16(b), nil
+	case 'i':
+		b, err := d.take(4, 4)
+		if err != nil {
+			return nil, err
+		}
+		return int32(d.order.Uint32(b)), nil
+	case 'u', 'h':
+		b, err := d.take(4, 4)
+		if err != nil {
+			return nil, err
+		}
+		return d.order.Uint32(b), nil
+	case 'x':
+		b, err := d.take(8, 8)
+		if err != nil {
+			return nil, err
+		}
+		return int64(d.order.Uint64(b)), nil
+	case 't':
+		b, err := d.take(8, 8)
+		if err != nil {
+			return nil, err
+		}
+		return d.order.Uint64(b), nil
+	case 'd':
+		b, err := d.take(8, 8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(d.order.Uint64(b)), nil
+	case 's', 'o':
+		b, err := d.take(4, 4)
+		if err != nil {
+			return nil, err
+		}
+		s, err := d.take(int(d.order.Uint32(b))+1, 1)
+		if err != nil {
+			return nil, err
+		}
+		return string(s[:len(s)-1]), nil
+	case 'g':
+		b, err := d.take(1, 1)
+		if err != nil {
+			return nil, err
+		}
+		s, err := d.take(int(b[0])+1, 1)
+		if err != nil {
+			return nil, err
+		}
+		return string(s[:len(s)-1]), nil
+	case 'v':
+		inner, err := d.value("g")
+		if err != nil {
+			return nil, err
+		}
+		if inner.(string) == "" {
+			return nil, fmt.Errorf("无效的 variant 签名")
+		}
+		return d.value(inner.(string))
+	case 'a':
+		b, err := d.take(4, 4)
+		if err != nil {
+			return nil, err
+		}
+		length := int(d.order.Uint32(b))
+		elem := sig[1:]
+		d.pos = dbusAlign(d.pos, dbusTypeAlignment(elem[0]))
+		end := d.pos + length
+		if end > len(d.buf) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		items := []interface{}{}
+		for d.pos < end {
+			v, err := d.value(elem)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	case '(', '{':
+		d.pos = dbusAlign(d.pos, 8)
+		var fields []interface{}
+		for rest := sig[1 : len(sig)-1]; rest != ""; {
+			typ, next, err := dbusNextType(rest)
+			if err != nil {
+				return nil, err
+			}
+			v, err := d.value(typ)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, v)
+			rest = next
+		}
+		return fields, nil
+	default:
+		return nil, fmt.Errorf("不支持的 D-Bus 类型: %c", sig[0])
+	}
+}
+
+// dbusNextType 从签名中拆出第一个完整类型
+func dbusNextType(sig string) (string, string, error) {
+	if sig == "" {
+		return "", "", fmt.Errorf("D-Bus 签名为空")
+	}
+	switch sig[0] {
+	case 'a':
+		elem, rest, err := dbusNextType(sig[1:])
+		if err != nil {
+			return "", "", err
+		}
+		return "a" + elem, rest, nil
+	case '(', '{':
+		closing := byte(')')
+		if sig[0] == '{' {
+			closing = '}'
+		}
+		depth := 0
+		for i := 0; i < len(sig); i++ {
+			switch sig[i] {
+			case '(', '{':
+				depth++
+			case ')', '}':
+				depth--
+				if depth == 0 {
+					if sig[i] != closing {
+						return "", "", fmt.Errorf("无效的 D-Bus 签名: %s", sig)
+					}
+					return sig[:i+1], sig[i+1:], nil
+				}
+			}
+		}
+		return "", "", fmt.Errorf("无效的 D-Bus 签名: %s", sig)
+	default:
+		return sig[:1], sig[1:], nil
+	}
+}
+
+func dbusTypeAlignment(t byte) int {
+	switch t {
+	case 'n', 'q':
+		return 2
+	case 'b', 'i', 'u', 'h', 's', 'o', 'a':
+		return 4
+	case 'x', 't', 'd', '(', '{':
+		return 8
+	default:
+		return 1
+	}
+}
+
+func dbusAlign(pos, n int) int {
+	return (pos + n - 1) / n * n
+}
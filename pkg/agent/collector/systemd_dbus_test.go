@@ -0,0 +1,256 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 测试数据由 dbus-daemon 转发的真实消息抓取而来，服务端为实现了 ListUnits 和 NRestarts 的 GDBus 服务：
+//   - dbus_hello_reply.bin: Hello 的返回（reply_serial=1）及随后的 NameAcquired 信号
+//   - dbus_list_units_reply.bin: ListUnits 的返回（reply_serial=2）
+//   - dbus_nrestarts_reply.bin: Properties.Get NRestarts 的返回（reply_serial=3）
+//   - dbus_get_error_reply.bin: Properties.Get 不存在的属性返回的错误（reply_serial=4）
+//   - dbus_get_call.bin: gdbus call 发送的 Properties.Get 调用
+
+// fixtureConn 从字节流读取消息的连接，只用于解析
+func fixtureConn(data []byte) *dbusConn {
+	return &dbusConn{reader: bufio.NewReader(bytes.NewReader(data))}
+}
+
+// splitDBusMessages 按消息头中的长度拆分连续的消息
+func splitDBusMessages(t *testing.T, data []byte) [][]byte {
+	t.Helper()
+	var msgs [][]byte
+	for len(data) > 0 {
+		if len(data) < 16 {
+			t.Fatalf("消息不完整: %d 字节", len(data))
+		}
+		order := binary.ByteOrder(binary.LittleEndian)
+		if data[0] == 'B' {
+			order = binary.BigEndian
+		}
+		total := dbusAlign(16+int(order.Uint32(data[12:])), 8) + int(order.Uint32(data[4:]))
+		msgs = append(msgs, data[:total])
+		data = data[total:]
+	}
+	return msgs
+}
+
+// serveDBus 在管道的另一端读取一条调用，交给 handle 检查后写回 replies
+func serveDBus(t *testing.T, handle func(fields map[byte]interface{}, body []interface{}), replies ...[]byte) *dbusConn {
+	t.Helper()
+	client, server := net.Pipe()
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	go func() {
+		s := &dbusConn{conn: server, reader: bufio.NewReader(server)}
+		msgType, fields, body, err := s.readMessage()
+		if err != nil {
+			t.Errorf("解析调用失败: %v", err)
+			return
+		}
+		if msgType != dbusMessageMethodCall {
+			t.Errorf("消息类型为 %d，应为方法调用", msgType)
+		}
+		if handle != nil {
+			handle(fields, body)
+		}
+		for _, reply := range replies {
+			if _, err := server.Write(reply); err != nil {
+				return
+			}
+		}
+	}()
+
+	return &dbusConn{conn: client, reader: bufio.NewReader(client)}
+}
+
+func TestDBusDecodeListUnits(t *testing.T) {
+	msgType, fields, body, err := fixtureConn(readFixture(t, "dbus_list_units_reply.bin")).readMessage()
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if msgType != dbusMessageMethodReturn {
+		t.Errorf("消息类型为 %d，应为方法返回", msgType)
+	}
+	if serial, _ := fields[dbusFieldReplySerial].(uint32); serial != 2 {
+		t.Errorf("reply_serial 为 %d，应为 2", serial)
+	}
+	if signature, _ := fields[dbusFieldSignature].(string); signature != "a(ssssssouso)" {
+		t.Errorf("签名为 %q，应为 a(ssssssouso)", signature)
+	}
+
+	if len(body) != 1 {
+		t.Fatalf("消息体应只有一个参数，实际为 %d", len(body))
+	}
+	units, ok := body[0].([]interface{})
+	if !ok || len(units) != 4 {
+		t.Fatalf("单元列表解析错误: %#v", body[0])
+	}
+
+	want := []interface{}{
+		"nginx.service",
+		"A high performance web server and a reverse proxy server",
+		"loaded", "active", "running", "",
+		"/org/freedesktop/systemd1/unit/nginx_2eservice",
+		uint32(0), "", "/",
+	}
+	if !reflect.DeepEqual(units[0], want) {
+		t.Errorf("第一个单元为 %#v，应为 %#v", units[0], want)
+	}
+
+	// 最后一个单元带有正在执行的任务
+	last := units[3].([]interface{})
+	if last[0] != "apt-daily.service" || last[7] != uint32(1234) || last[8] != "start" || last[9] != "/org/freedesktop/systemd1/job/1234" {
+		t.Errorf("任务字段解析错误: %#v", last)
+	}
+}
+
+func TestDBusDecodeVariant(t *testing.T) {
+	_, fields, body, err := fixtureConn(readFixture(t, "dbus_nrestarts_reply.bin")).readMessage()
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if signature, _ := fields[dbusFieldSignature].(string); signature != "v" {
+		t.Errorf("签名为 %q，应为 v", signature)
+	}
+	if len(body) != 1 || body[0] != uint32(3) {
+		t.Errorf("NRestarts 解析错误: %#v", body)
+	}
+}
+
+func TestDBusDecodeSignal(t *testing.T) {
+	msgs := splitDBusMessages(t, readFixture(t, "dbus_hello_reply.bin"))
+	if len(msgs) != 2 {
+		t.Fatalf("应包含 2 条消息，实际为 %d", len(msgs))
+	}
+
+	msgType, fields, body, err := fixtureConn(msgs[1]).readMessage()
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if msgType != 4 {
+		t.Errorf("消息类型为 %d，应为信号", msgType)
+	}
+	if fields[dbusFieldMember] != "NameAcquired" || fields[dbusFieldPath] != "/org/freedesktop/DBus" {
+		t.Errorf("头部字段解析错误: %#v", fields)
+	}
+	if len(body) != 1 || !strings.HasPrefix(body[0].(string), ":1.") {
+		t.Errorf("唯一名称解析错误: %#v", body)
+	}
+}
+
+func TestDBusEncodeCall(t *testing.T) {
+	// 与 gdbus 发送的同一调用对比头部字段和参数
+	_, wantFields, wantBody, err := fixtureConn(readFixture(t, "dbus_get_call.bin")).readMessage()
+	if err != nil {
+		t.Fatalf("解析 gdbus 调用失败: %v", err)
+	}
+
+	conn := serveDBus(t, func(fields map[byte]interface{}, body []interface{}) {
+		for _, code := range []byte{dbusFieldPath, dbusFieldInterface, dbusFieldMember, dbusFieldDestination, dbusFieldSignature} {
+			if fields[code] != wantFields[code] {
+				t.Errorf("头部字段 %d 为 %#v，应为 %#v", code, fields[code], wantFields[code])
+			}
+		}
+		if serial, ok := fields[dbusFieldReplySerial]; ok {
+			t.Errorf("方法调用不应包含 reply_serial: %v", serial)
+		}
+		if !reflect.DeepEqual(body, wantBody) {
+			t.Errorf("参数为 %#v，应为 %#v", body, wantBody)
+		}
+	}, readFixture(t, "dbus_nrestarts_reply.bin"))
+	conn.serial = 2
+
+	c := &SystemdCollector{}
+	if n := c.restarts(conn, "/org/freedesktop/systemd1/unit/nginx_2eservice"); n != 3 {
+		t.Errorf("NRestarts 为 %d，应为 3", n)
+	}
+}
+
+func TestDBusCallSkipsUnrelatedMessages(t *testing.T) {
+	msgs := splitDBusMessages(t, readFixture(t, "dbus_hello_reply.bin"))
+	// 先发送信号再发送返回，调用应跳过信号
+	conn := serveDBus(t, func(fields map[byte]interface{}, body []interface{}) {
+		if fields[dbusFieldMember] != "Hello" || len(body) != 0 {
+			t.Errorf("Hello 调用编码错误: %#v %#v", fields, body)
+		}
+		if _, ok := fields[dbusFieldSignature]; ok {
+			t.Error("无参数的调用不应包含签名字段")
+		}
+	}, msgs[1], msgs[0])
+
+	body, err := conn.call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "Hello", "")
+	if err != nil {
+		t.Fatalf("调用失败: %v", err)
+	}
+	if len(body) != 1 || !strings.HasPrefix(body[0].(string), ":1.") {
+		t.Errorf("Hello 返回解析错误: %#v", body)
+	}
+}
+
+func TestDBusCallError(t *testing.T) {
+	conn := serveDBus(t, nil, readFixture(t, "dbus_get_error_reply.bin"))
+	conn.serial = 3
+
+	_, err := conn.call("org.freedesktop.systemd1", "/org/freedesktop/systemd1/unit/nginx_2eservice",
+		"org.freedesktop.DBus.Properties", "Get", "ss", "org.freedesktop.systemd1.Service", "Missing")
+	if err == nil || !strings.HasPrefix(err.Error(), "org.freedesktop.DBus.Error.InvalidArgs: ") {
+		t.Errorf("应返回 InvalidArgs 错误，实际为 %v", err)
+	}
+}
+
+func TestDBusDecodeTruncated(t *testing.T) {
+	data := readFixture(t, "dbus_list_units_reply.bin")
+	if _, _, _, err := fixtureConn(data[:len(data)-10]).readMessage(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("截断的消息应返回 ErrUnexpectedEOF，实际为 %v", err)
+	}
+
+	invalid := append([]byte{'x'}, data[1:]...)
+	if _, _, _, err := fixtureConn(invalid).readMessage(); err == nil {
+		t.Error("无效的字节序标记应返回错误")
+	}
+
+	// 数组长度超出消息范围
+	msg := append([]byte{}, data...)
+	headerLen := dbusAlign(16+int(binary.LittleEndian.Uint32(msg[12:])), 8)
+	binary.LittleEndian.PutUint32(msg[headerLen:], 1<<20)
+	if _, _, _, err := fixtureConn(msg).readMessage(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("数组越界应返回 ErrUnexpectedEOF，实际为 %v", err)
+	}
+}
+
+func TestDBusNextType(t *testing.T) {
+	tests := []struct {
+		sig, first, rest string
+	}{
+		{"s", "s", ""},
+		{"ss", "s", "s"},
+		{"a(ssssssouso)", "a(ssssssouso)", ""},
+		{"a{sv}u", "a{sv}", "u"},
+		{"(a(yv)s)o", "(a(yv)s)", "o"},
+		{"aas", "aas", ""},
+	}
+	for _, tt := range tests {
+		first, rest, err := dbusNextType(tt.sig)
+		if err != nil || first != tt.first || rest != tt.rest {
+			t.Errorf("dbusNextType(%q) = %q, %q, %v，应为 %q, %q", tt.sig, first, rest, err, tt.first, tt.rest)
+		}
+	}
+
+	for _, sig := range []string{"", "(ss", "(s}", "a"} {
+		if _, _, err := dbusNextType(sig); err == nil {
+			t.Errorf("dbusNextType(%q) 应返回错误", sig)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+)
+
+// 握手时由 WebSocket 库生成的请求头，不允许通过 headers 覆盖
+var reservedHeaders = map[string]bool{
+	"Host":       true,
+	"Upgrade":    true,
+	"Connection": true,
+}
+
+// validateConnection 验证代理和请求头配置
+func (s *ServerConfig) validateConnection() error {
+	if s.Proxy != "" {
+		u, err := url.Parse(s.Proxy)
+		if err != nil {
+			return fmt.Errorf("代理地址格式错误: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "socks5" {
+			return fmt.Errorf("代理地址只支持 http:// 和 socks5://: %s", s.Proxy)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("代理地址缺少主机: %s", s.Proxy)
+		}
+	}
+
+	for name := range s.Headers {
+		if name == "" || strings.ContainsAny(name, " \t:\r\n") {
+			return fmt.Errorf("请求头名称无效: %q", name)
+		}
+		canonical := textproto.CanonicalMIMEHeaderKey(name)
+		if reservedHeaders[canonical] || strings.HasPrefix(canonical, "Sec-Websocket-") {
+			return fmt.Errorf("请求头 %s 不能自定义，覆盖 Host 请使用 host 配置", name)
+		}
+	}
+	return nil
+}
+
+// ProxyFunc 返回连接服务端使用的代理，未配置时使用环境变量 HTTPS_PROXY、HTTP_PROXY 和 NO_PROXY
+func (s *ServerConfig) ProxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	if s.Proxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	u, err := url.Parse(s.Proxy)
+	if err != nil {
+		return nil, fmt.Errorf("代理地址格式错误: %w", err)
+	}
+	return http.ProxyURL(u), nil
+}
+
+// RequestHeader 返回连接服务端时附加的请求头，包括自定义请求头和 Host 覆盖
+func (s *ServerConfig) RequestHeader() http.Header {
+	header := make(http.Header, len(s.Headers)+1)
+	for name, value := range s.Headers {
+		header.Set(name, value)
+	}
+	if s.Host != "" {
+		header.Set("Host", s.Host)
+	}
+	return header
+}
+
+// HTTPTransport 构造访问服务端 HTTP 接口使用的 Transport，与 WebSocket 连接使用相同的代理、TLS 和请求头配置
+func (s *ServerConfig) HTTPTransport() (http.RoundTripper, error) {
+	proxy, err := s.ProxyFunc()
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := s.TLSClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	return &headerTransport{base: transport, header: s.RequestHeader()}, nil
+}
+
+// headerTransport 为每个请求附加固定的请求头
+type headerTransport struct {
+	base   http.RoundTripper
+	header http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(t.header) == 0 {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	for name, values := range t.header {
+		if name == "Host" {
+			req.Host = values[0]
+			continue
+		}
+		req.Header[name] = values
+	}
+	return t.base.RoundTrip(req)
+}
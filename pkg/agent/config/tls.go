@@ -0,0 +1,141 @@
+package config
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TLSConfig 连接服务端的 TLS 配置
+type TLSConfig struct {
+	// 自定义 CA 证书文件（PEM），用于校验服务端证书，为空时使用系统证书
+	CAFile string `yaml:"ca_file"`
+
+	// 固定的服务端证书公钥指纹（SubjectPublicKeyInfo 的 SHA-256，base64 编码），配置后证书链中至少一个证书需要匹配
+	// 跳过证书验证时只匹配服务端证书本身
+	// 生成命令: openssl x509 -in server.pem -pubkey -noout | openssl pkey -pubin -outform der | openssl dgst -sha256 -binary | base64
+	PinnedSPKI []string `yaml:"pinned_spki"`
+
+	// 客户端证书和私钥文件（PEM），服务端要求 mTLS 时使用，每次连接时重新读取，证书更新后无需重启
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+
+	// 覆盖 TLS SNI 和证书校验使用的服务器名称，为空时使用 endpoint 中的主机名
+	ServerName string `yaml:"server_name"`
+}
+
+// Validate 验证 TLS 配置
+func (t *TLSConfig) Validate() error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("客户端证书 cert_file 和私钥 key_file 需要同时配置")
+	}
+	if t.CertFile != "" {
+		if _, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile); err != nil {
+			return fmt.Errorf("加载客户端证书失败: %w", err)
+		}
+	}
+	if t.CAFile != "" {
+		if _, err := t.loadCAPool(); err != nil {
+			return err
+		}
+	}
+	if _, err := t.parsePins(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// TLSClientConfig 根据配置构造连接服务端使用的 TLS 配置，未做任何配置时返回 nil 使用默认配置
+func (s *ServerConfig) TLSClientConfig() (*tls.Config, error) {
+	t := &s.TLS
+	if !s.InsecureSkipVerify && t.CAFile == "" && len(t.PinnedSPKI) == 0 && t.CertFile == "" && t.ServerName == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: s.InsecureSkipVerify,
+		ServerName:         t.ServerName,
+	}
+
+	if t.CAFile != "" {
+		pool, err := t.loadCAPool()
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	pins, err := t.parsePins()
+	if err != nil {
+		return nil, err
+	}
+	if len(pins) > 0 {
+		insecure := s.InsecureSkipVerify
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			return verifyPins(state, pins, insecure)
+		}
+	}
+
+	if t.CertFile != "" {
+		certFile, keyFile := t.CertFile, t.KeyFile
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, fmt.Errorf("加载客户端证书失败: %w", err)
+			}
+			return &cert, nil
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+func (t *TLSConfig) loadCAPool() (*x509.CertPool, error) {
+	data, err := os.ReadFile(t.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("读取 CA 证书失败: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("CA 证书文件中没有有效的证书: %s", t.CAFile)
+	}
+	return pool, nil
+}
+
+func (t *TLSConfig) parsePins() (map[[sha256.Size]byte]bool, error) {
+	pins := make(map[[sha256.Size]byte]bool, len(t.PinnedSPKI))
+	for _, pin := range t.PinnedSPKI {
+		data, err := base64.StdEncoding.DecodeString(pin)
+		if err != nil || len(data) != sha256.Size {
+			return nil, fmt.Errorf("证书公钥指纹格式错误: %s", pin)
+		}
+		pins[[sha256.Size]byte(data)] = true
+	}
+	return pins, nil
+}
+
+// verifyPins 校验服务端证书公钥指纹
+// 正常验证时匹配已验证证书链中的任意证书，跳过验证时证书链不可信，只匹配服务端证书
+func verifyPins(state tls.ConnectionState, pins map[[sha256.Size]byte]bool, insecure bool) error {
+	var certs []*x509.Certificate
+	if insecure {
+		if len(state.PeerCertificates) > 0 {
+			certs = state.PeerCertificates[:1]
+		}
+	} else {
+		for _, chain := range state.VerifiedChains {
+			certs = append(certs, chain...)
+		}
+	}
+
+	for _, cert := range certs {
+		if pins[sha256.Sum256(cert.RawSubjectPublicKeyInfo)] {
+			return nil
+		}
+	}
+	return errors.New("服务端证书公钥与固定的指纹不匹配")
+}
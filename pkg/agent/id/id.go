@@ -9,15 +9,19 @@ import (
 	"github.com/google/uuid"
 )
 
-// Manager 管理探针的唯一标识
+// Manager 管理探针的唯一标识和服务端签发的凭证
 type Manager struct {
-	idFilePath string
+	idFilePath    string
+	tokenFilePath string
 }
 
 // NewManager 创建 ID 管理器
 func NewManager() *Manager {
+	idFilePath := getIDFilePath()
 	return &Manager{
-		idFilePath: getIDFilePath(),
+		idFilePath: idFilePath,
+		// 凭证与 ID 存放在同一目录
+		tokenFilePath: filepath.Join(filepath.Dir(idFilePath), "agent.token"),
 	}
 }
 
@@ -84,6 +88,42 @@ func (m *Manager) save(id string) error {
 	return nil
 }
 
+// LoadToken 读取探针凭证，尚未签发时返回空字符串
+func (m *Manager) LoadToken() (string, error) {
+	data, err := os.ReadFile(m.tokenFilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// SaveToken 保存探针凭证，仅当前用户可读写
+// 先写入临时文件再改名，避免写入中断导致凭证丢失
+func (m *Manager) SaveToken(token string) error {
+	dir := filepath.Dir(m.tokenFilePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	tmpPath := m.tokenFilePath + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(token), 0600); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	if err := os.Rename(tmpPath, m.tokenFilePath); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
+
+// GetTokenPath 获取凭证文件路径
+func (m *Manager) GetTokenPath() string {
+	return m.tokenFilePath
+}
+
 // GetPath 获取 ID 文件路径
 func (m *Manager) GetPath() string {
 	return m.idFilePath
@@ -0,0 +1,242 @@
+package logtail
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// 每个文件每次轮询最多读取的字节数，日志暴涨时分多次读取
+	maxReadPerPoll = 1 << 20
+	// 没有换行符的不完整行的缓冲上限，超过时按一行处理
+	maxPartialLength = 64 << 10
+)
+
+// fileTracker 轮询跟踪日志文件，处理轮转和截断
+// 只在 Tailer.Run 的协程中使用
+type fileTracker struct {
+	tailer *Tailer
+	files  map[string]*tailedFile
+	seen   map[string]bool // 已展开过的路径模式
+	buf    []byte
+}
+
+// tailedFile 正在跟踪的文件
+type tailedFile struct {
+	path    string
+	file    *os.File
+	info    os.FileInfo
+	offset  int64
+	partial []byte
+}
+
+func newFileTracker(tailer *Tailer) *fileTracker {
+	return &fileTracker{
+		tailer: tailer,
+		files:  make(map[string]*tailedFile),
+		seen:   make(map[string]bool),
+		buf:    make([]byte, 32<<10),
+	}
+}
+
+// poll 展开路径模式并读取所有文件的新增内容
+// 路径模式首次展开时已存在的文件从末尾开始读取，之后出现的文件从头读取
+func (ft *fileTracker) poll(rules []*rule) {
+	rulesByPath := make(map[string][]*rule)
+	newPaths := make(map[string]bool)
+	for _, r := range rules {
+		for _, pattern := range r.Paths {
+			matches, err := filepath.Glob(pattern)
+			if err != nil {
+				continue
+			}
+			first := !ft.seen[pattern]
+			ft.seen[pattern] = true
+			for _, path := range matches {
+				// 展开后的文件（包括符号链接指向的文件）也需要在允许范围内
+				if !ft.tailer.allowedFile(path) {
+					continue
+				}
+				rulesByPath[path] = append(rulesByPath[path], r)
+				if !first {
+					newPaths[path] = true
+				}
+			}
+		}
+	}
+
+	// 不再匹配任何规则的文件停止跟踪
+	for path, f := range ft.files {
+		if _, ok := rulesByPath[path]; !ok {
+			f.close()
+			delete(ft.files, path)
+		}
+	}
+
+	// 新出现的路径需要在已跟踪的文件检测轮转之前判断是否为改名后的旧文件
+	for path, pathRules := range rulesByPath {
+		if _, ok := ft.files[path]; ok {
+			continue
+		}
+		// 轮转后改名的文件（例如 app.log.1）由原来的句柄读完，从末尾开始跟踪
+		fromEnd := !newPaths[path] || ft.tracking(path)
+		f, err := openTailedFile(path, fromEnd)
+		if err != nil {
+			continue
+		}
+		ft.files[path] = f
+		ft.tailer.track(pathRules, path)
+	}
+
+	for path, f := range ft.files {
+		if !ft.follow(f, rulesByPath[path]) {
+			f.close()
+			delete(ft.files, path)
+		}
+	}
+}
+
+// follow 读取文件新增内容，文件被删除时返回 false
+func (ft *fileTracker) follow(f *tailedFile, rules []*rule) bool {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		// 文件已删除，读完剩余内容后停止跟踪，重新出现时从头读取
+		ft.read(f, rules)
+		ft.flushPartial(f, rules)
+		return false
+	}
+
+	if !os.SameFile(f.info, info) {
+		// 文件被轮转：读完旧文件剩余内容，再从头读取新文件
+		ft.read(f, rules)
+		ft.flushPartial(f, rules)
+		f.close()
+
+		file, err := os.Open(f.path)
+		if err != nil {
+			return false
+		}
+		f.file = file
+		f.info = info
+		f.offset = 0
+	} else if info.Size() < f.offset {
+		// 文件被截断（copytruncate），从头读取
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			return false
+		}
+		f.offset = 0
+		f.partial = nil
+		f.info = info
+	}
+
+	ft.read(f, rules)
+	return true
+}
+
+// read 从当前位置读取新增内容并按行处理
+func (ft *fileTracker) read(f *tailedFile, rules []*rule) {
+	var total int
+	for total < maxReadPerPoll {
+		n, err := f.file.Read(ft.buf)
+		if n > 0 {
+			total += n
+			f.offset += int64(n)
+			ft.lines(f, rules, ft.buf[:n])
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.Printf("⚠️  读取日志文件 %s 失败: %v", f.path, err)
+			}
+			return
+		}
+	}
+}
+
+// lines 将读取的内容按换行符切分，最后不完整的一行留到下次
+func (ft *fileTracker) lines(f *tailedFile, rules []*rule, data []byte) {
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			f.partial = append(f.partial, data...)
+			if len(f.partial) > maxPartialLength {
+				ft.flushPartial(f, rules)
+			}
+			return
+		}
+
+		line := data[:i]
+		if len(f.partial) > 0 {
+			line = append(f.partial, line...)
+			f.partial = nil
+		}
+		ft.tailer.process(rules, f.path, f.path, string(bytes.TrimRight(line, "\r")))
+		data = data[i+1:]
+	}
+}
+
+func (ft *fileTracker) flushPartial(f *tailedFile, rules []*rule) {
+	if len(f.partial) == 0 {
+		return
+	}
+	ft.tailer.process(rules, f.path, f.path, string(f.partial))
+	f.partial = nil
+}
+
+// tracking 判断文件是否已经以其他路径在跟踪
+func (ft *fileTracker) tracking(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	for _, f := range ft.files {
+		if os.SameFile(f.info, info) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ft *fileTracker) closeAll() {
+	for path, f := range ft.files {
+		f.close()
+		delete(ft.files, path)
+	}
+}
+
+func openTailedFile(path string, fromEnd bool) (*tailedFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, errors.New("不是文件")
+	}
+
+	f := &tailedFile{path: path, file: file, info: info}
+	if fromEnd {
+		offset, err := file.Seek(0, io.SeekEnd)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		f.offset = offset
+	}
+	return f, nil
+}
+
+func (f *tailedFile) close() {
+	if f.file != nil {
+		_ = f.file.Close()
+		f.file = nil
+	}
+}
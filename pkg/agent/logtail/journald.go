@@ -0,0 +1,177 @@
+package logtail
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log"
+	"os/exec"
+	"slices"
+	"sort"
+	"time"
+)
+
+const (
+	journalSource = "journald"
+	// journalctl 异常退出后重新启动的最小间隔
+	journalRestartInterval = 30 * time.Second
+	// journald 单条日志的最大长度
+	journalMaxEntrySize = 1 << 20
+)
+
+// journalReader 通过 journalctl -f 读取 journald 日志
+// ensure 和 stop 只在 Tailer.Run 的协程中调用
+type journalReader struct {
+	tailer *Tailer
+
+	args      []string
+	cancel    context.CancelFunc
+	done      chan struct{}
+	lastStart time.Time
+	missing   bool
+}
+
+// journalEntry journalctl -o json 输出的字段
+type journalEntry struct {
+	Message interface{} `json:"MESSAGE"` // 包含不可打印字符时为字节数组
+	Unit    string      `json:"_SYSTEMD_UNIT"`
+}
+
+func newJournalReader(tailer *Tailer) *journalReader {
+	return &journalReader{tailer: tailer}
+}
+
+// ensure 根据规则启动、重启或停止 journalctl
+func (jr *journalReader) ensure(ctx context.Context, rules []*rule) {
+	var journalRules []*rule
+	for _, r := range rules {
+		if r.Journald {
+			journalRules = append(journalRules, r)
+		}
+	}
+	if len(journalRules) == 0 {
+		jr.stop()
+		jr.args = nil
+		return
+	}
+
+	args := journalArgs(journalRules)
+	changed := !slices.Equal(args, jr.args)
+	if !changed && jr.running() {
+		return
+	}
+	// 配置未变化时限制异常退出后的重启频率
+	if !changed && time.Since(jr.lastStart) < journalRestartInterval {
+		return
+	}
+	jr.stop()
+	jr.args = args
+	jr.lastStart = time.Now()
+
+	if _, err := exec.LookPath("journalctl"); err != nil {
+		if !jr.missing {
+			log.Printf("ℹ️  未找到 journalctl，跳过 journald 日志监控")
+			jr.missing = true
+		}
+		return
+	}
+	jr.missing = false
+
+	jr.tailer.track(journalRules, journalSource)
+	runCtx, cancel := context.WithCancel(ctx)
+	jr.cancel = cancel
+	jr.done = make(chan struct{})
+	go jr.run(runCtx, args, jr.done)
+}
+
+func (jr *journalReader) running() bool {
+	if jr.done == nil {
+		return false
+	}
+	select {
+	case <-jr.done:
+		return false
+	default:
+		return true
+	}
+}
+
+func (jr *journalReader) stop() {
+	if jr.cancel == nil {
+		return
+	}
+	jr.cancel()
+	<-jr.done
+	jr.cancel = nil
+	jr.done = nil
+}
+
+func (jr *journalReader) run(ctx context.Context, args []string, done chan struct{}) {
+	defer close(done)
+
+	cmd := exec.CommandContext(ctx, "journalctl", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		log.Printf("⚠️  启动 journalctl 失败: %v", err)
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		log.Printf("⚠️  启动 journalctl 失败: %v", err)
+		return
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64<<10), journalMaxEntrySize)
+	for scanner.Scan() {
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		message, ok := entry.Message.(string)
+		if !ok {
+			continue
+		}
+
+		var rules []*rule
+		for _, r := range jr.tailer.snapshotRules() {
+			if r.Journald && r.matchesUnit(entry.Unit) {
+				rules = append(rules, r)
+			}
+		}
+		eventSource := journalSource
+		if entry.Unit != "" {
+			eventSource = journalSource + ":" + entry.Unit
+		}
+		jr.tailer.process(rules, journalSource, eventSource, message)
+	}
+
+	err = cmd.Wait()
+	if ctx.Err() == nil {
+		log.Printf("⚠️  journalctl 已退出: %v", err)
+	}
+}
+
+// journalArgs 构造 journalctl 参数，任一规则未限制单元时读取全部日志
+func journalArgs(rules []*rule) []string {
+	args := []string{"--follow", "--lines=0", "--output=json", "--no-pager"}
+
+	units := make(map[string]bool)
+	for _, r := range rules {
+		if len(r.JournalUnits) == 0 {
+			return args
+		}
+		for _, unit := range r.JournalUnits {
+			units[unit] = true
+		}
+	}
+
+	names := make([]string, 0, len(units))
+	for unit := range units {
+		names = append(names, unit)
+	}
+	sort.Strings(names)
+	for _, unit := range names {
+		args = append(args, "--unit="+unit)
+	}
+	return args
+}
@@ -0,0 +1,310 @@
+package logtail
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/pkg/agent/config"
+)
+
+const (
+	// 文件轮询间隔
+	pollInterval = time.Second
+	// 单个规则每分钟上报行数的默认值
+	defaultMaxEventsPerMinute = 10
+	// 所有规则每分钟上报行数的总上限，保护 WebSocket 连接
+	globalMaxEventsPerMinute = 100
+	// 待发送日志行的上限，断线期间超出的部分计入丢弃数
+	maxPendingEvents = 500
+	// 上报的单行日志最大长度（字节）
+	maxLineLength = 1024
+)
+
+// rule 预编译正则的匹配规则
+type rule struct {
+	protocol.LogRuleData
+	re      *regexp.Regexp
+	limiter *rateLimiter
+}
+
+// matchesUnit 判断 journald 单元是否在规则的过滤范围内，与 journalctl -u 一样省略 .service 后缀也能匹配
+func (r *rule) matchesUnit(unit string) bool {
+	if len(r.JournalUnits) == 0 {
+		return true
+	}
+	for _, u := range r.JournalUnits {
+		if u == unit || u+".service" == unit {
+			return true
+		}
+	}
+	return false
+}
+
+// rateLimiter 固定窗口限流，每分钟最多放行 limit 次
+type rateLimiter struct {
+	windowStart time.Time
+	count       int
+}
+
+// available 判断当前窗口是否还有余量，不计数
+func (l *rateLimiter) available(now time.Time, limit int) bool {
+	if now.Sub(l.windowStart) >= time.Minute {
+		l.windowStart = now
+		l.count = 0
+	}
+	return l.count < limit
+}
+
+// countKey 匹配计数的标识
+type countKey struct {
+	ruleID string
+	source string
+}
+
+// Tailer 日志监控器，跟踪日志文件和 journald 并按服务端下发的规则匹配
+// 匹配计数为累计值，匹配到的日志行经过限流后等待发送
+// 规则只能读取本地配置允许的文件和 journald，避免服务端读取探针上的任意文件
+type Tailer struct {
+	config *config.LogTailConfig
+
+	mu            sync.Mutex
+	rules         []*rule
+	counts        map[countKey]*protocol.LogMatchData
+	events        []protocol.LogEventData
+	globalLimiter rateLimiter
+
+	updated chan struct{}
+	files   *fileTracker
+	journal *journalReader
+}
+
+// New 创建日志监控器
+func New(cfg *config.LogTailConfig) *Tailer {
+	t := &Tailer{
+		config:  cfg,
+		counts:  make(map[countKey]*protocol.LogMatchData),
+		updated: make(chan struct{}, 1),
+	}
+	t.files = newFileTracker(t)
+	t.journal = newJournalReader(t)
+	return t
+}
+
+// UpdateRules 替换全部规则，正则无效的规则会被跳过
+// 超出本地允许范围的路径和 journald 会从规则中移除，没有剩余来源的规则会被跳过
+func (t *Tailer) UpdateRules(rules []protocol.LogRuleData) {
+	if !t.config.Enabled && len(rules) > 0 {
+		log.Printf("⚠️  日志监控未启用，忽略服务端下发的 %d 条规则", len(rules))
+		rules = nil
+	}
+
+	compiled := make([]*rule, 0, len(rules))
+	active := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		if !t.restrict(&r) {
+			continue
+		}
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			log.Printf("⚠️  日志规则 %s 的正则表达式无效: %v", r.Name, err)
+			continue
+		}
+		if r.MaxEventsPerMinute <= 0 {
+			r.MaxEventsPerMinute = defaultMaxEventsPerMinute
+		}
+		compiled = append(compiled, &rule{LogRuleData: r, re: re})
+		active[r.ID] = true
+	}
+
+	t.mu.Lock()
+	// 沿用同一规则的限流状态，避免每次下发配置或重连后重新计数
+	limiters := make(map[string]*rateLimiter, len(t.rules))
+	for _, r := range t.rules {
+		limiters[r.ID] = r.limiter
+	}
+	for _, r := range compiled {
+		r.limiter = limiters[r.ID]
+		if r.limiter == nil {
+			r.limiter = &rateLimiter{}
+		}
+	}
+	t.rules = compiled
+	// 移除已删除规则的计数，避免一直上报
+	for key := range t.counts {
+		if !active[key.ruleID] {
+			delete(t.counts, key)
+		}
+	}
+	t.mu.Unlock()
+
+	select {
+	case t.updated <- struct{}{}:
+	default:
+	}
+}
+
+// restrict 移除规则中不允许读取的来源，规则没有剩余来源时返回 false
+func (t *Tailer) restrict(r *protocol.LogRuleData) bool {
+	paths := make([]string, 0, len(r.Paths))
+	for _, pattern := range r.Paths {
+		pattern = filepath.Clean(pattern)
+		if !t.allowed(pattern) {
+			log.Printf("⚠️  日志规则 %s 的路径 %s 不在允许范围内，已忽略", r.Name, pattern)
+			continue
+		}
+		paths = append(paths, pattern)
+	}
+	r.Paths = paths
+
+	if r.Journald && !t.config.Journald {
+		log.Printf("⚠️  日志规则 %s 需要读取 journald，但本地未允许，已忽略", r.Name)
+		r.Journald = false
+	}
+	return len(r.Paths) > 0 || r.Journald
+}
+
+// allowed 判断路径是否匹配本地允许的路径
+func (t *Tailer) allowed(path string) bool {
+	if !filepath.IsAbs(path) {
+		return false
+	}
+	for _, pattern := range t.config.Paths {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedFile 判断文件及其符号链接指向的文件是否都在允许范围内
+func (t *Tailer) allowedFile(path string) bool {
+	if !t.allowed(path) {
+		return false
+	}
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	return target == path || t.allowed(target)
+}
+
+// Run 开始跟踪日志，阻塞直到 ctx 取消
+func (t *Tailer) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	defer t.files.closeAll()
+	defer t.journal.stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.updated:
+		case <-ticker.C:
+		}
+
+		rules := t.snapshotRules()
+		t.files.poll(rules)
+		t.journal.ensure(ctx, rules)
+	}
+}
+
+// Collect 返回所有规则的累计匹配计数，并取出等待发送的日志行
+func (t *Tailer) Collect() ([]protocol.LogMatchData, []protocol.LogEventData) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var matches []protocol.LogMatchData
+	for _, count := range t.counts {
+		matches = append(matches, *count)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].RuleID != matches[j].RuleID {
+			return matches[i].RuleID < matches[j].RuleID
+		}
+		return matches[i].Source < matches[j].Source
+	})
+
+	events := t.events
+	t.events = nil
+	return matches, events
+}
+
+func (t *Tailer) snapshotRules() []*rule {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.rules
+}
+
+// track 开始跟踪某个来源时记录为 0 的计数，服务端据此计算增量
+func (t *Tailer) track(rules []*rule, source string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, r := range rules {
+		t.counter(r.ID, source)
+	}
+}
+
+func (t *Tailer) counter(ruleID, source string) *protocol.LogMatchData {
+	key := countKey{ruleID: ruleID, source: source}
+	count, ok := t.counts[key]
+	if !ok {
+		count = &protocol.LogMatchData{RuleID: ruleID, Source: source}
+		t.counts[key] = count
+	}
+	return count
+}
+
+// process 用规则匹配一行日志，source 用于计数，eventSource 为上报日志行时的来源
+func (t *Tailer) process(rules []*rule, source, eventSource, line string) {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, r := range rules {
+		if !r.re.MatchString(line) {
+			continue
+		}
+		count := t.counter(r.ID, source)
+		count.Matches++
+		if !r.Ship {
+			continue
+		}
+
+		// 两个限流器都有余量时才计数，避免被总限流丢弃的行占用规则的配额
+		if len(t.events) >= maxPendingEvents ||
+			!r.limiter.available(now, r.MaxEventsPerMinute) ||
+			!t.globalLimiter.available(now, globalMaxEventsPerMinute) {
+			count.Dropped++
+			continue
+		}
+		r.limiter.count++
+		t.globalLimiter.count++
+		t.events = append(t.events, protocol.LogEventData{
+			RuleID:    r.ID,
+			Source:    eventSource,
+			Line:      truncateLine(line),
+			Timestamp: now.UnixMilli(),
+		})
+	}
+}
+
+// truncateLine 截断过长的日志行，保证不截断在 UTF-8 字符中间
+func truncateLine(line string) string {
+	if len(line) <= maxLineLength {
+		return line
+	}
+	end := maxLineLength
+	for end > 0 && !utf8.RuneStart(line[end]) {
+		end--
+	}
+	return line[:end]
+}
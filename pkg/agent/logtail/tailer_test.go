@@ -0,0 +1,131 @@
+package logtail
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/pkg/agent/config"
+)
+
+func TestUpdateRulesDisabled(t *testing.T) {
+	tailer := New(&config.LogTailConfig{Paths: []string{"/var/log/*.log"}})
+	tailer.UpdateRules([]protocol.LogRuleData{{ID: "1", Pattern: "error", Paths: []string{"/var/log/app.log"}}})
+	if rules := tailer.snapshotRules(); len(rules) != 0 {
+		t.Errorf("未启用时不应接受规则: %d 条", len(rules))
+	}
+}
+
+func TestUpdateRulesRestrictsSources(t *testing.T) {
+	tailer := New(&config.LogTailConfig{
+		Enabled: true,
+		Paths:   []string{"/var/log/nginx/*.log"},
+	})
+	tailer.UpdateRules([]protocol.LogRuleData{
+		{ID: "1", Pattern: "error", Paths: []string{
+			"/var/log/nginx/access.log",
+			"/var/log/nginx/*.log",
+			"/etc/shadow",
+			"/var/log/nginx/../../../etc/shadow",
+			"var/log/nginx/error.log",
+		}},
+		{ID: "2", Pattern: "error", Paths: []string{"/etc/passwd"}},
+		{ID: "3", Pattern: "error", Journald: true},
+		{ID: "4", Pattern: "error", Paths: []string{"/etc/passwd"}, Journald: true},
+	})
+
+	rules := tailer.snapshotRules()
+	if len(rules) != 1 || rules[0].ID != "1" {
+		t.Fatalf("只应保留有允许来源的规则: %#v", rules)
+	}
+	want := []string{"/var/log/nginx/access.log", "/var/log/nginx/*.log"}
+	if !reflect.DeepEqual(rules[0].Paths, want) {
+		t.Errorf("允许的路径为 %v，应为 %v", rules[0].Paths, want)
+	}
+
+	tailer.config.Journald = true
+	tailer.UpdateRules([]protocol.LogRuleData{{ID: "4", Pattern: "error", Paths: []string{"/etc/passwd"}, Journald: true}})
+	rules = tailer.snapshotRules()
+	if len(rules) != 1 || len(rules[0].Paths) != 0 || !rules[0].Journald {
+		t.Errorf("允许 journald 时应只保留 journald 来源: %#v", rules)
+	}
+}
+
+func TestPollSkipsSymlinkOutsideAllowlist(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	logDir := filepath.Join(dir, "log")
+	secretDir := filepath.Join(dir, "secret")
+	for _, d := range []string{logDir, secretDir} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(logDir, "app.log"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(secretDir, "shadow"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(secretDir, "shadow"), filepath.Join(logDir, "evil.log")); err != nil {
+		t.Fatal(err)
+	}
+
+	tailer := New(&config.LogTailConfig{
+		Enabled: true,
+		Paths:   []string{filepath.Join(logDir, "*.log")},
+	})
+	tailer.UpdateRules([]protocol.LogRuleData{{ID: "1", Pattern: "error", Paths: []string{filepath.Join(logDir, "*.log")}}})
+	tailer.files.poll(tailer.snapshotRules())
+	defer tailer.files.closeAll()
+
+	if _, ok := tailer.files.files[filepath.Join(logDir, "app.log")]; !ok {
+		t.Error("允许范围内的文件应被跟踪")
+	}
+	if _, ok := tailer.files.files[filepath.Join(logDir, "evil.log")]; ok {
+		t.Error("指向允许范围之外的符号链接不应被跟踪")
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	tailer := New(&config.LogTailConfig{Enabled: true, Paths: []string{"/var/log/*.log"}})
+	rules := []protocol.LogRuleData{
+		{ID: "1", Pattern: "error", Paths: []string{"/var/log/a.log"}, Ship: true, MaxEventsPerMinute: 2},
+		{ID: "2", Pattern: "error", Paths: []string{"/var/log/b.log"}, Ship: true, MaxEventsPerMinute: globalMaxEventsPerMinute},
+	}
+	tailer.UpdateRules(rules)
+
+	tailer.process(tailer.snapshotRules()[:1], "a", "a", "error 1")
+	// 重新下发配置后沿用原来的限流状态
+	tailer.UpdateRules(rules)
+	tailer.process(tailer.snapshotRules()[:1], "a", "a", "error 2")
+	tailer.process(tailer.snapshotRules()[:1], "a", "a", "error 3")
+	if _, events := tailer.Collect(); len(events) != 2 {
+		t.Errorf("规则限流后应上报 2 行，实际为 %d", len(events))
+	}
+
+	// 用完总限流配额后，被丢弃的行不应占用规则的配额
+	tailer = New(&config.LogTailConfig{Enabled: true, Paths: []string{"/var/log/*.log"}})
+	tailer.UpdateRules(rules)
+	compiled := tailer.snapshotRules()
+	for i := 0; i < globalMaxEventsPerMinute; i++ {
+		tailer.process(compiled[1:], "b", "b", "error")
+	}
+	tailer.process(compiled[:1], "a", "a", "error")
+	if compiled[0].limiter.count != 0 {
+		t.Errorf("被总限流丢弃的行占用了规则配额: %d", compiled[0].limiter.count)
+	}
+	matches, events := tailer.Collect()
+	if len(events) != globalMaxEventsPerMinute {
+		t.Errorf("应上报 %d 行，实际为 %d", globalMaxEventsPerMinute, len(events))
+	}
+	for _, m := range matches {
+		if m.RuleID == "1" && m.Dropped != 1 {
+			t.Errorf("规则 1 应丢弃 1 行，实际为 %d", m.Dropped)
+		}
+	}
+}
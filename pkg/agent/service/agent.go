@@ -2,21 +2,24 @@ package service
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"os"
 	"runtime"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dushixiang/pika/internal/protocol"
 	"github.com/dushixiang/pika/pkg/agent/audit"
+	"github.com/dushixiang/pika/pkg/agent/buffer"
 	"github.com/dushixiang/pika/pkg/agent/collector"
 	"github.com/dushixiang/pika/pkg/agent/config"
 	"github.com/dushixiang/pika/pkg/agent/id"
+	"github.com/dushixiang/pika/pkg/agent/logtail"
 	"github.com/dushixiang/pika/pkg/agent/tamper"
 	"github.com/dushixiang/pika/pkg/version"
 	"github.com/gorilla/websocket"
@@ -30,6 +33,13 @@ var (
 	ErrConnectionEstablished = errors.New("connection was established")
 )
 
+const (
+	// 补发断线缓存时每条消息携带的指标数量
+	backfillBatchSize = 200
+	// 补发断线缓存时两条消息之间的间隔，避免占满连接影响实时数据
+	backfillInterval = 100 * time.Millisecond
+)
+
 // safeConn 线程安全的 WebSocket 连接包装器
 type safeConn struct {
 	conn *websocket.Conn
@@ -62,23 +72,46 @@ func (sc *safeConn) Close() error {
 
 // Agent 探针服务
 type Agent struct {
-	cfg              *config.Config
-	idMgr            *id.Manager
-	cancel           context.CancelFunc
-	connMu           sync.RWMutex
-	activeConn       *safeConn
-	collectorMu      sync.RWMutex
-	collectorManager *collector.Manager
-	tamperProtector  *tamper.Protector
+	cfg                 *config.Config
+	idMgr               *id.Manager
+	cancel              context.CancelFunc
+	connMu              sync.RWMutex
+	activeConn          *safeConn
+	collectorMu         sync.RWMutex
+	collectorManager    *collector.Manager
+	tamperProtector     *tamper.Protector
+	customCollector     *collector.CustomCollector
+	prometheusCollector *collector.PrometheusCollector
+	logTailer           *logtail.Tailer
+	// 服务端支持合并发送指标，每次注册时根据注册响应更新
+	metricsBatch atomic.Bool
+	// 断线缓存，未启用或打开失败时为空
+	buffer *buffer.Buffer
+	// 服务端支持补发断线期间的指标，每次注册时根据注册响应更新
+	metricsBackfill atomic.Bool
+	// 服务端要求携带 API 密钥注册，已保存凭证时默认不发送 API 密钥
+	apiKeyRequired atomic.Bool
 }
 
 // New 创建 Agent 实例
 func New(cfg *config.Config) *Agent {
-	return &Agent{
-		cfg:             cfg,
-		idMgr:           id.NewManager(),
-		tamperProtector: tamper.NewProtector(),
+	a := &Agent{
+		cfg:                 cfg,
+		idMgr:               id.NewManager(),
+		tamperProtector:     tamper.NewProtector(),
+		customCollector:     collector.NewCustomCollector(cfg),
+		prometheusCollector: collector.NewPrometheusCollector(cfg),
+		logTailer:           logtail.New(&cfg.Collector.LogTail),
+	}
+	if cfg.Collector.Buffer.Enabled {
+		buf, err := buffer.Open(cfg.GetBufferPath(), int64(cfg.Collector.Buffer.MaxSize)*1024*1024)
+		if err != nil {
+			log.Printf("⚠️  打开断线缓存失败，断线期间的指标将丢失: %v", err)
+		} else {
+			a.buffer = buf
+		}
 	}
+	return a
 }
 
 // Start 启动探针服务
@@ -87,6 +120,17 @@ func (a *Agent) Start(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	a.cancel = cancel
 
+	// 自定义指标脚本、推送接口和 Prometheus 抓取不随连接重建，断线期间的数据在重连后发送
+	go a.customCollector.Run(ctx)
+	go a.prometheusCollector.Run(ctx)
+	// 日志监控同样不随连接重建，规则在连接后由服务端下发
+	go a.logTailer.Run(ctx)
+	// 断线期间继续采集指标写入磁盘，重连后补发
+	if a.buffer != nil {
+		defer a.buffer.Close()
+		go a.bufferLoop(ctx)
+	}
+
 	// 启动探针主循环
 	b := &backoff.Backoff{
 		Min:    5 * time.Second,
@@ -143,16 +187,23 @@ func (a *Agent) runOnce(ctx context.Context, onConnected func()) error {
 	log.Printf("🔌 正在连接到服务器: %s", wsURL)
 
 	// 创建自定义的 Dialer
-	var dialer = websocket.DefaultDialer
+	dialer := *websocket.DefaultDialer
+	tlsConfig, err := a.cfg.Server.TLSClientConfig()
+	if err != nil {
+		return fmt.Errorf("加载 TLS 配置失败: %w", err)
+	}
+	dialer.TLSClientConfig = tlsConfig
+	// 服务端支持时启用 permessage-deflate 压缩
+	dialer.EnableCompression = true
 	if a.cfg.Server.InsecureSkipVerify {
-		dialer.TLSClientConfig = &tls.Config{
-			InsecureSkipVerify: true,
-		}
 		log.Println("⚠️  警告: 已禁用 TLS 证书验证")
 	}
+	if dialer.Proxy, err = a.cfg.Server.ProxyFunc(); err != nil {
+		return fmt.Errorf("加载代理配置失败: %w", err)
+	}
 
 	// 连接到服务器
-	rawConn, _, err := dialer.Dial(wsURL, nil)
+	rawConn, _, err := dialer.Dial(wsURL, a.cfg.Server.RequestHeader())
 	if err != nil {
 		return fmt.Errorf("连接失败: %w", err)
 	}
@@ -227,6 +278,13 @@ func (a *Agent) runOnce(ctx context.Context, onConnected func()) error {
 		}
 	})
 
+	// 补发断线期间缓存的指标
+	if a.buffer != nil {
+		wg.Go(func() {
+			a.backfillMetrics(conn, done)
+		})
+	}
+
 	// 启动防篡改事件监控
 	wg.Go(func() {
 		a.tamperEventLoop(ctx, conn, done)
@@ -291,11 +349,15 @@ func (a *Agent) readLoop(conn *websocket.Conn, done chan struct{}) error {
 		case protocol.MessageTypeCommand:
 			go a.handleCommand(msg.Data)
 		case protocol.MessageTypeMonitorConfig:
-			go a.handleMonitorConfig(msg.Data)
+			go a.applyConfig(msg, a.handleMonitorConfig)
 		case protocol.MessageTypeTamperProtect:
-			go a.handleTamperProtect(msg.Data)
+			go a.applyConfig(msg, a.handleTamperProtect)
 		case protocol.MessageTypeDDNSConfig:
-			go a.handleDDNSConfig(msg.Data)
+			go a.applyConfig(msg, a.handleDDNSConfig)
+		case protocol.MessageTypeLogConfig:
+			go a.applyConfig(msg, a.handleLogConfig)
+		case protocol.MessageTypeCredential:
+			go a.handleCredential(msg.Data)
 		default:
 			// 忽略其他类型
 		}
@@ -311,6 +373,12 @@ func (a *Agent) registerAgent(conn *safeConn) error {
 	}
 	log.Printf("🆔 Agent ID: %s (存储在: %s)", agentID, a.idMgr.GetPath())
 
+	// 已签发凭证时使用凭证注册，否则使用 API 密钥申请凭证
+	token, err := a.idMgr.LoadToken()
+	if err != nil {
+		return fmt.Errorf("读取探针凭证失败: %w", err)
+	}
+
 	// 获取主机信息
 	hostname, _ := os.Hostname()
 	if hostname == "" {
@@ -323,6 +391,12 @@ func (a *Agent) registerAgent(conn *safeConn) error {
 		agentName = hostname
 	}
 
+	// 已保存凭证时只使用凭证注册，服务端要求时才携带 API 密钥
+	var apiKey string
+	if token == "" || a.apiKeyRequired.Load() {
+		apiKey = a.cfg.Server.APIKey
+	}
+
 	// 构建注册请求
 	registerReq := protocol.RegisterRequest{
 		AgentInfo: protocol.AgentInfo{
@@ -333,7 +407,11 @@ func (a *Agent) registerAgent(conn *safeConn) error {
 			Arch:     runtime.GOARCH,
 			Version:  GetVersion(),
 		},
-		ApiKey: a.cfg.Server.APIKey,
+		ApiKey:             apiKey,
+		Token:              token,
+		SupportsCredential: true,
+		ProtocolVersion:    protocol.ProtocolVersion,
+		Capabilities:       capabilities(a.cfg),
 	}
 
 	if err := conn.WriteJSON(protocol.OutboundMessage{
@@ -353,6 +431,10 @@ func (a *Agent) registerAgent(conn *safeConn) error {
 	if response.Type == protocol.MessageTypeRegisterErr {
 		var errResp protocol.RegisterResponse
 		if err := json.Unmarshal(response.Data, &errResp); err == nil {
+			if errResp.ApiKeyRequired {
+				// 下次重连时携带 API 密钥
+				a.apiKeyRequired.Store(true)
+			}
 			return fmt.Errorf("注册失败: %s", errResp.Message)
 		}
 		return fmt.Errorf("注册失败: 未知错误")
@@ -367,27 +449,128 @@ func (a *Agent) registerAgent(conn *safeConn) error {
 		return fmt.Errorf("解析注册响应失败: %w", err)
 	}
 
+	a.apiKeyRequired.Store(false)
+	if registerResp.Token != "" {
+		// 新凭证在服务端收到确认后才生效，保存失败时下次仍使用原来的凭证或 API 密钥注册
+		if err := a.idMgr.SaveToken(registerResp.Token); err != nil {
+			log.Printf("⚠️  保存探针凭证失败: %v", err)
+		} else {
+			log.Printf("🔑 已获取探针凭证 (存储在: %s)", a.idMgr.GetTokenPath())
+			if err := conn.WriteJSON(protocol.OutboundMessage{
+				Type: protocol.MessageTypeCredentialAck,
+				Data: struct{}{},
+			}); err != nil {
+				log.Printf("⚠️  发送凭证确认失败: %v", err)
+			}
+		}
+	}
+
+	a.metricsBatch.Store(slices.Contains(registerResp.Features, protocol.FeatureMetricsBatch))
+	a.metricsBackfill.Store(slices.Contains(registerResp.Features, protocol.FeatureMetricsBackfill))
+	if registerResp.ProtocolVersion > protocol.ProtocolVersion {
+		log.Printf("⚠️  服务端协议版本 %d 高于探针协议版本 %d，部分功能不可用，建议升级探针", registerResp.ProtocolVersion, protocol.ProtocolVersion)
+	}
+
 	log.Printf("注册成功: AgentId=%s, Status=%s", registerResp.AgentID, registerResp.Status)
 	return nil
 }
 
-func (a *Agent) handleMonitorConfig(data json.RawMessage) {
+// capabilities 返回当前探针支持的功能，注册时上报给服务端
+func capabilities(cfg *config.Config) []string {
+	caps := []string{
+		protocol.CapabilityDDNS,
+		protocol.CapabilityMonitorHTTP,
+		protocol.CapabilityMonitorTCP,
+		protocol.CapabilityMonitorICMP,
+		protocol.CapabilityConfigAck,
+	}
+	// 日志监控需要在本地配置中开启，未开启时服务端不会下发日志规则
+	if cfg.Collector.LogTail.Enabled {
+		caps = append(caps, protocol.CapabilityLogTail)
+	}
+	// 防篡改和安全审计依赖 Linux 特性
+	if runtime.GOOS == "linux" {
+		caps = append(caps, protocol.CapabilityTamper, protocol.CapabilityAudit)
+	}
+	if collector.GPUAvailable() {
+		caps = append(caps, protocol.CapabilityGPU)
+	}
+	return caps
+}
+
+// handleCredential 保存服务端轮换后的凭证，保存成功后通知服务端使旧凭证失效
+func (a *Agent) handleCredential(data json.RawMessage) {
+	var credential protocol.CredentialData
+	if err := json.Unmarshal(data, &credential); err != nil {
+		log.Printf("⚠️  解析探针凭证失败: %v", err)
+		return
+	}
+	if credential.Token == "" {
+		return
+	}
+
+	if err := a.idMgr.SaveToken(credential.Token); err != nil {
+		log.Printf("⚠️  保存探针凭证失败: %v", err)
+		return
+	}
+	log.Println("🔑 探针凭证已轮换")
+
+	conn := a.getActiveConn()
+	if conn == nil {
+		return
+	}
+	if err := conn.WriteJSON(protocol.OutboundMessage{
+		Type: protocol.MessageTypeCredentialAck,
+		Data: struct{}{},
+	}); err != nil {
+		log.Printf("⚠️  发送凭证确认失败: %v", err)
+	}
+}
+
+// applyConfig 应用服务端下发的配置，消息带有ID时向服务端确认应用结果
+func (a *Agent) applyConfig(msg protocol.InputMessage, handle func(data json.RawMessage) error) {
+	err := handle(msg.Data)
+	if msg.ID == "" {
+		return
+	}
+
+	conn := a.getActiveConn()
+	if conn == nil {
+		return
+	}
+	ack := protocol.AckData{
+		ID:   msg.ID,
+		Type: msg.Type,
+		OK:   err == nil,
+	}
+	if err != nil {
+		ack.Error = err.Error()
+	}
+	if err := conn.WriteJSON(protocol.OutboundMessage{
+		Type: protocol.MessageTypeAck,
+		Data: ack,
+	}); err != nil {
+		log.Printf("⚠️  发送配置确认失败: %v", err)
+	}
+}
+
+func (a *Agent) handleMonitorConfig(data json.RawMessage) error {
 	var payload protocol.MonitorConfigPayload
 	if err := json.Unmarshal(data, &payload); err != nil {
 		log.Printf("⚠️  解析监控配置失败: %v", err)
-		return
+		return fmt.Errorf("解析监控配置失败: %w", err)
 	}
 
 	if len(payload.Items) == 0 {
 		log.Println("ℹ️  收到空的服务监控配置，跳过")
-		return
+		return nil
 	}
 
 	conn := a.getActiveConn()
 	manager := a.getCollectorManager()
 	if conn == nil || manager == nil {
 		log.Println("⚠️  当前连接未就绪，无法执行服务监控任务")
-		return
+		return errors.New("连接未就绪")
 	}
 
 	log.Printf("📥 收到服务监控配置，总计 %d 个监控项，立即执行检测", len(payload.Items))
@@ -395,9 +578,10 @@ func (a *Agent) handleMonitorConfig(data json.RawMessage) {
 	// 立即执行一次监控检测
 	if err := manager.CollectAndSendMonitor(conn, payload.Items); err != nil {
 		log.Printf("⚠️  监控检测失败: %v", err)
-	} else {
-		log.Printf("✅ 服务监控检测完成，已上报 %d 个监控项结果", len(payload.Items))
+		return fmt.Errorf("监控检测失败: %w", err)
 	}
+	log.Printf("✅ 服务监控检测完成，已上报 %d 个监控项结果", len(payload.Items))
+	return nil
 }
 
 // heartbeatLoop 心跳循环
@@ -474,61 +658,116 @@ func (a *Agent) metricsLoop(ctx context.Context, conn *safeConn, manager *collec
 }
 
 // collectAndSendAllMetrics 采集并发送所有动态指标
+// 服务端支持时将本次采集的所有指标合并为一条消息发送，否则逐条发送
 func (a *Agent) collectAndSendAllMetrics(conn *safeConn, manager *collector.Manager) error {
 	var hasError bool
 
+	var writer collector.WebSocketWriter = conn
+	var batch *collector.MetricsBatch
+	if a.metricsBatch.Load() {
+		batch = collector.NewMetricsBatch(conn)
+		writer = batch
+	}
+
 	// CPU 动态指标
-	if err := manager.CollectAndSendCPU(conn); err != nil {
+	if err := manager.CollectAndSendCPU(writer); err != nil {
 		log.Printf("⚠️  发送CPU指标失败: %v", err)
 		hasError = true
 	}
 
 	// 内存动态指标
-	if err := manager.CollectAndSendMemory(conn); err != nil {
+	if err := manager.CollectAndSendMemory(writer); err != nil {
 		log.Printf("⚠️  发送内存指标失败: %v", err)
 		hasError = true
 	}
 
 	// 磁盘指标
-	if err := manager.CollectAndSendDisk(conn); err != nil {
+	if err := manager.CollectAndSendDisk(writer); err != nil {
 		log.Printf("⚠️  发送磁盘指标失败: %v", err)
 		hasError = true
 	}
 
 	// 磁盘 IO 指标
-	if err := manager.CollectAndSendDiskIO(conn); err != nil {
+	if err := manager.CollectAndSendDiskIO(writer); err != nil {
 		log.Printf("⚠️  发送磁盘IO指标失败: %v", err)
 		hasError = true
 	}
 
 	// 网络指标
-	if err := manager.CollectAndSendNetwork(conn); err != nil {
+	if err := manager.CollectAndSendNetwork(writer); err != nil {
 		log.Printf("⚠️  发送网络指标失败: %v", err)
 		hasError = true
 	}
 
 	// 网络连接统计
-	if err := manager.CollectAndSendNetworkConnection(conn); err != nil {
+	if err := manager.CollectAndSendNetworkConnection(writer); err != nil {
 		log.Printf("⚠️  发送网络连接统计失败: %v", err)
 		hasError = true
 	}
 
 	// 主机信息
-	if err := manager.CollectAndSendHost(conn); err != nil {
+	if err := manager.CollectAndSendHost(writer); err != nil {
 		log.Printf("⚠️  发送主机信息失败: %v", err)
 		hasError = true
 	}
 
+	// 内核指标
+	if err := manager.CollectAndSendKernel(writer); err != nil {
+		log.Printf("⚠️  发送内核指标失败: %v", err)
+		hasError = true
+	}
+
 	// GPU 信息（可选）
-	if err := manager.CollectAndSendGPU(conn); err != nil {
+	if err := manager.CollectAndSendGPU(writer); err != nil {
 		log.Printf("ℹ️  发送GPU信息失败: %v", err)
 	}
 
 	// 温度信息（可选）
-	if err := manager.CollectAndSendTemperature(conn); err != nil {
+	if err := manager.CollectAndSendTemperature(writer); err != nil {
 		log.Printf("ℹ️  发送温度信息失败: %v", err)
 	}
 
+	// 磁盘健康（可选）
+	if err := manager.CollectAndSendDiskHealth(writer); err != nil {
+		log.Printf("ℹ️  发送磁盘健康信息失败: %v", err)
+	}
+
+	// 进程信息（可选）
+	if err := manager.CollectAndSendProcess(writer); err != nil {
+		log.Printf("ℹ️  发送进程信息失败: %v", err)
+	}
+
+	// 容器信息（可选）
+	if err := manager.CollectAndSendContainer(writer); err != nil {
+		log.Printf("ℹ️  发送容器信息失败: %v", err)
+	}
+
+	// systemd 单元状态（可选）
+	if err := manager.CollectAndSendSystemd(writer); err != nil {
+		log.Printf("ℹ️  发送systemd单元状态失败: %v", err)
+	}
+
+	// 自定义指标（可选）
+	if err := manager.CollectAndSendCustom(writer, a.customCollector); err != nil {
+		log.Printf("ℹ️  发送自定义指标失败: %v", err)
+	}
+
+	// Prometheus 抓取结果（可选）
+	if err := manager.CollectAndSendPrometheus(writer, a.prometheusCollector); err != nil {
+		log.Printf("ℹ️  发送Prometheus抓取结果失败: %v", err)
+	}
+
+	// 日志匹配计数和匹配到的日志行（可选）
+	if err := manager.CollectAndSendLog(writer, a.logTailer); err != nil {
+		log.Printf("ℹ️  发送日志监控数据失败: %v", err)
+	}
+
+	if batch != nil {
+		if err := batch.Flush(); err != nil {
+			return fmt.Errorf("发送指标失败: %w", err)
+		}
+	}
+
 	if hasError {
 		return fmt.Errorf("部分指标采集失败")
 	}
@@ -536,6 +775,101 @@ func (a *Agent) collectAndSendAllMetrics(conn *safeConn, manager *collector.Mana
 	return nil
 }
 
+// offlineWriter 断线期间采集时使用的写入目标，指标由 MetricsBatch 缓存，其他消息无法发送
+type offlineWriter struct{}
+
+func (offlineWriter) WriteJSON(v interface{}) error {
+	return errors.New("连接未就绪")
+}
+
+// bufferLoop 与服务端断开期间继续采集指标，按采集时间写入断线缓存
+func (a *Agent) bufferLoop(ctx context.Context) {
+	ticker := time.NewTicker(a.cfg.GetCollectorInterval())
+	defer ticker.Stop()
+
+	// 断线期间复用同一个采集器，速率类指标需要前后两次采集的数据
+	var manager *collector.Manager
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		if a.getActiveConn() != nil {
+			manager = nil
+			continue
+		}
+		if manager == nil {
+			manager = collector.NewManager(a.cfg)
+		}
+		if err := a.collectAndBufferMetrics(manager); err != nil {
+			log.Printf("⚠️  写入断线缓存失败: %v", err)
+		}
+	}
+}
+
+// collectAndBufferMetrics 采集数值类指标写入断线缓存
+// 主机、进程、容器等状态类信息只关心最新值，重连后会重新上报，不缓存；
+// 自定义指标、Prometheus 抓取结果和日志计数由各自的采集器在断线期间保留
+func (a *Agent) collectAndBufferMetrics(manager *collector.Manager) error {
+	batch := collector.NewMetricsBatch(offlineWriter{})
+	collectors := []func(collector.WebSocketWriter) error{
+		manager.CollectAndSendCPU,
+		manager.CollectAndSendMemory,
+		manager.CollectAndSendDisk,
+		manager.CollectAndSendDiskIO,
+		manager.CollectAndSendNetwork,
+		manager.CollectAndSendNetworkConnection,
+		manager.CollectAndSendKernel,
+		manager.CollectAndSendGPU,
+		manager.CollectAndSendTemperature,
+	}
+	for _, collect := range collectors {
+		// 单项采集失败不影响其他指标
+		_ = collect(batch)
+	}
+
+	samples := batch.Take()
+	now := time.Now().UnixMilli()
+	for i := range samples {
+		samples[i].Timestamp = now
+	}
+	return a.buffer.Append(samples)
+}
+
+// backfillMetrics 补发断线缓存中的指标，服务端不支持补发时丢弃缓存
+// 补发中断时已发送的段会在下次连接时重新发送，服务端按相同的采集时间写入
+func (a *Agent) backfillMetrics(conn *safeConn, done chan struct{}) {
+	if !a.metricsBackfill.Load() {
+		if err := a.buffer.Clear(); err != nil {
+			log.Printf("⚠️  清理断线缓存失败: %v", err)
+		}
+		return
+	}
+
+	sent, err := a.buffer.Drain(backfillBatchSize, func(samples []protocol.MetricsPayload) error {
+		select {
+		case <-time.After(backfillInterval):
+		case <-done:
+			return errors.New("连接已断开")
+		}
+		return conn.WriteJSON(protocol.OutboundMessage{
+			Type: protocol.MessageTypeMetricsBackfill,
+			Data: protocol.MetricsBackfill{
+				SentAt:  time.Now().UnixMilli(),
+				Samples: samples,
+			},
+		})
+	})
+	if sent > 0 {
+		log.Printf("📤 已补发断线期间缓存的 %d 条指标", sent)
+	}
+	if err != nil {
+		log.Printf("⚠️  补发断线缓存中断: %v", err)
+	}
+}
+
 // handleCommand 处理服务端下发的指令
 func (a *Agent) handleCommand(data json.RawMessage) {
 	var cmdReq protocol.CommandRequest
@@ -610,12 +944,12 @@ func GetVersion() string {
 }
 
 // handleTamperProtect 处理防篡改保护指令（增量更新）
-func (a *Agent) handleTamperProtect(data json.RawMessage) {
+func (a *Agent) handleTamperProtect(data json.RawMessage) error {
 	var tamperProtectConfig protocol.TamperProtectConfig
 	if err := json.Unmarshal(data, &tamperProtectConfig); err != nil {
 		log.Printf("⚠️  解析防篡改保护配置失败: %v", err)
 		a.sendTamperProtectResponse(false, "解析配置失败", nil, nil, nil, err.Error())
-		return
+		return fmt.Errorf("解析防篡改保护配置失败: %w", err)
 	}
 
 	log.Printf("📥 收到防篡改保护增量配置: Added=%v, Removed=%v", tamperProtectConfig.Added, tamperProtectConfig.Removed)
@@ -623,14 +957,14 @@ func (a *Agent) handleTamperProtect(data json.RawMessage) {
 	conn := a.getActiveConn()
 	if conn == nil {
 		log.Println("⚠️  当前连接未就绪，无法执行防篡改保护")
-		return
+		return errors.New("连接未就绪")
 	}
 
 	// 如果没有新增也没有移除，不需要做任何操作
 	if len(tamperProtectConfig.Added) == 0 && len(tamperProtectConfig.Removed) == 0 {
 		log.Println("ℹ️  配置无变化，跳过更新")
 		a.sendTamperProtectResponse(true, "配置无变化", a.tamperProtector.GetProtectedPaths(), []string{}, []string{}, "")
-		return
+		return nil
 	}
 
 	ctx := context.Background()
@@ -645,7 +979,7 @@ func (a *Agent) handleTamperProtect(data json.RawMessage) {
 		} else {
 			a.sendTamperProtectResponse(false, "更新失败", nil, nil, nil, err.Error())
 		}
-		return
+		return fmt.Errorf("应用防篡改保护配置失败: %w", err)
 	}
 
 	// 成功更新
@@ -653,6 +987,7 @@ func (a *Agent) handleTamperProtect(data json.RawMessage) {
 		len(result.Added), len(result.Removed), len(result.Current))
 	log.Printf("✅ %s", message)
 	a.sendTamperProtectResponse(true, message, result.Current, result.Added, result.Removed, "")
+	return nil
 }
 
 // sendTamperProtectResponse 发送防篡改保护响应
@@ -745,24 +1080,39 @@ func (a *Agent) tamperAlertLoop(ctx context.Context, conn *safeConn, done chan s
 	}
 }
 
+// handleLogConfig 处理日志监控配置（服务端在连接时和规则变更时下发完整规则）
+func (a *Agent) handleLogConfig(data json.RawMessage) error {
+	var logConfig protocol.LogConfigData
+	if err := json.Unmarshal(data, &logConfig); err != nil {
+		log.Printf("⚠️  解析日志监控配置失败: %v", err)
+		return fmt.Errorf("解析日志监控配置失败: %w", err)
+	}
+
+	a.logTailer.UpdateRules(logConfig.Rules)
+	if len(logConfig.Rules) > 0 {
+		log.Printf("📝 日志监控规则已更新: %d 条", len(logConfig.Rules))
+	}
+	return nil
+}
+
 // handleDDNSConfig 处理 DDNS 配置（服务端定时下发）
-func (a *Agent) handleDDNSConfig(data json.RawMessage) {
+func (a *Agent) handleDDNSConfig(data json.RawMessage) error {
 	var ddnsConfig protocol.DDNSConfigData
 	if err := json.Unmarshal(data, &ddnsConfig); err != nil {
 		log.Printf("⚠️  解析 DDNS 配置失败: %v", err)
-		return
+		return fmt.Errorf("解析 DDNS 配置失败: %w", err)
 	}
 
 	if !ddnsConfig.Enabled {
 		log.Println("ℹ️  DDNS 已禁用，跳过 IP 检查")
-		return
+		return nil
 	}
 
 	conn := a.getActiveConn()
 	manager := a.getCollectorManager()
 	if conn == nil || manager == nil {
 		log.Println("⚠️  当前连接未就绪，无法执行 DDNS IP 检查")
-		return
+		return errors.New("连接未就绪")
 	}
 
 	log.Println("📥 收到 DDNS 配置检查请求，开始采集 IP 地址")
@@ -770,9 +1120,10 @@ func (a *Agent) handleDDNSConfig(data json.RawMessage) {
 	// 采集 IP 地址并上报
 	if err := a.collectAndSendDDNSIP(conn, manager, &ddnsConfig); err != nil {
 		log.Printf("⚠️  DDNS IP 采集失败: %v", err)
-	} else {
-		log.Println("✅ DDNS IP 地址已上报")
+		return fmt.Errorf("DDNS IP 采集失败: %w", err)
 	}
+	log.Println("✅ DDNS IP 地址已上报")
+	return nil
 }
 
 // collectAndSendDDNSIP 采集并发送 DDNS IP 地址
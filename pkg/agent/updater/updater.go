@@ -2,7 +2,6 @@ package updater
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -34,16 +33,14 @@ func New(cfg *config.Config, currentVer string) (*Updater, error) {
 		return nil, fmt.Errorf("获取可执行文件路径失败: %w", err)
 	}
 
-	// 创建 HTTP 客户端，根据配置决定是否跳过证书验证
-	httpClient := &http.Client{
-		Timeout: 60 * time.Second,
+	// 创建 HTTP 客户端，与探针连接使用相同的代理、TLS 和请求头配置
+	transport, err := cfg.Server.HTTPTransport()
+	if err != nil {
+		return nil, fmt.Errorf("加载连接配置失败: %w", err)
 	}
-	if cfg.Server.InsecureSkipVerify {
-		httpClient.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
-		}
+	httpClient := &http.Client{
+		Timeout:   60 * time.Second,
+		Transport: transport,
 	}
 
 	return &Updater{
@@ -8,7 +8,8 @@ export interface ListAgentsResponse {
 
 export interface GetAgentMetricsRequest {
     agentId: string;
-    type: 'cpu' | 'memory' | 'disk' | 'network' | 'network_connection' | 'disk_io' | 'gpu' | 'temperature' | 'monitor';
+    type: 'cpu' | 'memory' | 'disk' | 'network' | 'network_connection' | 'disk_io' | 'gpu' | 'temperature' | 'monitor' | 'process' | 'container' | 'systemd' | 'disk_health' | 'log'
+        | 'cpu_mode' | 'cpu_core' | 'inode' | 'load' | 'pressure' | 'context_switch' | 'file_descriptor' | 'entropy';
     range?: string; // 时间范围，如 '15m', '1h', '1d' 等，从后端配置获取
     start?: number; // 自定义开始时间（毫秒时间戳）
     end?: number; // 自定义结束时间（毫秒时间戳）
@@ -86,6 +87,97 @@ export const getAgentLatestMetrics = (agentId: string) => {
     return get<LatestMetrics>(`/agents/${agentId}/metrics/latest`);
 };
 
+// 进程数据（仅管理员可见）
+export interface ProcessStat {
+    pid: number;
+    name: string;
+    cmdline?: string;
+    username?: string;
+    cpuPercent: number;
+    memoryPercent: number;
+    rss: number;
+    numFds: number;
+    numThreads: number;
+    createTime: number;
+}
+
+export interface WatchedProcess {
+    name: string;
+    running: boolean;
+    count: number;
+    pids: number[];
+    cpuPercent: number;
+    rss: number;
+    numFds: number;
+    numThreads: number;
+    startedAt: number;
+    restarts: number;
+}
+
+export interface ProcessData {
+    top: ProcessStat[];
+    watched: WatchedProcess[];
+}
+
+export const getAgentProcesses = (agentId: string) => {
+    return get<ProcessData>(`/admin/agents/${agentId}/processes`);
+};
+
+export interface ContainerData {
+    id: string;
+    name: string;
+    image?: string;
+    runtime: string;
+    cpuPercent: number;
+    memoryUsage: number;
+    memoryLimit: number;
+    memoryPercent: number;
+    blkioReadBytesRate: number;
+    blkioWriteBytesRate: number;
+    netRxBytesRate: number;
+    netTxBytesRate: number;
+    pids: number;
+}
+
+export const getAgentContainers = (agentId: string) => {
+    return get<ContainerData[]>(`/admin/agents/${agentId}/containers`);
+};
+
+export interface SystemdUnit {
+    name: string;
+    description: string;
+    loadState: string;
+    activeState: string;
+    subState: string;
+    restarts: number;
+}
+
+export const getAgentSystemdUnits = (agentId: string) => {
+    return get<SystemdUnit[]>(`/admin/agents/${agentId}/systemd`);
+};
+
+export interface DiskHealthData {
+    device: string;
+    model: string;
+    serial: string;
+    protocol: string;
+    passed: boolean;
+    temperature: number;
+    powerOnHours: number;
+    reallocatedSectors: number;
+    pendingSectors: number;
+    uncorrectableSectors: number;
+    percentageUsed: number;
+    availableSpare: number;
+    availableSpareThresh: number;
+    mediaErrors: number;
+    criticalWarning: number;
+}
+
+export const getAgentDiskHealth = (agentId: string) => {
+    return get<DiskHealthData[]>(`/admin/agents/${agentId}/disk-health`);
+};
+
 // 获取探针的可用网卡列表
 export interface GetNetworkInterfacesResponse {
     interfaces: string[];
@@ -95,6 +187,49 @@ export const getAvailableNetworkInterfaces = (agentId: string) => {
     return get<GetNetworkInterfacesResponse>(`/agents/${agentId}/network-interfaces`);
 };
 
+// Prometheus 抓取目标状态（仅管理员可见）
+export interface PrometheusTarget {
+    job: string;
+    instance: string;
+    up: boolean;
+    scrapeDuration: number; // 秒
+    timestamp: number;
+    error?: string;
+}
+
+export const getAgentPrometheusTargets = (agentId: string) => {
+    return get<PrometheusTarget[]>(`/admin/agents/${agentId}/prometheus-targets`);
+};
+
+// 自定义指标（脚本输出和本地推送，仅管理员可见）
+export interface GetCustomMetricNamesResponse {
+    names: string[];
+}
+
+export const getAgentCustomMetricNames = (agentId: string) => {
+    return get<GetCustomMetricNamesResponse>(`/admin/agents/${agentId}/custom-metrics`);
+};
+
+export interface GetAgentCustomMetricsRequest {
+    agentId: string;
+    name: string;
+    range?: string;
+    start?: number;
+    end?: number;
+}
+
+export const getAgentCustomMetrics = (params: GetAgentCustomMetricsRequest) => {
+    const {agentId, name, range = '1h', start, end} = params;
+    const query = new URLSearchParams();
+    if (start !== undefined && end !== undefined) {
+        query.append('start', start.toString());
+        query.append('end', end.toString());
+    } else {
+        query.append('range', range);
+    }
+    return get<GetAgentMetricsResponse>(`/admin/agents/${agentId}/custom-metrics/${encodeURIComponent(name)}?${query.toString()}`);
+};
+
 export interface GetNetworkMetricsByInterfaceRequest {
     agentId: string;
     range?: '1m' | '5m' | '15m' | '30m' | '1h' | '3h' | '6h' | '12h' | '1d' | '24h' | '3d' | '7d' | '30d';
@@ -442,6 +577,27 @@ export const listAuditResults = (agentId: string) => {
     return get<{ items: AuditResultSummary[]; total: number }>(`/admin/agents/${agentId}/audit/results`);
 };
 
+// 探针配置下发状态
+export interface AgentConfigState {
+    id: string;
+    agentId: string;
+    kind: 'tamper' | 'ddns' | 'log';
+    ref: string;
+    messageId: string;
+    desiredHash: string;
+    appliedHash: string;
+    status: 'queued' | 'pending' | 'applied' | 'failed' | 'timeout' | 'sent';
+    error?: string;
+    sentAt: number;
+    appliedAt: number;
+    updatedAt: number;
+}
+
+// 获取探针配置下发状态（管理员接口）
+export const listAgentConfigStates = (agentId: string) => {
+    return get<{ items: AgentConfigState[]; total: number }>(`/admin/agents/${agentId}/config-status`);
+};
+
 // 更新探针名称
 export const updateAgentName = (agentId: string, name: string) => {
     return put(`/admin/agents/${agentId}/name`, {name});
@@ -453,6 +609,7 @@ export interface UpdateAgentInfoRequest {
     tags?: string[];
     expireTime?: number;
     visibility?: string;
+    certSubject?: string;
 }
 
 export const updateAgentInfo = (agentId: string, data: UpdateAgentInfoRequest) => {
@@ -519,6 +676,21 @@ export const resetAgentTraffic = (agentId: string) => {
     return post(`/admin/agents/${agentId}/traffic-reset`, {});
 };
 
+// 轮换探针凭证（管理员接口）
+export const rotateAgentCredential = (agentId: string) => {
+    return post<{ message: string }>(`/admin/agents/${agentId}/credential/rotate`, {});
+};
+
+// 吊销探针凭证（管理员接口）
+export const revokeAgentCredential = (agentId: string) => {
+    return post<{ message: string }>(`/admin/agents/${agentId}/credential/revoke`, {});
+};
+
+// 允许探针使用 API 密钥重新注册（管理员接口）
+export const resetAgentCredential = (agentId: string) => {
+    return post<{ message: string }>(`/admin/agents/${agentId}/credential/reset`, {});
+};
+
 // 获取服务器地址（管理员接口）
 export interface GetServerUrlResponse {
     serverUrl: string;
@@ -0,0 +1,112 @@
+import type {LatestMetrics} from '@/types';
+
+// 实时事件类型，与服务端 service.LiveEvent* 保持一致
+export type LiveEventType = 'metrics' | 'presence' | 'alert';
+
+export interface LivePresence {
+    online: boolean;
+    at: number;
+}
+
+export interface LiveEvent<T = unknown> {
+    type: LiveEventType;
+    agentId: string;
+    data: T;
+}
+
+export type LiveMetricsEvent = LiveEvent<LatestMetrics>;
+
+export interface LiveSubscribeOptions {
+    agentIds?: string[];
+    types?: LiveEventType[];
+    onEvent: (event: LiveEvent) => void;
+    // 连接建立或断开时回调，断开期间可回退为轮询
+    onStatusChange?: (connected: boolean) => void;
+}
+
+const RECONNECT_DELAY = 5000;
+
+/**
+ * 订阅服务端推送的实时事件（SSE）
+ * 使用 fetch 读取事件流以便携带登录凭证，连接断开后自动重连
+ * @returns 取消订阅函数
+ */
+export const subscribeLive = ({agentIds, types, onEvent, onStatusChange}: LiveSubscribeOptions) => {
+    const params = new URLSearchParams();
+    if (agentIds && agentIds.length > 0) {
+        params.set('agentIds', agentIds.join(','));
+    }
+    if (types && types.length > 0) {
+        params.set('types', types.join(','));
+    }
+    const url = `/api/live${params.toString() ? `?${params.toString()}` : ''}`;
+
+    let closed = false;
+    let controller: AbortController | null = null;
+    let timer: number | undefined;
+
+    const connect = async () => {
+        controller = new AbortController();
+        const headers = new Headers({Accept: 'text/event-stream'});
+        const token = localStorage.getItem('token');
+        if (token) {
+            headers.set('Authorization', `Bearer ${token}`);
+        }
+
+        try {
+            const response = await fetch(url, {headers, signal: controller.signal});
+            if (!response.ok || !response.body) {
+                throw new Error(`订阅实时事件失败: ${response.status}`);
+            }
+            onStatusChange?.(true);
+
+            const reader = response.body.pipeThrough(new TextDecoderStream()).getReader();
+            let buffer = '';
+            while (true) {
+                const {value, done} = await reader.read();
+                if (done) {
+                    break;
+                }
+                buffer += value;
+
+                // 事件之间以空行分隔
+                let index = buffer.indexOf('\n\n');
+                while (index >= 0) {
+                    const block = buffer.slice(0, index);
+                    buffer = buffer.slice(index + 2);
+                    const data = block
+                        .split('\n')
+                        .filter(line => line.startsWith('data:'))
+                        .map(line => line.slice(5).trim())
+                        .join('\n');
+                    if (data) {
+                        try {
+                            onEvent(JSON.parse(data) as LiveEvent);
+                        } catch (error) {
+                            console.warn('解析实时事件失败', error);
+                        }
+                    }
+                    index = buffer.indexOf('\n\n');
+                }
+            }
+        } catch (error) {
+            if (closed) {
+                return;
+            }
+            console.warn('实时事件连接断开', error);
+        }
+
+        if (!closed) {
+            onStatusChange?.(false);
+            timer = window.setTimeout(connect, RECONNECT_DELAY);
+        }
+    };
+
+    connect();
+
+    return () => {
+        closed = true;
+        window.clearTimeout(timer);
+        controller?.abort();
+    };
+};
@@ -0,0 +1,70 @@
+import {del, get, post, put} from './request';
+
+export interface LogRule {
+    id: string;
+    agentId: string;
+    name: string;
+    enabled: boolean;
+    paths: string[];
+    journald: boolean;
+    journalUnits: string[];
+    pattern: string;
+    shipLines: boolean;
+    alertEnabled: boolean;
+    alertWindow: number;
+    maxEventsPerMinute: number;
+    createdAt: number;
+    updatedAt: number;
+}
+
+export type LogRuleRequest = Omit<LogRule, 'id' | 'agentId' | 'createdAt' | 'updatedAt'>;
+
+export interface LogEvent {
+    id: string;
+    agentId: string;
+    ruleId: string;
+    ruleName: string;
+    source: string;
+    line: string;
+    timestamp: number;
+    createdAt: number;
+}
+
+export interface LogEventListResponse {
+    items: LogEvent[];
+    total: number;
+}
+
+// 获取探针的日志规则
+export const getLogRules = (agentId: string) => {
+    return get<LogRule[]>(`/admin/agents/${agentId}/log-rules`);
+};
+
+// 创建日志规则
+export const createLogRule = (agentId: string, data: LogRuleRequest) => {
+    return post<LogRule>(`/admin/agents/${agentId}/log-rules`, data);
+};
+
+// 更新日志规则
+export const updateLogRule = (id: string, data: LogRuleRequest) => {
+    return put<{ message: string }>(`/admin/log-rules/${id}`, data);
+};
+
+// 删除日志规则
+export const deleteLogRule = (id: string) => {
+    return del<{ message: string }>(`/admin/log-rules/${id}`);
+};
+
+// 获取匹配到的日志（分页）
+export const getLogEvents = (agentId: string, pageIndex: number, pageSize: number, ruleId?: string, keyword?: string) => {
+    const params = new URLSearchParams();
+    params.append('pageIndex', pageIndex.toString());
+    params.append('pageSize', pageSize.toString());
+    if (ruleId) {
+        params.append('ruleId', ruleId);
+    }
+    if (keyword) {
+        params.append('keyword', keyword);
+    }
+    return get<LogEventListResponse>(`/admin/agents/${agentId}/log-events?${params.toString()}`);
+};
@@ -1,13 +1,16 @@
 import {del, get, post, put} from './request';
 import type {AgentMonitorStat, MonitorDetail, MonitorListResponse, MonitorTask, MonitorTaskRequest, PublicMonitor} from '../types';
 
-export const listMonitors = (page: number = 1, pageSize: number = 10, keyword?: string) => {
+export const listMonitors = (page: number = 1, pageSize: number = 10, keyword?: string, groupName?: string) => {
     const params = new URLSearchParams();
     params.append('pageIndex', page.toString());
     params.append('pageSize', pageSize.toString());
     if (keyword) {
         params.append('keyword', keyword);
     }
+    if (groupName) {
+        params.append('groupName', groupName);
+    }
     params.set('sortOrder', 'asc');
     params.set('sortField', 'name');
     return get<MonitorListResponse>(`/admin/monitors?${params.toString()}`);
@@ -29,6 +32,10 @@ export const deleteMonitor = (id: number) => {
     return del(`/admin/monitors/${id}`);
 };
 
+export const getMonitorGroups = () => {
+    return get<{ groups: string[] }>('/admin/monitors/groups');
+};
+
 // 公开接口 - 获取监控配置及聚合统计
 export const getPublicMonitors = () => {
     return get<PublicMonitor[]>('/monitors');
@@ -83,3 +90,121 @@ export const getMonitorHistory = (id: string, params: GetMonitorHistoryRequest =
     }
     return get<GetMetricsResponse>(`/monitors/${encodeURIComponent(id)}/history?${query.toString()}`);
 };
+
+// SLA 可用率窗口
+export interface UptimeWindow {
+    window: string;
+    start: number;
+    end: number;
+    uptime: number;
+    hasData: boolean;
+}
+
+// 停机事件
+export interface SLAIncident {
+    monitorId: string;
+    monitorName?: string;
+    start: number;
+    end: number;
+    duration: number;
+    ongoing: boolean;
+}
+
+// SLO 错误预算
+export interface SLOBudget {
+    target: number;
+    met: boolean;
+    allowedDowntime: number;
+    consumedDowntime: number;
+    remainingDowntime: number;
+    budgetConsumed: number;
+    burnRate: number;
+}
+
+export interface MonitorSLA {
+    monitorId: string;
+    monitorName: string;
+    type: string;
+    start: number;
+    end: number;
+    hasData: boolean;
+    uptime: number;
+    observed: number;
+    downtime: number;
+    incidentCount: number;
+    slo?: SLOBudget;
+    agents: {agentId: string; agentName: string; uptime: number; downtime: number}[];
+    windows: UptimeWindow[];
+}
+
+export interface SLATimeRange {
+    range?: '24h' | '7d' | '30d' | '90d';
+    start?: number;
+    end?: number;
+}
+
+const buildSLAQuery = ({range = '30d', start, end}: SLATimeRange) => {
+    const query = new URLSearchParams();
+    if (start !== undefined && end !== undefined) {
+        query.append('start', start.toString());
+        query.append('end', end.toString());
+    } else {
+        query.append('range', range);
+    }
+    return query.toString();
+};
+
+// 获取监控任务的 SLA 统计
+export const getMonitorSLA = (id: string, params: SLATimeRange = {}) => {
+    return get<MonitorSLA>(`/admin/monitors/${encodeURIComponent(id)}/sla?${buildSLAQuery(params)}`);
+};
+
+// 获取监控任务的停机事件
+export const getMonitorIncidents = (id: string, params: SLATimeRange = {}) => {
+    return get<SLAIncident[]>(`/admin/monitors/${encodeURIComponent(id)}/sla/incidents?${buildSLAQuery(params)}`);
+};
+
+// 导出月度 SLA 报告（CSV 文本）
+export const exportSLAReportCsv = (month: string) => {
+    return get<string>(`/admin/sla/report?month=${encodeURIComponent(month)}&format=csv`);
+};
+
+export interface MonitorFieldDiff {
+    field: string;
+    old: unknown;
+    new: unknown;
+}
+
+export interface MonitorImportChange {
+    name: string;
+    action: 'create' | 'update' | 'delete' | 'unchanged';
+    fields?: MonitorFieldDiff[];
+}
+
+export interface MonitorImportResult {
+    dryRun: boolean;
+    created: number;
+    updated: number;
+    deleted: number;
+    unchanged: number;
+    changes: MonitorImportChange[];
+}
+
+// 导出所有监控任务（YAML/JSON 文本）
+export const exportMonitors = (format: 'yaml' | 'json' = 'yaml') => {
+    return get<string>(`/admin/monitors/export?format=${format}`);
+};
+
+// 导入监控任务，dryRun 时只返回差异
+export const importMonitors = (content: string, options: { dryRun?: boolean; prune?: boolean } = {}) => {
+    const params = new URLSearchParams();
+    if (options.dryRun) {
+        params.set('dryRun', 'true');
+    }
+    if (options.prune) {
+        params.set('prune', 'true');
+    }
+    return post<MonitorImportResult>(`/admin/monitors/import?${params.toString()}`, content, {
+        headers: {'Content-Type': 'application/x-yaml'},
+    });
+};
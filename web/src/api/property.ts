@@ -113,6 +113,9 @@ export interface AlertRules {
     diskEnabled: boolean;
     diskThreshold: number;
     diskDuration: number;
+    inodeEnabled?: boolean;
+    inodeThreshold?: number;
+    inodeDuration?: number;
     networkEnabled: boolean;
     networkThreshold: number;  // 网速阈值(MB/s)
     networkDuration: number;
@@ -122,6 +125,12 @@ export interface AlertRules {
     serviceDuration: number;   // 服务下线持续时间（秒）
     agentOfflineEnabled: boolean;   // 探针离线告警开关
     agentOfflineDuration: number;   // 探针离线持续时间（秒）
+    processEnabled?: boolean;       // 关注进程消失告警开关
+    processDuration?: number;       // 进程消失持续时间（秒）
+    systemdEnabled?: boolean;       // systemd 单元失败/重启告警开关
+    systemdRestartWindow?: number;  // 重启告警恢复时间（秒）
+    diskHealthEnabled?: boolean;    // 磁盘健康告警开关
+    logEnabled?: boolean;           // 日志关键字告警开关
 }
 
 // 全局告警配置
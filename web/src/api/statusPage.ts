@@ -0,0 +1,91 @@
+import {del, get, post, put} from './request';
+import type {
+    StatusIncident,
+    StatusIncidentRequest,
+    StatusIncidentUpdate,
+    StatusMaintenance,
+    StatusMaintenanceRequest,
+    StatusPage,
+    StatusPageRequest,
+    StatusPageView,
+} from '@/types/statusPage';
+
+export interface StatusPageListResponse {
+    items: StatusPage[];
+    total: number;
+}
+
+// 获取状态页列表（分页）
+export const getStatusPages = (page: number, size: number, keyword?: string) => {
+    const params = new URLSearchParams();
+    params.append('pageIndex', page.toString());
+    params.append('pageSize', size.toString());
+    if (keyword) {
+        params.append('keyword', keyword);
+    }
+    return get<StatusPageListResponse>(`/admin/status-pages?${params.toString()}`);
+};
+
+export const createStatusPage = (data: StatusPageRequest) => {
+    return post<StatusPage>('/admin/status-pages', data);
+};
+
+export const getStatusPage = (id: string) => {
+    return get<StatusPage>(`/admin/status-pages/${id}`);
+};
+
+export const updateStatusPage = (id: string, data: StatusPageRequest) => {
+    return put<StatusPage>(`/admin/status-pages/${id}`, data);
+};
+
+export const deleteStatusPage = (id: string) => {
+    return del(`/admin/status-pages/${id}`);
+};
+
+// 故障事件
+export const getStatusIncidents = (statusPageId: string) => {
+    return get<StatusIncident[]>(`/admin/status-pages/${statusPageId}/incidents`);
+};
+
+export const createStatusIncident = (statusPageId: string, data: StatusIncidentRequest) => {
+    return post<StatusIncident>(`/admin/status-pages/${statusPageId}/incidents`, data);
+};
+
+export const updateStatusIncident = (id: string, data: StatusIncidentRequest) => {
+    return put<StatusIncident>(`/admin/status-incidents/${id}`, data);
+};
+
+export const deleteStatusIncident = (id: string) => {
+    return del(`/admin/status-incidents/${id}`);
+};
+
+export const addStatusIncidentUpdate = (id: string, data: Pick<StatusIncidentUpdate, 'status' | 'message'>) => {
+    return post<StatusIncidentUpdate>(`/admin/status-incidents/${id}/updates`, data);
+};
+
+// 计划维护
+export const getStatusMaintenances = (statusPageId: string) => {
+    return get<StatusMaintenance[]>(`/admin/status-pages/${statusPageId}/maintenances`);
+};
+
+export const createStatusMaintenance = (statusPageId: string, data: StatusMaintenanceRequest) => {
+    return post<StatusMaintenance>(`/admin/status-pages/${statusPageId}/maintenances`, data);
+};
+
+export const updateStatusMaintenance = (id: string, data: StatusMaintenanceRequest) => {
+    return put<StatusMaintenance>(`/admin/status-maintenances/${id}`, data);
+};
+
+export const deleteStatusMaintenance = (id: string) => {
+    return del(`/admin/status-maintenances/${id}`);
+};
+
+// 公开接口 - 根据 slug 获取状态页
+export const getPublicStatusPage = (slug: string) => {
+    return get<StatusPageView>(`/status-pages/${encodeURIComponent(slug)}`);
+};
+
+// 公开接口 - 根据当前访问域名获取状态页
+export const getPublicStatusPageByHost = () => {
+    return get<StatusPageView>('/status-page');
+};
@@ -0,0 +1,120 @@
+import {useMemo} from 'react';
+import {Layers} from 'lucide-react';
+import {Area, AreaChart, CartesianGrid, Legend, ResponsiveContainer, Tooltip, XAxis, YAxis} from 'recharts';
+import {ChartPlaceholder, CustomTooltip} from '@/components/common';
+import {useMetricsQuery} from '@/hooks/server/queries';
+import {ChartContainer} from './ChartContainer';
+import {formatChartTime} from '@/utils/util';
+
+interface CpuModeChartProps {
+    agentId: string;
+    timeRange: string;
+    start?: number;
+    end?: number;
+}
+
+// CPU 模式及显示名称、颜色（不含 idle）
+const CPU_MODES = [
+    {key: 'user', name: 'user', color: '#2563eb'},
+    {key: 'nice', name: 'nice', color: '#6366f1'},
+    {key: 'system', name: 'system', color: '#10b981'},
+    {key: 'iowait', name: 'iowait', color: '#f59e0b'},
+    {key: 'irq', name: 'irq', color: '#a855f7'},
+    {key: 'softirq', name: 'softirq', color: '#ec4899'},
+    {key: 'steal', name: 'steal', color: '#ef4444'},
+    {key: 'guest', name: 'guest', color: '#64748b'},
+];
+
+/**
+ * CPU 模式占比图表组件
+ * 堆叠展示 user/system/iowait/steal 等模式，超售的 VPS 重点关注 steal 和 iowait
+ */
+export const CpuModeChart = ({agentId, timeRange, start, end}: CpuModeChartProps) => {
+    const rangeMs = start !== undefined && end !== undefined ? end - start : undefined;
+    // 数据查询
+    const {data: metricsResponse, isLoading} = useMetricsQuery({
+        agentId,
+        type: 'cpu_mode',
+        range: start !== undefined && end !== undefined ? undefined : timeRange,
+        start,
+        end,
+    });
+
+    // 数据转换
+    const chartData = useMemo(() => {
+        if (!metricsResponse?.data.series || metricsResponse.data.series?.length === 0) return [];
+
+        // 按时间戳聚合各模式系列
+        const timeMap = new Map<number, any>();
+
+        metricsResponse.data.series?.forEach(series => {
+            const mode = series.labels?.mode;
+            if (!mode) return;
+            series.data.forEach(point => {
+                if (!timeMap.has(point.timestamp)) {
+                    timeMap.set(point.timestamp, {timestamp: point.timestamp});
+                }
+                timeMap.get(point.timestamp)![mode] = Number(point.value.toFixed(2));
+            });
+        });
+
+        return Array.from(timeMap.values()).sort((a, b) => a.timestamp - b.timestamp);
+    }, [metricsResponse, timeRange, start, end]);
+
+    // 渲染
+    if (isLoading) {
+        return (
+            <ChartContainer title="CPU 模式占比" icon={Layers}>
+                <ChartPlaceholder/>
+            </ChartContainer>
+        );
+    }
+
+    return (
+        <ChartContainer title="CPU 模式占比" icon={Layers}>
+            {chartData.length > 0 ? (
+                <ResponsiveContainer width="100%" height={250}>
+                    <AreaChart data={chartData}>
+                        <CartesianGrid stroke="currentColor" strokeDasharray="4 4" className="stroke-slate-200 dark:stroke-cyan-900/30"/>
+                        <XAxis
+                            dataKey="timestamp"
+                            type="number"
+                            scale="time"
+                            domain={['dataMin', 'dataMax']}
+                            tickFormatter={(value) => formatChartTime(Number(value), timeRange, rangeMs)}
+                            stroke="currentColor"
+                            angle={-15}
+                            textAnchor="end"
+                            className="text-xs text-gray-600 dark:text-cyan-500 font-mono"
+                            height={45}
+                        />
+                        <YAxis
+                            domain={[0, 100]}
+                            stroke="currentColor"
+                            className="stroke-gray-400 dark:stroke-cyan-600 text-xs"
+                            tickFormatter={(value) => `${value}%`}
+                        />
+                        <Tooltip content={<CustomTooltip unit="%"/>}/>
+                        <Legend/>
+                        {CPU_MODES.map(mode => (
+                            <Area
+                                key={mode.key}
+                                type="monotone"
+                                dataKey={mode.key}
+                                name={mode.name}
+                                stackId="cpu"
+                                stroke={mode.color}
+                                fill={mode.color}
+                                fillOpacity={0.3}
+                                strokeWidth={1.5}
+                                activeDot={{r: 3}}
+                            />
+                        ))}
+                    </AreaChart>
+                </ResponsiveContainer>
+            ) : (
+                <ChartPlaceholder subtitle="暂无 CPU 模式数据"/>
+            )}
+        </ChartContainer>
+    );
+};
@@ -0,0 +1,111 @@
+import {useMemo} from 'react';
+import {FileStack} from 'lucide-react';
+import {CartesianGrid, Legend, Line, LineChart, ResponsiveContainer, Tooltip, XAxis, YAxis} from 'recharts';
+import {ChartPlaceholder, CustomTooltip} from '@/components/common';
+import {useMetricsQuery} from '@/hooks/server/queries';
+import {ChartContainer} from './ChartContainer';
+import {formatChartTime} from '@/utils/util';
+
+interface FileDescriptorChartProps {
+    agentId: string;
+    timeRange: string;
+    start?: number;
+    end?: number;
+}
+
+/**
+ * 文件描述符和连接跟踪表使用率图表组件
+ * 非 Linux 平台没有数据时不渲染
+ */
+export const FileDescriptorChart = ({agentId, timeRange, start, end}: FileDescriptorChartProps) => {
+    const rangeMs = start !== undefined && end !== undefined ? end - start : undefined;
+    // 数据查询
+    const {data: metricsResponse, isLoading} = useMetricsQuery({
+        agentId,
+        type: 'file_descriptor',
+        range: start !== undefined && end !== undefined ? undefined : timeRange,
+        start,
+        end,
+    });
+
+    // 数据转换
+    const chartData = useMemo(() => {
+        if (!metricsResponse?.data.series || metricsResponse.data.series?.length === 0) return [];
+
+        // 按时间戳聚合所有系列
+        const timeMap = new Map<number, any>();
+
+        metricsResponse.data.series?.forEach(series => {
+            series.data.forEach(point => {
+                if (!timeMap.has(point.timestamp)) {
+                    timeMap.set(point.timestamp, {timestamp: point.timestamp});
+                }
+                timeMap.get(point.timestamp)![series.name] = Number(point.value.toFixed(2));
+            });
+        });
+
+        return Array.from(timeMap.values()).sort((a, b) => a.timestamp - b.timestamp);
+    }, [metricsResponse, timeRange, start, end]);
+
+    // 渲染
+    if (isLoading) {
+        return (
+            <ChartContainer title="文件描述符与连接跟踪表" icon={FileStack}>
+                <ChartPlaceholder/>
+            </ChartContainer>
+        );
+    }
+
+    // 没有数据时不渲染组件
+    if (chartData.length === 0) {
+        return null;
+    }
+
+    return (
+        <ChartContainer title="文件描述符与连接跟踪表" icon={FileStack}>
+            <ResponsiveContainer width="100%" height={250}>
+                <LineChart data={chartData}>
+                    <CartesianGrid stroke="currentColor" strokeDasharray="4 4" className="stroke-slate-200 dark:stroke-cyan-900/30"/>
+                    <XAxis
+                        dataKey="timestamp"
+                        type="number"
+                        scale="time"
+                        domain={['dataMin', 'dataMax']}
+                        tickFormatter={(value) => formatChartTime(Number(value), timeRange, rangeMs)}
+                        stroke="currentColor"
+                        angle={-15}
+                        textAnchor="end"
+                        className="text-xs text-gray-600 dark:text-cyan-500 font-mono"
+                        height={45}
+                    />
+                    <YAxis
+                        domain={[0, 100]}
+                        tickFormatter={(value) => `${value}%`}
+                        stroke="currentColor"
+                        className="stroke-gray-400 dark:stroke-cyan-600 text-xs"
+                    />
+                    <Tooltip content={<CustomTooltip unit="%"/>}/>
+                    <Legend/>
+                    <Line
+                        type="monotone"
+                        dataKey="file_descriptor"
+                        name="文件描述符"
+                        stroke="#2563eb"
+                        strokeWidth={2}
+                        dot={false}
+                        activeDot={{r: 3}}
+                    />
+                    <Line
+                        type="monotone"
+                        dataKey="conntrack"
+                        name="连接跟踪表"
+                        stroke="#ef4444"
+                        strokeWidth={2}
+                        dot={false}
+                        activeDot={{r: 3}}
+                    />
+                </LineChart>
+            </ResponsiveContainer>
+        </ChartContainer>
+    );
+};
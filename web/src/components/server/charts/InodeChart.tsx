@@ -0,0 +1,118 @@
+import {useMemo} from 'react';
+import {FileStack} from 'lucide-react';
+import {CartesianGrid, Legend, Line, LineChart, ResponsiveContainer, Tooltip, XAxis, YAxis} from 'recharts';
+import {ChartPlaceholder, CustomTooltip} from '@/components/common';
+import {useMetricsQuery} from '@/hooks/server/queries';
+import {ChartContainer} from './ChartContainer';
+import {formatChartTime} from '@/utils/util';
+
+interface InodeChartProps {
+    agentId: string;
+    timeRange: string;
+    start?: number;
+    end?: number;
+}
+
+// 挂载点线条颜色
+const MOUNT_POINT_COLORS = ['#2563eb', '#10b981', '#f59e0b', '#a855f7', '#ef4444', '#06b6d4', '#ec4899', '#64748b'];
+
+/**
+ * 各挂载点 inode 使用率图表组件
+ * 不提供 inode 数量的文件系统没有数据，全部没有数据时不渲染
+ */
+export const InodeChart = ({agentId, timeRange, start, end}: InodeChartProps) => {
+    const rangeMs = start !== undefined && end !== undefined ? end - start : undefined;
+    // 数据查询
+    const {data: metricsResponse, isLoading} = useMetricsQuery({
+        agentId,
+        type: 'inode',
+        range: start !== undefined && end !== undefined ? undefined : timeRange,
+        start,
+        end,
+    });
+
+    // 数据转换
+    const chartData = useMemo(() => {
+        if (!metricsResponse?.data.series || metricsResponse.data.series?.length === 0) return [];
+
+        // 按时间戳聚合各挂载点系列
+        const timeMap = new Map<number, any>();
+
+        metricsResponse.data.series?.forEach(series => {
+            const mountPoint = series.labels?.mount_point;
+            if (!mountPoint) return;
+            series.data.forEach(point => {
+                if (!timeMap.has(point.timestamp)) {
+                    timeMap.set(point.timestamp, {timestamp: point.timestamp});
+                }
+                timeMap.get(point.timestamp)![mountPoint] = Number(point.value.toFixed(2));
+            });
+        });
+
+        return Array.from(timeMap.values()).sort((a, b) => a.timestamp - b.timestamp);
+    }, [metricsResponse, timeRange, start, end]);
+
+    // 提取所有挂载点
+    const mountPoints = useMemo(() => {
+        const names = metricsResponse?.data.series
+            ?.map(s => s.labels?.mount_point)
+            .filter((name): name is string => !!name) || [];
+        return Array.from(new Set(names)).sort();
+    }, [metricsResponse]);
+
+    // 渲染
+    if (isLoading) {
+        return (
+            <ChartContainer title="inode 使用率" icon={FileStack}>
+                <ChartPlaceholder/>
+            </ChartContainer>
+        );
+    }
+
+    // 没有数据时不渲染组件
+    if (chartData.length === 0) {
+        return null;
+    }
+
+    return (
+        <ChartContainer title="inode 使用率" icon={FileStack}>
+            <ResponsiveContainer width="100%" height={250}>
+                <LineChart data={chartData}>
+                    <CartesianGrid stroke="currentColor" strokeDasharray="4 4" className="stroke-slate-200 dark:stroke-cyan-900/30"/>
+                    <XAxis
+                        dataKey="timestamp"
+                        type="number"
+                        scale="time"
+                        domain={['dataMin', 'dataMax']}
+                        tickFormatter={(value) => formatChartTime(Number(value), timeRange, rangeMs)}
+                        stroke="currentColor"
+                        angle={-15}
+                        textAnchor="end"
+                        className="text-xs text-gray-600 dark:text-cyan-500 font-mono"
+                        height={45}
+                    />
+                    <YAxis
+                        domain={[0, 100]}
+                        tickFormatter={(value) => `${value}%`}
+                        stroke="currentColor"
+                        className="stroke-gray-400 dark:stroke-cyan-600 text-xs"
+                    />
+                    <Tooltip content={<CustomTooltip unit="%"/>}/>
+                    <Legend/>
+                    {mountPoints.map((mountPoint, index) => (
+                        <Line
+                            key={mountPoint}
+                            type="monotone"
+                            dataKey={mountPoint}
+                            name={mountPoint}
+                            stroke={MOUNT_POINT_COLORS[index % MOUNT_POINT_COLORS.length]}
+                            strokeWidth={2}
+                            dot={false}
+                            activeDot={{r: 3}}
+                        />
+                    ))}
+                </LineChart>
+            </ResponsiveContainer>
+        </ChartContainer>
+    );
+};
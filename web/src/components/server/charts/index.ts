@@ -1,5 +1,6 @@
 export {ChartContainer} from './ChartContainer';
 export {CpuChart} from './CpuChart';
+export {CpuModeChart} from './CpuModeChart';
 export {MemoryChart} from './MemoryChart';
 export {NetworkChart} from './NetworkChart';
 export {DiskIOChart} from './DiskIOChart';
@@ -7,3 +8,8 @@ export {NetworkConnectionChart} from './NetworkConnectionChart';
 export {GpuChart} from './GpuChart';
 export {TemperatureChart} from './TemperatureChart';
 export {MonitorChart} from './MonitorChart';
+export {LoadChart} from './LoadChart';
+export {PressureChart} from './PressureChart';
+export {ContextSwitchChart} from './ContextSwitchChart';
+export {FileDescriptorChart} from './FileDescriptorChart';
+export {InodeChart} from './InodeChart';
@@ -1,17 +1,50 @@
-import {useQuery} from '@tanstack/react-query';
+import {useEffect, useState} from 'react';
+import {useQuery, useQueryClient} from '@tanstack/react-query';
 import {getAgentLatestMetrics} from '@/api/agent';
+import type {HttpResponse} from '@/api/request';
+import {subscribeLive} from '@/api/live';
+import type {LatestMetrics} from '@/types';
 
 /**
  * 查询 Agent 最新指标
- * 自动每 5 秒刷新一次
+ * 通过实时事件推送更新，推送连接断开时每 5 秒轮询一次
  * @param agentId Agent ID
  * @returns 最新指标查询结果
  */
 export const useLatestMetricsQuery = (agentId?: string) => {
+    const queryClient = useQueryClient();
+    const [live, setLive] = useState(false);
+    const queryKey = ['agent', agentId, 'metrics', 'latest'];
+
+    useEffect(() => {
+        if (!agentId) {
+            return;
+        }
+        const unsubscribe = subscribeLive({
+            agentIds: [agentId],
+            types: ['metrics'],
+            onEvent: (event) => {
+                queryClient.setQueryData<HttpResponse<LatestMetrics>>(queryKey, (previous) => ({
+                    status: 200,
+                    statusText: 'OK',
+                    headers: {},
+                    url: '',
+                    ...previous,
+                    data: event.data as LatestMetrics,
+                }));
+            },
+            onStatusChange: setLive,
+        });
+        return () => {
+            unsubscribe();
+            setLive(false);
+        };
+    }, [agentId, queryClient]);
+
     return useQuery({
-        queryKey: ['agent', agentId, 'metrics', 'latest'],
+        queryKey,
         queryFn: () => getAgentLatestMetrics(agentId!),
         enabled: !!agentId,
-        refetchInterval: 5000, // 5秒自动刷新
+        refetchInterval: live ? false : 5000, // 推送不可用时 5 秒自动刷新
     });
 };
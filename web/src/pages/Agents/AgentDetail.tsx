@@ -2,14 +2,55 @@ import {useEffect, useState} from 'react';
 import {useNavigate, useParams, useSearchParams} from 'react-router-dom';
 import type {MenuProps, TabsProps} from 'antd';
 import {Alert, App, Button, Card, Descriptions, Dropdown, Space, Spin, Tabs, Tag} from 'antd';
-import {Activity, ArrowLeft, Clock, FileWarning, RefreshCw, Shield, Terminal} from 'lucide-react';
+import {Activity, ArrowLeft, Clock, FileWarning, RefreshCw, ScrollText, Shield, Terminal} from 'lucide-react';
 import TamperProtection from './TamperProtection.tsx';
-import {getAgentForAdmin, getAuditResult, sendAuditCommand, type VPSAuditResult} from '@/api/agent.ts';
+import LogMonitor from './LogMonitor.tsx';
+import {
+    type AgentConfigState,
+    getAgentForAdmin,
+    getAuditResult,
+    listAgentConfigStates,
+    sendAuditCommand,
+    type VPSAuditResult
+} from '@/api/agent.ts';
 import type {Agent} from '@/types';
 import dayjs from 'dayjs';
 import {getErrorMessage} from '@/lib/utils';
 import AuditResultView from './AuditResultView';
 
+// 不支持能力协商的旧版本探针默认具备的能力，与服务端 protocol.LegacyCapabilities 保持一致
+const legacyCapabilities = (os: string): string[] => {
+    const capabilities = ['ddns', 'monitor_http', 'monitor_tcp', 'monitor_icmp'];
+    return os === 'linux' ? [...capabilities, 'tamper', 'audit'] : capabilities;
+};
+
+// 探针不支持指定功能时返回提示信息，支持时返回 null
+const getUnsupportedReason = (agent: Agent, capability: string, feature: string): string | null => {
+    const capabilities = agent.protocolVersion ? agent.capabilities || [] : legacyCapabilities(agent.os);
+    if (capabilities.includes(capability)) {
+        return null;
+    }
+    if (!agent.protocolVersion) {
+        return `当前探针版本 ${agent.version} 过旧，不支持${feature}，请升级探针后使用。`;
+    }
+    return `当前探针不支持${feature}（操作系统 ${agent.os}）。`;
+};
+
+const CONFIG_KIND_LABELS: Record<AgentConfigState['kind'], string> = {
+    tamper: '防篡改',
+    ddns: 'DDNS',
+    log: '日志监控',
+};
+
+const CONFIG_STATUS_TAGS: Record<AgentConfigState['status'], { color: string; text: string }> = {
+    queued: {color: 'default', text: '等待连接'},
+    pending: {color: 'processing', text: '等待确认'},
+    applied: {color: 'green', text: '已应用'},
+    failed: {color: 'red', text: '应用失败'},
+    timeout: {color: 'orange', text: '确认超时'},
+    sent: {color: 'blue', text: '已下发'},
+};
+
 const AgentDetail = () => {
     const {id} = useParams<{ id: string }>();
     const navigate = useNavigate();
@@ -18,6 +59,7 @@ const AgentDetail = () => {
     const [loading, setLoading] = useState(false);
     const [agent, setAgent] = useState<Agent | null>(null);
     const [auditResult, setAuditResult] = useState<VPSAuditResult | null>(null);
+    const [configStates, setConfigStates] = useState<AgentConfigState[]>([]);
     const [auditing, setAuditing] = useState(false);
     const [activeTab, setActiveTab] = useState<string>(searchParams.get('tab') || 'info');
 
@@ -26,13 +68,15 @@ const AgentDetail = () => {
 
         setLoading(true);
         try {
-            const [agentRes, auditRes] = await Promise.all([
+            const [agentRes, auditRes, configRes] = await Promise.all([
                 getAgentForAdmin(id),
                 getAuditResult(id).catch(() => ({data: null})),
+                listAgentConfigStates(id).catch(() => ({data: {items: [], total: 0}})),
             ]);
 
             setAgent(agentRes.data);
             setAuditResult(auditRes.data);
+            setConfigStates(configRes.data.items || []);
         } catch (error: any) {
             messageApi.error(error.response?.data?.message || '获取探针信息失败');
         } finally {
@@ -41,11 +85,11 @@ const AgentDetail = () => {
     };
 
     const handleStartAudit = async () => {
-        if (!id) return;
+        if (!id || !agent) return;
 
-        // 检查是否为 Linux 系统
-        if (!agent?.os.toLowerCase().includes('linux')) {
-            messageApi.warning('安全审计功能仅支持 Linux 系统');
+        const reason = getUnsupportedReason(agent, 'audit', '安全审计');
+        if (reason) {
+            messageApi.warning(reason);
             return;
         }
 
@@ -82,6 +126,10 @@ const AgentDetail = () => {
         );
     }
 
+    const auditUnsupported = agent ? getUnsupportedReason(agent, 'audit', '安全审计') : null;
+    const tamperUnsupported = agent ? getUnsupportedReason(agent, 'tamper', '防篡改保护') : null;
+    const logUnsupported = agent ? getUnsupportedReason(agent, 'log_tail', '日志监控') : null;
+
     // 命令菜单配置
     const commandMenuItems: MenuProps['items'] = [
         {
@@ -147,6 +195,30 @@ const AgentDetail = () => {
                         ) : '-'}
                     </Descriptions.Item>
                     <Descriptions.Item label="探针版本">{agent?.version}</Descriptions.Item>
+                    <Descriptions.Item label="协议版本">
+                        {agent?.protocolVersion ? agent.protocolVersion : <Tag color="orange">旧版本</Tag>}
+                    </Descriptions.Item>
+                    <Descriptions.Item label="支持的功能">
+                        {agent?.protocolVersion ? (
+                            <Space size={[0, 4]} wrap>
+                                {(agent.capabilities || []).map(capability => <Tag key={capability}>{capability}</Tag>)}
+                            </Space>
+                        ) : '-'}
+                    </Descriptions.Item>
+                    <Descriptions.Item label="配置同步" span={2}>
+                        {configStates.length > 0 ? (
+                            <Space size={[0, 4]} wrap>
+                                {configStates.map(state => {
+                                    const status = CONFIG_STATUS_TAGS[state.status] || {color: 'default', text: state.status};
+                                    return (
+                                        <Tag key={state.id} color={status.color} title={state.error}>
+                                            {CONFIG_KIND_LABELS[state.kind] || state.kind}: {status.text}
+                                        </Tag>
+                                    );
+                                })}
+                            </Space>
+                        ) : '-'}
+                    </Descriptions.Item>
                     <Descriptions.Item label="最后活跃时间">
                         <Space>
                             <Clock size={14}/>
@@ -172,18 +244,18 @@ const AgentDetail = () => {
             ),
             children: (
                 <Space direction="vertical" style={{width: '100%'}}>
-                    {/* 非 Linux 系统提示 */}
-                    {agent && !agent.os.toLowerCase().includes('linux') && (
+                    {/* 探针不支持时提示 */}
+                    {auditUnsupported && (
                         <Alert
                             message="功能限制"
-                            description="安全审计功能仅支持 Linux 系统。当前系统为 Windows 或其他系统，无法使用此功能。"
+                            description={auditUnsupported}
                             type="warning"
                             showIcon
                         />
                     )}
 
                     {!auditResult ? (
-                        agent?.os.toLowerCase().includes('linux') ? (
+                        agent && !auditUnsupported ? (
                             <Alert
                                 message="暂无审计结果"
                                 description={
@@ -217,17 +289,36 @@ const AgentDetail = () => {
                     <div>防篡改保护</div>
                 </div>
             ),
-            children: agent?.os.toLowerCase().includes('linux') ? (
+            children: agent && !tamperUnsupported ? (
                 <TamperProtection agentId={agent.id}/>
             ) : (
                 <Alert
                     message="功能限制"
-                    description="防篡改保护功能仅支持 Linux 系统。当前系统为 Windows 或其他系统，无法使用此功能。"
+                    description={tamperUnsupported}
                     type="warning"
                     showIcon
                 />
             ),
         },
+        {
+            key: 'log',
+            label: (
+                <div className="flex items-center gap-2 text-sm">
+                    <ScrollText size={16}/>
+                    <div>日志监控</div>
+                </div>
+            ),
+            children: agent ? (
+                logUnsupported ? (
+                    <Alert
+                        message="功能限制"
+                        description={logUnsupported}
+                        type="warning"
+                        showIcon
+                    />
+                ) : <LogMonitor agentId={agent.id}/>
+            ) : null,
+        },
     ];
 
     return (
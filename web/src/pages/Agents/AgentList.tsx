@@ -4,8 +4,18 @@ import type {ActionType, ProColumns} from '@ant-design/pro-components';
 import {ProTable} from '@ant-design/pro-components';
 import type {MenuProps} from 'antd';
 import {App, Button, DatePicker, Divider, Dropdown, Form, Input, InputNumber, Modal, Radio, Select, Space, Tag} from 'antd';
-import {Edit, Eye, MoreVertical, Plus, RefreshCw, Shield, Tags, Trash2} from 'lucide-react';
-import {batchUpdateTags, deleteAgent, getAgentPaging, getTags, updateAgentInfo, updateTrafficConfig} from '@/api/agent.ts';
+import {Edit, Eye, KeyRound, MoreVertical, Plus, RefreshCw, RotateCcw, Shield, ShieldOff, Tags, Trash2} from 'lucide-react';
+import {
+    batchUpdateTags,
+    deleteAgent,
+    getAgentPaging,
+    getTags,
+    resetAgentCredential,
+    revokeAgentCredential,
+    rotateAgentCredential,
+    updateAgentInfo,
+    updateTrafficConfig
+} from '@/api/agent.ts';
 import type {Agent} from '@/types';
 import {getErrorMessage} from '@/lib/utils';
 import dayjs from 'dayjs';
@@ -46,6 +56,7 @@ const AgentList = () => {
             tags: agent.tags || [],
             expireTime: agent.expireTime ? dayjs(agent.expireTime) : null,
             visibility: agent.visibility || 'public',
+            certSubject: agent.certSubject || '',
             trafficLimit: agent.trafficLimit ? agent.trafficLimit / (1024 * 1024 * 1024) : 0, // 转换为GB
             trafficResetDay: agent.trafficResetDay || 0,
         });
@@ -66,6 +77,7 @@ const AgentList = () => {
                 name: values.name,
                 visibility: values.visibility || 'public',
                 tags: values.tags || [],
+                certSubject: values.certSubject || '',
             };
 
             if (values.expireTime) {
@@ -119,6 +131,73 @@ const AgentList = () => {
         });
     };
 
+    // 轮换探针凭证
+    const handleRotateCredential = (agent: Agent) => {
+        modal.confirm({
+            title: '轮换凭证',
+            content: `确定要为探针「${agent.name || agent.hostname}」签发新凭证吗？探针在线时会立即收到新凭证，旧凭证在探针确认后失效。`,
+            okText: '确认轮换',
+            cancelText: '取消',
+            centered: true,
+            onOk: async () => {
+                try {
+                    const response = await rotateAgentCredential(agent.id);
+                    messageApi.success(response.data.message);
+                } catch (error: unknown) {
+                    messageApi.error(getErrorMessage(error, '轮换凭证失败'));
+                }
+            },
+        });
+    };
+
+    // 吊销探针凭证
+    const handleRevokeCredential = (agent: Agent) => {
+        modal.confirm({
+            title: '吊销凭证',
+            content: (
+                <div>
+                    <p>确定要吊销探针「{agent.name || agent.hostname}」的凭证吗？</p>
+                    <p className="text-red-500 text-sm mt-2">
+                        吊销后探针会被断开且无法再连接，API 密钥也不能重新注册，需要手动允许重新注册。
+                    </p>
+                </div>
+            ),
+            okText: '确认吊销',
+            cancelText: '取消',
+            okButtonProps: {danger: true},
+            centered: true,
+            onOk: async () => {
+                try {
+                    await revokeAgentCredential(agent.id);
+                    messageApi.success('凭证已吊销');
+                    actionRef.current?.reload();
+                } catch (error: unknown) {
+                    messageApi.error(getErrorMessage(error, '吊销凭证失败'));
+                }
+            },
+        });
+    };
+
+    // 允许探针重新注册
+    const handleResetCredential = (agent: Agent) => {
+        modal.confirm({
+            title: '允许重新注册',
+            content: `确定允许探针「${agent.name || agent.hostname}」使用 API 密钥重新注册吗？下一个使用该探针 ID 注册的客户端将获得新凭证。`,
+            okText: '确认',
+            cancelText: '取消',
+            centered: true,
+            onOk: async () => {
+                try {
+                    await resetAgentCredential(agent.id);
+                    messageApi.success('已允许探针重新注册');
+                    actionRef.current?.reload();
+                } catch (error: unknown) {
+                    messageApi.error(getErrorMessage(error, '操作失败'));
+                }
+            },
+        });
+    };
+
     // 打开批量操作标签模态框
     const handleBatchTags = () => {
         if (selectedRowKeys.length === 0) {
@@ -222,11 +301,16 @@ const AgentList = () => {
             key: 'status',
             hideInSearch: true,
             width: 80,
-            render: (_, record) => (
-                <Tag color={record.status === 1 ? 'success' : 'default'}>
-                    {record.status === 1 ? '在线' : '离线'}
-                </Tag>
-            ),
+            render: (_, record) => {
+                if (record.credentialStatus === 'revoked') {
+                    return <Tag color="error">已吊销</Tag>;
+                }
+                return (
+                    <Tag color={record.status === 1 ? 'success' : 'default'}>
+                        {record.status === 1 ? '在线' : '离线'}
+                    </Tag>
+                );
+            },
         },
         {
             title: '可见性',
@@ -301,6 +385,29 @@ const AgentList = () => {
                         icon: <Edit size={14}/>,
                         onClick: () => handleEdit(record),
                     },
+                    ...(record.credentialStatus === 'active' ? [
+                        {
+                            key: 'rotateCredential',
+                            label: '轮换凭证',
+                            icon: <KeyRound size={14}/>,
+                            onClick: () => handleRotateCredential(record),
+                        },
+                        {
+                            key: 'revokeCredential',
+                            label: '吊销凭证',
+                            icon: <ShieldOff size={14}/>,
+                            danger: true,
+                            onClick: () => handleRevokeCredential(record),
+                        },
+                    ] : []),
+                    ...(record.credentialStatus === 'revoked' || !record.credentialStatus ? [
+                        {
+                            key: 'resetCredential',
+                            label: '允许重新注册',
+                            icon: <RotateCcw size={14}/>,
+                            onClick: () => handleResetCredential(record),
+                        },
+                    ] : []),
                     {
                         type: 'divider',
                     },
@@ -474,6 +581,13 @@ const AgentList = () => {
                             ]}
                         />
                     </Form.Item>
+                    <Form.Item
+                        label="客户端证书主题"
+                        name="certSubject"
+                        extra="探针使用客户端证书连接时绑定的证书主题，为空时首次使用证书连接自动绑定"
+                    >
+                        <Input className="font-mono" placeholder="例如：CN=web-01,O=pika"/>
+                    </Form.Item>
                     <Form.Item
                         label="流量限额"
                         name="trafficLimit"
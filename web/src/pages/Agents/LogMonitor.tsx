@@ -0,0 +1,374 @@
+import React, {useEffect, useState} from 'react';
+import {App, Button, Form, Input, InputNumber, Modal, Popconfirm, Select, Space, Switch, Table, Tag} from 'antd';
+import type {ColumnType} from 'antd/es/table';
+import {FileText, Plus, RefreshCw, ScrollText} from 'lucide-react';
+import {
+    createLogRule,
+    deleteLogRule,
+    getLogEvents,
+    getLogRules,
+    type LogEvent,
+    type LogRule,
+    type LogRuleRequest,
+    updateLogRule
+} from '@/api/log.ts';
+import {getErrorMessage} from '@/lib/utils';
+
+interface LogMonitorProps {
+    agentId: string;
+}
+
+const PAGE_SIZE = 20;
+
+const defaultRule: LogRuleRequest = {
+    name: '',
+    enabled: true,
+    paths: [],
+    journald: false,
+    journalUnits: [],
+    pattern: '',
+    shipLines: true,
+    alertEnabled: false,
+    alertWindow: 300,
+    maxEventsPerMinute: 10,
+};
+
+const LogMonitor: React.FC<LogMonitorProps> = ({agentId}) => {
+    const [activeTab, setActiveTab] = useState<'rules' | 'events'>('rules');
+    const [rules, setRules] = useState<LogRule[]>([]);
+    const [events, setEvents] = useState<LogEvent[]>([]);
+    const [eventsPage, setEventsPage] = useState(1);
+    const [eventsTotal, setEventsTotal] = useState(0);
+    const [eventsRuleId, setEventsRuleId] = useState<string>();
+    const [keyword, setKeyword] = useState('');
+    const [loading, setLoading] = useState(false);
+    const [saving, setSaving] = useState(false);
+    const [editing, setEditing] = useState<LogRule | null>(null);
+    const [modalOpen, setModalOpen] = useState(false);
+    const [form] = Form.useForm<LogRuleRequest>();
+    const {message} = App.useApp();
+
+    // 加载规则
+    const loadRules = async () => {
+        try {
+            setLoading(true);
+            const response = await getLogRules(agentId);
+            setRules(response.data || []);
+        } catch (error) {
+            message.error(getErrorMessage(error, '加载日志规则失败'));
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    // 加载匹配到的日志
+    const loadEvents = async (page: number = 1) => {
+        try {
+            setLoading(true);
+            const response = await getLogEvents(agentId, page, PAGE_SIZE, eventsRuleId, keyword);
+            setEvents(response.data.items || []);
+            setEventsTotal(response.data.total || 0);
+            setEventsPage(page);
+        } catch (error) {
+            message.error(getErrorMessage(error, '加载日志失败'));
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    useEffect(() => {
+        loadRules();
+    }, [agentId]);
+
+    useEffect(() => {
+        if (activeTab === 'events') {
+            loadEvents(1);
+        }
+    }, [activeTab, agentId, eventsRuleId]);
+
+    const openModal = (rule?: LogRule) => {
+        setEditing(rule || null);
+        setModalOpen(true);
+    };
+
+    const handleSave = async () => {
+        const values = await form.validateFields();
+        try {
+            setSaving(true);
+            if (editing) {
+                await updateLogRule(editing.id, values);
+            } else {
+                await createLogRule(agentId, values);
+            }
+            message.success('日志规则已保存，将实时同步到探针');
+            setModalOpen(false);
+            await loadRules();
+        } catch (error) {
+            message.error(getErrorMessage(error, '保存日志规则失败'));
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    const handleDelete = async (rule: LogRule) => {
+        try {
+            await deleteLogRule(rule.id);
+            message.success('日志规则已删除');
+            await loadRules();
+        } catch (error) {
+            message.error(getErrorMessage(error, '删除日志规则失败'));
+        }
+    };
+
+    const ruleColumns: ColumnType<LogRule>[] = [
+        {
+            title: '名称',
+            dataIndex: 'name',
+            key: 'name',
+            render: (name: string, rule) => (
+                <Space>
+                    <span>{name}</span>
+                    {!rule.enabled && <Tag>已停用</Tag>}
+                </Space>
+            ),
+        },
+        {
+            title: '日志来源',
+            key: 'sources',
+            render: (_, rule) => (
+                <div className="space-y-1">
+                    {(rule.paths || []).map(path => (
+                        <div key={path} className="font-mono text-xs">{path}</div>
+                    ))}
+                    {rule.journald && (
+                        <div className="font-mono text-xs">
+                            journald{rule.journalUnits?.length ? `（${rule.journalUnits.join(', ')}）` : ''}
+                        </div>
+                    )}
+                </div>
+            ),
+        },
+        {
+            title: '匹配规则',
+            dataIndex: 'pattern',
+            key: 'pattern',
+            render: (pattern: string) => <code className="text-xs">{pattern}</code>,
+        },
+        {
+            title: '处理方式',
+            key: 'actions',
+            render: (_, rule) => (
+                <Space size={4} wrap>
+                    <Tag color="blue">计数</Tag>
+                    {rule.shipLines && <Tag color="cyan">上报日志</Tag>}
+                    {rule.alertEnabled && <Tag color="red">告警</Tag>}
+                </Space>
+            ),
+        },
+        {
+            title: '操作',
+            key: 'operation',
+            width: 140,
+            render: (_, rule) => (
+                <Space>
+                    <Button type="link" size="small" onClick={() => openModal(rule)}>编辑</Button>
+                    <Popconfirm title="确定删除该日志规则吗？" onConfirm={() => handleDelete(rule)}>
+                        <Button type="link" size="small" danger>删除</Button>
+                    </Popconfirm>
+                </Space>
+            ),
+        },
+    ];
+
+    const eventColumns: ColumnType<LogEvent>[] = [
+        {
+            title: '时间',
+            dataIndex: 'timestamp',
+            key: 'timestamp',
+            width: 180,
+            render: (timestamp: number) => new Date(timestamp).toLocaleString('zh-CN'),
+        },
+        {
+            title: '规则',
+            dataIndex: 'ruleName',
+            key: 'ruleName',
+            width: 140,
+        },
+        {
+            title: '来源',
+            dataIndex: 'source',
+            key: 'source',
+            width: 200,
+            render: (source: string) => <span className="font-mono text-xs">{source}</span>,
+        },
+        {
+            title: '日志内容',
+            dataIndex: 'line',
+            key: 'line',
+            render: (line: string) => <span className="font-mono text-xs break-all">{line}</span>,
+        },
+    ];
+
+    return (
+        <div className="space-y-6">
+            {/* 标签页导航 */}
+            <div className="flex space-x-2 border-b border-slate-200">
+                <button
+                    onClick={() => setActiveTab('rules')}
+                    className={`px-4 py-2 text-sm font-medium transition ${
+                        activeTab === 'rules'
+                            ? 'border-b-2 border-blue-600 text-blue-600'
+                            : 'text-slate-500 hover:text-slate-700'
+                    }`}
+                >
+                    <ScrollText className="inline h-4 w-4 mr-1"/>
+                    匹配规则
+                </button>
+                <button
+                    onClick={() => setActiveTab('events')}
+                    className={`px-4 py-2 text-sm font-medium transition ${
+                        activeTab === 'events'
+                            ? 'border-b-2 border-blue-600 text-blue-600'
+                            : 'text-slate-500 hover:text-slate-700'
+                    }`}
+                >
+                    <FileText className="inline h-4 w-4 mr-1"/>
+                    匹配日志
+                </button>
+            </div>
+
+            {activeTab === 'rules' && (
+                <div className="space-y-4">
+                    <div className="rounded-lg border border-slate-200 bg-blue-50 p-4">
+                        <p className="text-sm text-slate-700">
+                            探针跟踪日志文件（支持通配符，自动处理轮转）和 journald，按正则表达式统计匹配行数。
+                            匹配到的日志行可以上报并触发告警，上报数量按规则限流以保护探针连接。
+                        </p>
+                    </div>
+
+                    <div className="flex justify-end gap-2">
+                        <Button icon={<RefreshCw size={14}/>} onClick={loadRules} loading={loading}>
+                            刷新
+                        </Button>
+                        <Button type="primary" icon={<Plus size={14}/>} onClick={() => openModal()}>
+                            添加规则
+                        </Button>
+                    </div>
+
+                    <Table<LogRule>
+                        rowKey="id"
+                        size="small"
+                        loading={loading}
+                        columns={ruleColumns}
+                        dataSource={rules}
+                        pagination={false}
+                    />
+                </div>
+            )}
+
+            {activeTab === 'events' && (
+                <div className="space-y-4">
+                    <div className="flex flex-wrap gap-2">
+                        <Select
+                            allowClear
+                            placeholder="全部规则"
+                            style={{width: 200}}
+                            value={eventsRuleId}
+                            onChange={setEventsRuleId}
+                            options={rules.map(rule => ({label: rule.name, value: rule.id}))}
+                        />
+                        <Input.Search
+                            allowClear
+                            placeholder="搜索日志内容"
+                            style={{width: 260}}
+                            value={keyword}
+                            onChange={e => setKeyword(e.target.value)}
+                            onSearch={() => loadEvents(1)}
+                        />
+                    </div>
+
+                    <Table<LogEvent>
+                        rowKey="id"
+                        size="small"
+                        loading={loading}
+                        columns={eventColumns}
+                        dataSource={events}
+                        pagination={{
+                            current: eventsPage,
+                            pageSize: PAGE_SIZE,
+                            total: eventsTotal,
+                            showSizeChanger: false,
+                            onChange: page => loadEvents(page),
+                        }}
+                    />
+                </div>
+            )}
+
+            <Modal
+                title={editing ? '编辑日志规则' : '添加日志规则'}
+                open={modalOpen}
+                onCancel={() => setModalOpen(false)}
+                onOk={handleSave}
+                confirmLoading={saving}
+                destroyOnHidden
+                width={600}
+            >
+                <Form
+                    form={form}
+                    layout="vertical"
+                    preserve={false}
+                    initialValues={editing ? {...defaultRule, ...editing} : defaultRule}
+                >
+                    <Form.Item name="name" label="规则名称" rules={[{required: true, message: '请输入规则名称'}]}>
+                        <Input placeholder="例如：OOM Killer"/>
+                    </Form.Item>
+                    <Form.Item name="enabled" label="启用" valuePropName="checked">
+                        <Switch/>
+                    </Form.Item>
+                    <Form.Item
+                        name="paths"
+                        label="日志文件"
+                        tooltip="支持通配符，例如 /var/log/nginx/*.log；已存在的文件从末尾开始读取"
+                    >
+                        <Select mode="tags" placeholder="输入文件路径后回车" open={false}/>
+                    </Form.Item>
+                    <Form.Item name="journald" label="读取 journald" valuePropName="checked">
+                        <Switch/>
+                    </Form.Item>
+                    <Form.Item noStyle dependencies={['journald']}>
+                        {({getFieldValue}) => getFieldValue('journald') && (
+                            <Form.Item name="journalUnits" label="journald 单元" tooltip="为空时读取全部单元">
+                                <Select mode="tags" placeholder="例如 nginx.service" open={false}/>
+                            </Form.Item>
+                        )}
+                    </Form.Item>
+                    <Form.Item
+                        name="pattern"
+                        label="匹配规则（正则表达式）"
+                        rules={[{required: true, message: '请输入匹配规则'}]}
+                    >
+                        <Input className="font-mono" placeholder="例如：Out of memory: Killed process"/>
+                    </Form.Item>
+                    <Form.Item name="shipLines" label="上报匹配的日志" valuePropName="checked">
+                        <Switch/>
+                    </Form.Item>
+                    <Form.Item name="maxEventsPerMinute" label="每分钟最多上报行数" tooltip="超出部分只计数，不上报">
+                        <InputNumber min={1} max={120} style={{width: '100%'}}/>
+                    </Form.Item>
+                    <Form.Item name="alertEnabled" label="匹配时告警" valuePropName="checked">
+                        <Switch/>
+                    </Form.Item>
+                    <Form.Item noStyle dependencies={['alertEnabled']}>
+                        {({getFieldValue}) => getFieldValue('alertEnabled') && (
+                            <Form.Item name="alertWindow" label="恢复时间（秒）" tooltip="超过该时间没有新的匹配后告警恢复">
+                                <InputNumber min={60} style={{width: '100%'}}/>
+                            </Form.Item>
+                        )}
+                    </Form.Item>
+                </Form>
+            </Modal>
+        </div>
+    );
+};
+
+export default LogMonitor;
@@ -30,12 +30,21 @@ const AlertRecordList = () => {
         cpu: 'CPU使用率',
         memory: '内存使用率',
         disk: '磁盘使用率',
+        inode: 'inode使用率',
         network: '网速',
         cert: 'HTTPS证书',
         service: '服务下线',
         agent_offline: '探针离线',
+        process: '进程消失',
+        systemd: 'systemd单元失败',
+        systemd_restart: 'systemd单元重启',
+        disk_health: '磁盘健康',
+        log: '日志关键字',
     };
 
+    // 以秒为单位的告警类型
+    const secondsAlertTypes = ['service', 'agent_offline', 'process'];
+
     // 告警级别映射
     const getLevelTag = (level: string) => {
         const config = {
@@ -148,7 +157,13 @@ const AlertRecordList = () => {
                 if (record.alertType === 'cert') {
                     return `${record.threshold.toFixed(0)} 天`;
                 }
-                if (record.alertType === 'service' || record.alertType === 'agent_offline') {
+                if (record.alertType === 'systemd' || record.alertType === 'disk_health' || record.alertType === 'log') {
+                    return '-';
+                }
+                if (record.alertType === 'systemd_restart') {
+                    return `${record.threshold.toFixed(0)} 次`;
+                }
+                if (secondsAlertTypes.includes(record.alertType)) {
                     return `${record.threshold.toFixed(0)} 秒`;
                 }
                 return `${record.threshold.toFixed(2)}%`;
@@ -166,7 +181,16 @@ const AlertRecordList = () => {
                 if (record.alertType === 'cert') {
                     return `${record.actualValue.toFixed(0)} 天`;
                 }
-                if (record.alertType === 'service' || record.alertType === 'agent_offline') {
+                if (record.alertType === 'systemd' || record.alertType === 'disk_health') {
+                    return '-';
+                }
+                if (record.alertType === 'log') {
+                    return `${record.actualValue.toFixed(0)} 行`;
+                }
+                if (record.alertType === 'systemd_restart') {
+                    return `${record.actualValue.toFixed(0)} 次`;
+                }
+                if (secondsAlertTypes.includes(record.alertType)) {
                     return `${record.actualValue.toFixed(0)} 秒`;
                 }
                 return `${record.actualValue.toFixed(2)}%`;
@@ -9,13 +9,19 @@ import {
     TemperatureMonitorSection,
 } from '@/components/server';
 import {
+    ContextSwitchChart,
     CpuChart,
+    CpuModeChart,
     DiskIOChart,
+    FileDescriptorChart,
+    InodeChart,
     GpuChart,
+    LoadChart,
     MemoryChart,
     MonitorChart,
     NetworkChart,
     NetworkConnectionChart,
+    PressureChart,
     TemperatureChart,
 } from '@/components/server/charts';
 import {useAgentQuery, useLatestMetricsQuery} from '@/hooks/server/queries';
@@ -100,9 +106,19 @@ const ServerDetail = () => {
 
                             {/* 进阶指标：单列全宽 */}
                             <div className="grid gap-4 sm:gap-5 lg:gap-6 grid-cols-1">
+                                <CpuModeChart agentId={id!} timeRange={timeRange} start={customStart} end={customEnd}/>
                                 <NetworkConnectionChart agentId={id!} timeRange={timeRange} start={customStart} end={customEnd}/>
                             </div>
 
+                            {/* 内核指标：大屏 2 列，中屏 1 列，PSI 等平台不支持时不渲染 */}
+                            <div className="grid gap-4 sm:gap-5 lg:gap-6 grid-cols-1 lg:grid-cols-2">
+                                <LoadChart agentId={id!} timeRange={timeRange} start={customStart} end={customEnd}/>
+                                <PressureChart agentId={id!} timeRange={timeRange} start={customStart} end={customEnd}/>
+                                <ContextSwitchChart agentId={id!} timeRange={timeRange} start={customStart} end={customEnd}/>
+                                <FileDescriptorChart agentId={id!} timeRange={timeRange} start={customStart} end={customEnd}/>
+                                <InodeChart agentId={id!} timeRange={timeRange} start={customStart} end={customEnd}/>
+                            </div>
+
                             {/* 硬件指标：条件渲染，单列全宽 */}
                             <div className="grid gap-4 sm:gap-5 lg:gap-6 grid-cols-1">
                                 <GpuChart agentId={id!} timeRange={timeRange} start={customStart} end={customEnd}/>
@@ -64,6 +64,7 @@ const AlertSettings = () => {
                         { key: 'cpu', title: 'CPU 告警规则', thresholdLabel: 'CPU 使用率阈值 (%)', max: 100 },
                         { key: 'memory', title: '内存告警规则', thresholdLabel: '内存使用率阈值 (%)', max: 100 },
                         { key: 'disk', title: '磁盘告警规则', thresholdLabel: '磁盘使用率阈值 (%)', max: 100 },
+                        { key: 'inode', title: 'inode 告警规则', thresholdLabel: '任一分区 inode 使用率阈值 (%)', max: 100 },
                         { key: 'network', title: '网速告警规则', thresholdLabel: '网速阈值 (MB/s)', max: 10000 },
                     ].map((rule) => (
                         <Card key={rule.key} title={rule.title} type="inner">
@@ -206,6 +207,98 @@ const AlertSettings = () => {
                         </Form.Item>
                     </Card>
 
+                    <Card title="进程消失告警规则" type="inner">
+                        <Form.Item noStyle shouldUpdate>
+                            {({ getFieldValue }) => {
+                                const enabled = getFieldValue(['rules', 'processEnabled']);
+                                return (
+                                    <div className="flex items-center gap-8">
+                                        <Form.Item
+                                            label="开关"
+                                            name={['rules', 'processEnabled']}
+                                            valuePropName="checked"
+                                            className="mb-0"
+                                            tooltip="探针配置文件中 collector.process.watch 关注的进程消失时告警"
+                                        >
+                                            <Switch />
+                                        </Form.Item>
+                                        <Form.Item
+                                            label="持续时间（秒）"
+                                            name={['rules', 'processDuration']}
+                                            className="mb-0"
+                                            tooltip="进程持续消失多久后触发告警"
+                                        >
+                                            <InputNumber
+                                                min={1}
+                                                max={3600}
+                                                style={{ width: '100%' }}
+                                                disabled={!enabled}
+                                            />
+                                        </Form.Item>
+                                    </div>
+                                );
+                            }}
+                        </Form.Item>
+                    </Card>
+
+                    <Card title="systemd 单元告警规则" type="inner">
+                        <Form.Item noStyle shouldUpdate>
+                            {({ getFieldValue }) => {
+                                const enabled = getFieldValue(['rules', 'systemdEnabled']);
+                                return (
+                                    <div className="flex items-center gap-8">
+                                        <Form.Item
+                                            label="开关"
+                                            name={['rules', 'systemdEnabled']}
+                                            valuePropName="checked"
+                                            className="mb-0"
+                                            tooltip="探针上报的 systemd 单元进入 failed 状态或自动重启时告警"
+                                        >
+                                            <Switch />
+                                        </Form.Item>
+                                        <Form.Item
+                                            label="重启告警恢复时间（秒）"
+                                            name={['rules', 'systemdRestartWindow']}
+                                            className="mb-0"
+                                            tooltip="单元在此时间内没有再次重启时，重启告警自动恢复"
+                                        >
+                                            <InputNumber
+                                                min={60}
+                                                max={86400}
+                                                style={{ width: '100%' }}
+                                                disabled={!enabled}
+                                            />
+                                        </Form.Item>
+                                    </div>
+                                );
+                            }}
+                        </Form.Item>
+                    </Card>
+
+                    <Card title="磁盘健康告警规则" type="inner">
+                        <Form.Item
+                            label="开关"
+                            name={['rules', 'diskHealthEnabled']}
+                            valuePropName="checked"
+                            className="mb-0"
+                            tooltip="SMART 健康评估未通过、NVMe 严重警告、出现待映射或不可修复扇区、备用空间不足时告警，需要探针安装 smartctl 或 nvme-cli"
+                        >
+                            <Switch />
+                        </Form.Item>
+                    </Card>
+
+                    <Card title="日志关键字告警规则" type="inner">
+                        <Form.Item
+                            label="开关"
+                            name={['rules', 'logEnabled']}
+                            valuePropName="checked"
+                            className="mb-0"
+                            tooltip="探针日志规则开启告警后，匹配到日志时立即告警，在规则设置的恢复时间内没有新的匹配后恢复；匹配规则在探针详情的日志监控中配置"
+                        >
+                            <Switch />
+                        </Form.Item>
+                    </Card>
+
                     <Button
                         type="primary"
                         loading={saveMutation.isPending}
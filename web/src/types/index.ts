@@ -38,6 +38,13 @@ export interface Agent {
     trafficAlertSent80?: boolean; // 是否已发送80%告警
     trafficAlertSent90?: boolean; // 是否已发送90%告警
     trafficAlertSent100?: boolean;// 是否已发送100%告警
+    // 探针凭证相关字段
+    credentialStatus?: string;    // 凭证状态: 空-未签发, pending-等待探针确认, active-已签发, revoked-已吊销
+    credentialIssuedAt?: number;  // 凭证签发时间(时间戳毫秒)
+    certSubject?: string;         // 绑定的客户端证书主题
+    // 协议版本和能力
+    protocolVersion?: number;     // 探针协议版本，0 表示不支持能力协商的旧版本
+    capabilities?: string[];      // 探针支持的功能: gpu, tamper, ddns, audit, log_tail, monitor_http, monitor_tcp, monitor_icmp
 }
 
 // 聚合指标数据（所有图表查询只返回聚合数据）
@@ -116,6 +123,21 @@ export interface CPUMetric {
     physicalCores: number;
     modelName: string;
     usagePercent: number;
+    perCore?: number[];
+    modes?: CPUModes;             // 各模式 CPU 时间占比
+    perCoreModes?: CPUModes[];    // 每个核心的各模式 CPU 时间占比
+}
+
+export interface CPUModes {
+    user: number;
+    nice: number;
+    system: number;
+    idle: number;
+    iowait: number;
+    irq: number;
+    softirq: number;
+    steal: number;
+    guest: number;
 }
 
 export interface MemoryMetric {
@@ -139,6 +161,8 @@ export interface DiskSummary {
     total: number;            // 总容量(字节)
     used: number;             // 已使用(字节)
     free: number;             // 空闲(字节)
+    inodesUsagePercent: number; // 各分区中最高的 inode 使用率
+    readOnlyDisks: number;    // 只读挂载的分区数量
 }
 
 // 磁盘详细数据
@@ -238,6 +262,12 @@ export interface MonitorIcmpConfig {
     count?: number;
 }
 
+export interface MonitorTimeWindow {
+    days?: number[];       // 生效的星期（0 为周日），为空表示每天
+    start: string;         // 开始时间，如 09:00
+    end: string;           // 结束时间，早于开始时间表示跨天
+}
+
 export interface MonitorTask {
     id: number;
     name: string;
@@ -254,6 +284,13 @@ export interface MonitorTask {
     agentIds?: string[];
     agentNames?: string[];
     tags?: string[];       // 标签列表，拥有这些标签的探针都会执行此监控
+    sloTarget?: number;    // SLO 目标可用率（百分比），0 表示不设置
+    groupName?: string;    // 分组名称
+    dependsOn?: string[];  // 上游依赖的监控任务 ID
+    cronExpr?: string;     // Cron 表达式，设置后忽略检测频率
+    timezone?: string;     // 调度时区，如 Asia/Shanghai
+    activeWindows?: MonitorTimeWindow[]; // 生效时间窗口，为空表示全天
+    jitter?: number;       // Cron 调度的错峰范围（秒）
     createdAt: number;
     updatedAt: number;
 }
@@ -272,6 +309,13 @@ export interface MonitorTaskRequest {
     icmpConfig?: MonitorIcmpConfig | null;
     agentIds?: string[];
     tags?: string[];       // 标签列表
+    sloTarget?: number;    // SLO 目标可用率（百分比）
+    groupName?: string;    // 分组名称
+    dependsOn?: string[];  // 上游依赖的监控任务 ID
+    cronExpr?: string;     // Cron 表达式，设置后忽略检测频率
+    timezone?: string;     // 调度时区，如 Asia/Shanghai
+    activeWindows?: MonitorTimeWindow[]; // 生效时间窗口，为空表示全天
+    jitter?: number;       // Cron 调度的错峰范围（秒）
 }
 
 export interface MonitorListResponse {
@@ -293,6 +337,7 @@ export interface PublicMonitor {
     interval: number;
     agentIds: string[];
     agentCount: number;
+    groupName?: string;
 
     status: string;
     responseTime: number;
@@ -386,6 +431,37 @@ export interface LatestMetrics {
     host?: HostMetric;        // 主机信息
     gpu?: GPUMetric[];        // GPU 列表
     temperature?: TemperatureMetric[];  // 温度传感器列表
+    kernel?: KernelMetric;    // 内核指标（负载、PSI、文件描述符等）
+}
+
+// 内核指标，无法获取的项为空
+export interface KernelMetric {
+    load?: {
+        load1: number;
+        load5: number;
+        load15: number;
+    };
+    pressure?: {
+        cpuSome: number;
+        memorySome: number;
+        memoryFull: number;
+        ioSome: number;
+        ioFull: number;
+    };
+    fileDescriptors?: {
+        allocated: number;
+        max: number;
+    };
+    conntrack?: {
+        entries: number;
+        max: number;
+    };
+    entropyAvailable?: number;
+    contextSwitchesRate?: number;
+    interruptsRate?: number;
+    forksRate?: number;
+    procsRunning?: number;
+    procsBlocked?: number;
 }
 
 // API Key 相关
@@ -418,6 +494,9 @@ export interface AlertRules {
     diskEnabled: boolean;
     diskThreshold: number;
     diskDuration: number;
+    inodeEnabled?: boolean;
+    inodeThreshold?: number;
+    inodeDuration?: number;
     networkEnabled: boolean;
     networkThreshold: number;  // 网速阈值(MB/s)
     networkDuration: number;
@@ -427,6 +506,12 @@ export interface AlertRules {
     serviceDuration: number;   // 服务下线持续时间（秒）
     agentOfflineEnabled: boolean;   // 探针离线告警开关
     agentOfflineDuration: number;   // 探针离线持续时间（秒）
+    processEnabled?: boolean;       // 关注进程消失告警开关
+    processDuration?: number;       // 进程消失持续时间（秒）
+    systemdEnabled?: boolean;       // systemd 单元失败/重启告警开关
+    systemdRestartWindow?: number;  // 重启告警恢复时间（秒）
+    diskHealthEnabled?: boolean;    // 磁盘健康告警开关
+    logEnabled?: boolean;           // 日志关键字告警开关
 }
 
 // 全局告警配置（现在存储在 Property 中）
@@ -447,6 +532,7 @@ export interface AlertRecord {
     actualValue: number;
     level: string;
     status: string;
+    suppressed?: string;   // 抑制原因: suppressed-by-dependency, suppressed-by-agent-offline
     firedAt: number;
     resolvedAt?: number;
     createdAt: number;
@@ -0,0 +1,103 @@
+// 状态页监控分组
+export interface StatusPageGroup {
+    name: string;
+    monitorIds: string[];
+}
+
+// 状态页配置
+export interface StatusPage {
+    id: string;
+    slug: string;
+    title: string;
+    description?: string;
+    domain?: string;       // 自定义域名，按请求 Host 匹配
+    enabled: boolean;
+    groups: StatusPageGroup[];
+    createdAt: number;
+    updatedAt: number;
+}
+
+export type StatusPageRequest = Omit<StatusPage, 'id' | 'createdAt' | 'updatedAt'>;
+
+export type IncidentStatus = 'investigating' | 'identified' | 'monitoring' | 'resolved';
+export type IncidentImpact = 'none' | 'minor' | 'major' | 'critical';
+
+// 故障事件进展
+export interface StatusIncidentUpdate {
+    id: string;
+    incidentId: string;
+    status: IncidentStatus;
+    message: string;
+    createdAt: number;
+}
+
+// 故障事件
+export interface StatusIncident {
+    id: string;
+    statusPageId: string;
+    title: string;
+    status: IncidentStatus;
+    impact: IncidentImpact;
+    monitorIds: string[];
+    resolvedAt: number;
+    updates: StatusIncidentUpdate[];
+    createdAt: number;
+    updatedAt: number;
+}
+
+export interface StatusIncidentRequest {
+    title: string;
+    status: IncidentStatus;
+    impact: IncidentImpact;
+    monitorIds?: string[];
+    message?: string;      // 首条更新内容（仅创建时使用）
+}
+
+// 计划维护
+export interface StatusMaintenance {
+    id: string;
+    statusPageId: string;
+    title: string;
+    description?: string;
+    monitorIds: string[];
+    startAt: number;
+    endAt: number;
+    createdAt: number;
+    updatedAt: number;
+}
+
+export type StatusMaintenanceRequest = Pick<StatusMaintenance, 'title' | 'description' | 'monitorIds' | 'startAt' | 'endAt'>;
+
+// 单日可用率
+export interface DailyUptime {
+    date: string;
+    hasData: boolean;
+    uptime: number;
+    downtime: number;
+}
+
+export interface StatusPageMonitorView {
+    id: string;
+    name: string;
+    type: string;
+    status: 'up' | 'down' | 'unknown' | 'maintenance';
+    responseTime: number;
+    uptime: number;
+    days: DailyUptime[];
+}
+
+// 公开状态页数据
+export interface StatusPageView {
+    id: string;
+    slug: string;
+    title: string;
+    description: string;
+    systemNameZh: string;
+    systemNameEn: string;
+    logo?: string;
+    status: 'operational' | 'degraded' | 'major_outage' | 'maintenance';
+    groups: {name: string; monitors: StatusPageMonitorView[]}[];
+    incidents: StatusIncident[];
+    maintenances: StatusMaintenance[];
+    updatedAt: number;
+}